@@ -0,0 +1,203 @@
+package octanox
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ginContextType = reflect.TypeOf((*gin.Context)(nil))
+	userType       = reflect.TypeOf((*User)(nil)).Elem()
+)
+
+// aggregateComponent is a route served as part of an aggregate route, in the field of the combined response named after it.
+type aggregateComponent struct {
+	name  string
+	route *Route
+}
+
+// Aggregate registers a GET route at the given path which serves the routes with the given names in a single request, e.g. for
+// a dashboard whose parts have to be consistent with each other. The names are the names of the generated TypeScript
+// functions of the routes, e.g. getStats, so the routes have to be registered before.
+//
+// The handlers of the routes are called one after another with the same request context, binding their parameters from the
+// parameters of the aggregate route, so its path has to declare the path parameters of all of them. If a transaction middleware
+// is set, they all run in one read-only transaction, or a read-write one if any of them is Transactional, and see the same
+// snapshot. The response is an object with a field per route named after it, holding its response. If any of the handlers
+// fails, the aggregate route fails as a whole.
+//
+// The aggregate is generated as a normal function whose response type nests the response types of the routes, so it follows
+// their changes. Panics if a route does not exist, is no GET route, responds without a body or with a download, or if two of
+// them bind different parameters to the same field name.
+func (r *SubRouter) Aggregate(path string, components ...string) *Route {
+	if len(components) == 0 {
+		panic("octanox: aggregate " + r.combineURL(path) + " has no routes")
+	}
+
+	aggregated := make([]aggregateComponent, 0, len(components))
+	for _, name := range components {
+		route := findAggregateComponent(name)
+		if route == nil {
+			panic(fmt.Sprintf("octanox: aggregate %s references unknown route %q", r.combineURL(path), name))
+		}
+
+		switch {
+		case route.method != http.MethodGet:
+			panic(fmt.Sprintf("octanox: aggregate %s references %s %s, which is no GET route", r.combineURL(path), route.method, route.path))
+		case !route.hasResponseBody() || route.responseType == downloadType:
+			panic(fmt.Sprintf("octanox: aggregate %s references %s %s, which does not respond with a JSON body", r.combineURL(path), route.method, route.path))
+		}
+
+		aggregated = append(aggregated, aggregateComponent{name: name, route: route})
+	}
+
+	requestType := aggregateRequestType(r.combineURL(path), aggregated)
+
+	// the response is declared as the combined response types, but filled with the serialized responses of the handlers
+	declared := make([]reflect.StructField, 0, len(aggregated))
+	served := make([]reflect.StructField, 0, len(aggregated))
+	for _, component := range aggregated {
+		field := reflect.StructField{
+			Name: strings.ToUpper(component.name[:1]) + component.name[1:],
+			Type: component.route.responseType,
+			Tag:  reflect.StructTag(`json:"` + component.name + `"`),
+		}
+		declared = append(declared, field)
+
+		field.Type = anyType
+		served = append(served, field)
+	}
+	responseType := reflect.StructOf(served)
+
+	handlerType := reflect.FuncOf([]reflect.Type{reflect.PointerTo(requestType)}, []reflect.Type{responseType}, false)
+	handler := reflect.MakeFunc(handlerType, func(args []reflect.Value) []reflect.Value {
+		req := args[0].Elem()
+		c := req.FieldByName("Gin").Interface().(*gin.Context)
+		user, _ := req.FieldByName("User").Interface().(User)
+
+		res := reflect.New(responseType).Elem()
+		for n, component := range aggregated {
+			if out := serveAggregateComponent(c, component.route, user); out != nil {
+				res.Field(n).Set(reflect.ValueOf(out))
+			}
+		}
+
+		return []reflect.Value{res}
+	})
+
+	rt := r.RegisterManually(path, handler.Interface(), Current.Authenticator != nil)
+	rt.defaultAccess = true
+	rt.responseType = reflect.StructOf(declared)
+	rt.aggregate = aggregated
+	rt.handlerPackage = aggregated[0].route.handlerPackage
+
+	rt.tx = txReadOnly
+	for _, component := range aggregated {
+		if component.route.tx == txReadWrite {
+			rt.tx = txReadWrite
+		}
+	}
+
+	return rt
+}
+
+// findAggregateComponent returns the registered route whose generated TypeScript function has the given name. Can be nil.
+func findAggregateComponent(name string) *Route {
+	tb := &tsCodeBuilder{opts: Current.tsGenOptions}
+	for _, route := range Current.routes {
+		if tb.generateFunctionName(route) == name {
+			return route
+		}
+	}
+
+	return nil
+}
+
+// aggregateRequestType returns the request type of the aggregate route with the given path, which binds the client parameters
+// of all its routes, so they are validated and generated as parameters of the aggregate route. The handlers bind their own
+// requests from the same parameters.
+func aggregateRequestType(path string, components []aggregateComponent) reflect.Type {
+	fields := []reflect.StructField{
+		{Name: "GetRequest", Type: reflect.TypeOf(GetRequest{}), Anonymous: true},
+		{Name: "Gin", Type: ginContextType, Tag: `gin:"true"`},
+		{Name: "User", Type: userType, Tag: `user:"optional"`},
+	}
+
+	bound := make(map[string]reflect.StructField)
+	for _, component := range components {
+		for _, bf := range component.route.plan.fields {
+			if bf.index == nil || !bf.isClientParam() {
+				continue
+			}
+
+			field := reflect.StructField{Name: bf.field.Name, Type: bf.field.Type, Tag: bf.field.Tag}
+			if existing, ok := bound[field.Name]; ok {
+				if existing.Type != field.Type || existing.Tag != field.Tag {
+					panic(fmt.Sprintf("octanox: aggregate %s binds field %s of %s %s differently than another route", path, field.Name, component.route.method, component.route.path))
+				}
+				continue
+			}
+
+			bound[field.Name] = field
+			fields = append(fields, field)
+		}
+	}
+
+	return reflect.StructOf(fields)
+}
+
+// serveAggregateComponent calls the handler of the given route of an aggregate route and returns its serialized response.
+// Fails the request by panicking, like the handler itself would, if the user may not access the route.
+func serveAggregateComponent(c *gin.Context, rt *Route, user User) any {
+	if Current.requiresAuthentication(rt) {
+		if user == nil {
+			panic(failedRequest{status: http.StatusUnauthorized, message: "unauthorized", code: ErrorCodeUnauthorized})
+		}
+
+		if len(rt.roles) > 0 && !hasAnyRole(user, rt.roles) {
+			panic(failedRequest{status: http.StatusForbidden, message: "forbidden", code: ErrorCodeForbidden})
+		}
+	}
+
+	if rt.quota != nil {
+		Current.consumeQuota(c, rt.quota, user)
+	}
+
+	req := populateRequest(c, rt, rt.plan, user)
+	rv := rt.handlerFunc.Call([]reflect.Value{reflect.ValueOf(req)})
+
+	res := rv[0].Interface()
+	if res == nil {
+		return nil
+	}
+
+	if _, ok := res.(error); ok {
+		panic(res)
+	}
+
+	var sc Context
+	if len(rv) > 1 {
+		sc = rv[1].Interface().(Context)
+	}
+
+	if rt.streamType != nil {
+		res = collectStream(c.Request.Context(), rv[0], rt.responseType.Elem())
+	}
+
+	return Current.Serialize(res, sc)
+}
+
+// hasAnyRole checks if the given user has at least one of the given roles.
+func hasAnyRole(user User, roles []string) bool {
+	for _, role := range roles {
+		if user.HasRole(role) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -37,8 +37,27 @@ const (
 	AuthenticationMethodApiKey
 	// AuthenticationMethodBearerOAuth2 is the Bearer OAuth2 authentication method.
 	AuthenticationMethodBearerOAuth2
+	// AuthenticationMethodCookie is the cookie authentication method.
+	AuthenticationMethodCookie
 )
 
+func (m AuthenticationMethod) String() string {
+	switch m {
+	case AuthenticationMethodBearer:
+		return "bearer"
+	case AuthenticationMethodBasic:
+		return "basic"
+	case AuthenticationMethodApiKey:
+		return "api key"
+	case AuthenticationMethodBearerOAuth2:
+		return "bearer oauth2"
+	case AuthenticationMethodCookie:
+		return "cookie"
+	}
+
+	return "unknown"
+}
+
 // Authenticator is an struct that defines the authentication module.
 type Authenticator interface {
 	// Method returns the authentication method.
@@ -53,6 +72,8 @@ type Authenticator interface {
 type AuthenticatorBuilder struct {
 	instance *Instance
 	provider interface{}
+	// routePattern is the pattern of the routes the authenticator is plugged into. Empty for the Authenticator of the instance.
+	routePattern string
 }
 
 // Plugs in the authentication module into Octanox.
@@ -61,7 +82,7 @@ func (i *Instance) Authenticate(provider interface{}) *AuthenticatorBuilder {
 		panic("octanox: authenticator already exists")
 	}
 
-	return &AuthenticatorBuilder{i, provider}
+	return &AuthenticatorBuilder{instance: i, provider: provider}
 }
 
 // Bearer creates a new BearerAuthenticator with the given secret and plugs it into the Authenticator.
@@ -82,8 +103,7 @@ func (b *AuthenticatorBuilder) Bearer(secret, basePath string) *BearerAuthentica
 
 	bearer.registerRoutes(b.instance.Gin.Group(basePath))
 
-	b.instance.Authenticator = bearer
-	b.instance.authLoginBasePath = basePath
+	b.plug(bearer, basePath)
 
 	return bearer
 }
@@ -120,8 +140,7 @@ func (b *AuthenticatorBuilder) BearerOAuth2(oauth2Endpoint oauth2.Endpoint, scop
 
 	bearer.registerRoutes(b.instance.Gin.Group(basePath))
 
-	b.instance.Authenticator = bearer
-	b.instance.authLoginBasePath = basePath
+	b.plug(bearer, basePath)
 
 	return bearer
 }
@@ -137,7 +156,7 @@ func (b *AuthenticatorBuilder) Basic() *BasicAuthenticator {
 		provider: userProvider,
 	}
 
-	b.instance.Authenticator = basic
+	b.plug(basic, "")
 
 	return basic
 }
@@ -153,7 +172,26 @@ func (b *AuthenticatorBuilder) ApiKey() *ApiKeyAuthenticator {
 		provider: userProvider,
 	}
 
-	b.instance.Authenticator = apiKey
+	b.plug(apiKey, "")
 
 	return apiKey
 }
+
+// Cookie creates a new CookieAuthenticator authenticating requests by the cookie with the given name and plugs it into the
+// Authenticator. The validate function resolves the value of the cookie to the user, and returns nil if the value is invalid,
+// e.g. an expired session. The user provider of the builder is not used. The generated TypeScript client sends its requests
+// with credentials, so the browser sends the cookie.
+func (b *AuthenticatorBuilder) Cookie(name string, validate func(value string) (User, error)) *CookieAuthenticator {
+	if name == "" || validate == nil {
+		panic("octanox: cookie authentication needs a cookie name and a validate function")
+	}
+
+	cookie := &CookieAuthenticator{
+		name:     name,
+		validate: validate,
+	}
+
+	b.plug(cookie, "")
+
+	return cookie
+}
@@ -1,9 +1,35 @@
 package octanox
 
-import "github.com/gin-gonic/gin"
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BasicUserLookup is a function that looks up the user with the given username and returns it together with its stored password,
+// or its stored password hash if a matching verifier is used. If the user does not exist, it should return a nil user.
+type BasicUserLookup func(username string) (user User, storedPassword string, err error)
+
+// PasswordVerifier is a function that checks the given password against the stored password or password hash.
+type PasswordVerifier func(storedPassword, password string) bool
 
 type BasicAuthenticator struct {
 	provider UserProvider
+	lookup   BasicUserLookup
+	verifier PasswordVerifier
+}
+
+// SetLookup makes the authenticator look up users with the given function and verify their passwords itself, instead of
+// delegating both to the user provider's ProvideByUserPass. If the verifier is nil, the stored password is compared with
+// ConstantTimePasswordEqual.
+func (a *BasicAuthenticator) SetLookup(lookup BasicUserLookup, verifier PasswordVerifier) {
+	if verifier == nil {
+		verifier = ConstantTimePasswordEqual
+	}
+
+	a.lookup = lookup
+	a.verifier = verifier
 }
 
 func (a *BasicAuthenticator) Method() AuthenticationMethod {
@@ -16,6 +42,10 @@ func (a *BasicAuthenticator) Authenticate(c *gin.Context) (User, error) {
 		return nil, nil
 	}
 
+	if a.lookup != nil {
+		return a.authenticateByLookup(username, password)
+	}
+
 	user, err := a.provider.ProvideByUserPass(username, password)
 	if err != nil {
 		return nil, err
@@ -23,3 +53,27 @@ func (a *BasicAuthenticator) Authenticate(c *gin.Context) (User, error) {
 
 	return user, nil
 }
+
+func (a *BasicAuthenticator) authenticateByLookup(username, password string) (User, error) {
+	user, storedPassword, err := a.lookup(username)
+	if err != nil {
+		return nil, err
+	}
+
+	// verify unknown users as well, so the response time does not reveal which usernames exist
+	valid := a.verifier(storedPassword, password)
+	if user == nil || !valid {
+		return nil, nil
+	}
+
+	return user, nil
+}
+
+// ConstantTimePasswordEqual checks if the given passwords are equal in constant time. Both are hashed first, so not even their
+// lengths leak through the timing.
+func ConstantTimePasswordEqual(storedPassword, password string) bool {
+	stored := sha256.Sum256([]byte(storedPassword))
+	given := sha256.Sum256([]byte(password))
+
+	return subtle.ConstantTimeCompare(stored[:], given[:]) == 1
+}
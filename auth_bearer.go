@@ -47,7 +47,7 @@ func (a *BearerAuthenticator) login(c *gin.Context) {
 	password := c.PostForm("password")
 
 	if username == "" || password == "" {
-		c.JSON(400, gin.H{"error": "missing username or password"})
+		abortWithError(c, failedRequest{status: 400, message: "missing username or password", code: ErrorCodeInvalidCredentials})
 		return
 	}
 
@@ -57,7 +57,7 @@ func (a *BearerAuthenticator) login(c *gin.Context) {
 	}
 
 	if user == nil {
-		c.JSON(401, gin.H{"error": "invalid username or password"})
+		abortWithError(c, failedRequest{status: 401, message: "invalid username or password", code: ErrorCodeInvalidCredentials})
 		return
 	}
 
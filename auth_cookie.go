@@ -0,0 +1,38 @@
+package octanox
+
+import "github.com/gin-gonic/gin"
+
+// CookieAuthenticator is a struct that authenticates requests by a cookie, e.g. the session cookie of a server-side rendered
+// app or a same-origin SPA. The value of the cookie is resolved to the user by the validate function it has been created with.
+type CookieAuthenticator struct {
+	name     string
+	validate func(value string) (User, error)
+}
+
+func (a *CookieAuthenticator) Method() AuthenticationMethod {
+	return AuthenticationMethodCookie
+}
+
+func (a *CookieAuthenticator) Authenticate(c *gin.Context) (User, error) {
+	value, err := c.Cookie(a.name)
+	if err != nil || value == "" {
+		return nil, nil
+	}
+
+	return a.validate(value)
+}
+
+// CookieName returns the name of the cookie the requests are authenticated by.
+func (a *CookieAuthenticator) CookieName() string {
+	return a.name
+}
+
+// credentialsInit returns the credentials field of an object literal of a RequestInit, which sends the requests with the
+// cookies of the server if they are authenticated by a cookie.
+func (tb *tsCodeBuilder) credentialsInit() string {
+	if tb.cookieAuth {
+		return " credentials: 'include',"
+	}
+
+	return ""
+}
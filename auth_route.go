@@ -0,0 +1,193 @@
+package octanox
+
+// routeAuthenticator is an authenticator overriding the authenticator of the instance for the routes matching its pattern.
+type routeAuthenticator struct {
+	pattern       string
+	authenticator Authenticator
+}
+
+// SetRouteAuthenticator overrides the Authenticator of the instance for the routes matching the given pattern, e.g. to
+// authenticate the routes of an admin group by API key while the others are authenticated by bearer token. The pattern is
+// matched against the path of the routes: segments starting with ":" match any segment, and a trailing segment starting
+// with "*" matches the rest of the path, e.g. "/admin/*". The authenticator can be nil, which makes the matching routes
+// registered with Register public, while the matching routes registered with RegisterProtected reject every request with
+// 401 Unauthorized. Routes registered with Register are protected if a pattern with an authenticator matches them, even if
+// the instance has no Authenticator. Patterns are checked in the order they are set, and the first matching pattern wins,
+// regardless of whether the routes are registered before or after. The generated TypeScript client authenticates each
+// function by the scheme of its route.
+func (i *Instance) SetRouteAuthenticator(routePattern string, a Authenticator) *Instance {
+	if routePattern == "" {
+		panic("octanox: route authenticator needs a route pattern")
+	}
+
+	i.routeAuthenticators = append(i.routeAuthenticators, routeAuthenticator{pattern: routePattern, authenticator: a})
+	return i
+}
+
+// AuthenticateRoutes builds an authenticator like Authenticate, but plugs it in as authenticator of the routes matching the
+// given pattern with SetRouteAuthenticator instead of as the Authenticator of the instance.
+func (i *Instance) AuthenticateRoutes(routePattern string, provider interface{}) *AuthenticatorBuilder {
+	if routePattern == "" {
+		panic("octanox: route authenticator needs a route pattern")
+	}
+
+	return &AuthenticatorBuilder{instance: i, provider: provider, routePattern: routePattern}
+}
+
+// plug plugs the built authenticator into the instance, or into the routes matching the pattern of the builder.
+func (b *AuthenticatorBuilder) plug(a Authenticator, basePath string) {
+	if b.routePattern != "" {
+		b.instance.SetRouteAuthenticator(b.routePattern, a)
+		return
+	}
+
+	b.instance.Authenticator = a
+	if basePath != "" {
+		b.instance.authLoginBasePath = basePath
+	}
+}
+
+// authenticatorFor returns the authenticator of the given route: the authenticator of the first route pattern matching its
+// path, or the Authenticator of the instance. Can be nil if the route is not authenticated.
+func (i *Instance) authenticatorFor(route *Route) Authenticator {
+	if a, ok := i.routeAuthenticatorFor(route); ok {
+		return a
+	}
+
+	return i.Authenticator
+}
+
+// requiresAuthentication checks if the requests of the given route need an authenticated user. Routes registered with
+// Register need one if they have an authenticator, which can be a route authenticator set after their registration.
+func (i *Instance) requiresAuthentication(route *Route) bool {
+	if route.defaultAccess {
+		return i.authenticatorFor(route) != nil
+	}
+
+	return route.authenticated
+}
+
+// rejectsWithoutAuthenticator checks if the request of the given route is rejected because the route requires an
+// authenticated user but a route authenticator removed its authenticator, so protected routes never become public by a
+// route pattern.
+func (i *Instance) rejectsWithoutAuthenticator(route *Route, authenticated bool) bool {
+	if !authenticated {
+		return false
+	}
+
+	a, overridden := i.routeAuthenticatorFor(route)
+	return overridden && a == nil
+}
+
+// routeAuthenticatorFor returns the authenticator of the first route pattern matching the path of the given route, and
+// whether any pattern matches.
+func (i *Instance) routeAuthenticatorFor(route *Route) (Authenticator, bool) {
+	for _, ra := range i.routeAuthenticators {
+		if matchesRoutePath(ra.pattern, route.path) {
+			return ra.authenticator, true
+		}
+	}
+
+	return nil, false
+}
+
+// authenticationScheme returns the name of the scheme the given authenticator authenticates requests with, or "none" if it is nil.
+func authenticationScheme(a Authenticator) string {
+	if a == nil {
+		return "none"
+	}
+
+	return a.Method().String()
+}
+
+// routeAuthenticatorsOf returns the authenticators of the given routes whose scheme differs from the scheme of the Authenticator
+// of the instance, one per scheme, in the order of the routes. Empty if all routes are authenticated by the instance.
+func (i *Instance) routeAuthenticatorsOf(routes []*Route) []Authenticator {
+	var result []Authenticator
+	if len(i.routeAuthenticators) == 0 {
+		return result
+	}
+
+	seen := map[string]bool{authenticationScheme(i.Authenticator): true}
+	for _, route := range routes {
+		a := i.authenticatorFor(route)
+		if scheme := authenticationScheme(a); !seen[scheme] {
+			seen[scheme] = true
+			result = append(result, a)
+		}
+	}
+
+	return result
+}
+
+// mixedAuth checks if any route of the client is authenticated by another scheme than the Authenticator of the instance, so
+// the helpers take the base config of the route.
+func (tb *tsCodeBuilder) mixedAuth() bool {
+	return len(tb.routeAuthenticators) > 0
+}
+
+// usesAuthenticationMethod checks if the Authenticator of the instance or the authenticator of any route of the client
+// authenticates with the given method.
+func (tb *tsCodeBuilder) usesAuthenticationMethod(method AuthenticationMethod) bool {
+	for _, a := range append([]Authenticator{tb.authenticator}, tb.routeAuthenticators...) {
+		if a != nil && a.Method() == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// baseConfigName returns the name of the function returning the base config of the requests authenticated by the given
+// authenticator of a route, which can be nil for public routes.
+func baseConfigName(a Authenticator) string {
+	if a == nil {
+		return "getBaseConfigPublic"
+	}
+
+	switch a.Method() {
+	case AuthenticationMethodBasic:
+		return "getBaseConfigBasic"
+	case AuthenticationMethodApiKey:
+		return "getBaseConfigApiKey"
+	case AuthenticationMethodBearerOAuth2:
+		return "getBaseConfigOAuth2"
+	case AuthenticationMethodCookie:
+		return "getBaseConfigCookie"
+	default:
+		return "getBaseConfigBearer"
+	}
+}
+
+// baseConfigParam returns the parameter of the helpers taking the base config of the requests of the route, which defaults
+// to the base config of the Authenticator of the instance. Empty if all routes are authenticated by the instance.
+func (tb *tsCodeBuilder) baseConfigParam() string {
+	if !tb.mixedAuth() {
+		return ""
+	}
+
+	if tb.classStyle() {
+		return ", base: Promise<RequestInit> = this.getBaseConfig()"
+	}
+
+	return ", base: RequestInit = getBaseConfig()"
+}
+
+// baseConfigArg returns the base config argument of the helper call of the given route, or an empty string if the route is
+// authenticated by the scheme of the Authenticator of the instance.
+func (tb *tsCodeBuilder) baseConfigArg(route *Route) string {
+	if !tb.mixedAuth() {
+		return ""
+	}
+
+	a := tb.authenticatorFor(route)
+	if authenticationScheme(a) == authenticationScheme(tb.authenticator) {
+		return ""
+	}
+
+	if tb.classStyle() {
+		return "this.getBaseConfig(" + tsStringLiteral(authenticationScheme(a)) + ")"
+	}
+
+	return baseConfigName(a) + "()"
+}
@@ -0,0 +1,96 @@
+package octanox
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type authRouteRequest struct {
+	GetRequest
+}
+
+type authRouteResponse struct {
+	Secret string `json:"secret"`
+}
+
+func authRouteHandler(*authRouteRequest) *authRouteResponse {
+	return &authRouteResponse{Secret: "secret"}
+}
+
+func TestRouteAuthenticatorWithoutInstanceAuthenticator(t *testing.T) {
+	i := newTestInstance(t)
+	i.Register("/admin/stats", authRouteHandler)
+	i.Register("/health", authRouteHandler)
+	i.RegisterPublic("/admin/public", authRouteHandler)
+	// the pattern is set after the registration of the routes
+	i.SetRouteAuthenticator("/admin/*", headerAuthenticator{method: AuthenticationMethodApiKey})
+
+	tests := []struct {
+		path, user string
+		status     int
+	}{
+		{"/admin/stats", "", http.StatusUnauthorized},
+		{"/admin/stats", "alice", http.StatusOK},
+		{"/admin/public", "", http.StatusOK},
+		{"/health", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.user != "" {
+			req.Header.Set("X-Test-User", tt.user)
+		}
+
+		if rec := serveTest(i, req); rec.Code != tt.status {
+			t.Errorf("GET %s as %q: status %d, want %d: %s", tt.path, tt.user, rec.Code, tt.status, rec.Body.String())
+		}
+	}
+}
+
+func TestRouteAuthenticatorNilKeepsProtectedRoutesProtected(t *testing.T) {
+	i := newTestInstance(t)
+	i.Authenticator = headerAuthenticator{method: AuthenticationMethodBearer}
+	i.SetRouteAuthenticator("/open/*", nil)
+	i.Register("/open/default", authRouteHandler)
+	i.RegisterProtected("/open/protected", authRouteHandler)
+	i.Register("/me", authRouteHandler)
+
+	tests := []struct {
+		path, user string
+		status     int
+	}{
+		{"/open/default", "", http.StatusOK},
+		{"/open/protected", "", http.StatusUnauthorized},
+		{"/open/protected", "alice", http.StatusUnauthorized},
+		{"/me", "", http.StatusUnauthorized},
+		{"/me", "alice", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.user != "" {
+			req.Header.Set("X-Test-User", tt.user)
+		}
+
+		if rec := serveTest(i, req); rec.Code != tt.status {
+			t.Errorf("GET %s as %q: status %d, want %d: %s", tt.path, tt.user, rec.Code, tt.status, rec.Body.String())
+		}
+	}
+}
+
+func TestRouteAuthenticatorFirstPatternWins(t *testing.T) {
+	i := newTestInstance(t)
+	basic := headerAuthenticator{method: AuthenticationMethodBasic}
+	i.SetRouteAuthenticator("/users/:id", basic)
+	i.SetRouteAuthenticator("/users/*", nil)
+	i.Register("/users/:id", authRouteHandler)
+	i.Register("/users/:id/avatar", authRouteHandler)
+
+	if a := i.authenticatorFor(i.routes[0]); a != basic {
+		t.Errorf("authenticator of %s is %v, want the first matching pattern", i.routes[0].path, a)
+	}
+	if a := i.authenticatorFor(i.routes[1]); a != nil {
+		t.Errorf("authenticator of %s is %v, want nil", i.routes[1].path, a)
+	}
+}
@@ -0,0 +1,382 @@
+package octanox
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// bindingSource is the source a request field is bound from.
+type bindingSource int
+
+const (
+	sourceNone bindingSource = iota
+	sourceList
+	sourceUser
+	sourceGin
+	sourceInject
+	sourceTenant
+	sourcePath
+	sourceQuery
+	sourceHeader
+	sourceCookie
+	sourceClaim
+	sourceBody
+	sourceRawBody
+	sourceForm
+	sourceFile
+)
+
+// ClaimProvider is an optional interface of users exposing claims, e.g. of their token. Request fields tagged with
+// `claim:"<name>"` are bound to the claim of the authenticated user.
+type ClaimProvider interface {
+	// Claim returns the value of the claim with the given name and whether the user has it.
+	Claim(name string) (string, bool)
+}
+
+// paramConverter converts the raw string value of a parameter into the value of the field it is bound to.
+type paramConverter func(raw string) (reflect.Value, error)
+
+// bindingField is the parsed binding of a single request field.
+type bindingField struct {
+	// index is the index path of the field, leading through the embedded structs it is promoted from.
+	index  []int
+	field  reflect.StructField
+	source bindingSource
+	// name is the wire name of the field, e.g. the name of the query parameter.
+	name string
+	// required is a flag that indicates whether the request fails if the parameter is missing.
+	required bool
+	// def is the default value of a missing parameter. Only used if hasDefault is set.
+	def        string
+	hasDefault bool
+	// convert converts the raw value of a parameter, or of each element of a slice query parameter. Only set for parameter
+	// sources.
+	convert paramConverter
+	// array is the format of a slice query parameter. Empty for all other fields.
+	array queryArrayFormat
+	// group is a flag that indicates whether the path parameter is declared by a group of the route, which binds it once for
+	// all its routes. Fields of parameters that the request struct does not bind have no index.
+	group bool
+}
+
+// queryArrayFormat is the format a slice query parameter is sent in, declared by the option of its tag, e.g.
+// `query:"tags,csv"`.
+type queryArrayFormat string
+
+const (
+	// queryArrayRepeat repeats the parameter for every value, e.g. ?tags=a&tags=b. It is the default.
+	queryArrayRepeat queryArrayFormat = "repeat"
+	// queryArrayCSV joins the values with commas, e.g. ?tags=a,b, so the values cannot contain commas.
+	queryArrayCSV queryArrayFormat = "csv"
+)
+
+// bindingPlan is the parsed binding of all fields of a request struct, including the fields promoted from embedded structs. It is
+// computed once per type at registration, so the binder does not parse struct tags per request, and it is the single source
+// the validator and the generators read the parameters from, so they cannot disagree with the server.
+type bindingPlan struct {
+	t      reflect.Type
+	fields []bindingField
+}
+
+// bindingPlans caches the plans of all request types by type.
+var bindingPlans sync.Map
+
+// planBinding returns the binding plan of the given request struct type, computing it on first use. Returns an error if a field
+// cannot be bound, e.g. because a parameter has an unsupported type.
+func planBinding(t reflect.Type) (*bindingPlan, error) {
+	if plan, ok := bindingPlans.Load(t); ok {
+		return plan.(*bindingPlan), nil
+	}
+
+	plan := &bindingPlan{t: t, fields: make([]bindingField, 0, t.NumField())}
+	if err := plan.collect(t, nil); err != nil {
+		return nil, err
+	}
+	if err := plan.validateForm(); err != nil {
+		return nil, err
+	}
+
+	actual, _ := bindingPlans.LoadOrStore(t, plan)
+	return actual.(*bindingPlan), nil
+}
+
+func (p *bindingPlan) collect(t reflect.Type, index []int) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		bf := bindingField{index: append(append([]int{}, index...), i), field: field}
+		switch {
+		case field.Type == listQueryType:
+			bf.source = sourceList
+		case field.Anonymous:
+			if field.Type.Kind() == reflect.Struct {
+				if err := p.collect(field.Type, bf.index); err != nil {
+					return err
+				}
+			}
+			continue
+		case field.Tag.Get("user") != "":
+			bf.source, bf.name = sourceUser, field.Tag.Get("user")
+			bf.required = bf.name != "optional"
+		case field.Tag.Get("gin") != "":
+			if field.Type.Kind() != reflect.Ptr {
+				return fmt.Errorf("field %s with 'gin' tag must be a pointer to a gin.Context", field.Name)
+			}
+			bf.source = sourceGin
+		case field.Tag.Get("inject") != "":
+			bf.source = sourceInject
+		case field.Tag.Get("tenant") != "":
+			if field.Type.Kind() != reflect.String {
+				return fmt.Errorf("field %s with 'tenant' tag must be a string", field.Name)
+			}
+			bf.source = sourceTenant
+		case field.Tag.Get("path") != "":
+			bf.source, bf.name = sourcePath, field.Tag.Get("path")
+		case field.Tag.Get("query") != "":
+			name, format, _ := strings.Cut(field.Tag.Get("query"), ",")
+			bf.source, bf.name = sourceQuery, name
+			if field.Type.Kind() == reflect.Slice {
+				bf.array = queryArrayFormat(format)
+				if format == "" {
+					bf.array = queryArrayRepeat
+				}
+				if bf.array != queryArrayRepeat && bf.array != queryArrayCSV {
+					return fmt.Errorf("query parameter field %s has unknown array format %q, use repeat or csv", field.Name, format)
+				}
+			} else if format != "" {
+				return fmt.Errorf("query parameter field %s has the array format %q but is no slice", field.Name, format)
+			}
+		case field.Tag.Get("header") != "":
+			bf.source, bf.name = sourceHeader, field.Tag.Get("header")
+		case field.Tag.Get("cookie") != "":
+			bf.source, bf.name = sourceCookie, field.Tag.Get("cookie")
+		case field.Tag.Get("claim") != "":
+			bf.source, bf.name = sourceClaim, field.Tag.Get("claim")
+		case field.Tag.Get("form") != "":
+			bf.source, bf.name = sourceForm, field.Tag.Get("form")
+		case field.Tag.Get("file") != "":
+			if field.Type != fileHeaderType {
+				return fmt.Errorf("field %s with 'file' tag must be a *multipart.FileHeader", field.Name)
+			}
+			bf.source, bf.name = sourceFile, field.Tag.Get("file")
+			bf.required = field.Tag.Get("optional") != "true"
+		case field.Tag.Get("body") == "raw":
+			if field.Type != rawBodyType {
+				return fmt.Errorf("field %s with 'body:\"raw\"' tag must be a nox.RawBody", field.Name)
+			}
+			bf.source = sourceRawBody
+		case field.Tag.Get("body") != "":
+			bf.source, bf.name = sourceBody, field.Tag.Get("body")
+		default:
+			continue
+		}
+
+		if bf.isParam() {
+			// slice query parameters convert each of their values
+			t := field.Type
+			if bf.array != "" {
+				t = t.Elem()
+			}
+
+			convert, ok := converterFor(t)
+			if !ok {
+				return fmt.Errorf("%s parameter field %s has unsupported type %s", bf.sourceName(), field.Name, field.Type.String())
+			}
+			bf.convert = convert
+
+			bf.def, bf.hasDefault = field.Tag.Lookup("default")
+			if bf.hasDefault {
+				if _, err := bf.convertAll(bf.defaultValues()); err != nil {
+					return fmt.Errorf("%s parameter field %s has an invalid default: %w", bf.sourceName(), field.Name, err)
+				}
+			}
+
+			// a missing slice query parameter is an empty list
+			bf.required = bf.source != sourcePath && !bf.hasDefault && field.Tag.Get("optional") != "true" && field.Type.Kind() != reflect.Ptr && bf.array == ""
+		}
+
+		p.fields = append(p.fields, bf)
+	}
+
+	return nil
+}
+
+// bodyField returns the field bound to the request body, or nil if the request has no body.
+func (p *bindingPlan) bodyField() *bindingField {
+	for n := range p.fields {
+		if p.fields[n].source == sourceBody {
+			return &p.fields[n]
+		}
+	}
+
+	return nil
+}
+
+// rawBodyField returns the field bound to the raw request body, or nil if there is none.
+func (p *bindingPlan) rawBodyField() *bindingField {
+	for n := range p.fields {
+		if p.fields[n].source == sourceRawBody {
+			return &p.fields[n]
+		}
+	}
+
+	return nil
+}
+
+// clientBodyField returns the field the generated client sends as body: the raw body field if there is one, as the decoded
+// body is decoded from the same bytes, or the body field. Can be nil.
+func (p *bindingPlan) clientBodyField() *bindingField {
+	if bf := p.rawBodyField(); bf != nil {
+		return bf
+	}
+
+	return p.bodyField()
+}
+
+// clientParam returns the field with the given name which is sent by the generated client, or nil if there is none.
+func (p *bindingPlan) clientParam(name string) *bindingField {
+	for n := range p.fields {
+		if p.fields[n].field.Name == name && p.fields[n].isClientParam() {
+			return &p.fields[n]
+		}
+	}
+
+	return nil
+}
+
+// isParam checks if the field is bound from a single string value, which is converted into the field's type.
+func (bf *bindingField) isParam() bool {
+	switch bf.source {
+	case sourcePath, sourceQuery, sourceHeader, sourceCookie, sourceClaim, sourceForm:
+		return true
+	}
+
+	return false
+}
+
+// isClientParam checks if the field is sent by the generated client as path, query or header parameter, as body or as field
+// of a form.
+func (bf *bindingField) isClientParam() bool {
+	switch bf.source {
+	case sourcePath, sourceQuery, sourceHeader, sourceBody, sourceRawBody, sourceForm, sourceFile:
+		return true
+	}
+
+	return false
+}
+
+// sourceName returns the human readable name of the source of the field.
+func (bf *bindingField) sourceName() string {
+	switch bf.source {
+	case sourcePath:
+		return "path"
+	case sourceQuery:
+		return "query"
+	case sourceHeader:
+		return "header"
+	case sourceCookie:
+		return "cookie"
+	case sourceClaim:
+		return "claim"
+	case sourceBody, sourceRawBody:
+		return "body"
+	case sourceForm, sourceFile:
+		return "form"
+	}
+
+	return "request"
+}
+
+// defaultValues returns the raw values of the default of the parameter, split by commas for slice query parameters.
+func (bf *bindingField) defaultValues() []string {
+	if bf.array == "" {
+		return []string{bf.def}
+	}
+
+	return splitCSV(bf.def)
+}
+
+// convertAll converts the raw values of the parameter into the value of its field. Slice query parameters convert all raw
+// values, all other parameters the only one.
+func (bf *bindingField) convertAll(raw []string) (reflect.Value, error) {
+	if bf.array == "" {
+		return bf.convert(raw[0])
+	}
+
+	values := reflect.MakeSlice(bf.field.Type, 0, len(raw))
+	for _, r := range raw {
+		v, err := bf.convert(r)
+		if err != nil {
+			return values, err
+		}
+
+		values = reflect.Append(values, v)
+	}
+
+	return values, nil
+}
+
+// splitCSV splits the given comma separated values. Returns no values for an empty string.
+func splitCSV(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, ",")
+}
+
+// converterFor returns the converter of parameters bound to fields of the given type. Pointers are supported for optional parameters.
+func converterFor(t reflect.Type) (paramConverter, bool) {
+	if t.Kind() == reflect.Ptr {
+		convert, ok := converterFor(t.Elem())
+		if !ok {
+			return nil, false
+		}
+
+		return func(raw string) (reflect.Value, error) {
+			v, err := convert(raw)
+			if err != nil {
+				return v, err
+			}
+
+			ptr := reflect.New(t.Elem())
+			ptr.Elem().Set(v)
+			return ptr, nil
+		}, true
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return func(raw string) (reflect.Value, error) {
+			return reflect.ValueOf(raw).Convert(t), nil
+		}, true
+	case reflect.Bool:
+		return func(raw string) (reflect.Value, error) {
+			b, err := strconv.ParseBool(raw)
+			return reflect.ValueOf(b).Convert(t), err
+		}, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(raw string) (reflect.Value, error) {
+			n, err := strconv.ParseInt(raw, 10, t.Bits())
+			return reflect.ValueOf(n).Convert(t), err
+		}, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(raw string) (reflect.Value, error) {
+			n, err := strconv.ParseUint(raw, 10, t.Bits())
+			return reflect.ValueOf(n).Convert(t), err
+		}, true
+	case reflect.Float32, reflect.Float64:
+		return func(raw string) (reflect.Value, error) {
+			f, err := strconv.ParseFloat(raw, t.Bits())
+			return reflect.ValueOf(f).Convert(t), err
+		}, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,236 @@
+package octanox
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Budget is a struct that declares the expected request size, response size and latency of a route. Zero values are unlimited.
+type Budget struct {
+	// MaxRequestBytes is the maximum size of the request body in bytes.
+	MaxRequestBytes int64
+	// MaxResponseBytes is the maximum size of the response body in bytes.
+	MaxResponseBytes int64
+	// MaxLatency is the maximum duration of handling the request.
+	MaxLatency time.Duration
+}
+
+// BudgetEnforcement is an enum that defines what happens when a request exceeds the budget of its route.
+type BudgetEnforcement int
+
+const (
+	// BudgetsReported only counts violations in the route stats and reports them to Instance.ReplayBudgets. This is the default.
+	BudgetsReported BudgetEnforcement = iota
+	// BudgetsLogged additionally logs every violation.
+	BudgetsLogged
+	// BudgetsRejected additionally replaces responses exceeding the response size budget with 500 Internal Server Error.
+	// The response is buffered to do so, which is why this is meant for staging environments.
+	BudgetsRejected
+)
+
+// Budget kinds reported in BudgetViolation.Kind.
+const (
+	BudgetRequestBytes  = "request_bytes"
+	BudgetResponseBytes = "response_bytes"
+	BudgetLatency       = "latency"
+)
+
+// BudgetViolation is an error describing a request that exceeded the budget of its route.
+type BudgetViolation struct {
+	Method string
+	Path   string
+	// Kind is the exceeded part of the budget, one of BudgetRequestBytes, BudgetResponseBytes and BudgetLatency.
+	Kind string
+	// Limit is the budgeted value, in bytes or nanoseconds.
+	Limit int64
+	// Actual is the measured value, in bytes or nanoseconds.
+	Actual int64
+}
+
+func (v *BudgetViolation) Error() string {
+	if v.Kind == BudgetLatency {
+		return v.Method + " " + v.Path + ": latency " + time.Duration(v.Actual).String() + " exceeds the budget of " + time.Duration(v.Limit).String()
+	}
+
+	return v.Method + " " + v.Path + ": " + v.Kind + " " + strconv.FormatInt(v.Actual, 10) + " exceeds the budget of " + strconv.FormatInt(v.Limit, 10)
+}
+
+type budgetSinkKey struct{}
+
+// budgetSink collects the violations of replayed requests.
+type budgetSink struct {
+	mu         sync.Mutex
+	violations []BudgetViolation
+}
+
+// Budget declares the expected request size, response size and latency of this route.
+func (r *Route) Budget(budget Budget) *Route {
+	r.budget = &budget
+	return r
+}
+
+// SetBudgetEnforcement sets what happens when a request exceeds the budget of its route.
+func (i *Instance) SetBudgetEnforcement(enforcement BudgetEnforcement) *Instance {
+	i.budgetEnforcement = enforcement
+	return i
+}
+
+// ReplayBudgets replays the given requests against the instance and returns every budget violation they caused.
+func (i *Instance) ReplayBudgets(requests []*http.Request) []BudgetViolation {
+	sink := &budgetSink{}
+	handler := i.Handler()
+
+	for _, req := range requests {
+		req = req.WithContext(context.WithValue(req.Context(), budgetSinkKey{}, sink))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	return sink.violations
+}
+
+// enforceBudget runs the given handler while measuring the request against the budget of the route.
+func enforceBudget(c *gin.Context, rt *Route, handler func()) {
+	budget := rt.budget
+	start := time.Now()
+
+	body := &countingReader{r: c.Request.Body}
+	if c.Request.Body != nil {
+		c.Request.Body = readCloser{body, c.Request.Body}
+	}
+
+	var buffered *bufferedResponseWriter
+	if Current.budgetEnforcement == BudgetsRejected && budget.MaxResponseBytes > 0 {
+		buffered = &bufferedResponseWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = buffered
+	}
+
+	defer func() {
+		// let the recovery middleware write to the actual response of a panicking handler
+		if err := recover(); err != nil {
+			if buffered != nil {
+				c.Writer = buffered.ResponseWriter
+			}
+			panic(err)
+		}
+	}()
+
+	handler()
+
+	violations := make([]BudgetViolation, 0)
+	check := func(kind string, limit, actual int64) {
+		if limit > 0 && actual > limit {
+			violations = append(violations, BudgetViolation{Method: rt.method, Path: rt.path, Kind: kind, Limit: limit, Actual: actual})
+		}
+	}
+
+	responseBytes := int64(c.Writer.Size())
+	if buffered != nil {
+		responseBytes = int64(buffered.body.Len())
+	}
+
+	check(BudgetRequestBytes, budget.MaxRequestBytes, body.n)
+	check(BudgetResponseBytes, budget.MaxResponseBytes, responseBytes)
+	check(BudgetLatency, int64(budget.MaxLatency), int64(time.Since(start)))
+
+	if buffered != nil {
+		c.Writer = buffered.ResponseWriter
+		if responseBytes > budget.MaxResponseBytes {
+			abortWithError(c, failedRequest{
+				status:  http.StatusInternalServerError,
+				message: "Response exceeds the budget of the route",
+				code:    ErrorCodeBudgetExceeded,
+			})
+		} else {
+			buffered.flush()
+		}
+	}
+
+	Current.reportBudgetViolations(c, violations)
+}
+
+// reportBudgetViolations counts, logs and collects the given violations according to the budget enforcement.
+func (i *Instance) reportBudgetViolations(c *gin.Context, violations []BudgetViolation) {
+	if len(violations) == 0 {
+		return
+	}
+
+	if sink, ok := c.Request.Context().Value(budgetSinkKey{}).(*budgetSink); ok {
+		sink.mu.Lock()
+		sink.violations = append(sink.violations, violations...)
+		sink.mu.Unlock()
+	}
+
+	for _, violation := range violations {
+		if i.routeStats != nil {
+			i.routeStats.recordBudgetViolation(violation.Method, c.FullPath())
+		}
+
+		if i.budgetEnforcement != BudgetsReported {
+			log.Println("octanox: budget exceeded: " + violation.Error())
+		}
+	}
+}
+
+// readCloser combines a reader with the closer of the body it reads from.
+type readCloser struct {
+	*countingReader
+	closer io.Closer
+}
+
+func (r readCloser) Close() error {
+	return r.closer.Close()
+}
+
+// bufferedResponseWriter holds back the response until it is flushed, so it can still be replaced.
+type bufferedResponseWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *bufferedResponseWriter) WriteHeaderNow() {}
+
+// Flush does nothing, as flushing the underlying writer would send its status before the response is released.
+func (w *bufferedResponseWriter) Flush() {}
+
+func (w *bufferedResponseWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedResponseWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedResponseWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedResponseWriter) Size() int {
+	if w.body.Len() == 0 {
+		return -1
+	}
+
+	return w.body.Len()
+}
+
+func (w *bufferedResponseWriter) Written() bool {
+	return w.body.Len() > 0
+}
+
+func (w *bufferedResponseWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(w.body.Bytes())
+}
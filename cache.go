@@ -0,0 +1,190 @@
+package octanox
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Cache is a concurrency-safe in-memory singleton of a small, read-mostly dataset, which is reloaded once it is older than its TTL.
+// Expired values keep being served while a single background refresh is running, so lookups never wait for the loader after
+// the first load. Provide it with Instance.Provide and inject it into request structs with the `inject:"true"` tag.
+type Cache[T any] struct {
+	loader func(ctx context.Context) (T, error)
+	ttl    time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu       sync.RWMutex
+	value    T
+	loaded   bool
+	loadedAt time.Time
+	inflight *cacheLoad[T]
+	// generation is incremented by every Invalidate, loads started in an older generation are not stored.
+	generation uint64
+	stats      CacheStats
+}
+
+// CacheStats is a struct that contains the refresh metrics of a Cache.
+type CacheStats struct {
+	// Refreshes is the number of successful loads.
+	Refreshes int
+	// RefreshFailures is the number of failed loads.
+	RefreshFailures int
+	// LastError is the error of the last failed load. Can be nil.
+	LastError error
+	// LoadedAt is the time the current value was loaded at. Zero if no value has been loaded yet.
+	LoadedAt time.Time
+}
+
+// cacheLoad is a load of a Cache in progress, which concurrent callers wait for instead of loading again.
+type cacheLoad[T any] struct {
+	done chan struct{}
+	// generation is the generation of the cache the load has been started in.
+	generation uint64
+	value      T
+	err        error
+}
+
+// Cached creates a cache of the value returned by the loader, which is refreshed once it is older than the given TTL. Background
+// refreshes are stopped when the current instance shuts down.
+//
+//	countries := nox.Cached(func(ctx context.Context) ([]Country, error) {
+//		return db.LoadCountries(ctx)
+//	}, time.Hour)
+//	instance.Provide(countries)
+//
+//	type ListCountriesRequest struct {
+//		nox.GetRequest
+//		Countries *nox.Cache[[]Country] `inject:"true"`
+//	}
+//
+//	instance.Register("/countries", func(r *ListCountriesRequest) []Country {
+//		countries, err := r.Countries.Get(context.Background())
+//		if err != nil {
+//			r.Failed(503, "Countries unavailable")
+//		}
+//		return countries
+//	})
+func Cached[T any](loader func(ctx context.Context) (T, error), ttl time.Duration) *Cache[T] {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Cache[T]{
+		loader: loader,
+		ttl:    ttl,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	if Current != nil {
+		Current.Hook(Hook_Shutdown, func(*Instance) {
+			c.Close()
+		})
+	}
+
+	return c
+}
+
+// Get returns the cached value. The first call, and the first call after Invalidate, waits for the loader. Afterwards, an expired
+// value is returned right away while it is refreshed in the background.
+func (c *Cache[T]) Get(ctx context.Context) (T, error) {
+	c.mu.RLock()
+	value, loaded, fresh := c.value, c.loaded, time.Since(c.loadedAt) < c.ttl
+	c.mu.RUnlock()
+
+	if loaded && fresh {
+		return value, nil
+	}
+
+	load := c.startLoad()
+	if loaded {
+		return value, nil
+	}
+
+	select {
+	case <-load.done:
+		return load.value, load.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Invalidate drops the cached value, so the next Get waits for a fresh one. A load in progress may have read the data before
+// it changed, so its value is not stored and the next Get starts a new load instead of waiting for it.
+func (c *Cache[T]) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero T
+	c.value = zero
+	c.loaded = false
+	c.generation++
+	c.inflight = nil
+}
+
+// Stats returns the refresh metrics of the cache.
+func (c *Cache[T]) Stats() CacheStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.stats
+}
+
+// Close stops the background refreshes and waits for the running one to finish.
+func (c *Cache[T]) Close() {
+	c.cancel()
+	c.wg.Wait()
+}
+
+// startLoad starts loading the value in the background, or returns the load already in progress.
+func (c *Cache[T]) startLoad() *cacheLoad[T] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.inflight != nil {
+		return c.inflight
+	}
+
+	load := &cacheLoad[T]{done: make(chan struct{}), generation: c.generation}
+	c.inflight = load
+
+	if c.ctx.Err() != nil {
+		load.err = c.ctx.Err()
+		c.inflight = nil
+		close(load.done)
+		return load
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		value, err := c.loader(c.ctx)
+
+		c.mu.Lock()
+		switch {
+		case load.generation != c.generation:
+			// the cache has been invalidated while loading
+		case err != nil:
+			c.stats.RefreshFailures++
+			c.stats.LastError = err
+		default:
+			c.value = value
+			c.loaded = true
+			c.loadedAt = time.Now()
+			c.stats.Refreshes++
+			c.stats.LoadedAt = c.loadedAt
+		}
+		if c.inflight == load {
+			c.inflight = nil
+		}
+		c.mu.Unlock()
+
+		load.value, load.err = value, err
+		close(load.done)
+	}()
+
+	return load
+}
@@ -0,0 +1,78 @@
+package octanox
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingLoader returns a loader whose nth call returns "load n" once the nth channel of release is closed, and reports the
+// call to started.
+func blockingLoader(release []chan struct{}, started chan<- int) func(ctx context.Context) (string, error) {
+	var calls int32
+	return func(ctx context.Context) (string, error) {
+		n := int(atomic.AddInt32(&calls, 1))
+		started <- n
+		<-release[n-1]
+		return "load " + strconv.Itoa(n), nil
+	}
+}
+
+func TestCacheInvalidateDropsLoadInProgress(t *testing.T) {
+	release := []chan struct{}{make(chan struct{}), make(chan struct{})}
+	started := make(chan int, 2)
+	cache := Cached(blockingLoader(release, started), time.Hour)
+	t.Cleanup(cache.Close)
+	// a failing test still lets the loads finish, so Close does not wait forever
+	t.Cleanup(func() {
+		for _, ch := range release {
+			select {
+			case <-ch:
+			default:
+				close(ch)
+			}
+		}
+	})
+
+	stale := make(chan string, 1)
+	go func() {
+		value, _ := cache.Get(context.Background())
+		stale <- value
+	}()
+	<-started
+
+	cache.Invalidate()
+
+	fresh := make(chan string, 1)
+	go func() {
+		value, _ := cache.Get(context.Background())
+		fresh <- value
+	}()
+	select {
+	case n := <-started:
+		if n != 2 {
+			t.Fatalf("load %d started, want 2", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Get after Invalidate joined the load in progress")
+	}
+
+	close(release[1])
+	if value := <-fresh; value != "load 2" {
+		t.Errorf("Get after Invalidate returned %q, want load 2", value)
+	}
+
+	// the load started before Invalidate finishes last and must not replace the fresh value
+	close(release[0])
+	if value := <-stale; value != "load 1" {
+		t.Errorf("Get before Invalidate returned %q, want load 1", value)
+	}
+	if value, err := cache.Get(context.Background()); err != nil || value != "load 2" {
+		t.Errorf("cached value %q, %v, want load 2", value, err)
+	}
+	if stats := cache.Stats(); stats.Refreshes != 1 {
+		t.Errorf("%d refreshes, want the fresh load only", stats.Refreshes)
+	}
+}
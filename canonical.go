@@ -0,0 +1,183 @@
+package octanox
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/goccy/go-json"
+)
+
+// CanonicalJSON encodes the given value as canonical JSON, a stable byte representation to compute signatures and hashes of
+// payloads with. It follows the JSON Canonicalization Scheme of RFC 8785: object keys are sorted by their UTF-16 code units,
+// numbers are formatted like JavaScript formats doubles, strings only escape what JSON requires, and there is no whitespace.
+// The value is encoded with its JSON tags and marshalers first. Integers beyond 2^53 lose their precision, exactly as they
+// do in JavaScript. The generated TypeScript client has a matching canonicalJson function producing the same bytes.
+func CanonicalJSON(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := writeCanonicalJSON(&buf, value); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeCanonicalJSON(buf *bytes.Buffer, value any) error {
+	switch value := value.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(value))
+	case json.Number:
+		f, err := strconv.ParseFloat(string(value), 64)
+		if err != nil {
+			return errors.New("octanox: number " + string(value) + " cannot be canonicalized: " + err.Error())
+		}
+		buf.WriteString(canonicalNumber(f))
+	case string:
+		writeCanonicalString(buf, value)
+	case []any:
+		buf.WriteByte('[')
+		for n, item := range value {
+			if n > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonicalJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+
+		// JavaScript compares strings by their UTF-16 code units, which orders characters beyond the BMP differently than UTF-8
+		sort.Slice(keys, func(a, b int) bool {
+			return compareUTF16(keys[a], keys[b]) < 0
+		})
+
+		buf.WriteByte('{')
+		for n, key := range keys {
+			if n > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, key)
+			buf.WriteByte(':')
+			if err := writeCanonicalJSON(buf, value[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	}
+
+	return nil
+}
+
+// canonicalNumber formats the given double like Number.prototype.toString of JavaScript, with the shortest digits that read
+// back as the same double.
+func canonicalNumber(f float64) string {
+	if f == 0 {
+		return "0"
+	}
+
+	sign := ""
+	if f < 0 {
+		sign, f = "-", -f
+	}
+
+	// the shortest digits in the form d.ddde±x
+	mantissa, exponent, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	exp, _ := strconv.Atoi(exponent)
+
+	k, n := len(digits), exp+1
+	switch {
+	case k <= n && n <= 21:
+		return sign + digits + strings.Repeat("0", n-k)
+	case 0 < n && n <= 21:
+		return sign + digits[:n] + "." + digits[n:]
+	case -6 < n && n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits
+	}
+
+	e := "e+"
+	if n-1 < 0 {
+		e = "e-"
+	}
+
+	abs := n - 1
+	if abs < 0 {
+		abs = -abs
+	}
+
+	if k == 1 {
+		return sign + digits + e + strconv.Itoa(abs)
+	}
+
+	return sign + digits[:1] + "." + digits[1:] + e + strconv.Itoa(abs)
+}
+
+// writeCanonicalString writes the given string as JSON string, escaping only the quote, the backslash and the control
+// characters, like JSON.stringify does.
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hex[r>>4])
+				buf.WriteByte(hex[r&0xf])
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// compareUTF16 compares the given strings by their UTF-16 code units.
+func compareUTF16(a, b string) int {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for n := 0; n < len(ua) && n < len(ub); n++ {
+		if ua[n] != ub[n] {
+			return int(ua[n]) - int(ub[n])
+		}
+	}
+
+	return len(ua) - len(ub)
+}
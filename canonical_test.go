@@ -0,0 +1,248 @@
+package octanox
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+// canonicalRunes are the runes the random strings are made of, including ones which are escaped, not escaped, and beyond the
+// BMP, which sort differently by UTF-16 code units than by UTF-8 bytes.
+var canonicalRunes = []rune{'a', 'B', 'z', '0', ' ', '"', '\\', '/', '\n', '\t', '\x01', '\x1f', 0x7f, 'é', 'ß', 0x2028, 0xff5e, 0x1f600, 0x10437}
+
+// canonicalDTO is a representative DTO of the corpus, encoded with its JSON tags.
+type canonicalDTO struct {
+	canonicalNested
+	Nested *canonicalNested `json:"nested,omitempty"`
+}
+
+// canonicalNested are the fields of canonicalDTO, which it also nests. It is no recursive type, as go-json v0.10.3 fails to
+// encode those holding interface values.
+type canonicalNested struct {
+	ID       string             `json:"id"`
+	Amount   float64            `json:"amount"`
+	Count    int64              `json:"count"`
+	Tags     []string           `json:"tags"`
+	Labels   map[string]string  `json:"labels"`
+	Scores   map[string]float64 `json:"scores,omitempty"`
+	Disabled bool               `json:"disabled"`
+	Extra    any                `json:"extra"`
+}
+
+func randomString(r *rand.Rand) string {
+	var sb strings.Builder
+	for n := r.Intn(8); n > 0; n-- {
+		sb.WriteRune(canonicalRunes[r.Intn(len(canonicalRunes))])
+	}
+
+	return sb.String()
+}
+
+// randomNumber returns a random double of all magnitudes, which are formatted as integers, decimals and exponents.
+func randomNumber(r *rand.Rand) float64 {
+	switch r.Intn(5) {
+	case 0:
+		return float64(r.Int63n(1<<53) - 1<<52)
+	case 1:
+		return float64(r.Intn(2000)-1000) / 8
+	case 2:
+		return r.NormFloat64() * math.Pow(10, float64(r.Intn(60)-30))
+	case 3:
+		return math.Float64frombits(r.Uint64()&^(0x7ff<<52) | uint64(r.Intn(2046)+1)<<52)
+	}
+
+	return []float64{0, 1e21, 1e-7, 123456789012345680000, 0.000001, 5e-324, math.MaxFloat64}[r.Intn(7)]
+}
+
+// randomValue returns a random JSON value nested at most depth levels.
+func randomValue(r *rand.Rand, depth int) any {
+	kind := r.Intn(7)
+	if depth <= 0 {
+		kind = r.Intn(4)
+	}
+
+	switch kind {
+	case 0:
+		return nil
+	case 1:
+		return r.Intn(2) == 0
+	case 2:
+		return randomNumber(r)
+	case 3:
+		return randomString(r)
+	case 4:
+		items := make([]any, r.Intn(4))
+		for n := range items {
+			items[n] = randomValue(r, depth-1)
+		}
+		return items
+	}
+
+	object := make(map[string]any)
+	for n := r.Intn(5); n > 0; n-- {
+		object[randomString(r)] = randomValue(r, depth-1)
+	}
+	return object
+}
+
+func randomNested(r *rand.Rand) canonicalNested {
+	dto := canonicalNested{
+		ID:       randomString(r),
+		Amount:   randomNumber(r),
+		Count:    r.Int63n(1 << 53),
+		Tags:     []string{randomString(r), randomString(r)},
+		Labels:   map[string]string{randomString(r): randomString(r), randomString(r): randomString(r)},
+		Disabled: r.Intn(2) == 0,
+		Extra:    randomValue(r, 3),
+	}
+	if r.Intn(2) == 0 {
+		dto.Scores = map[string]float64{randomString(r): randomNumber(r)}
+	}
+
+	return dto
+}
+
+func randomDTO(r *rand.Rand) *canonicalDTO {
+	dto := &canonicalDTO{canonicalNested: randomNested(r)}
+	if r.Intn(2) == 0 {
+		nested := randomNested(r)
+		dto.Nested = &nested
+	}
+
+	return dto
+}
+
+// canonicalCorpus returns the generated DTO values of the property tests.
+func canonicalCorpus() []any {
+	r := rand.New(rand.NewSource(8785))
+
+	corpus := make([]any, 0, 1000)
+	for n := 0; n < 500; n++ {
+		corpus = append(corpus, randomDTO(r), randomValue(r, 4))
+	}
+
+	return corpus
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{map[string]any{"b": 1, "a": []any{true, nil}, "c": map[string]any{}}, `{"a":[true,null],"b":1,"c":{}}`},
+		{map[string]any{"\U0001F600": 1, "～": 2}, `{"😀":1,"～":2}`},
+		{[]float64{1e21, 1e20, 1e-7, 0.000001, -0.5, 100, 4.35, 5e-324}, `[1e+21,100000000000000000000,1e-7,0.000001,-0.5,100,4.35,5e-324]`},
+		{"\"\\/\n\x01 <>&", `"\"\\/\n\u0001` + " " + `<>&"`},
+	}
+
+	for _, tt := range tests {
+		got, err := CanonicalJSON(tt.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("CanonicalJSON(%v) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+// TestCanonicalJSONIsStable checks that the canonical JSON of the corpus is valid JSON of the same value, and stays the same
+// when it is decoded and canonicalized again, independent of the order the keys are decoded in.
+func TestCanonicalJSONIsStable(t *testing.T) {
+	for _, value := range canonicalCorpus() {
+		canonical, err := CanonicalJSON(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded any
+		if err := json.Unmarshal(canonical, &decoded); err != nil {
+			t.Fatalf("canonical JSON %s is invalid: %v", canonical, err)
+		}
+
+		again, err := CanonicalJSON(decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(canonical, again) {
+			t.Fatalf("canonical JSON %s changed to %s", canonical, again)
+		}
+	}
+}
+
+// TestCanonicalJSONMatchesClient checks that the canonicalJson function of the generated client produces the same bytes as
+// CanonicalJSON for the corpus. Needs Node.js and is skipped without it.
+func TestCanonicalJSONMatchesClient(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed")
+	}
+
+	i := newTestInstance(t)
+	code := i.typeScriptClientCode(i.routes)
+	start := strings.Index(code, "export function canonicalJson(")
+	if start < 0 {
+		t.Fatal("client has no canonicalJson function")
+	}
+	end := strings.Index(code[start:], "\n}\n")
+	function := strings.Replace(code[start+len("export "):start+end+2], "(value: any): string", "(value)", 1)
+
+	corpus := canonicalCorpus()
+	inputs := make([]string, len(corpus))
+	want := make([]string, len(corpus))
+	for n, value := range corpus {
+		data, err := json.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputs[n] = string(data)
+
+		canonical, err := CanonicalJSON(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[n] = string(canonical)
+	}
+
+	input, err := json.Marshal(inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	script := function + "\nlet input = ''\n" +
+		"process.stdin.setEncoding('utf8')\n" +
+		"process.stdin.on('data', (chunk) => { input += chunk })\n" +
+		"process.stdin.on('end', () => { process.stdout.write(JSON.stringify(JSON.parse(input).map((item) => canonicalJson(JSON.parse(item))))) })\n"
+
+	cmd := exec.Command(node, "-e", script)
+	cmd.Stdin = bytes.NewReader(input)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("node: %v", err)
+	}
+
+	var got []string
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("%d results, want %d", len(got), len(want))
+	}
+
+	mismatches := 0
+	for n := range want {
+		if got[n] != want[n] {
+			if mismatches++; mismatches <= 5 {
+				t.Errorf("value %s: client %s, server %s", inputs[n], got[n], want[n])
+			}
+		}
+	}
+	if mismatches > 5 {
+		t.Errorf("%d mismatches in total", mismatches)
+	}
+}
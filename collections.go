@@ -0,0 +1,209 @@
+package octanox
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sync"
+)
+
+// NilCollectionPolicy is a type that decides how nil slices and maps in responses are encoded and typed in the generated client.
+// It can be overridden per struct field with the tag `nullable:"true"` or `nullable:"false"`.
+type NilCollectionPolicy int
+
+const (
+	// NilCollectionsNull encodes nil slices and maps as null, while the generated client types them as non-nullable. This is the default.
+	NilCollectionsNull NilCollectionPolicy = iota
+	// NilCollectionsEmpty encodes nil slices and maps as empty collections, so they are never null on the wire.
+	NilCollectionsEmpty
+	// NilCollectionsNullable encodes nil slices and maps as null and types slice fields in the generated client as `Array<T> | null`.
+	NilCollectionsNullable
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// SetNilCollectionPolicy sets the policy of how nil slices and maps in responses are encoded and typed in the generated client.
+func (i *Instance) SetNilCollectionPolicy(policy NilCollectionPolicy) *Instance {
+	i.collections = &collectionNormalizer{policy: policy}
+	return i
+}
+
+// isNullableField checks if the given slice or map field may be null on the wire according to its tag and the given policy.
+func isNullableField(field reflect.StructField, policy NilCollectionPolicy) bool {
+	switch field.Tag.Get("nullable") {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	return policy != NilCollectionsEmpty
+}
+
+// isNullableInClient checks if the given slice field is typed as nullable in the generated client according to its tag and the given policy.
+func isNullableInClient(field reflect.StructField, policy NilCollectionPolicy) bool {
+	switch field.Tag.Get("nullable") {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	return policy == NilCollectionsNullable
+}
+
+// collectionNormalizer replaces nil slices and maps of response values with empty ones wherever the policy declares them non-nullable.
+type collectionNormalizer struct {
+	policy NilCollectionPolicy
+	// needed caches per type whether a value of it can contain a collection that has to be normalized.
+	needed sync.Map
+}
+
+// normalizeCollections returns a copy of the given response value with its non-nullable nil collections replaced by empty ones.
+// The value itself is never modified. Values without any collection to normalize are returned as they are.
+func (i *Instance) normalizeCollections(v any) any {
+	if v == nil {
+		return v
+	}
+
+	n := i.collections
+	rv := reflect.ValueOf(v)
+	if !n.needsCached(rv.Type(), n.policy == NilCollectionsEmpty) {
+		return v
+	}
+
+	return n.normalize(rv, n.policy == NilCollectionsEmpty).Interface()
+}
+
+// opaque checks if values of the given type are encoded by themselves or as protobuf, so their contents are left untouched.
+func (n *collectionNormalizer) opaque(t reflect.Type) bool {
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		return true
+	}
+
+	return (t.Kind() == reflect.Ptr || t.Kind() == reflect.Struct) && isProtoMessage(t)
+}
+
+// needsCached checks if a value of the given type can contain a nil collection that has to be normalized, caching the result.
+func (n *collectionNormalizer) needsCached(t reflect.Type, normalize bool) bool {
+	key := collectionNormalizerKey{t, normalize}
+	if cached, ok := n.needed.Load(key); ok {
+		return cached.(bool)
+	}
+
+	needed := n.needs(t, normalize, map[reflect.Type]bool{})
+	n.needed.Store(key, needed)
+	return needed
+}
+
+// needs checks if a value of the given type can contain a nil collection that has to be normalized. Recursive types are cut off at
+// their first repetition, so only the outermost result is complete enough to be cached.
+func (n *collectionNormalizer) needs(t reflect.Type, normalize bool, visiting map[reflect.Type]bool) bool {
+	if visiting[t] || n.opaque(t) {
+		return false
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	needed := false
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Array:
+		needed = n.needs(t.Elem(), normalize, visiting)
+	case reflect.Interface:
+		needed = true
+	case reflect.Slice:
+		needed = normalize && t.Elem().Kind() != reflect.Uint8 || n.needs(t.Elem(), n.policy == NilCollectionsEmpty, visiting)
+	case reflect.Map:
+		needed = normalize || n.needs(t.Elem(), n.policy == NilCollectionsEmpty, visiting)
+	case reflect.Struct:
+		for i := 0; i < t.NumField() && !needed; i++ {
+			field := t.Field(i)
+			if field.IsExported() {
+				needed = n.needs(field.Type, !isNullableField(field, n.policy), visiting)
+			}
+		}
+	}
+
+	return needed
+}
+
+type collectionNormalizerKey struct {
+	t         reflect.Type
+	normalize bool
+}
+
+// normalize returns a copy of the given value with its nil collections replaced by empty ones. If normalize is false, the value
+// itself may stay nil, but collections nested inside it are still normalized according to their own declaration.
+func (n *collectionNormalizer) normalize(v reflect.Value, normalize bool) reflect.Value {
+	t := v.Type()
+	if !n.needsCached(t, normalize) {
+		return v
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+
+		out := reflect.New(t.Elem())
+		out.Elem().Set(n.normalize(v.Elem(), normalize))
+		return out
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+
+		out := reflect.New(t).Elem()
+		out.Set(n.normalize(v.Elem(), normalize))
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			if normalize && t.Elem().Kind() != reflect.Uint8 {
+				return reflect.MakeSlice(t, 0, 0)
+			}
+			return v
+		}
+
+		out := reflect.MakeSlice(t, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(n.normalize(v.Index(i), n.policy == NilCollectionsEmpty))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(t).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(n.normalize(v.Index(i), normalize))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			if normalize {
+				return reflect.MakeMap(t)
+			}
+			return v
+		}
+
+		out := reflect.MakeMapWithSize(t, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), n.normalize(iter.Value(), n.policy == NilCollectionsEmpty))
+		}
+		return out
+	case reflect.Struct:
+		out := reflect.New(t).Elem()
+		out.Set(v)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.IsExported() && out.Field(i).CanSet() {
+				out.Field(i).Set(n.normalize(v.Field(i), !isNullableField(field, n.policy)))
+			}
+		}
+		return out
+	}
+
+	return v
+}
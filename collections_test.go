@@ -0,0 +1,107 @@
+package octanox
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type collectionsRequest struct {
+	GetRequest
+}
+
+type collectionsResponse struct {
+	Tags        []string          `json:"tags"`
+	ForcedNull  []string          `json:"forced_null" nullable:"true"`
+	ForcedEmpty []string          `json:"forced_empty" nullable:"false"`
+	Labels      map[string]string `json:"labels" nullable:"false"`
+}
+
+type invalidNullableResponse struct {
+	Tags []string `json:"tags" nullable:"yes"`
+	Name string   `json:"name" nullable:"true"`
+}
+
+// TestNilCollectionsEncoderAndGeneratorAgree checks that a collection field is encoded as null only if the generated client
+// and the contract type it as nullable.
+func TestNilCollectionsEncoderAndGeneratorAgree(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy NilCollectionPolicy
+		// fields are the fields checked, the default ones of NilCollectionsNull are null on the wire but not in the client
+		fields []string
+	}{
+		{"null", NilCollectionsNull, []string{"forced_null", "forced_empty", "labels"}},
+		{"empty", NilCollectionsEmpty, []string{"tags", "forced_null", "forced_empty", "labels"}},
+		{"nullable", NilCollectionsNullable, []string{"tags", "forced_null", "forced_empty", "labels"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newTestInstance(t)
+			i.SetNilCollectionPolicy(tt.policy)
+			i.Register("/collections", func(*collectionsRequest) *collectionsResponse { return &collectionsResponse{} })
+
+			rec := serveTest(i, httptest.NewRequest(http.MethodGet, "/collections", nil))
+			var encoded map[string]json.RawMessage
+			if err := json.Unmarshal(rec.Body.Bytes(), &encoded); err != nil {
+				t.Fatalf("status %d: %v", rec.Code, err)
+			}
+
+			code := i.typeScriptClientCode(i.routes)
+			contract := i.contract(i.routes)
+			var contractFields map[string]bool
+			for _, ct := range contract.Types {
+				if ct.Name == "collectionsResponse" {
+					contractFields = make(map[string]bool)
+					for _, field := range ct.Fields {
+						contractFields[field.WireName] = field.Type.Nullable
+					}
+				}
+			}
+			if contractFields == nil {
+				t.Fatal("contract has no type collectionsResponse")
+			}
+
+			for _, field := range tt.fields {
+				null := string(encoded[field]) == "null"
+
+				property := regexp.MustCompile(`\n\s*` + field + `: ([^;\n]*)`).FindStringSubmatch(code)
+				if property == nil {
+					t.Fatalf("client has no property %s", field)
+				}
+
+				if client := strings.HasSuffix(property[1], "| null"); client != null {
+					t.Errorf("%s encoded as %s, but typed as %s in the client", field, encoded[field], property[1])
+				}
+				if contractFields[field] != null {
+					t.Errorf("%s encoded as %s, but nullable %t in the contract", field, encoded[field], contractFields[field])
+				}
+			}
+		})
+	}
+}
+
+func TestContractReportFlagsInvalidNullable(t *testing.T) {
+	i := newTestInstance(t)
+	i.Register("/collections", func(*collectionsRequest) *invalidNullableResponse { return nil })
+
+	i.StrictContracts()
+	report := i.contractReport()
+	if n := strings.Count(report, "["+ContractInvalidNullable+"]"); n != 2 {
+		t.Errorf("report %q, want two %s findings", report, ContractInvalidNullable)
+	}
+	for _, want := range []string{`invalid nullable tag "yes"`, "field Name of octanox.invalidNullableResponse has a nullable tag but is neither a slice nor a map"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report %q does not contain %q", report, want)
+		}
+	}
+
+	i.StrictContracts(ContractInvalidNullable)
+	if report := i.contractReport(); report != "" {
+		t.Errorf("report %q, want none with %s allowlisted", report, ContractInvalidNullable)
+	}
+}
@@ -0,0 +1,182 @@
+package octanox
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+)
+
+// constantResponse is the cached response of a constant route.
+type constantResponse struct {
+	mu sync.RWMutex
+	// body is the JSON encoded response. Nil until the response has been computed.
+	body []byte
+	etag string
+}
+
+// Constant declares the response of this route as static for the lifetime of the process, e.g. build info or catalogs of
+// enums. The handler is called once at startup, and every request is served from the cached JSON and its ETag, without
+// binding the request or calling the handler. The response is only computed again by Instance.RefreshConstants. Since
+// the handler is called without a request, constant routes must not have request parameters, which Instance.Validate
+// reports, and fields with a visible tag are hidden as from anonymous users. With EmbedConstants, the generated TypeScript
+// client resolves to the response embedded at generation time instead of requesting it. Panics if the route has no response
+// body, or responds with a download, a stream or a job.
+func (r *Route) Constant() *Route {
+	if !r.hasResponseBody() || r.responseType == downloadType || r.streamType != nil || r.jobResult != nil {
+		panic("octanox: route " + r.method + " " + r.path + " cannot be constant, only routes responding with a JSON body can")
+	}
+
+	r.constant = &constantResponse{}
+	return r
+}
+
+// RefreshConstants calls the handlers of the constant routes again and serves their new responses. The previous response
+// of a route is kept if its handler fails, and the failures are returned joined.
+func (i *Instance) RefreshConstants() error {
+	var errs []error
+	for _, route := range i.routes {
+		if route.constant == nil {
+			continue
+		}
+
+		if err := route.refreshConstant(); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", route.method, route.path, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// refreshConstant calls the handler of the constant route and caches its response.
+func (r *Route) refreshConstant() error {
+	body, err := r.computeConstant()
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(body)
+
+	r.constant.mu.Lock()
+	r.constant.body, r.constant.etag = body, `"`+hex.EncodeToString(sum[:16])+`"`
+	r.constant.mu.Unlock()
+	return nil
+}
+
+// cachedConstant returns the cached response of the constant route and its ETag, computing it first if the route has
+// been registered after the startup or is generated in dry-run mode.
+func (r *Route) cachedConstant() ([]byte, string, error) {
+	r.constant.mu.RLock()
+	body, etag := r.constant.body, r.constant.etag
+	r.constant.mu.RUnlock()
+
+	if body != nil {
+		return body, etag, nil
+	}
+
+	if err := r.refreshConstant(); err != nil {
+		return nil, "", err
+	}
+
+	return r.cachedConstant()
+}
+
+// computeConstant calls the handler of the constant route with an empty request and returns its encoded response.
+func (r *Route) computeConstant() (body []byte, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			if failedReq, ok := panicFailure(rec); ok {
+				err = errors.New(failedReq.message)
+				return
+			}
+
+			err = fmt.Errorf("handler panicked: %v", rec)
+		}
+	}()
+
+	c := gin.CreateTestContextOnly(httptest.NewRecorder(), Current.Gin)
+	c.Request = httptest.NewRequest(r.method, r.path, nil)
+
+	rv := r.handlerFunc.Call([]reflect.Value{reflect.ValueOf(populateRequest(c, r, r.plan, nil))})
+	res := rv[0].Interface()
+	if resErr, ok := res.(error); ok {
+		return nil, resErr
+	}
+
+	var sc Context
+	if len(rv) > 1 {
+		sc = rv[1].Interface().(Context)
+	}
+
+	out := Current.applyVisibility(Current.normalizeCollections(Current.Serialize(res, sc)), nil)
+	return json.Marshal(out)
+}
+
+// serveConstant serves the cached response of the constant route, or 304 Not Modified if the client has it already. Only
+// the authentication of the route is checked.
+func serveConstant(c *gin.Context, rt *Route) {
+	authenticated := Current.requiresAuthentication(rt)
+	if Current.rejectsWithoutAuthenticator(rt, authenticated) {
+		abortWithError(c, failedRequest{status: http.StatusUnauthorized, message: "unauthorized", code: ErrorCodeUnauthorized})
+		return
+	}
+
+	if authenticator := Current.authenticatorFor(rt); authenticated && authenticator != nil {
+		user, err := authenticator.Authenticate(c)
+		if err != nil {
+			panic(err)
+		}
+
+		if user == nil {
+			abortWithError(c, failedRequest{status: http.StatusUnauthorized, message: "unauthorized", code: ErrorCodeUnauthorized})
+			return
+		}
+	}
+
+	body, etag, err := rt.cachedConstant()
+	if err != nil {
+		panic(err)
+	}
+
+	c.Header("ETag", etag)
+	for _, candidate := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// generateEmbeddedConstant generates the return of the response of the given constant route, embedded as literal. Panics
+// if its handler fails.
+func (tb *tsCodeBuilder) generateEmbeddedConstant(route *Route) {
+	body, _, err := route.cachedConstant()
+	if err != nil {
+		panic("octanox: cannot embed the response of the constant route " + route.method + " " + route.path + ": " + err.Error())
+	}
+
+	value := string(body)
+	if tb.mapsWire() && tb.needsWireMapping(route.responseType) {
+		mapper := tb.wireMapperFunc(route.responseType)
+		if strings.HasPrefix(mapper, "(") {
+			mapper = "(" + mapper + ")"
+		}
+
+		value = mapper + "(" + value + ")"
+	}
+
+	tb.writeIndented("return " + value + " as ")
+	tb.writeResponseType(route)
+	tb.writeLineNoIdent(";")
+}
@@ -0,0 +1,407 @@
+package octanox
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// ContractVersion is the version of the format of the contract documents written by Instance.ExportContract. Fields are
+// only added to the format within a version, so readers of a version can read every document of it. The version is
+// incremented on every change which is incompatible with existing readers, e.g. a removed or renamed field.
+const ContractVersion = 1
+
+// Contract is a struct that describes everything Octanox knows about the API, e.g. for gateway configs or client generators
+// of other languages. It is the format of the documents written by Instance.ExportContract, which are read with LoadContract.
+type Contract struct {
+	// Version is the ContractVersion of the format of the document.
+	Version int `json:"version"`
+	// APIVersion is the newest declared version of the API. Empty if the versions of the API are not declared.
+	APIVersion string `json:"apiVersion,omitempty"`
+	// Authentication is the method of the authenticator, e.g. "bearer", "basic" or "apiKey". Empty if there is none.
+	Authentication string `json:"authentication,omitempty"`
+	// Routes are the routes of the API, in the order of their registration, with versioned routes as their client variant.
+	Routes []ContractRoute `json:"routes"`
+	// Types are the named structs and registered enums the routes reference, sorted by name.
+	Types []ContractType `json:"types"`
+}
+
+// ContractRoute is a struct that describes a route of the contract.
+type ContractRoute struct {
+	// Name is the name of the route in the generated clients, e.g. get_users_id.
+	Name   string `json:"name"`
+	Method string `json:"method"`
+	// Path is the path of the route, with its path parameters prefixed by a colon, e.g. /users/:id.
+	Path string `json:"path"`
+	// Description is the description of the route declared with Route.Description.
+	Description string `json:"description,omitempty"`
+	// Params are the parameters clients send, in the order of the fields of the request.
+	Params []ContractParam `json:"params,omitempty"`
+	// Body is the type of the JSON body of the request. Nil if the request has no JSON body.
+	Body *ContractTypeRef `json:"body,omitempty"`
+	// Response is the type of the JSON body of the response. Nil if the route responds without a body or with a download.
+	Response *ContractTypeRef `json:"response,omitempty"`
+	// Error is the type of the bodies of the error responses declared for the route. Nil if the route declares none.
+	Error         *ContractTypeRef `json:"error,omitempty"`
+	Authenticated bool             `json:"authenticated"`
+	// Authentication is the method of the authenticator of the route if it differs from the authenticator of the API, e.g.
+	// "api key", or "none" if the route is made public by a route authenticator. Empty if the route is authenticated as the API.
+	Authentication string `json:"authentication,omitempty"`
+	// Roles are the roles of which the user needs one to access the route.
+	Roles    []string              `json:"roles,omitempty"`
+	Metadata ContractRouteMetadata `json:"metadata"`
+}
+
+// ContractRouteMetadata is a struct that describes the declared behaviour of a route of the contract.
+type ContractRouteMetadata struct {
+	// Group and Member are the client group of the route, e.g. invoices.list. Empty if the route is in no group.
+	Group  string `json:"group,omitempty"`
+	Member string `json:"member,omitempty"`
+	// Version is the version of the API the route is described in. Empty if the route is not versioned.
+	Version         string   `json:"version,omitempty"`
+	Idempotent      bool     `json:"idempotent,omitempty"`
+	Immutable       bool     `json:"immutable,omitempty"`
+	Constant        bool     `json:"constant,omitempty"`
+	Gone            bool     `json:"gone,omitempty"`
+	ExistenceCheck  bool     `json:"existenceCheck,omitempty"`
+	PersistedQuery  bool     `json:"persistedQuery,omitempty"`
+	Partial         bool     `json:"partial,omitempty"`
+	Streamed        bool     `json:"streamed,omitempty"`
+	Download        bool     `json:"download,omitempty"`
+	Sortable        []string `json:"sortable,omitempty"`
+	Filterable      []string `json:"filterable,omitempty"`
+	ResponseHeaders []string `json:"responseHeaders,omitempty"`
+	// JobResult is the type of the result of the jobs of an async route. Nil if the route is not async.
+	JobResult *ContractTypeRef `json:"jobResult,omitempty"`
+}
+
+// ContractParam is a struct that describes a parameter of a route of the contract.
+type ContractParam struct {
+	// Field is the name of the field of the request binding the parameter.
+	Field string `json:"field"`
+	// Name is the name of the parameter in its source, e.g. the name of the query parameter. Empty for bodies.
+	Name string `json:"name,omitempty"`
+	// Source is where the parameter is sent, one of "path", "query", "header", "cookie", "body", "rawBody", "form" for the
+	// values of a form, "file" for its files or "list" for the query parameters of a ListQuery.
+	Source   string          `json:"source"`
+	Type     ContractTypeRef `json:"type"`
+	Required bool            `json:"required"`
+}
+
+// ContractType is a struct that describes a named struct, or a registered enum, of the contract.
+type ContractType struct {
+	Name string `json:"name"`
+	// Kind is "object" for structs and "enum" for enums.
+	Kind string `json:"kind"`
+	// Description is the description of the type declared with Instance.DescribeType.
+	Description string `json:"description,omitempty"`
+	// Fields are the fields of a struct, in the order they are encoded.
+	Fields []ContractField `json:"fields,omitempty"`
+	// Values are the legal values of an enum.
+	Values []any `json:"values,omitempty"`
+}
+
+// ContractField is a struct that describes a field of a struct of the contract.
+type ContractField struct {
+	// Name is the name of the Go field.
+	Name string `json:"name"`
+	// WireName is the name of the property the field is encoded as.
+	WireName string          `json:"wireName"`
+	Type     ContractTypeRef `json:"type"`
+	// Description is the description of the field declared with the doc tag.
+	Description string `json:"description,omitempty"`
+	// Optional is whether the property is omitted when the field is empty.
+	Optional bool `json:"optional,omitempty"`
+	// Visible is the rule of the visible tag of the field. Empty if the field is visible to everyone.
+	Visible string `json:"visible,omitempty"`
+}
+
+// ContractTypeRef is a struct that references a type of the contract.
+type ContractTypeRef struct {
+	// Kind is one of "string", "boolean", "integer", "number", "bytes", "file", "time", "duration", "array", "map", "list"
+	// for a ListResult, "object" for anonymous structs, "named" for named structs and enums, "protobuf" or "any".
+	Kind string `json:"kind"`
+	// Name is the name of the named type in Types, or the full name of the protobuf message.
+	Name string `json:"name,omitempty"`
+	// Elem is the type of the elements of arrays, maps and lists.
+	Elem *ContractTypeRef `json:"elem,omitempty"`
+	// Fields are the fields of anonymous structs.
+	Fields   []ContractField `json:"fields,omitempty"`
+	Nullable bool            `json:"nullable,omitempty"`
+}
+
+// ExportContract writes the contract of the registered routes to the given writer as indented JSON.
+func (i *Instance) ExportContract(w io.Writer) error {
+	content, err := json.MarshalIndent(i.contract(i.routes), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(content)
+	return err
+}
+
+// ContractGenerator returns a generator writing the contract of the registered routes to the given path, as ExportContract
+// does, e.g. to register it with AddGenerator next to the TypeScript client.
+func ContractGenerator(outputPath string) Generator {
+	return func(ctx *GenContext) error {
+		content, err := json.MarshalIndent(ctx.Instance.contract(ctx.Instance.routes), "", "  ")
+		if err != nil {
+			return err
+		}
+
+		return ctx.WriteFile(outputPath, content)
+	}
+}
+
+// LoadContract reads a contract written by ExportContract. Fails if the contract has a newer version than ContractVersion.
+func LoadContract(r io.Reader) (*Contract, error) {
+	var contract Contract
+	if err := json.NewDecoder(r).Decode(&contract); err != nil {
+		return nil, err
+	}
+
+	if contract.Version < 1 || contract.Version > ContractVersion {
+		return nil, fmt.Errorf("octanox: contract has version %d, only versions up to %d are supported", contract.Version, ContractVersion)
+	}
+
+	return &contract, nil
+}
+
+// contract returns the contract of the given routes. Versioned routes are described as their newest variant, or as the
+// variant of the version pinned with NOX__CLIENT_VERSION, like in the client.
+func (i *Instance) contract(routes []*Route) *Contract {
+	routes = clientRoutes(routes, os.Getenv("NOX__CLIENT_VERSION"))
+	checkFunctionNames(routes)
+
+	b := &contractBuilder{
+		instance: i,
+		names:    &tsCodeBuilder{opts: i.tsGenOptions},
+		types:    make(map[string]*ContractType),
+	}
+
+	contract := &Contract{Version: ContractVersion, Routes: make([]ContractRoute, 0, len(routes))}
+	if len(i.apiVersions) > 0 {
+		contract.APIVersion = i.apiVersions[len(i.apiVersions)-1]
+	}
+	if i.Authenticator != nil {
+		contract.Authentication = i.Authenticator.Method().String()
+	}
+
+	if i.ErrorResponseType != nil {
+		b.ref(i.ErrorResponseType)
+	}
+	for _, route := range routes {
+		contract.Routes = append(contract.Routes, b.route(route))
+	}
+
+	contract.Types = make([]ContractType, 0, len(b.types))
+	for _, t := range b.types {
+		contract.Types = append(contract.Types, *t)
+	}
+	sort.Slice(contract.Types, func(a, c int) bool {
+		return contract.Types[a].Name < contract.Types[c].Name
+	})
+
+	return contract
+}
+
+// contractBuilder builds the routes and types of a contract.
+type contractBuilder struct {
+	instance *Instance
+	// names names the routes as the functions of the TypeScript client.
+	names *tsCodeBuilder
+	// types are the named structs and enums, by their name.
+	types map[string]*ContractType
+}
+
+// route returns the description of the given route.
+func (b *contractBuilder) route(route *Route) ContractRoute {
+	cr := ContractRoute{
+		Name:          b.names.generateFunctionName(route),
+		Method:        route.method,
+		Path:          route.path,
+		Description:   route.description,
+		Authenticated: b.instance.requiresAuthentication(route),
+		Roles:         route.roles,
+		Metadata: ContractRouteMetadata{
+			Group:          route.clientGroup,
+			Version:        route.clientVersion,
+			Idempotent:     route.idempotent,
+			Immutable:      route.immutable,
+			Constant:       route.constant != nil,
+			Gone:           route.gone,
+			ExistenceCheck: route.existenceCheck,
+			PersistedQuery: route.persistedQuery,
+			Partial:        route.partial,
+			Streamed:       route.streamType != nil,
+			Download:       route.responseType == downloadType,
+			Sortable:       route.list.sortable,
+			Filterable:     route.list.filterable,
+		},
+	}
+
+	if route.clientGroup != "" {
+		cr.Metadata.Member = b.names.clientMemberName(route)
+	}
+	if scheme := authenticationScheme(b.instance.authenticatorFor(route)); scheme != authenticationScheme(b.instance.Authenticator) {
+		cr.Authentication = scheme
+	}
+
+	for _, header := range route.responseHeaders {
+		cr.Metadata.ResponseHeaders = append(cr.Metadata.ResponseHeaders, header.name)
+	}
+
+	for n := range route.plan.fields {
+		bf := &route.plan.fields[n]
+
+		switch bf.source {
+		case sourcePath, sourceQuery, sourceHeader, sourceCookie, sourceForm:
+			cr.Params = append(cr.Params, ContractParam{Field: bf.field.Name, Name: bf.name, Source: bf.sourceName(), Type: b.ref(bf.field.Type), Required: bf.required || bf.source == sourcePath})
+		case sourceList:
+			cr.Params = append(cr.Params, ContractParam{Field: bf.field.Name, Source: "list", Type: ContractTypeRef{Kind: "any"}})
+		case sourceBody:
+			if route.plan.rawBodyField() == nil {
+				body := b.ref(bf.field.Type)
+				cr.Body = &body
+				cr.Params = append(cr.Params, ContractParam{Field: bf.field.Name, Source: "body", Type: body, Required: true})
+			}
+		case sourceFile:
+			cr.Params = append(cr.Params, ContractParam{Field: bf.field.Name, Name: bf.name, Source: "file", Type: ContractTypeRef{Kind: "file"}, Required: bf.required})
+		case sourceRawBody:
+			cr.Params = append(cr.Params, ContractParam{Field: bf.field.Name, Source: "rawBody", Type: ContractTypeRef{Kind: "bytes"}, Required: true})
+			if body := route.plan.bodyField(); body != nil {
+				ref := b.ref(body.field.Type)
+				cr.Body = &ref
+			}
+		}
+	}
+
+	if route.hasResponseBody() && route.responseType != downloadType {
+		response := b.ref(route.responseType)
+		cr.Response = &response
+	}
+
+	if route.errorType != nil {
+		errorType := b.ref(route.errorType)
+		cr.Error = &errorType
+	}
+
+	if route.jobs != nil {
+		result := b.ref(route.jobResult)
+		cr.Metadata.JobResult = &result
+	}
+
+	return cr
+}
+
+// ref returns the reference of the given type as it is encoded, describing the named structs and enums it references.
+func (b *contractBuilder) ref(t reflect.Type) ContractTypeRef {
+	if (t.Kind() == reflect.Ptr || t.Kind() == reflect.Struct) && isProtoMessage(t) {
+		return ContractTypeRef{Kind: "protobuf", Name: string(protoDescriptor(t).FullName()), Nullable: t.Kind() == reflect.Ptr}
+	}
+
+	switch {
+	case isTimeType(t):
+		return ContractTypeRef{Kind: "time"}
+	case t == reflect.TypeOf(time.Duration(0)):
+		return ContractTypeRef{Kind: "duration"}
+	}
+
+	if literals, ok := b.instance.enums[t]; ok {
+		if _, ok := b.types[t.Name()]; !ok {
+			b.types[t.Name()] = &ContractType{Name: t.Name(), Kind: "enum", Description: b.instance.typeDescriptions[t], Values: openAPIEnumValues(t.Kind(), literals)}
+		}
+
+		return ContractTypeRef{Kind: "named", Name: t.Name()}
+	}
+
+	if t.Kind() != reflect.Ptr && marshalsItself(t) {
+		pt := reflect.PointerTo(t)
+		if !t.Implements(jsonMarshalerType) && !pt.Implements(jsonMarshalerType) {
+			return ContractTypeRef{Kind: "string"}
+		}
+
+		return ContractTypeRef{Kind: "any"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		ref := b.ref(t.Elem())
+		ref.Nullable = true
+		return ref
+	case reflect.String:
+		return ContractTypeRef{Kind: "string"}
+	case reflect.Bool:
+		return ContractTypeRef{Kind: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return ContractTypeRef{Kind: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return ContractTypeRef{Kind: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return ContractTypeRef{Kind: "bytes"}
+		}
+
+		elem := b.ref(t.Elem())
+		return ContractTypeRef{Kind: "array", Elem: &elem}
+	case reflect.Map:
+		elem := b.ref(t.Elem())
+		return ContractTypeRef{Kind: "map", Elem: &elem}
+	case reflect.Struct:
+		if isListResultType(t) {
+			elem := b.ref(listResultItemType(t))
+			return ContractTypeRef{Kind: "list", Elem: &elem}
+		}
+
+		// instances of generic types are inlined, as their names differ between Go versions
+		if t.Name() == "" || strings.Contains(t.Name(), "[") {
+			return ContractTypeRef{Kind: "object", Fields: b.fields(t)}
+		}
+
+		if _, ok := b.types[t.Name()]; !ok {
+			// the placeholder ends recursive types, e.g. a Node with Children []Node
+			b.types[t.Name()] = &ContractType{Name: t.Name(), Kind: "object", Description: b.instance.typeDescriptions[t]}
+			b.types[t.Name()].Fields = b.fields(t)
+		}
+
+		return ContractTypeRef{Kind: "named", Name: t.Name()}
+	}
+
+	return ContractTypeRef{Kind: "any"}
+}
+
+// fields returns the descriptions of the fields of the given struct type, with the fields of embedded structs flattened.
+func (b *contractBuilder) fields(t reflect.Type) []ContractField {
+	fields := make([]ContractField, 0, t.NumField())
+
+	for _, jf := range jsonFields(t) {
+		field := t.FieldByIndex(jf.index)
+		_, omitempty, _ := jsonFieldName(field)
+
+		ref := b.ref(jf.t)
+		if encodesQuoted(field) {
+			ref = ContractTypeRef{Kind: "string", Nullable: field.Type.Kind() == reflect.Ptr}
+		}
+		if field.Type.Kind() == reflect.Slice && isNullableInClient(field, b.instance.collections.policy) {
+			ref.Nullable = true
+		}
+
+		fields = append(fields, ContractField{
+			Name:        field.Name,
+			WireName:    jf.name,
+			Type:        ref,
+			Optional:    omitempty,
+			Visible:     jf.tag.Get("visible"),
+			Description: fieldDescription(jf.tag),
+		})
+	}
+
+	return fields
+}
@@ -0,0 +1,72 @@
+package octanox
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+func TestContractRoundTrip(t *testing.T) {
+	i := newTestInstance(t)
+	i.Authenticator = headerAuthenticator{method: AuthenticationMethodBearer}
+	registerTSGenRoutes(i)
+	registerWireRoutes(i)
+
+	var exported bytes.Buffer
+	if err := i.ExportContract(&exported); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadContract(bytes.NewReader(exported.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if live := i.contract(i.routes); !reflect.DeepEqual(loaded, live) {
+		t.Errorf("loaded contract %+v, want the live %+v", loaded, live)
+	}
+
+	again, err := json.MarshalIndent(loaded, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(exported.Bytes(), again) {
+		t.Errorf("contract changed when loaded and exported again:\n%s\n%s", exported.Bytes(), again)
+	}
+}
+
+// TestContractWireNamesMatchClient checks that the wire names of the contract are the properties the generated wire mappers
+// send, so generators driven by the contract encode like the TypeScript client.
+func TestContractWireNamesMatchClient(t *testing.T) {
+	i := newTestInstance(t)
+	i.SetTSGenOptions(TypeScriptGenerationOptions{CamelCaseProperties: true})
+	registerWireRoutes(i)
+
+	code := i.typeScriptClientCode(i.routes)
+	for _, ct := range i.contract(i.routes).Types {
+		start := strings.Index(code, "export function "+ct.Name+"ToWire(")
+		if start < 0 {
+			t.Errorf("client has no wire mapper of %s", ct.Name)
+			continue
+		}
+		mapper := code[start : start+strings.Index(code[start:], "\n}\n")]
+
+		for _, field := range ct.Fields {
+			if !strings.Contains(mapper, "\n    '"+field.WireName+"': ") {
+				t.Errorf("wire mapper of %s does not send %s", ct.Name, field.WireName)
+			}
+		}
+	}
+}
+
+func TestLoadContractRejectsUnknownVersions(t *testing.T) {
+	for _, version := range []int{0, ContractVersion + 1} {
+		document := `{"version":` + strconv.Itoa(version) + `,"routes":[],"types":[]}`
+		if _, err := LoadContract(strings.NewReader(document)); err == nil {
+			t.Errorf("version %d loaded", version)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package octanox
+
+import (
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// RouteCoverage is a struct that describes whether a route has been requested since the instance was created.
+type RouteCoverage struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Package is the import path of the package declaring the handler of the route.
+	Package string `json:"package"`
+	Covered bool   `json:"covered"`
+}
+
+// CoverageReport is a struct that describes which routes of an instance have been requested, e.g. by the tests of a service.
+// Routes marked with SkipCoverage are not part of the report.
+type CoverageReport struct {
+	// Routes are the routes of the instance, sorted by package, path and method.
+	Routes  []RouteCoverage `json:"routes"`
+	Covered int             `json:"covered"`
+	Total   int             `json:"total"`
+	// Ratio is the share of the covered routes, between 0 and 1. An instance without routes is fully covered.
+	Ratio float64 `json:"ratio"`
+}
+
+// Untested returns the routes of the report which have not been requested.
+func (r CoverageReport) Untested() []RouteCoverage {
+	untested := make([]RouteCoverage, 0, r.Total-r.Covered)
+	for _, route := range r.Routes {
+		if !route.Covered {
+			untested = append(untested, route)
+		}
+	}
+
+	return untested
+}
+
+// SkipCoverage excludes the route from the coverage report, e.g. for routes which cannot be requested in tests.
+func (r *Route) SkipCoverage() *Route {
+	r.skipCoverage = true
+	return r
+}
+
+// Coverage returns which routes of the instance have been requested since it was created. A route counts as requested as
+// soon as its handler is reached, regardless of the response.
+func (i *Instance) Coverage() CoverageReport {
+	report := CoverageReport{Routes: make([]RouteCoverage, 0, len(i.routes)), Ratio: 1}
+	for _, route := range i.routes {
+		if route.skipCoverage {
+			continue
+		}
+
+		covered := atomic.LoadUint32(&route.covered) == 1
+		report.Routes = append(report.Routes, RouteCoverage{
+			Method:  route.method,
+			Path:    route.path,
+			Package: route.handlerPackage,
+			Covered: covered,
+		})
+
+		report.Total++
+		if covered {
+			report.Covered++
+		}
+	}
+
+	sort.SliceStable(report.Routes, func(a, b int) bool {
+		ra, rb := report.Routes[a], report.Routes[b]
+		if ra.Package != rb.Package {
+			return ra.Package < rb.Package
+		}
+		if ra.Path != rb.Path {
+			return ra.Path < rb.Path
+		}
+		return ra.Method < rb.Method
+	})
+
+	if report.Total > 0 {
+		report.Ratio = float64(report.Covered) / float64(report.Total)
+	}
+
+	return report
+}
+
+// markCovered marks the route as requested. The flag is read first, so requests after the first one do not write to it.
+func (r *Route) markCovered() {
+	if atomic.LoadUint32(&r.covered) == 0 {
+		atomic.StoreUint32(&r.covered, 1)
+	}
+}
+
+// handlerLocation returns the file and line the given handler function is declared at, e.g. "users/handler.go:42". Empty
+// if it is unknown.
+func handlerLocation(handler reflect.Value) string {
+	if !handler.IsValid() || handler.Kind() != reflect.Func {
+		return ""
+	}
+
+	fn := runtime.FuncForPC(handler.Pointer())
+	if fn == nil {
+		return ""
+	}
+
+	file, line := fn.FileLine(fn.Entry())
+	return file + ":" + strconv.Itoa(line)
+}
+
+// handlerPackage returns the import path of the package declaring the given handler function.
+func handlerPackage(handler interface{}) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(handler).Pointer())
+	if fn == nil {
+		return ""
+	}
+
+	// the name is the import path followed by the qualified name of the function, e.g. github.com/acme/users.(*Handler).Get
+	name := fn.Name()
+	slash := strings.LastIndex(name, "/")
+	if dot := strings.Index(name[slash+1:], "."); dot >= 0 {
+		return name[:slash+1+dot]
+	}
+
+	return name
+}
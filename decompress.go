@@ -0,0 +1,201 @@
+package octanox
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultMaxDecompressedBodySize is the maximum size of a decompressed request body, if the options do not declare their own.
+	defaultMaxDecompressedBodySize = 10 << 20
+	// defaultMaxInflationRatio is the maximum ratio between the decompressed and compressed size of a request body, if the options do not declare their own.
+	defaultMaxInflationRatio = 100
+	// inflationRatioThreshold is the decompressed size in bytes from which on the inflation ratio is enforced, so small but highly
+	// redundant bodies are not rejected.
+	inflationRatioThreshold = 64 << 10
+)
+
+// ContentDecoder is a function that wraps a compressed request body into a reader returning the decompressed body.
+type ContentDecoder func(r io.Reader) (io.ReadCloser, error)
+
+// RequestDecompressionOptions is a struct that configures the transparent decompression of request bodies sent with a Content-Encoding.
+type RequestDecompressionOptions struct {
+	// MaxBodySize is the maximum size in bytes a request body may have after decompression. Defaults to 10 MiB.
+	MaxBodySize int64
+	// MaxInflationRatio is the maximum ratio between the decompressed and the compressed size of a request body. Defaults to 100.
+	// Bodies larger than 64 KiB inflating beyond it are rejected early, even before reaching MaxBodySize, to stop decompression bombs.
+	MaxInflationRatio int64
+}
+
+// errRequestBodyTooLarge is returned while reading a decompressed request body exceeding the limits of the decompression options.
+var errRequestBodyTooLarge = errors.New("request body too large")
+
+// SetRequestDecompression sets the options of the transparent request body decompression.
+func (i *Instance) SetRequestDecompression(opts RequestDecompressionOptions) *Instance {
+	i.decompression = opts
+	return i
+}
+
+// RegisterContentDecoder registers a decoder for request bodies sent with the given Content-Encoding, e.g. to support zstd.
+// The gzip and deflate encodings are supported out of the box.
+func (i *Instance) RegisterContentDecoder(encoding string, decoder ContentDecoder) *Instance {
+	encoding = strings.ToLower(encoding)
+	if _, ok := i.contentDecoders[encoding]; ok {
+		panic("octanox: content decoder for encoding " + encoding + " already registered")
+	}
+
+	i.contentDecoders[encoding] = decoder
+	return i
+}
+
+// defaultContentDecoders returns the content decoders supported out of the box.
+func defaultContentDecoders() map[string]ContentDecoder {
+	gzipDecoder := func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	}
+
+	return map[string]ContentDecoder{
+		"gzip":    gzipDecoder,
+		"x-gzip":  gzipDecoder,
+		"deflate": zlib.NewReader,
+	}
+}
+
+// decompressRequest transparently decompresses request bodies sent with a Content-Encoding, so the binder and the body
+// transformers always see the decoded body. Unsupported encodings are rejected with 415 Unsupported Media Type.
+func decompressRequest() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Content-Encoding")
+		if header == "" || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		opts := Current.decompression
+		if opts.MaxBodySize <= 0 {
+			opts.MaxBodySize = defaultMaxDecompressedBodySize
+		}
+		if opts.MaxInflationRatio <= 0 {
+			opts.MaxInflationRatio = defaultMaxInflationRatio
+		}
+
+		compressed := &countingReader{r: c.Request.Body}
+		var body io.Reader = compressed
+		decoders := make([]io.Closer, 0, 1)
+
+		// encodings are listed in the order they were applied, so they are decoded from last to first
+		encodings := strings.Split(header, ",")
+		for n := len(encodings) - 1; n >= 0; n-- {
+			encoding := strings.ToLower(strings.TrimSpace(encodings[n]))
+			if encoding == "" || encoding == "identity" {
+				continue
+			}
+
+			decoder, ok := Current.contentDecoders[encoding]
+			if !ok {
+				abortWithError(c, failedRequest{
+					status:  http.StatusUnsupportedMediaType,
+					message: "Unsupported Content-Encoding: " + encoding,
+					code:    ErrorCodeUnsupportedEncoding,
+				})
+				return
+			}
+
+			decoded, err := decoder(body)
+			if err != nil {
+				message := "Invalid " + encoding + " request body"
+
+				if Current.isDebug {
+					message += ": " + err.Error()
+				}
+
+				abortWithError(c, failedRequest{
+					status:  http.StatusBadRequest,
+					message: message,
+					code:    ErrorCodeInvalidBody,
+				})
+				return
+			}
+
+			decoders = append(decoders, decoded)
+			body = decoded
+		}
+
+		defer func() {
+			for _, decoder := range decoders {
+				decoder.Close()
+			}
+		}()
+
+		c.Request.Body = &inflationLimitReader{
+			r:          body,
+			compressed: compressed,
+			maxSize:    opts.MaxBodySize,
+			maxRatio:   opts.MaxInflationRatio,
+			closer:     c.Request.Body,
+		}
+		c.Request.Header.Del("Content-Encoding")
+		c.Request.Header.Del("Content-Length")
+		c.Request.ContentLength = -1
+
+		c.Next()
+	}
+}
+
+// readBody reads the whole request body. Bodies exceeding the decompression limits abort the request with 413 Request Entity Too Large.
+func readBody(c *gin.Context) ([]byte, error) {
+	body, err := io.ReadAll(c.Request.Body)
+	if errors.Is(err, errRequestBodyTooLarge) {
+		panic(failedRequest{
+			status:  http.StatusRequestEntityTooLarge,
+			message: "Request body too large",
+			code:    ErrorCodeBodyTooLarge,
+		})
+	}
+
+	return body, err
+}
+
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// inflationLimitReader reads a decompressed body, failing with errRequestBodyTooLarge as soon as it exceeds the maximum size or
+// inflates beyond the maximum ratio of the compressed bytes read so far.
+type inflationLimitReader struct {
+	r          io.Reader
+	compressed *countingReader
+	maxSize    int64
+	maxRatio   int64
+	n          int64
+	closer     io.Closer
+}
+
+func (r *inflationLimitReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+
+	if r.n > r.maxSize || r.n > inflationRatioThreshold && r.n > r.maxRatio*r.compressed.n {
+		return n, errRequestBodyTooLarge
+	}
+
+	return n, err
+}
+
+func (r *inflationLimitReader) Close() error {
+	return r.closer.Close()
+}
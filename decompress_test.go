@@ -0,0 +1,121 @@
+package octanox
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type decompressBody struct {
+	Name string `json:"name"`
+}
+
+type decompressedRequest struct {
+	PostRequest
+	Body decompressBody `body:"true"`
+}
+
+type decompressResponse struct {
+	Name string `json:"name"`
+}
+
+func decompressHandler(req *decompressedRequest) *decompressResponse {
+	return &decompressResponse{Name: req.Body.Name}
+}
+
+// gzipped returns the gzip compressed content.
+func gzipped(t testing.TB, content []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestInflationLimitReaderMaxSize(t *testing.T) {
+	compressed := &countingReader{r: strings.NewReader("compressed")}
+	r := &inflationLimitReader{r: strings.NewReader(strings.Repeat("a", 100)), compressed: compressed, maxSize: 64, maxRatio: 1 << 20}
+
+	if _, err := io.ReadAll(r); !errors.Is(err, errRequestBodyTooLarge) {
+		t.Errorf("error %v, want %v", err, errRequestBodyTooLarge)
+	}
+
+	r = &inflationLimitReader{r: strings.NewReader(strings.Repeat("a", 64)), compressed: compressed, maxSize: 64, maxRatio: 1 << 20}
+	if body, err := io.ReadAll(r); err != nil || len(body) != 64 {
+		t.Errorf("read %d bytes with error %v, want 64 bytes", len(body), err)
+	}
+}
+
+func TestInflationLimitReaderRatio(t *testing.T) {
+	size := 2 * inflationRatioThreshold
+
+	// the whole compressed body has been read, so the ratio is the one of the full body
+	compressed := &countingReader{r: strings.NewReader(""), n: int64(size / 10)}
+	r := &inflationLimitReader{r: strings.NewReader(strings.Repeat("a", size)), compressed: compressed, maxSize: 1 << 30, maxRatio: 5}
+	if _, err := io.ReadAll(r); !errors.Is(err, errRequestBodyTooLarge) {
+		t.Errorf("ratio 10 with maximum 5: error %v, want %v", err, errRequestBodyTooLarge)
+	}
+
+	compressed = &countingReader{r: strings.NewReader(""), n: int64(size / 10)}
+	r = &inflationLimitReader{r: strings.NewReader(strings.Repeat("a", size)), compressed: compressed, maxSize: 1 << 30, maxRatio: 20}
+	if _, err := io.ReadAll(r); err != nil {
+		t.Errorf("ratio 10 with maximum 20: error %v", err)
+	}
+
+	// small bodies are not held to the ratio
+	compressed = &countingReader{r: strings.NewReader(""), n: 1}
+	r = &inflationLimitReader{r: strings.NewReader(strings.Repeat("a", inflationRatioThreshold)), compressed: compressed, maxSize: 1 << 30, maxRatio: 5}
+	if _, err := io.ReadAll(r); err != nil {
+		t.Errorf("body below the threshold: error %v", err)
+	}
+}
+
+func TestDecompressRequest(t *testing.T) {
+	body := gzipped(t, []byte(`{"name":"alice"}`))
+	bomb := gzipped(t, []byte(`{"name":"`+strings.Repeat("a", 2<<20)+`"}`))
+
+	tests := []struct {
+		name, encoding string
+		body           []byte
+		status         int
+	}{
+		{"gzip", "gzip", body, http.StatusOK},
+		{"identity and gzip", "identity, gzip", body, http.StatusOK},
+		{"unsupported encoding", "br", body, http.StatusUnsupportedMediaType},
+		{"invalid gzip header", "gzip", []byte(`{"name":"alice"}`), http.StatusBadRequest},
+		{"truncated gzip", "gzip", body[:len(body)-8], http.StatusBadRequest},
+		{"too large", "gzip", bomb, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newTestInstance(t)
+			i.SetRequestDecompression(RequestDecompressionOptions{MaxBodySize: 1 << 20})
+			i.Register("/users", decompressHandler)
+
+			req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Content-Encoding", tt.encoding)
+
+			rec := serveTest(i, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status == http.StatusOK && !strings.Contains(rec.Body.String(), "alice") {
+				t.Errorf("body %s, want the decoded name", rec.Body.String())
+			}
+		})
+	}
+}
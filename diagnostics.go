@@ -0,0 +1,243 @@
+package octanox
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	rpprof "runtime/pprof"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+)
+
+// diagnosticsTokenHeader is the header clients send the diagnostics token in.
+const diagnosticsTokenHeader = "X-Nox-Diagnostics-Token"
+
+// capturedProfiles are the profiles CaptureDiagnostics writes, which are snapshots that do not need to be sampled for a duration.
+var capturedProfiles = []string{"heap", "allocs", "block", "mutex", "threadcreate"}
+
+// DiagnosticsOptions is a struct that configures the diagnostics endpoints mounted under /.nox/diagnostics. All endpoints are
+// served unless disabled. The pprof endpoints and the goroutine dump expose the heap and the stacks of the process, so they
+// need the internal endpoints to be guarded by a token or roles.
+type DiagnosticsOptions struct {
+	// Token is the token clients additionally have to send in the X-Nox-Diagnostics-Token header, on top of passing the guard of
+	// the internal endpoints. Can be empty if the guard is sufficient.
+	Token string
+	// DisablePprof disables the pprof endpoints at /.nox/diagnostics/pprof/.
+	DisablePprof bool
+	// DisableGoroutines disables the goroutine dump at /.nox/diagnostics/goroutines.
+	DisableGoroutines bool
+	// DisableMemory disables the GC and heap stats at /.nox/diagnostics/memory.
+	DisableMemory bool
+	// DisableRoutes disables the route table at /.nox/diagnostics/routes.
+	DisableRoutes bool
+	// DisableRuntimeConfig disables the current runtime config at /.nox/diagnostics/runtime-config.
+	DisableRuntimeConfig bool
+}
+
+// MemoryStats is a struct that contains the GC and heap stats of the runtime.
+type MemoryStats struct {
+	Goroutines   int    `json:"goroutines"`
+	GOMAXPROCS   int    `json:"gomaxprocs"`
+	HeapAlloc    uint64 `json:"heap_alloc_bytes"`
+	HeapInuse    uint64 `json:"heap_inuse_bytes"`
+	HeapIdle     uint64 `json:"heap_idle_bytes"`
+	HeapReleased uint64 `json:"heap_released_bytes"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	TotalAlloc   uint64 `json:"total_alloc_bytes"`
+	Sys          uint64 `json:"sys_bytes"`
+	NumGC        uint32 `json:"num_gc"`
+	// LastGC is the time the last garbage collection finished. Nil if no garbage collection ran yet.
+	LastGC        *time.Time `json:"last_gc"`
+	PauseTotalMs  float64    `json:"pause_total_ms"`
+	GCCPUFraction float64    `json:"gc_cpu_fraction"`
+}
+
+// RouteInfo is a struct that describes a registered route in the route table of the diagnostics.
+type RouteInfo struct {
+	Method        string   `json:"method"`
+	Path          string   `json:"path"`
+	Authenticated bool     `json:"authenticated"`
+	Roles         []string `json:"roles"`
+	RequestType   string   `json:"request_type"`
+	ResponseType  string   `json:"response_type"`
+	// Versions are the versions of the variants of the route. Empty if the route is not versioned.
+	Versions []string `json:"versions"`
+}
+
+// EnableDiagnostics serves runtime diagnostics under /.nox/diagnostics, guarded by the internal options and the optional
+// diagnostics token: the pprof endpoints, a goroutine dump, GC and heap stats, the route table and the current runtime config.
+// Every endpoint can be disabled on its own. Panics if the pprof endpoints or the goroutine dump are enabled while the internal
+// options configure neither a token nor roles, so SetInternalOptions has to be called first.
+func (i *Instance) EnableDiagnostics(opts DiagnosticsOptions) *Instance {
+	if (!opts.DisablePprof || !opts.DisableGoroutines) && i.internalOptions.Token == "" && len(i.internalOptions.Roles) == 0 {
+		panic("octanox: the pprof endpoints and the goroutine dump need the internal endpoints to be guarded by a token or roles")
+	}
+
+	diagnostics := i.internal().Group("/diagnostics", func(c *gin.Context) {
+		if opts.Token != "" && subtle.ConstantTimeCompare([]byte(c.GetHeader(diagnosticsTokenHeader)), []byte(opts.Token)) != 1 {
+			abortWithError(c, failedRequest{status: http.StatusForbidden, message: "forbidden", code: ErrorCodeForbidden})
+		}
+	})
+
+	if !opts.DisablePprof {
+		diagnostics.GET("/pprof/", gin.WrapF(pprof.Index))
+		diagnostics.GET("/pprof/:profile", func(c *gin.Context) {
+			switch profile := c.Param("profile"); profile {
+			case "cmdline":
+				pprof.Cmdline(c.Writer, c.Request)
+			case "profile":
+				pprof.Profile(c.Writer, c.Request)
+			case "symbol":
+				pprof.Symbol(c.Writer, c.Request)
+			case "trace":
+				pprof.Trace(c.Writer, c.Request)
+			default:
+				pprof.Handler(profile).ServeHTTP(c.Writer, c.Request)
+			}
+		})
+		diagnostics.POST("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	}
+
+	if !opts.DisableGoroutines {
+		diagnostics.GET("/goroutines", func(c *gin.Context) {
+			c.Header("Content-Type", "text/plain; charset=utf-8")
+			if err := rpprof.Lookup("goroutine").WriteTo(c.Writer, 2); err != nil {
+				panic(err)
+			}
+		})
+	}
+
+	if !opts.DisableMemory {
+		diagnostics.GET("/memory", func(c *gin.Context) {
+			c.JSON(http.StatusOK, readMemoryStats())
+		})
+	}
+
+	if !opts.DisableRoutes {
+		diagnostics.GET("/routes", func(c *gin.Context) {
+			c.JSON(http.StatusOK, i.routeTable())
+		})
+	}
+
+	if !opts.DisableRuntimeConfig {
+		diagnostics.GET("/runtime-config", func(c *gin.Context) {
+			c.JSON(http.StatusOK, i.RuntimeConfig())
+		})
+	}
+
+	return i
+}
+
+// CaptureDiagnostics writes a snapshot of the heap, allocs, block, mutex and threadcreate profiles, a goroutine dump, the GC and
+// heap stats, the route table and the runtime config into the given directory, e.g. to attach them to an incident. The directory
+// is created if it does not exist. A failing file does not stop the others; all failures are returned together.
+func (i *Instance) CaptureDiagnostics(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	var errs []error
+	capture := func(name string, write func(w io.Writer) error) {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+
+		if err := write(f); err != nil {
+			errs = append(errs, err)
+		}
+		if err := f.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	writeJSONFile := func(v any) func(w io.Writer) error {
+		return func(w io.Writer) error {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(v)
+		}
+	}
+
+	for _, profile := range capturedProfiles {
+		capture(profile+".pb.gz", func(w io.Writer) error {
+			return rpprof.Lookup(profile).WriteTo(w, 0)
+		})
+	}
+
+	capture("goroutines.txt", func(w io.Writer) error {
+		return rpprof.Lookup("goroutine").WriteTo(w, 2)
+	})
+	capture("memory.json", writeJSONFile(readMemoryStats()))
+	capture("routes.json", writeJSONFile(i.routeTable()))
+	capture("runtime-config.json", writeJSONFile(i.RuntimeConfig()))
+
+	return errors.Join(errs...)
+}
+
+func readMemoryStats() MemoryStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	stats := MemoryStats{
+		Goroutines:    runtime.NumGoroutine(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		HeapAlloc:     m.HeapAlloc,
+		HeapInuse:     m.HeapInuse,
+		HeapIdle:      m.HeapIdle,
+		HeapReleased:  m.HeapReleased,
+		HeapObjects:   m.HeapObjects,
+		TotalAlloc:    m.TotalAlloc,
+		Sys:           m.Sys,
+		NumGC:         m.NumGC,
+		PauseTotalMs:  float64(m.PauseTotalNs) / 1e6,
+		GCCPUFraction: m.GCCPUFraction,
+	}
+
+	if m.LastGC != 0 {
+		lastGC := time.Unix(0, int64(m.LastGC)).UTC()
+		stats.LastGC = &lastGC
+	}
+
+	return stats
+}
+
+// routeTable returns the registered routes, sorted by path and method.
+func (i *Instance) routeTable() []RouteInfo {
+	routes := make([]RouteInfo, 0, len(i.routes))
+	for _, route := range i.routes {
+		info := RouteInfo{
+			Method:        route.method,
+			Path:          route.path,
+			Authenticated: i.requiresAuthentication(route),
+			Roles:         append([]string{}, route.roles...),
+			RequestType:   route.requestType.String(),
+			ResponseType:  route.responseType.String(),
+			Versions:      make([]string, 0, len(route.variants)),
+		}
+
+		for _, variant := range route.variants {
+			info.Versions = append(info.Versions, variant.version)
+		}
+
+		routes = append(routes, info)
+	}
+
+	sort.SliceStable(routes, func(a, b int) bool {
+		if routes[a].Path != routes[b].Path {
+			return routes[a].Path < routes[b].Path
+		}
+		return routes[a].Method < routes[b].Method
+	})
+
+	return routes
+}
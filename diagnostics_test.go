@@ -0,0 +1,74 @@
+package octanox
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDiagnosticsNeedGuardedInternalEndpoints(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   DiagnosticsOptions
+		panics bool
+	}{
+		{"all endpoints", DiagnosticsOptions{}, true},
+		{"diagnostics token only", DiagnosticsOptions{Token: "secret"}, true},
+		{"goroutines", DiagnosticsOptions{DisablePprof: true}, true},
+		{"pprof", DiagnosticsOptions{DisableGoroutines: true}, true},
+		{"stats only", DiagnosticsOptions{DisablePprof: true, DisableGoroutines: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newTestInstance(t)
+			i.SetInternalOptions(InternalOptions{AllowUnauthenticated: true})
+
+			defer func() {
+				r := recover()
+				if panicked := r != nil; panicked != tt.panics {
+					t.Fatalf("panicked %t, want %t", panicked, tt.panics)
+				}
+				if r != nil && !strings.Contains(fmt.Sprint(r), "token or roles") {
+					t.Errorf("panic %v", r)
+				}
+			}()
+			i.EnableDiagnostics(tt.opts)
+		})
+	}
+}
+
+func TestDiagnosticsProfilesNeedCredentials(t *testing.T) {
+	i := newTestInstance(t)
+	if err := i.ApplyRuntimeConfig(RuntimeConfig{LogLevel: LogLevelOff}); err != nil {
+		t.Fatal(err)
+	}
+	i.SetInternalOptions(InternalOptions{Token: "internal"})
+	i.EnableDiagnostics(DiagnosticsOptions{Token: "diagnostics"})
+
+	for _, path := range []string{"/pprof/heap", "/pprof/goroutine", "/goroutines"} {
+		for _, tt := range []struct {
+			internal, diagnostics string
+			status                int
+		}{
+			{"", "", http.StatusForbidden},
+			{"internal", "", http.StatusForbidden},
+			{"", "diagnostics", http.StatusForbidden},
+			{"internal", "diagnostics", http.StatusOK},
+		} {
+			req := httptest.NewRequest(http.MethodGet, internalBasePath+"/diagnostics"+path, nil)
+			if tt.internal != "" {
+				req.Header.Set("X-Nox-Token", tt.internal)
+			}
+			if tt.diagnostics != "" {
+				req.Header.Set(diagnosticsTokenHeader, tt.diagnostics)
+			}
+
+			if rec := serveTest(i, req); rec.Code != tt.status {
+				t.Errorf("%s with internal token %q and diagnostics token %q: status %d, want %d", path, tt.internal, tt.diagnostics, rec.Code, tt.status)
+			}
+		}
+	}
+}
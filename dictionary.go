@@ -0,0 +1,333 @@
+package octanox
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// dictionaryBasePath is the path the shared compression dictionaries are served under, by their hex encoded hash. It is
+	// public, as browsers fetch the dictionaries without credentials.
+	dictionaryBasePath = "/.well-known/nox-dictionaries"
+	// defaultDictionarySamples is the number of responses of a route group sampled, if the options do not declare their own.
+	defaultDictionarySamples = 32
+	// defaultMaxDictionarySize is the maximum size of a built dictionary, if the options do not declare their own.
+	defaultMaxDictionarySize = 32 << 10
+	// minDictionaryCompressedSize is the size in bytes from which on responses are compressed against the dictionary.
+	minDictionaryCompressedSize = 256
+)
+
+// dictionaryStreamHeaders are the magic numbers RFC 9842 prefixes the streams of its encodings with, followed by the
+// SHA-256 of the dictionary.
+var dictionaryStreamHeaders = map[string][]byte{
+	"dcb": {0xff, 0x44, 0x43, 0x42},
+	"dcz": {0x5e, 0x2a, 0x4d, 0x18, 0x20, 0x00, 0x00, 0x00},
+}
+
+// DictionaryCompressor is an interface that compresses response bodies against a shared dictionary, e.g. Brotli or
+// Zstandard with a raw dictionary.
+type DictionaryCompressor interface {
+	// Encoding returns the Content-Encoding of the compressed bodies, dcb for Brotli or dcz for Zstandard as defined by
+	// RFC 9842. The stream header of these encodings is written before the compressed body.
+	Encoding() string
+	// Compress returns a writer compressing into the given writer against the given dictionary.
+	Compress(w io.Writer, dictionary []byte) (io.WriteCloser, error)
+}
+
+// CompressionDictionaryOptions is a struct that configures the experimental compression of responses against shared
+// dictionaries, following the Compression Dictionary Transport of RFC 9842. Every route group, the first segment of the
+// route paths like /users, has its own dictionary.
+type CompressionDictionaryOptions struct {
+	// Compressor compresses the responses against the dictionaries. Required.
+	Compressor DictionaryCompressor
+	// Dictionaries are precompiled dictionaries by route group, e.g. "/users". The dictionaries of the other groups are built
+	// from sampled responses.
+	Dictionaries map[string][]byte
+	// Samples is the number of successful responses of a route group sampled to build its dictionary. Defaults to 32.
+	Samples int
+	// MaxSize is the maximum size in bytes of a built dictionary. Defaults to 32 KiB.
+	MaxSize int
+}
+
+// CompressionDictionaries compresses the responses of the routes against a shared dictionary per route group, which cuts
+// the size of JSON responses sharing their keys. The dictionaries are served at /.well-known/nox-dictionaries/<hash> and
+// advertised to clients with a Link header. Clients announcing a dictionary with the Available-Dictionary header and
+// accepting the encoding of the compressor get responses compressed against it, all other clients get the regular
+// responses. Until the dictionary of a group is built from its sampled responses, its responses are not compressed.
+// Streamed responses and downloads are never compressed. Experimental.
+func (i *Instance) CompressionDictionaries(opts CompressionDictionaryOptions) *Instance {
+	if opts.Compressor == nil {
+		panic("octanox: compression dictionaries need a compressor")
+	}
+
+	if opts.Samples <= 0 {
+		opts.Samples = defaultDictionarySamples
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = defaultMaxDictionarySize
+	}
+
+	first := i.dictionaries == nil
+	i.dictionaries = &dictionaryCompression{opts: opts, groups: make(map[string]*dictionaryGroup), served: make(map[string]*sharedDictionary)}
+	for group, dictionary := range opts.Dictionaries {
+		i.dictionaries.group(group).use(i.dictionaries, dictionary)
+	}
+
+	if first {
+		i.Gin.GET(dictionaryBasePath+"/:hash", i.serveDictionary)
+	}
+
+	return i
+}
+
+// dictionaryCompression holds the dictionaries of the route groups.
+type dictionaryCompression struct {
+	opts   CompressionDictionaryOptions
+	mu     sync.Mutex
+	groups map[string]*dictionaryGroup
+	// served are all dictionaries ever used by their hex encoded hash, so clients still fetch the dictionaries they were
+	// advertised before a group got a new one.
+	served map[string]*sharedDictionary
+}
+
+// dictionaryGroup is the dictionary of a route group, or the samples it is built from.
+type dictionaryGroup struct {
+	name    string
+	mu      sync.Mutex
+	samples [][]byte
+	current *sharedDictionary
+}
+
+// sharedDictionary is a dictionary together with its hash.
+type sharedDictionary struct {
+	// group is the route group the dictionary is used by.
+	group string
+	data  []byte
+	hash  [sha256.Size]byte
+	// id is the hex encoded hash, used in the URL of the dictionary.
+	id string
+	// available is the Available-Dictionary header clients announce the dictionary with, the base64 encoded hash as
+	// structured field byte sequence.
+	available string
+}
+
+// group returns the dictionary group of the given name, creating it on first use.
+func (d *dictionaryCompression) group(name string) *dictionaryGroup {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	group, ok := d.groups[name]
+	if !ok {
+		group = &dictionaryGroup{name: name}
+		d.groups[name] = group
+	}
+
+	return group
+}
+
+// dictionary returns the dictionary with the given hex encoded hash.
+func (d *dictionaryCompression) dictionary(id string) (*sharedDictionary, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dictionary, ok := d.served[id]
+	return dictionary, ok
+}
+
+// use makes the given data the dictionary of the group.
+func (g *dictionaryGroup) use(d *dictionaryCompression, data []byte) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.current = d.register(g.name, data)
+	g.samples = nil
+}
+
+// register registers the given data as dictionary of the given route group, so it is served by its hash.
+func (d *dictionaryCompression) register(group string, data []byte) *sharedDictionary {
+	dictionary := &sharedDictionary{group: group, data: data, hash: sha256.Sum256(data)}
+	dictionary.id = hex.EncodeToString(dictionary.hash[:])
+	dictionary.available = ":" + base64.StdEncoding.EncodeToString(dictionary.hash[:]) + ":"
+
+	d.mu.Lock()
+	d.served[dictionary.id] = dictionary
+	d.mu.Unlock()
+
+	return dictionary
+}
+
+// dictionary returns the current dictionary of the group. Can be nil if it has not been built yet.
+func (g *dictionaryGroup) dictionary() *sharedDictionary {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.current
+}
+
+// sample adds the given response body to the samples of the group, building its dictionary once enough have been sampled.
+func (g *dictionaryGroup) sample(d *dictionaryCompression, body []byte) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.current != nil {
+		return
+	}
+
+	for _, sample := range g.samples {
+		if bytes.Equal(sample, body) {
+			return
+		}
+	}
+
+	g.samples = append(g.samples, append([]byte{}, body...))
+	if len(g.samples) >= d.opts.Samples {
+		g.current = d.register(g.name, buildDictionary(g.samples, d.opts.MaxSize))
+		g.samples = nil
+	}
+}
+
+// buildDictionary builds a raw dictionary of the given samples, which the compressors reference substrings in. The latest
+// samples are closest to the end of the dictionary, where references are cheapest.
+func buildDictionary(samples [][]byte, maxSize int) []byte {
+	var data []byte
+	for _, sample := range samples {
+		data = append(data, sample...)
+	}
+
+	if len(data) > maxSize {
+		data = data[len(data)-maxSize:]
+	}
+
+	return data
+}
+
+// serveDictionary serves the dictionary of the requested hash, immutable as its URL changes with its content.
+func (i *Instance) serveDictionary(c *gin.Context) {
+	dictionary, ok := i.dictionaries.dictionary(c.Param("hash"))
+	if !ok {
+		abortWithError(c, failedRequest{status: http.StatusNotFound, message: "Unknown compression dictionary", code: ErrorCodeNotFound})
+		return
+	}
+
+	// the dictionary applies to the paths of its route group
+	c.Header("Use-As-Dictionary", `match="`+strings.TrimSuffix(dictionary.group, "/")+`/*", id="`+dictionary.id+`"`)
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Data(http.StatusOK, "application/octet-stream", dictionary.data)
+}
+
+// compressWithDictionary serves the request of the given route, compressing its response against the dictionary of its
+// route group if the client has the dictionary, or sampling its response if the dictionary is not built yet.
+func compressWithDictionary(c *gin.Context, rt *Route, serve func()) {
+	d := Current.dictionaries
+	group := d.group(routeGroup(rt.path))
+	dictionary := group.dictionary()
+
+	if dictionary != nil {
+		c.Writer.Header().Add("Link", "<"+dictionaryBasePath+"/"+dictionary.id+`>; rel="compression-dictionary"`)
+		c.Writer.Header().Add("Vary", "Accept-Encoding, Available-Dictionary")
+	}
+
+	encoding := d.opts.Compressor.Encoding()
+	compress := dictionary != nil && c.GetHeader("Available-Dictionary") == dictionary.available && acceptsEncoding(c.GetHeader("Accept-Encoding"), encoding)
+	if dictionary != nil && !compress {
+		serve()
+		return
+	}
+
+	buffered := &bufferedResponseWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+	c.Writer = buffered
+
+	defer func() {
+		// let the recovery middleware write to the actual response of a panicking handler
+		if err := recover(); err != nil {
+			c.Writer = buffered.ResponseWriter
+			panic(err)
+		}
+	}()
+
+	serve()
+	c.Writer = buffered.ResponseWriter
+
+	body := buffered.body.Bytes()
+	success := buffered.status >= 200 && buffered.status < 300
+
+	if dictionary == nil {
+		if success && len(body) > 0 {
+			group.sample(d, body)
+		}
+
+		buffered.flush()
+		return
+	}
+
+	if !success || len(body) < minDictionaryCompressedSize || c.Writer.Header().Get("Content-Encoding") != "" {
+		buffered.flush()
+		return
+	}
+
+	var compressed bytes.Buffer
+	if header, ok := dictionaryStreamHeaders[encoding]; ok {
+		compressed.Write(header)
+		compressed.Write(dictionary.hash[:])
+	}
+
+	w, err := d.opts.Compressor.Compress(&compressed, dictionary.data)
+	if err == nil {
+		_, err = w.Write(body)
+		if closeErr := w.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	// responses which cannot be compressed are sent as they are
+	if err != nil {
+		buffered.flush()
+		return
+	}
+
+	c.Writer.Header().Set("Content-Encoding", encoding)
+	c.Writer.Header().Set("Content-Length", strconv.Itoa(compressed.Len()))
+	c.Writer.WriteHeader(buffered.status)
+	c.Writer.Write(compressed.Bytes())
+}
+
+// usesDictionary checks if the responses of the route can be compressed against a dictionary. Streamed responses and
+// downloads are sent while they are produced and cannot be buffered.
+func (r *Route) usesDictionary() bool {
+	return Current.dictionaries != nil && r.streamType == nil && r.responseType != downloadType
+}
+
+// routeGroup returns the route group of the given path, its first segment, e.g. "/users".
+func routeGroup(path string) string {
+	group, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return "/" + group
+}
+
+// acceptsEncoding checks if the given Accept-Encoding header accepts the given encoding.
+func acceptsEncoding(header, encoding string) bool {
+	for _, accepted := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(accepted, ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+
+		q, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !ok {
+			return true
+		}
+
+		weight, err := strconv.ParseFloat(strings.TrimSpace(q), 64)
+		return err == nil && weight > 0
+	}
+
+	return false
+}
@@ -0,0 +1,218 @@
+package octanox
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeCompressor "compresses" by prefixing the body with the size of the dictionary, or fails if err is set.
+type fakeCompressor struct {
+	err error
+}
+
+func (fakeCompressor) Encoding() string {
+	return "dcz"
+}
+
+func (f fakeCompressor) Compress(w io.Writer, dictionary []byte) (io.WriteCloser, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	io.WriteString(w, strconv.Itoa(len(dictionary))+":")
+	return nopWriteCloser{w}, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+type dictionaryRequest struct {
+	GetRequest
+	ID string `path:"id"`
+}
+
+type dictionaryResponse struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+}
+
+// newDictionaryInstance returns an instance compressing with the given options, whose /users/:id responses are large enough
+// to be compressed unless the id is fail, and /small responses are not.
+func newDictionaryInstance(t *testing.T, opts CompressionDictionaryOptions) *Instance {
+	i := newTestInstance(t)
+	if err := i.ApplyRuntimeConfig(RuntimeConfig{LogLevel: LogLevelOff}); err != nil {
+		t.Fatal(err)
+	}
+
+	i.CompressionDictionaries(opts)
+	i.Register("/users/:id", func(req *dictionaryRequest) *dictionaryResponse {
+		if req.ID == "fail" {
+			panic("handler failed")
+		}
+		return &dictionaryResponse{ID: req.ID, Description: strings.Repeat("a user of the shared dictionary ", 10)}
+	})
+	i.Register("/small/:id", func(req *dictionaryRequest) *dictionaryResponse {
+		return &dictionaryResponse{ID: req.ID}
+	})
+
+	return i
+}
+
+// availableDictionary returns the Available-Dictionary header announcing the given dictionary.
+func availableDictionary(dictionary []byte) string {
+	hash := sha256.Sum256(dictionary)
+	return ":" + base64.StdEncoding.EncodeToString(hash[:]) + ":"
+}
+
+func getWithDictionary(i *Instance, path, available, acceptEncoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if available != "" {
+		req.Header.Set("Available-Dictionary", available)
+	}
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
+	return serveTest(i, req)
+}
+
+func TestDictionaryBuiltFromSamples(t *testing.T) {
+	i := newDictionaryInstance(t, CompressionDictionaryOptions{Compressor: fakeCompressor{}, Samples: 3, MaxSize: 100})
+
+	// failed responses are not sampled
+	if rec := getWithDictionary(i, "/users/fail", "", "dcz"); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status %d of a panicking handler, want 500", rec.Code)
+	}
+
+	// the duplicate response is sampled once, so the dictionary is built with the third distinct one
+	var last []byte
+	for _, id := range []string{"1", "1", "2", "3"} {
+		rec := getWithDictionary(i, "/users/"+id, "", "dcz")
+		last = rec.Body.Bytes()
+		if rec.Header().Get("Link") != "" || rec.Header().Get("Content-Encoding") != "" {
+			t.Fatalf("response %s while sampling advertises a dictionary or is compressed", id)
+		}
+	}
+
+	rec := getWithDictionary(i, "/users/4", "", "dcz")
+	link := rec.Header().Get("Link")
+	id := strings.TrimSuffix(strings.TrimPrefix(link, "<"+dictionaryBasePath+"/"), `>; rel="compression-dictionary"`)
+	if len(id) != 64 {
+		t.Fatalf("Link %q does not advertise a dictionary", link)
+	}
+
+	served := serveTest(i, httptest.NewRequest(http.MethodGet, dictionaryBasePath+"/"+id, nil))
+	if served.Code != http.StatusOK || served.Body.Len() != 100 {
+		t.Fatalf("status %d, dictionary of %d bytes, want 100", served.Code, served.Body.Len())
+	}
+	if hash := sha256.Sum256(served.Body.Bytes()); hex.EncodeToString(hash[:]) != id {
+		t.Errorf("dictionary does not match its hash %s", id)
+	}
+	if use := served.Header().Get("Use-As-Dictionary"); use != `match="/users/*", id="`+id+`"` {
+		t.Errorf("Use-As-Dictionary %q", use)
+	}
+	if !strings.Contains(served.Header().Get("Cache-Control"), "immutable") {
+		t.Errorf("Cache-Control %q", served.Header().Get("Cache-Control"))
+	}
+	// the latest samples are kept at the end of the dictionary
+	if !bytes.HasSuffix(last, served.Body.Bytes()) {
+		t.Errorf("dictionary %q is not built from the samples", served.Body.String())
+	}
+
+	// the other route groups sample on their own
+	if rec := getWithDictionary(i, "/small/1", "", "dcz"); rec.Header().Get("Link") != "" {
+		t.Errorf("route group /small uses the dictionary of /users")
+	}
+}
+
+func TestDictionaryCompression(t *testing.T) {
+	dictionary := []byte(`{"id":"","description":"a user of the shared dictionary "}`)
+	available := availableDictionary(dictionary)
+	hash := sha256.Sum256(dictionary)
+
+	tests := []struct {
+		name, path, available, acceptEncoding string
+		err                                   error
+		compressed                            bool
+	}{
+		{"dictionary available", "/users/1", available, "gzip, dcz", nil, true},
+		{"no dictionary available", "/users/1", "", "gzip, dcz", nil, false},
+		{"other dictionary available", "/users/1", availableDictionary([]byte("old")), "dcz", nil, false},
+		{"encoding not accepted", "/users/1", available, "gzip, br", nil, false},
+		{"encoding refused", "/users/1", available, "dcz;q=0", nil, false},
+		{"small response", "/small/1", available, "dcz", nil, false},
+		{"compressor failure", "/users/1", available, "dcz", errors.New("out of memory"), false},
+		{"failed response", "/users/fail", available, "dcz", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newDictionaryInstance(t, CompressionDictionaryOptions{
+				Compressor:   fakeCompressor{err: tt.err},
+				Dictionaries: map[string][]byte{"/users": dictionary, "/small": dictionary},
+			})
+			uncompressed := getWithDictionary(i, tt.path, "", "")
+
+			rec := getWithDictionary(i, tt.path, tt.available, tt.acceptEncoding)
+			if rec.Code != uncompressed.Code {
+				t.Fatalf("status %d, want %d", rec.Code, uncompressed.Code)
+			}
+
+			if !tt.compressed {
+				if encoding := rec.Header().Get("Content-Encoding"); encoding != "" || !bytes.Equal(rec.Body.Bytes(), uncompressed.Body.Bytes()) {
+					t.Errorf("Content-Encoding %q, body %s, want the uncompressed %s", encoding, rec.Body.String(), uncompressed.Body.String())
+				}
+				return
+			}
+
+			if encoding := rec.Header().Get("Content-Encoding"); encoding != "dcz" {
+				t.Errorf("Content-Encoding %q", encoding)
+			}
+
+			want := append(append(append([]byte{}, dictionaryStreamHeaders["dcz"]...), hash[:]...), strconv.Itoa(len(dictionary))+":"...)
+			want = append(want, uncompressed.Body.Bytes()...)
+			if !bytes.Equal(rec.Body.Bytes(), want) {
+				t.Errorf("body %q, want %q", rec.Body.Bytes(), want)
+			}
+			if vary := rec.Header().Values("Vary"); !strings.Contains(strings.Join(vary, ","), "Available-Dictionary") {
+				t.Errorf("Vary %v", vary)
+			}
+		})
+	}
+}
+
+func TestDictionaryVersions(t *testing.T) {
+	i := newDictionaryInstance(t, CompressionDictionaryOptions{
+		Compressor:   fakeCompressor{},
+		Dictionaries: map[string][]byte{"/users": []byte("first version"), "/small": []byte("second version")},
+	})
+
+	users := getWithDictionary(i, "/users/1", "", "").Header().Get("Link")
+	small := getWithDictionary(i, "/small/1", "", "").Header().Get("Link")
+	if users == "" || users == small {
+		t.Errorf("Link %q of /users, %q of /small, want a URL per dictionary", users, small)
+	}
+
+	hash := sha256.Sum256([]byte("first version"))
+	if !strings.Contains(users, hex.EncodeToString(hash[:])) {
+		t.Errorf("Link %q is not the hash of the dictionary", users)
+	}
+
+	if rec := serveTest(i, httptest.NewRequest(http.MethodGet, dictionaryBasePath+"/"+strings.Repeat("0", 64), nil)); rec.Code != http.StatusNotFound {
+		t.Errorf("status %d of an unknown dictionary, want 404", rec.Code)
+	}
+}
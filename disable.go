@@ -0,0 +1,266 @@
+package octanox
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DisableMode is a type that decides how a disabled route answers its requests.
+type DisableMode string
+
+const (
+	// DisableUnavailable answers the requests of the disabled route with a retryable 503 Service Unavailable and cancels the
+	// context of its requests in flight.
+	DisableUnavailable DisableMode = "unavailable"
+	// DisableNotFound answers the requests of the disabled route with 404 Not Found, as if it did not exist, and cancels the
+	// context of its requests in flight.
+	DisableNotFound DisableMode = "not_found"
+	// DisableDrain answers new requests of the disabled route with a retryable 503 Service Unavailable, but lets its requests
+	// in flight finish. The route is drained once DisabledRouteStatus.InFlight drops to zero.
+	DisableDrain DisableMode = "drain"
+)
+
+// DisabledRoute is a struct that describes a disabled route in the runtime config.
+type DisabledRoute struct {
+	Mode DisableMode `json:"mode"`
+	// By is who disabled the route through the internal endpoint, as in RuntimeConfigChange.Actor. Empty if it has been
+	// disabled from code.
+	By string `json:"by,omitempty"`
+	// At is the time the route has been disabled at.
+	At time.Time `json:"at"`
+}
+
+// DisabledRouteStatus is a struct that contains the state of a disabled route.
+type DisabledRouteStatus struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	DisabledRoute
+	// InFlight is the number of requests of the route which are still being served.
+	InFlight int `json:"in_flight"`
+	// Rejected is the number of requests rejected since the route has been registered, because it was disabled.
+	Rejected uint64 `json:"rejected"`
+}
+
+// routeKey returns the key of the route with the given method and path in the disabled routes, e.g. "GET /users/:id".
+func routeKey(method, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
+
+// key returns the key of the route in the disabled routes.
+func (r *Route) key() string {
+	return routeKey(r.method, r.path)
+}
+
+// DisableRoute switches off the route with the given method and path, e.g. "/users/:id", while serving, without a restart.
+// The route stays disabled across changes of the runtime config until it is enabled again. Returns an error if there is no
+// such route or the mode is unknown.
+func (i *Instance) DisableRoute(method, path string, mode DisableMode) error {
+	return i.disableRoute(method, path, mode, "")
+}
+
+// EnableRoute switches the disabled route with the given method and path on again.
+func (i *Instance) EnableRoute(method, path string) error {
+	return i.enableRoute(method, path, "")
+}
+
+// DisabledRoutes returns the disabled routes with their requests in flight, sorted by path.
+func (i *Instance) DisabledRoutes() []DisabledRouteStatus {
+	disabled := i.runtimeSnapshot().config.DisabledRoutes
+	routes := make([]DisabledRouteStatus, 0, len(disabled))
+
+	for key, route := range disabled {
+		method, path, _ := strings.Cut(key, " ")
+		status := DisabledRouteStatus{Method: method, Path: path, DisabledRoute: route}
+		if gate, ok := i.routeGates[key]; ok {
+			status.InFlight = gate.inFlightCount()
+			status.Rejected = atomic.LoadUint64(&gate.rejected)
+		}
+
+		routes = append(routes, status)
+	}
+
+	sort.Slice(routes, func(a, b int) bool {
+		if routes[a].Path == routes[b].Path {
+			return routes[a].Method < routes[b].Method
+		}
+		return routes[a].Path < routes[b].Path
+	})
+
+	return routes
+}
+
+func (i *Instance) disableRoute(method, path string, mode DisableMode, actor string) error {
+	key := routeKey(method, path)
+	if _, ok := i.routeGates[key]; !ok {
+		return fmt.Errorf("no route %s to disable", key)
+	}
+
+	return i.updateRuntimeConfig(actor, func(config *RuntimeConfig) error {
+		config.DisabledRoutes[key] = DisabledRoute{Mode: mode, By: actor, At: time.Now()}
+		return nil
+	})
+}
+
+func (i *Instance) enableRoute(method, path string, actor string) error {
+	key := routeKey(method, path)
+	return i.updateRuntimeConfig(actor, func(config *RuntimeConfig) error {
+		if _, ok := config.DisabledRoutes[key]; !ok {
+			return fmt.Errorf("route %s is not disabled", key)
+		}
+
+		delete(config.DisabledRoutes, key)
+		return nil
+	})
+}
+
+// mountDisabledRoutes serves the disabled routes at /.nox/disabled-routes. GET lists them, PUT disables a route from a
+// {"method": "GET", "path": "/users/:id", "mode": "drain"} body and DELETE enables the route of the method and path query
+// parameters again.
+func (i *Instance) mountDisabledRoutes() {
+	i.internal().GET("/disabled-routes", func(c *gin.Context) {
+		c.JSON(http.StatusOK, i.DisabledRoutes())
+	})
+
+	i.internal().PUT("/disabled-routes", func(c *gin.Context) {
+		var body struct {
+			Method string      `json:"method"`
+			Path   string      `json:"path"`
+			Mode   DisableMode `json:"mode"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil || body.Method == "" || body.Path == "" {
+			abortWithError(c, failedRequest{status: http.StatusBadRequest, message: "Invalid body: expected {\"method\": ..., \"path\": ..., \"mode\": ...}", code: ErrorCodeInvalidBody})
+			return
+		}
+
+		if err := i.disableRoute(body.Method, body.Path, body.Mode, internalActor(c)); err != nil {
+			abortWithError(c, failedRequest{status: http.StatusUnprocessableEntity, message: err.Error(), code: ErrorCodeInvalidConfig})
+			return
+		}
+
+		c.JSON(http.StatusOK, i.DisabledRoutes())
+	})
+
+	i.internal().DELETE("/disabled-routes", func(c *gin.Context) {
+		if err := i.enableRoute(c.Query("method"), c.Query("path"), internalActor(c)); err != nil {
+			abortWithError(c, failedRequest{status: http.StatusNotFound, message: err.Error(), code: ErrorCodeNotFound})
+			return
+		}
+
+		c.JSON(http.StatusOK, i.DisabledRoutes())
+	})
+}
+
+// validateDisabledRoutes checks the modes of the given disabled routes.
+func validateDisabledRoutes(disabled map[string]DisabledRoute) error {
+	for key, route := range disabled {
+		switch route.Mode {
+		case DisableUnavailable, DisableNotFound, DisableDrain:
+		default:
+			return fmt.Errorf("invalid disable mode %q of route %s, must be unavailable, not_found or drain", route.Mode, key)
+		}
+	}
+
+	return nil
+}
+
+// routeGate tracks the requests in flight of a registered route, so they can be cancelled or drained when it is disabled.
+type routeGate struct {
+	// inFlight is the number of requests of the route which are being served.
+	inFlight int64
+	// rejected is the number of requests rejected because the route was disabled.
+	rejected uint64
+	mu       sync.Mutex
+	// cancels are the cancel functions of the contexts of the requests in flight.
+	cancels map[*http.Request]context.CancelFunc
+}
+
+func newRouteGate() *routeGate {
+	return &routeGate{cancels: make(map[*http.Request]context.CancelFunc)}
+}
+
+// admit answers the request if the route is disabled and tracks it as in flight otherwise. Returns the function to call
+// once the request has been served, or false if it has been answered. Every admitted request is tracked, so a route
+// disabled later cancels or drains all of its requests in flight.
+func (g *routeGate) admit(c *gin.Context, rt *Route) (func(), bool) {
+	if disabled, ok := requestRuntimeSnapshot(c).config.DisabledRoutes[rt.key()]; ok {
+		atomic.AddUint64(&g.rejected, 1)
+		if Current.routeStats != nil {
+			Current.routeStats.recordRejected(rt.method, rt.path)
+		}
+
+		if disabled.Mode == DisableNotFound {
+			abortWithError(c, failedRequest{status: http.StatusNotFound, message: "not found", code: ErrorCodeNotFound})
+		} else {
+			abortWithError(c, failedRequest{status: http.StatusServiceUnavailable, message: "Service Unavailable: route disabled", code: ErrorCodeRouteDisabled, retryable: true})
+		}
+		return nil, false
+	}
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	c.Request = c.Request.WithContext(ctx)
+	atomic.AddInt64(&g.inFlight, 1)
+
+	g.mu.Lock()
+	g.cancels[c.Request] = cancel
+	g.mu.Unlock()
+
+	request := c.Request
+	return func() {
+		g.mu.Lock()
+		delete(g.cancels, request)
+		g.mu.Unlock()
+
+		atomic.AddInt64(&g.inFlight, -1)
+		cancel()
+	}, true
+}
+
+// cancelInFlight cancels the contexts of all requests of the route in flight.
+func (g *routeGate) cancelInFlight() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, cancel := range g.cancels {
+		cancel()
+	}
+}
+
+func (g *routeGate) inFlightCount() int {
+	return int(atomic.LoadInt64(&g.inFlight))
+}
+
+// cancelDisabledRequests cancels the requests in flight of the routes the given change disabled with a mode which does not
+// let them finish.
+func (i *Instance) cancelDisabledRequests(previous, applied map[string]DisabledRoute) {
+	for key, route := range applied {
+		if route.Mode == DisableDrain {
+			continue
+		}
+
+		if before, ok := previous[key]; ok && before.Mode != DisableDrain {
+			continue
+		}
+
+		if gate, ok := i.routeGates[key]; ok {
+			gate.cancelInFlight()
+		}
+	}
+}
+
+// copyDisabledRoutes returns a copy of the given disabled routes, which is never nil.
+func copyDisabledRoutes(disabled map[string]DisabledRoute) map[string]DisabledRoute {
+	routes := make(map[string]DisabledRoute, len(disabled))
+	for key, route := range disabled {
+		routes[key] = route
+	}
+
+	return routes
+}
@@ -0,0 +1,192 @@
+package octanox
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type disableRequest struct {
+	GetRequest
+}
+
+type disableResponse struct {
+	OK bool `json:"ok"`
+}
+
+func disableHandler(*disableRequest) *disableResponse {
+	return &disableResponse{OK: true}
+}
+
+func TestDisableRouteStatuses(t *testing.T) {
+	tests := []struct {
+		mode      DisableMode
+		status    int
+		retryable bool
+	}{
+		{DisableNotFound, http.StatusNotFound, false},
+		{DisableUnavailable, http.StatusServiceUnavailable, true},
+		{DisableDrain, http.StatusServiceUnavailable, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			i := newTestInstance(t)
+			i.Register("/users", disableHandler)
+			if err := i.DisableRoute(http.MethodGet, "/users", tt.mode); err != nil {
+				t.Fatal(err)
+			}
+
+			rec := serveTest(i, httptest.NewRequest(http.MethodGet, "/users", nil))
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+
+			var body struct {
+				Retryable bool `json:"retryable"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Retryable != tt.retryable {
+				t.Errorf("retryable %t, want %t", body.Retryable, tt.retryable)
+			}
+
+			if status := i.DisabledRoutes(); len(status) != 1 || status[0].Rejected != 1 {
+				t.Errorf("disabled routes %+v, want one route with one rejected request", status)
+			}
+
+			if err := i.EnableRoute(http.MethodGet, "/users"); err != nil {
+				t.Fatal(err)
+			}
+			if rec := serveTest(i, httptest.NewRequest(http.MethodGet, "/users", nil)); rec.Code != http.StatusOK {
+				t.Errorf("status %d after enabling, want %d", rec.Code, http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestDisableRouteRejectsUnknownRoute(t *testing.T) {
+	i := newTestInstance(t)
+
+	if err := i.DisableRoute(http.MethodGet, "/missing", DisableUnavailable); err == nil {
+		t.Fatal("expected an error for an unknown route")
+	}
+}
+
+func TestDisableRouteDrainWaitsForInFlight(t *testing.T) {
+	i := newTestInstance(t)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	i.Register("/slow", func(*disableRequest) *disableResponse {
+		close(started)
+		<-release
+		return &disableResponse{OK: true}
+	})
+	handler := i.Handler()
+
+	var inFlight *httptest.ResponseRecorder
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		inFlight = httptest.NewRecorder()
+		handler.ServeHTTP(inFlight, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+	<-started
+
+	if err := i.DisableRoute(http.MethodGet, "/slow", DisableDrain); err != nil {
+		t.Fatal(err)
+	}
+
+	if status := i.DisabledRoutes(); len(status) != 1 || status[0].InFlight != 1 {
+		t.Fatalf("disabled routes %+v, want one request in flight", status)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("new request: status %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	close(release)
+	wg.Wait()
+	if inFlight.Code != http.StatusOK {
+		t.Errorf("request in flight: status %d, want %d", inFlight.Code, http.StatusOK)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for i.DisabledRoutes()[0].InFlight != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("route has not been drained")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDisableRouteCancelsInFlight(t *testing.T) {
+	for _, mode := range []DisableMode{DisableUnavailable, DisableNotFound, DisableDrain} {
+		t.Run(string(mode), func(t *testing.T) {
+			i := newTestInstance(t)
+			rt := i.Register("/slow", disableHandler)
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/slow", nil)
+			done, ok := rt.gate.admit(c, rt)
+			if !ok {
+				t.Fatal("request of an enabled route was not admitted")
+			}
+			ctx := c.Request.Context()
+
+			if err := i.DisableRoute(http.MethodGet, "/slow", mode); err != nil {
+				t.Fatal(err)
+			}
+			if status := i.DisabledRoutes(); len(status) != 1 || status[0].InFlight != 1 {
+				t.Fatalf("disabled routes %+v, want one request in flight", status)
+			}
+			if cancelled := ctx.Err() != nil; cancelled == (mode == DisableDrain) {
+				t.Errorf("request in flight cancelled %t", cancelled)
+			}
+
+			done()
+			if status := i.DisabledRoutes(); status[0].InFlight != 0 {
+				t.Errorf("%d requests in flight after serving, want none", status[0].InFlight)
+			}
+		})
+	}
+}
+
+// TestDisableRoutesConcurrently checks that routes disabled at the same time do not overwrite each other's change.
+func TestDisableRoutesConcurrently(t *testing.T) {
+	i := newTestInstance(t)
+	for n := 0; n < 20; n++ {
+		i.Register("/route"+strconv.Itoa(n), disableHandler)
+	}
+	// a slow audit hook widens the window in which a change read before the lock would be lost
+	i.AuditRuntimeConfig(func(RuntimeConfigChange) {
+		time.Sleep(time.Millisecond)
+	})
+
+	var wg sync.WaitGroup
+	for n := 0; n < 20; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			if err := i.DisableRoute(http.MethodGet, "/route"+strconv.Itoa(n), DisableUnavailable); err != nil {
+				t.Error(err)
+			}
+		}(n)
+	}
+	wg.Wait()
+
+	if disabled := i.DisabledRoutes(); len(disabled) != 20 {
+		t.Errorf("%d routes disabled, want 20", len(disabled))
+	}
+}
@@ -0,0 +1,114 @@
+package octanox
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Description describes this route, e.g. "Deprecated: use v2 instead.", as the doc comment of its handler would. The
+// generated TypeScript function carries it as JSDoc and the OpenAPI operation as its description. A paragraph starting
+// with "Deprecated:" marks the function as @deprecated.
+func (r *Route) Description(description string) *Route {
+	r.description = strings.TrimSpace(description)
+	return r
+}
+
+// DescribeType describes the given named struct or enum type, e.g. Invoice{}, as its doc comment would. The generated
+// TypeScript interface or union type carries it as JSDoc and the OpenAPI schema as its description. The fields are described
+// with the `doc:"..."` tag. Panics if the type is unnamed.
+func (i *Instance) DescribeType(dto any, description string) *Instance {
+	t := reflect.TypeOf(dto)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Name() == "" {
+		panic(fmt.Sprintf("octanox: the description of %T must be declared on a named type", dto))
+	}
+
+	if i.typeDescriptions == nil {
+		i.typeDescriptions = make(map[reflect.Type]string)
+	}
+	i.typeDescriptions[t] = strings.TrimSpace(description)
+
+	return i
+}
+
+// fieldDescription returns the description of the given struct field declared with the doc tag. Can be empty.
+func fieldDescription(tag reflect.StructTag) string {
+	return strings.TrimSpace(tag.Get("doc"))
+}
+
+// jsDocLines returns the lines of the JSDoc of the given descriptions, without the delimiters of the comment. Every line
+// of a multi-line description is a line of its own, "*/" is escaped so it does not end the comment early, and paragraphs
+// starting with "Deprecated:" become the @deprecated tag.
+func jsDocLines(descriptions ...string) []string {
+	var lines []string
+	for _, description := range descriptions {
+		if description == "" {
+			continue
+		}
+
+		for _, line := range strings.Split(description, "\n") {
+			line = strings.ReplaceAll(strings.TrimRight(line, " \t\r"), "*/", `*\/`)
+			if rest, ok := strings.CutPrefix(line, "Deprecated:"); ok {
+				line = "@deprecated" + rest
+			}
+
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// splitJSDocTags splits the given JSDoc lines into the text before the first tag, without its trailing empty lines, and the
+// lines from the first tag on.
+func splitJSDocTags(lines []string) (text []string, tags []string) {
+	for n, line := range lines {
+		if strings.HasPrefix(line, "@") {
+			text, tags = lines[:n], lines[n:]
+			break
+		}
+	}
+	if tags == nil {
+		text = lines
+	}
+
+	for len(text) > 0 && text[len(text)-1] == "" {
+		text = text[:len(text)-1]
+	}
+
+	return text, tags
+}
+
+// deprecatedDescription checks if the given description has a paragraph starting with "Deprecated:".
+func deprecatedDescription(description string) bool {
+	for _, line := range strings.Split(description, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "Deprecated:") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeDoc writes the JSDoc of the given descriptions, as a single line if it fits one. Writes nothing if all descriptions
+// are empty.
+func (tb *tsCodeBuilder) writeDoc(descriptions ...string) {
+	lines := jsDocLines(descriptions...)
+	switch len(lines) {
+	case 0:
+		return
+	case 1:
+		tb.writeLine("/** " + lines[0] + " */")
+		return
+	}
+
+	tb.writeLine("/**")
+	for _, line := range lines {
+		tb.writeLine(strings.TrimRight(" * "+line, " "))
+	}
+	tb.writeLine(" */")
+}
@@ -0,0 +1,238 @@
+package octanox
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FindingSeverity is an enum that defines the severity of a finding reported by Instance.Doctor.
+type FindingSeverity int
+
+const (
+	// SeverityInfo is the severity of findings that are worth knowing but most likely intended.
+	SeverityInfo FindingSeverity = iota
+	// SeverityWarning is the severity of findings that are most likely a misconfiguration.
+	SeverityWarning
+	// SeverityError is the severity of findings that break the service or its security.
+	SeverityError
+)
+
+const (
+	// FindingProtectedWithoutAuthenticator is reported when routes require authentication but no authenticator is configured, so they are public.
+	FindingProtectedWithoutAuthenticator = "NOX101"
+	// FindingRolesOnPublicRoute is reported when a public route declares roles, which are never checked.
+	FindingRolesOnPublicRoute = "NOX102"
+	// FindingClientDirUnwritable is reported when the TypeScript client output path is missing or cannot be written to.
+	FindingClientDirUnwritable = "NOX103"
+	// FindingCORSMissingForFrontend is reported when a frontend origin is configured but CORS does not allow it.
+	FindingCORSMissingForFrontend = "NOX104"
+	// FindingCORSWildcardCredentials is reported when CORS reflects any origin while allowing credentials.
+	FindingCORSWildcardCredentials = "NOX105"
+	// FindingInternalUnguarded is reported when internal endpoints are mounted without a token or roles guarding them.
+	FindingInternalUnguarded = "NOX106"
+	// FindingContractViolations is reported when the route contracts are incoherent but strict contracts are disabled.
+	FindingContractViolations = "NOX107"
+	// FindingNoRoutes is reported when no route has been registered.
+	FindingNoRoutes = "NOX108"
+)
+
+// Finding is a struct describing a probable misconfiguration found by Instance.Doctor.
+type Finding struct {
+	// ID is the stable identifier of the check that produced the finding, which can be used to suppress it.
+	ID string
+	// Severity is the severity of the finding.
+	Severity FindingSeverity
+	// Message describes what is wrong.
+	Message string
+	// Hint describes how to fix it.
+	Hint string
+}
+
+func (s FindingSeverity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+
+	return "unknown"
+}
+
+func (f Finding) String() string {
+	return f.ID + " [" + f.Severity.String() + "] " + f.Message + " (hint: " + f.Hint + ")"
+}
+
+// SuppressFindings suppresses the findings with the given IDs in Instance.Doctor. Findings can also be suppressed with the
+// comma separated NOX__DOCTOR_SUPPRESS environment variable.
+func (i *Instance) SuppressFindings(ids ...string) *Instance {
+	for _, id := range ids {
+		i.suppressedFindings[id] = true
+	}
+
+	return i
+}
+
+// Doctor runs heuristic checks against the configuration and the registered routes and returns the findings, ordered by
+// severity. It only inspects in-memory state and the client output path, so it is cheap enough to run at every startup.
+func (i *Instance) Doctor() []Finding {
+	d := &doctor{instance: i, suppressed: make(map[string]bool)}
+	for id := range i.suppressedFindings {
+		d.suppressed[id] = true
+	}
+	for _, id := range strings.Split(os.Getenv("NOX__DOCTOR_SUPPRESS"), ",") {
+		d.suppressed[strings.TrimSpace(id)] = true
+	}
+
+	d.checkRoutes()
+	d.checkClientDir()
+	d.checkCORS()
+	d.checkInternal()
+	d.checkContracts()
+
+	sort.SliceStable(d.findings, func(a, b int) bool {
+		return d.findings[a].Severity > d.findings[b].Severity
+	})
+
+	return d.findings
+}
+
+type doctor struct {
+	instance   *Instance
+	suppressed map[string]bool
+	findings   []Finding
+}
+
+func (d *doctor) report(id string, severity FindingSeverity, message, hint string) {
+	if d.suppressed[id] {
+		return
+	}
+
+	d.findings = append(d.findings, Finding{ID: id, Severity: severity, Message: message, Hint: hint})
+}
+
+func (d *doctor) checkRoutes() {
+	i := d.instance
+	if len(i.routes) == 0 {
+		d.report(FindingNoRoutes, SeverityWarning, "no routes are registered", "register the routes in the before_start hook or before calling Run")
+		return
+	}
+
+	var protected, rolesOnPublic []string
+	for _, route := range i.routes {
+		// routes made public by a route authenticator are public on purpose
+		if _, overridden := i.routeAuthenticatorFor(route); i.requiresAuthentication(route) && i.Authenticator == nil && !overridden {
+			protected = append(protected, route.method+" "+route.path)
+		}
+		if !i.requiresAuthentication(route) && len(route.roles) > 0 {
+			rolesOnPublic = append(rolesOnPublic, route.method+" "+route.path)
+		}
+	}
+
+	if len(protected) > 0 {
+		d.report(FindingProtectedWithoutAuthenticator, SeverityError,
+			"routes require authentication but no authenticator is configured, so they are public: "+summarizeList(protected),
+			"configure an authenticator with Authenticate before registering the routes")
+	}
+
+	if len(rolesOnPublic) > 0 {
+		d.report(FindingRolesOnPublicRoute, SeverityWarning,
+			"public routes declare roles that are never checked: "+summarizeList(rolesOnPublic),
+			"register the routes with RegisterProtected or drop the roles")
+	}
+}
+
+func (d *doctor) checkClientDir() {
+	path := os.Getenv("NOX__CLIENT_DIR")
+	if path == "" {
+		if d.instance.isDryRun {
+			d.report(FindingClientDirUnwritable, SeverityError, "dry-run mode is enabled but no client output path is configured",
+				"set NOX__CLIENT_DIR to the path of the generated TypeScript client")
+		}
+		return
+	}
+
+	dir := filepath.Dir(path)
+	file, err := os.CreateTemp(dir, ".nox-doctor-*")
+	if err != nil {
+		d.report(FindingClientDirUnwritable, SeverityError, "the client output directory "+dir+" is not writable: "+err.Error(),
+			"create the directory or point NOX__CLIENT_DIR to a writable location")
+		return
+	}
+
+	file.Close()
+	os.Remove(file.Name())
+}
+
+func (d *doctor) checkCORS() {
+	origins := d.instance.RuntimeConfig().CORSAllowedOrigins
+	if containsString(origins, "*") {
+		d.report(FindingCORSWildcardCredentials, SeverityWarning, "CORS reflects every origin while allowing credentials",
+			"set NOX__CORS_ALLOWED_ORIGINS to the origin of the frontend")
+	}
+
+	oauth2, ok := d.instance.Authenticator.(*OAuth2BearerAuthenticator)
+	if !ok || oauth2.loginSuccessRedirect == "" {
+		return
+	}
+
+	redirect, err := url.Parse(oauth2.loginSuccessRedirect)
+	if err != nil || redirect.Host == "" {
+		return
+	}
+
+	frontend := redirect.Scheme + "://" + redirect.Host
+	if !containsString(origins, "*") && !containsString(origins, frontend) {
+		d.report(FindingCORSMissingForFrontend, SeverityWarning, "the frontend origin "+frontend+" of the login redirect is not allowed by CORS",
+			"set NOX__CORS_ALLOWED_ORIGINS to "+frontend)
+	}
+}
+
+func (d *doctor) checkInternal() {
+	i := d.instance
+	if i.internalGroup == nil || i.internalOptions.Token != "" || len(i.internalOptions.Roles) > 0 {
+		return
+	}
+
+	severity := SeverityInfo
+	message := "internal endpoints are not guarded and therefore not served"
+	if i.internalOptions.AllowUnauthenticated {
+		severity = SeverityWarning
+		message = "internal endpoints are not guarded and served to everyone, as AllowUnauthenticated is set"
+	}
+
+	d.report(FindingInternalUnguarded, severity, message, "configure a token or roles with SetInternalOptions")
+}
+
+func (d *doctor) checkContracts() {
+	if d.instance.strictContracts {
+		return
+	}
+
+	if errs := d.instance.Validate(); len(errs) > 0 {
+		messages := make([]string, 0, len(errs))
+		for _, err := range errs {
+			messages = append(messages, err.Error())
+		}
+
+		d.report(FindingContractViolations, SeverityWarning, "the route contracts are incoherent: "+summarizeList(messages),
+			"fix the reported contracts or enable StrictContracts to fail at startup")
+	}
+}
+
+// summarizeList joins the given items, cutting the list off after a few items so findings stay readable for big route tables.
+func summarizeList(items []string) string {
+	const maxItems = 5
+
+	if len(items) <= maxItems {
+		return strings.Join(items, ", ")
+	}
+
+	return strings.Join(items[:maxItems], ", ") + " and " + strconv.Itoa(len(items)-maxItems) + " more"
+}
@@ -0,0 +1,355 @@
+package octanox
+
+import (
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// downloadFlushRows is the number of rows after which a download is flushed to the client.
+	downloadFlushRows = 256
+	csvContentType    = "text/csv; charset=utf-8"
+	xlsxContentType   = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// downloadType is the type of the response of download routes.
+var downloadType = reflect.TypeOf(&Download{})
+
+// Download is a struct that represents a file streamed to the client row by row. Return it from a handler to stream the rows
+// sent on its channel as they arrive, so the whole file never has to be held in memory. The channel provides the backpressure:
+// a row is only received when the previous one has been written. If the client disconnects, the remaining rows are drained
+// and discarded, so producers should stop early by watching the context of the request. BinaryDownload streams any other
+// content, e.g. a generated PDF, instead of rows.
+// The generated TypeScript client resolves download routes to the blob and the file name of the response.
+type Download struct {
+	format   downloadFormat
+	headers  []string
+	rows     <-chan []string
+	filename string
+	gzip     bool
+	// body and contentType are the content of binary downloads.
+	body        io.Reader
+	contentType string
+}
+
+type downloadFormat int
+
+const (
+	downloadCSV downloadFormat = iota
+	downloadXLSX
+	downloadBinary
+)
+
+// CSVStream returns a download, which streams the given header and rows as CSV. The rows have to be closed when all are sent.
+func CSVStream(headers []string, rows <-chan []string) *Download {
+	return &Download{format: downloadCSV, headers: headers, rows: rows, filename: "export.csv"}
+}
+
+// XLSXStream returns a download, which streams the given header and rows as a single sheet XLSX workbook. All cells are
+// written as text. The rows have to be closed when all are sent.
+func XLSXStream(headers []string, rows <-chan []string) *Download {
+	return &Download{format: downloadXLSX, headers: headers, rows: rows, filename: "export.xlsx"}
+}
+
+// BinaryDownload returns a download, which streams the content of the given reader with the given content type, e.g. a
+// generated PDF. The reader is closed once it has been streamed if it is an io.Closer. The file name defaults to "download"
+// with the extension of the content type, e.g. "download.pdf". Panics if the body is nil.
+func BinaryDownload(contentType string, body io.Reader) *Download {
+	if body == nil {
+		panic("octanox: nil body of binary download")
+	}
+
+	return &Download{format: downloadBinary, body: body, contentType: contentType, filename: "download" + extensionOf(contentType)}
+}
+
+// extensionOf returns the file extension of the given content type, preferring the one named like its subtype, e.g. ".jpeg"
+// for image/jpeg. Empty if the content type is unknown.
+func extensionOf(contentType string) string {
+	extensions, err := mime.ExtensionsByType(contentType)
+	if err != nil || len(extensions) == 0 {
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	_, subtype, _ := strings.Cut(mediaType, "/")
+	for _, extension := range extensions {
+		if extension == "."+subtype {
+			return extension
+		}
+	}
+
+	return extensions[0]
+}
+
+// As sets the file name the client saves the download as.
+func (d *Download) As(filename string) *Download {
+	d.filename = filename
+	return d
+}
+
+// Gzip compresses the download with gzip, if the client accepts it. XLSX workbooks are already compressed and ignore this,
+// so should binary downloads of compressed formats.
+func (d *Download) Gzip() *Download {
+	d.gzip = true
+	return d
+}
+
+// ExportOf declares this route as the export sibling of the given list route. It accepts the same sort and filter fields, and its
+// ListQuery is never paginated, so the export contains every item the list would return for the same query.
+func (r *Route) ExportOf(list *Route) *Route {
+	r.list.sortable = append(r.list.sortable, list.list.sortable...)
+	r.list.filterable = append(r.list.filterable, list.list.filterable...)
+	r.list.export = true
+	return r
+}
+
+// write streams the download to the client.
+func (d *Download) write(c *gin.Context) {
+	contentType := csvContentType
+	switch d.format {
+	case downloadXLSX:
+		contentType = xlsxContentType
+	case downloadBinary:
+		contentType = d.contentType
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": d.filename}))
+	header.Set("Cache-Control", "no-store")
+
+	var w io.Writer = c.Writer
+	if d.gzip && d.format != downloadXLSX && acceptsGzip(c) {
+		header.Set("Content-Encoding", "gzip")
+		header.Add("Vary", "Accept-Encoding")
+
+		gz := gzip.NewWriter(c.Writer)
+		defer gz.Close()
+		w = gz
+	}
+
+	c.Status(http.StatusOK)
+
+	if d.format == downloadBinary {
+		d.writeBinary(c, w)
+		return
+	}
+
+	// the rows are drained on every early return, so a producer without a context does not block forever
+	defer func() {
+		go func() {
+			for range d.rows {
+			}
+		}()
+	}()
+
+	var sink rowWriter
+	if d.format == downloadXLSX {
+		sink = newXLSXRowWriter(w)
+	} else {
+		sink = &csvRowWriter{w: csv.NewWriter(w)}
+	}
+
+	flush := func() error {
+		if err := sink.flush(); err != nil {
+			return err
+		}
+		if gz, ok := w.(*gzip.Writer); ok {
+			if err := gz.Flush(); err != nil {
+				return err
+			}
+		}
+
+		c.Writer.Flush()
+		return nil
+	}
+
+	if len(d.headers) > 0 && sink.write(d.headers) != nil {
+		return
+	}
+
+	done := c.Request.Context().Done()
+	for n := 1; ; n++ {
+		select {
+		case <-done:
+			return
+		case row, ok := <-d.rows:
+			if !ok {
+				if sink.close() == nil {
+					flush()
+				}
+				return
+			}
+
+			if err := sink.write(row); err != nil {
+				return
+			}
+
+			if n%downloadFlushRows == 0 && flush() != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeBinary streams the content of the binary download to the given writer. A disconnected client fails the write, which
+// ends the stream. Failures are emitted as errors, unless the request has been cancelled.
+func (d *Download) writeBinary(c *gin.Context, w io.Writer) {
+	if closer, ok := d.body.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	if _, err := io.Copy(w, d.body); err != nil && c.Request.Context().Err() == nil {
+		Current.emitError(Error(fmt.Errorf("streaming the download of %s %s failed: %w", c.Request.Method, c.FullPath(), err)))
+	}
+}
+
+// acceptsGzip checks if the client accepts gzip encoded responses.
+func acceptsGzip(c *gin.Context) bool {
+	for _, accepted := range strings.Split(c.GetHeader("Accept-Encoding"), ",") {
+		encoding, params, _ := strings.Cut(strings.TrimSpace(accepted), ";")
+		if strings.EqualFold(strings.TrimSpace(encoding), "gzip") && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rowWriter writes the rows of a download in its file format.
+type rowWriter interface {
+	write(row []string) error
+	flush() error
+	close() error
+}
+
+type csvRowWriter struct {
+	w *csv.Writer
+}
+
+func (r *csvRowWriter) write(row []string) error {
+	return r.w.Write(row)
+}
+
+func (r *csvRowWriter) flush() error {
+	r.w.Flush()
+	return r.w.Error()
+}
+
+func (r *csvRowWriter) close() error {
+	return r.flush()
+}
+
+// xlsxRowWriter streams the rows into the sheet of a minimal XLSX workbook. The sheet is the last entry of the archive, so
+// the other parts are written upfront and the sheet can be streamed without knowing the number of rows.
+type xlsxRowWriter struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	row   int
+	err   error
+}
+
+func newXLSXRowWriter(w io.Writer) *xlsxRowWriter {
+	r := &xlsxRowWriter{zip: zip.NewWriter(w)}
+
+	for _, part := range xlsxParts {
+		if r.err = r.writePart(part[0], part[1]); r.err != nil {
+			return r
+		}
+	}
+
+	sheet, err := r.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		r.err = err
+		return r
+	}
+
+	r.sheet = bufio.NewWriter(sheet)
+	_, r.err = r.sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return r
+}
+
+func (r *xlsxRowWriter) writePart(name, content string) error {
+	part, err := r.zip.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(part, xml.Header+content)
+	return err
+}
+
+func (r *xlsxRowWriter) write(row []string) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	r.row++
+	r.sheet.WriteString(`<row r="` + strconv.Itoa(r.row) + `">`)
+	for _, cell := range row {
+		r.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+		xml.EscapeText(r.sheet, []byte(cell))
+		r.sheet.WriteString(`</t></is></c>`)
+	}
+	_, r.err = r.sheet.WriteString(`</row>`)
+
+	return r.err
+}
+
+func (r *xlsxRowWriter) flush() error {
+	if r.err != nil {
+		return r.err
+	}
+
+	if r.err = r.sheet.Flush(); r.err != nil {
+		return r.err
+	}
+
+	r.err = r.zip.Flush()
+	return r.err
+}
+
+func (r *xlsxRowWriter) close() error {
+	if r.err != nil {
+		return r.err
+	}
+
+	r.sheet.WriteString(`</sheetData></worksheet>`)
+	if r.err = r.sheet.Flush(); r.err != nil {
+		return r.err
+	}
+
+	r.err = r.zip.Close()
+	return r.err
+}
+
+// xlsxParts are the names and contents of the static parts of a single sheet workbook.
+var xlsxParts = [][2]string{
+	{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
@@ -0,0 +1,85 @@
+package octanox
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type downloadRequest struct {
+	GetRequest
+}
+
+// failingReader returns its content and then the given error.
+type failingReader struct {
+	content io.Reader
+	err     error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if n, err := r.content.Read(p); err != io.EOF {
+		return n, err
+	}
+
+	return 0, r.err
+}
+
+func TestBinaryDownloadDefaultFilename(t *testing.T) {
+	tests := []struct {
+		contentType, filename string
+	}{
+		{"application/pdf", "download.pdf"},
+		{"image/jpeg", "download.jpeg"},
+		{"application/json; charset=utf-8", "download.json"},
+		{"application/x-octanox-unknown", "download"},
+	}
+
+	for _, tt := range tests {
+		if d := BinaryDownload(tt.contentType, strings.NewReader("")); d.filename != tt.filename {
+			t.Errorf("%s: filename %q, want %q", tt.contentType, d.filename, tt.filename)
+		}
+	}
+
+	if d := BinaryDownload("application/pdf", strings.NewReader("")).As("report.pdf"); d.filename != "report.pdf" {
+		t.Errorf("filename %q, want %q", d.filename, "report.pdf")
+	}
+}
+
+func TestBinaryDownloadRejectsNilBody(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a nil body")
+		}
+	}()
+
+	BinaryDownload("application/pdf", nil)
+}
+
+func TestBinaryDownloadEmitsCopyErrors(t *testing.T) {
+	i := newTestInstance(t)
+
+	var emitted []error
+	i.ErrorHandler(func(err error) {
+		emitted = append(emitted, err)
+	})
+
+	failure := errors.New("generating the pdf failed")
+	i.Register("/report", func(*downloadRequest) *Download {
+		return BinaryDownload("application/pdf", &failingReader{content: strings.NewReader("%PDF"), err: failure})
+	})
+
+	rec := serveTest(i, httptest.NewRequest(http.MethodGet, "/report", nil))
+	if rec.Body.String() != "%PDF" {
+		t.Errorf("body %q, want %q", rec.Body.String(), "%PDF")
+	}
+	if disposition := rec.Header().Get("Content-Disposition"); disposition != `attachment; filename=download.pdf` {
+		t.Errorf("Content-Disposition %q", disposition)
+	}
+
+	if len(emitted) != 1 || !errors.Is(emitted[0], failure) {
+		t.Errorf("emitted %v, want the error of the body", emitted)
+	}
+}
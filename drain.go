@@ -0,0 +1,264 @@
+package octanox
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultShutdownTimeout is the time in-flight requests are given to complete during a graceful shutdown by default.
+const defaultShutdownTimeout = 30 * time.Second
+
+// deploymentIDHeader is the response header carrying the deployment ID.
+const deploymentIDHeader = "X-Deployment-ID"
+
+// DrainStatus is a struct that contains the in-flight requests of the runtime, to verify that it drained during a shutdown.
+type DrainStatus struct {
+	// ShuttingDown is a flag that indicates whether the graceful shutdown has been initiated.
+	ShuttingDown bool `json:"shutting_down"`
+	InFlight     int  `json:"in_flight"`
+	// OldestInFlightSeconds is the age of the oldest in-flight request. Zero if no request is in flight.
+	OldestInFlightSeconds float64 `json:"oldest_in_flight_seconds"`
+	// Routes are the routes with in-flight requests.
+	Routes []RouteInFlight `json:"routes"`
+}
+
+// RouteInFlight is a struct that contains the number of in-flight requests of a single route.
+type RouteInFlight struct {
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	InFlight int    `json:"in_flight"`
+}
+
+// SetShutdownTimeout sets the time in-flight requests are given to complete during a graceful shutdown, after which they are
+// cancelled. Defaults to 30 seconds.
+func (i *Instance) SetShutdownTimeout(timeout time.Duration) *Instance {
+	i.shutdownTimeout = timeout
+	return i
+}
+
+// SetDeploymentID sets the ID every response is tagged with in the X-Deployment-ID header, so load balancer logs can attribute
+// traffic to pod generations. Defaults to the NOX__DEPLOYMENT_ID environment variable. An empty ID disables the header.
+func (i *Instance) SetDeploymentID(id string) *Instance {
+	i.deploymentID = id
+	return i
+}
+
+// EnableDrainStatus enables tracking the in-flight requests and serves them at /.nox/drain-status, guarded by the internal
+// options. The graceful shutdown then logs a final summary of the requests that completed during the drain and that were
+// cancelled at the deadline, per route. Must be called before any route is registered.
+func (i *Instance) EnableDrainStatus() *Instance {
+	if i.drain != nil {
+		panic("octanox: drain status already enabled")
+	}
+
+	i.drain = &drainTracker{
+		inFlight:  make(map[*inFlightRequest]struct{}),
+		completed: make(map[string]int),
+		cancelled: make(map[string]int),
+	}
+
+	i.Gin.Use(i.drain.middleware())
+	i.internal().GET("/drain-status", func(c *gin.Context) {
+		c.JSON(http.StatusOK, i.drain.status())
+	})
+
+	return i
+}
+
+// DrainStatus returns the in-flight requests of the runtime, e.g. to export them as metrics. Returns nil if the drain status
+// is not enabled.
+func (i *Instance) DrainStatus() *DrainStatus {
+	if i.drain == nil {
+		return nil
+	}
+
+	status := i.drain.status()
+	return &status
+}
+
+// shutdown gracefully shuts down the server, giving the in-flight requests the shutdown timeout to complete before they are
+// cancelled.
+func (i *Instance) shutdown() {
+	server := i.server.Load()
+	if server == nil {
+		return
+	}
+
+	if i.drain != nil {
+		i.drain.begin()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), i.shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		if !errors.Is(err, context.DeadlineExceeded) {
+			log.Println("octanox: graceful shutdown failed: " + err.Error())
+		}
+
+		if i.drain != nil {
+			i.drain.cancelInFlight()
+		}
+
+		// closing the connections cancels the contexts of the requests still in flight
+		server.Close()
+	}
+
+	if i.drain != nil {
+		i.drain.logSummary()
+	}
+}
+
+// deploymentID tags every response with the deployment ID, if one is set.
+func deploymentID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if id := Current.deploymentID; id != "" {
+			c.Header(deploymentIDHeader, id)
+		}
+
+		c.Next()
+	}
+}
+
+// drainTracker tracks the in-flight requests and, once the shutdown has been initiated, how they finish.
+type drainTracker struct {
+	mu       sync.Mutex
+	inFlight map[*inFlightRequest]struct{}
+	// draining is a flag that indicates whether the shutdown has been initiated, at drainStart.
+	draining   bool
+	drainStart time.Time
+	// completed and cancelled are the numbers of requests that completed during the drain and that were cancelled at the
+	// deadline, by route.
+	completed map[string]int
+	cancelled map[string]int
+}
+
+// inFlightRequest is a request in flight.
+type inFlightRequest struct {
+	method string
+	path   string
+	start  time.Time
+	// cancelled is a flag that indicates whether the request has been cancelled at the deadline of the shutdown.
+	cancelled bool
+}
+
+func (r *inFlightRequest) route() string {
+	return r.method + " " + r.path
+}
+
+func (t *drainTracker) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		if path == "" || strings.HasPrefix(path, internalBasePath) {
+			c.Next()
+			return
+		}
+
+		request := &inFlightRequest{method: c.Request.Method, path: path, start: time.Now()}
+
+		t.mu.Lock()
+		t.inFlight[request] = struct{}{}
+		t.mu.Unlock()
+
+		defer t.finish(request)
+		c.Next()
+	}
+}
+
+// finish removes the given request from the in-flight requests, counting it as completed if the runtime is draining.
+func (t *drainTracker) finish(request *inFlightRequest) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.inFlight, request)
+	if t.draining && !request.cancelled {
+		t.completed[request.route()]++
+	}
+}
+
+// begin marks the initiation of the shutdown.
+func (t *drainTracker) begin() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.draining = true
+	t.drainStart = time.Now()
+}
+
+// cancelInFlight counts all requests still in flight as cancelled at the deadline.
+func (t *drainTracker) cancelInFlight() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for request := range t.inFlight {
+		request.cancelled = true
+		t.cancelled[request.route()]++
+	}
+}
+
+func (t *drainTracker) status() DrainStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status := DrainStatus{ShuttingDown: t.draining, InFlight: len(t.inFlight), Routes: make([]RouteInFlight, 0)}
+	byRoute := make(map[string]*RouteInFlight)
+	now := time.Now()
+
+	for request := range t.inFlight {
+		if age := now.Sub(request.start).Seconds(); age > status.OldestInFlightSeconds {
+			status.OldestInFlightSeconds = age
+		}
+
+		route, ok := byRoute[request.route()]
+		if !ok {
+			status.Routes = append(status.Routes, RouteInFlight{Method: request.method, Path: request.path})
+			route = &status.Routes[len(status.Routes)-1]
+			byRoute[request.route()] = route
+		}
+		route.InFlight++
+	}
+
+	sort.Slice(status.Routes, func(a, b int) bool {
+		if status.Routes[a].Path != status.Routes[b].Path {
+			return status.Routes[a].Path < status.Routes[b].Path
+		}
+		return status.Routes[a].Method < status.Routes[b].Method
+	})
+
+	return status
+}
+
+// logSummary logs the requests that completed during the drain and that were cancelled at the deadline, per route.
+func (t *drainTracker) logSummary() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	routes := make([]string, 0, len(t.completed)+len(t.cancelled))
+	completed, cancelled := 0, 0
+	for route, n := range t.completed {
+		routes = append(routes, route)
+		completed += n
+	}
+	for route, n := range t.cancelled {
+		if _, ok := t.completed[route]; !ok {
+			routes = append(routes, route)
+		}
+		cancelled += n
+	}
+	sort.Strings(routes)
+
+	log.Println("octanox: drained in " + time.Since(t.drainStart).Round(time.Millisecond).String() + ": " + strconv.Itoa(completed) +
+		" requests completed, " + strconv.Itoa(cancelled) + " cancelled at the deadline")
+	for _, route := range routes {
+		log.Println("octanox:   " + route + ": " + strconv.Itoa(t.completed[route]) + " completed, " + strconv.Itoa(t.cancelled[route]) + " cancelled")
+	}
+}
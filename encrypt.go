@@ -0,0 +1,317 @@
+package octanox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const (
+	// encryptionAESGCM is the value of the encrypt tag of fields encrypted with AES-GCM.
+	encryptionAESGCM = "aes-gcm"
+	// encryptionPrefix starts the envelope of encrypted values, which is followed by the algorithm, the key ID and the base64
+	// encoded nonce and ciphertext, separated by colons.
+	encryptionPrefix = "nox:"
+)
+
+// ErrNoKeyProvider is returned by EncryptFields and DecryptFields if no key provider has been set.
+var ErrNoKeyProvider = errors.New("octanox: no key provider set for encrypted fields")
+
+// KeyProvider is an interface that provides the keys of the fields tagged with `encrypt:"aes-gcm"`. Keys are 16, 24 or 32
+// bytes long, selecting AES-128, AES-192 or AES-256.
+type KeyProvider interface {
+	// CurrentKey returns the ID and the key new values are encrypted with.
+	CurrentKey() (id string, key []byte, err error)
+	// Key returns the key with the given ID, which can be a previous key values were encrypted with before a rotation.
+	Key(id string) ([]byte, error)
+}
+
+// StaticKeys is a key provider of a fixed set of keys by their ID, encrypting with the key of the Current ID.
+type StaticKeys struct {
+	Current string
+	Keys    map[string][]byte
+}
+
+func (k StaticKeys) CurrentKey() (string, []byte, error) {
+	key, err := k.Key(k.Current)
+	return k.Current, key, err
+}
+
+func (k StaticKeys) Key(id string) ([]byte, error) {
+	key, ok := k.Keys[id]
+	if !ok {
+		return nil, fmt.Errorf("octanox: unknown encryption key %q", id)
+	}
+
+	return key, nil
+}
+
+// SetKeyProvider sets the provider of the keys EncryptFields and DecryptFields use.
+func (i *Instance) SetKeyProvider(provider KeyProvider) *Instance {
+	i.keyProvider = provider
+	return i
+}
+
+// EncryptFields encrypts all fields tagged with `encrypt:"aes-gcm"` of the given pointer to a struct in place, including the
+// fields of nested structs, pointers, slices and arrays, so the struct can be stored with the sensitive values encrypted.
+// Encrypted fields are strings or byte slices, whose values are replaced by an envelope naming the key they are encrypted
+// with. Empty values and values which are already encrypted are left as is. Handlers respond with the decrypted struct, the
+// tag does not change the serialization.
+func EncryptFields(v any) error {
+	if Current.keyProvider == nil {
+		return ErrNoKeyProvider
+	}
+
+	id, key, err := Current.keyProvider.CurrentKey()
+	if err != nil {
+		return err
+	}
+
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return err
+	}
+
+	return eachEncryptedField(v, func(plaintext []byte) ([]byte, error) {
+		if isEncrypted(plaintext) {
+			return plaintext, nil
+		}
+
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+
+		// the key ID is authenticated, so an envelope cannot be moved to another key
+		sealed := aead.Seal(nonce, nonce, plaintext, []byte(id))
+		return []byte(encryptionPrefix + encryptionAESGCM + ":" + id + ":" + base64.RawURLEncoding.EncodeToString(sealed)), nil
+	})
+}
+
+// DecryptFields decrypts all fields tagged with `encrypt:"aes-gcm"` of the given pointer to a struct in place, with the keys
+// named by their envelopes, so values encrypted before a key rotation can still be read. Values which are not encrypted are
+// left as is. To rotate the key of stored values, decrypt and encrypt them again.
+func DecryptFields(v any) error {
+	if Current.keyProvider == nil {
+		return ErrNoKeyProvider
+	}
+
+	ciphers := make(map[string]cipher.AEAD)
+	return eachEncryptedField(v, func(envelope []byte) ([]byte, error) {
+		if !isEncrypted(envelope) {
+			return envelope, nil
+		}
+
+		parts := strings.SplitN(string(envelope[len(encryptionPrefix):]), ":", 3)
+		if len(parts) != 3 || parts[0] != encryptionAESGCM {
+			return nil, errors.New("octanox: invalid envelope of encrypted field")
+		}
+		id := parts[1]
+
+		aead, ok := ciphers[id]
+		if !ok {
+			key, err := Current.keyProvider.Key(id)
+			if err != nil {
+				return nil, err
+			}
+
+			if aead, err = newAESGCM(key); err != nil {
+				return nil, err
+			}
+			ciphers[id] = aead
+		}
+
+		sealed, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil || len(sealed) < aead.NonceSize() {
+			return nil, errors.New("octanox: invalid envelope of encrypted field")
+		}
+
+		plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(id))
+		if err != nil {
+			return nil, fmt.Errorf("octanox: cannot decrypt field encrypted with key %q: %w", id, err)
+		}
+
+		return plaintext, nil
+	})
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// isEncrypted checks if the given value is the envelope of an encrypted value.
+func isEncrypted(value []byte) bool {
+	return strings.HasPrefix(string(value), encryptionPrefix+encryptionAESGCM+":")
+}
+
+// encryptionPlan is the parsed encrypt tags of a struct type: the indices of its encrypted fields and of the fields of nested
+// values containing encrypted fields. It is computed once per type.
+type encryptionPlan struct {
+	encrypted []int
+	nested    []int
+}
+
+// encryptionPlans caches the plans of all struct types by type.
+var encryptionPlans sync.Map
+
+// encryptionPlanFor returns the encryption plan of the given struct type, computing it on first use. Returns an error if a
+// field has an unsupported encrypt tag or type.
+func encryptionPlanFor(t reflect.Type) (*encryptionPlan, error) {
+	return planEncryption(t, map[reflect.Type]bool{})
+}
+
+// planEncryption computes the encryption plan of the given struct type. Visiting are the types currently planned, which are
+// assumed to contain encrypted fields, so recursive types end.
+func planEncryption(t reflect.Type, visiting map[reflect.Type]bool) (*encryptionPlan, error) {
+	if plan, ok := encryptionPlans.Load(t); ok {
+		return plan.(*encryptionPlan), nil
+	}
+
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	plan := &encryptionPlan{}
+	for n := 0; n < t.NumField(); n++ {
+		field := t.Field(n)
+		if !field.IsExported() {
+			continue
+		}
+
+		if algorithm, ok := field.Tag.Lookup("encrypt"); ok {
+			if err := checkEncryptedField(field, algorithm); err != nil {
+				return nil, fmt.Errorf("field %s of %s: %w", field.Name, t.String(), err)
+			}
+
+			plan.encrypted = append(plan.encrypted, n)
+			continue
+		}
+
+		nested := field.Type
+		for nested.Kind() == reflect.Ptr || nested.Kind() == reflect.Slice || nested.Kind() == reflect.Array {
+			nested = nested.Elem()
+		}
+		if nested.Kind() != reflect.Struct {
+			continue
+		}
+
+		if visiting[nested] {
+			plan.nested = append(plan.nested, n)
+			continue
+		}
+
+		nestedPlan, err := planEncryption(nested, visiting)
+		if err != nil {
+			return nil, err
+		}
+		if len(nestedPlan.encrypted) > 0 || len(nestedPlan.nested) > 0 {
+			plan.nested = append(plan.nested, n)
+		}
+	}
+
+	actual, _ := encryptionPlans.LoadOrStore(t, plan)
+	return actual.(*encryptionPlan), nil
+}
+
+// checkEncryptedField checks that the given field with the given encrypt tag can be encrypted.
+func checkEncryptedField(field reflect.StructField, algorithm string) error {
+	if algorithm != encryptionAESGCM {
+		return fmt.Errorf("unsupported encryption %q", algorithm)
+	}
+
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.String && (t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uint8) {
+		return fmt.Errorf("encrypted field has type %s, but only strings and byte slices can be encrypted", field.Type.String())
+	}
+
+	return nil
+}
+
+// eachEncryptedField replaces the value of every encrypted field of the given pointer to a struct with the result of f.
+func eachEncryptedField(v any, f func(value []byte) ([]byte, error)) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("octanox: encrypted fields can only be processed in a pointer to a struct, not %T", v)
+	}
+
+	return walkEncryptedFields(rv.Elem(), f)
+}
+
+func walkEncryptedFields(v reflect.Value, f func(value []byte) ([]byte, error)) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return walkEncryptedFields(v.Elem(), f)
+	case reflect.Slice, reflect.Array:
+		for n := 0; n < v.Len(); n++ {
+			if err := walkEncryptedFields(v.Index(n), f); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+	default:
+		return nil
+	}
+
+	plan, err := encryptionPlanFor(v.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, n := range plan.encrypted {
+		field := v.Field(n)
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				continue
+			}
+			field = field.Elem()
+		}
+
+		if field.Len() == 0 {
+			continue
+		}
+
+		var value []byte
+		if field.Kind() == reflect.String {
+			value = []byte(field.String())
+		} else {
+			value = field.Bytes()
+		}
+
+		result, err := f(value)
+		if err != nil {
+			return err
+		}
+
+		if field.Kind() == reflect.String {
+			field.SetString(string(result))
+		} else {
+			field.SetBytes(result)
+		}
+	}
+
+	for _, n := range plan.nested {
+		if err := walkEncryptedFields(v.Field(n), f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
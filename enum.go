@@ -0,0 +1,112 @@
+package octanox
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// RegisterEnum registers the legal values of the given named string or integer type, e.g. the constants of
+// `type OrderStatus string`. The generated client declares the type as the union of its values, e.g.
+// `export type OrderStatus = 'pending' | 'shipped'`, and uses it wherever the type appears. The values are constants of the
+// type or untyped literals of its kind. Panics if the type is already registered.
+func (i *Instance) RegisterEnum(t reflect.Type, values ...any) *Instance {
+	if t.Name() == "" || !isEnumKind(t.Kind()) {
+		panic("octanox: enum " + t.String() + " must be a named string or integer type")
+	}
+
+	if _, ok := i.enums[t]; ok {
+		panic("octanox: enum " + t.String() + " is already registered")
+	}
+
+	for registered := range i.enums {
+		if registered.Name() == t.Name() {
+			panic("octanox: enum " + t.String() + " has the same name in the generated client as the enum " + registered.String())
+		}
+	}
+
+	if len(values) == 0 {
+		panic("octanox: enum " + t.String() + " needs at least one value")
+	}
+
+	literals := make([]string, 0, len(values))
+	for _, value := range values {
+		v, ok := enumValue(t, value)
+		if !ok {
+			panic(fmt.Sprintf("octanox: enum %s cannot have the value %v of type %T", t.String(), value, value))
+		}
+
+		literal := enumLiteral(v)
+		if containsString(literals, literal) {
+			panic("octanox: enum " + t.String() + " has the value " + literal + " more than once")
+		}
+
+		literals = append(literals, literal)
+	}
+
+	i.enums[t] = literals
+	return i
+}
+
+// enumValue converts the given value into the given enum type. The value is either of the enum type or of a predeclared type
+// of the same kind, e.g. an untyped constant. Returns false if it cannot be converted or overflows the enum type.
+func enumValue(t reflect.Type, value any) (reflect.Value, bool) {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return v, false
+	}
+
+	if v.Type() == t {
+		return v, true
+	}
+
+	if v.Type().PkgPath() != "" || !isEnumKind(v.Kind()) || (v.Kind() == reflect.String) != (t.Kind() == reflect.String) {
+		return v, false
+	}
+
+	if v.Kind() != reflect.String {
+		switch {
+		case v.CanInt() && t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64:
+			if v.Int() < 0 || reflect.Zero(t).OverflowUint(uint64(v.Int())) {
+				return v, false
+			}
+		case v.CanInt():
+			if reflect.Zero(t).OverflowInt(v.Int()) {
+				return v, false
+			}
+		case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64:
+			if reflect.Zero(t).OverflowUint(v.Uint()) {
+				return v, false
+			}
+		default:
+			if v.Uint() > math.MaxInt64 || reflect.Zero(t).OverflowInt(int64(v.Uint())) {
+				return v, false
+			}
+		}
+	}
+
+	return v.Convert(t), true
+}
+
+// isEnumKind checks if types of the given kind can be registered as enums.
+func isEnumKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// enumLiteral returns the TypeScript literal of the given enum value, a single-quoted string or a number.
+func enumLiteral(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return tsStringLiteral(v.String())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	default:
+		return strconv.FormatInt(v.Int(), 10)
+	}
+}
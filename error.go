@@ -2,6 +2,7 @@ package octanox
 
 import (
 	"fmt"
+	"reflect"
 	"runtime/debug"
 )
 
@@ -9,3 +10,31 @@ import (
 func Error(err error) error {
 	return fmt.Errorf("%w\n%s", err, string(debug.Stack()))
 }
+
+// WithErrorType declares the type of the bodies of the error responses of this route, e.g. reflect.TypeOf(ValidationError{})
+// for a route answering with 422 and its field errors, overriding the ErrorResponseType of the instance. The generated
+// TypeScript function documents that it rejects with an ApiError of this body, and its React Query hook is typed with it.
+// The type has to be a named struct, or a pointer to one.
+func (r *Route) WithErrorType(t reflect.Type) *Route {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct || t.Name() == "" {
+		panic(fmt.Sprintf("octanox: error type of %s %s must be a named struct, got %v", r.method, r.path, t))
+	}
+
+	r.errorType = t
+	return r
+}
+
+// usesErrorTypes checks if any of the given routes declares the type of its error responses.
+func usesErrorTypes(routes []*Route) bool {
+	for _, route := range routes {
+		if route.errorType != nil {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,467 @@
+package octanox
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"google.golang.org/protobuf/proto"
+)
+
+// exampleTime is the time all synthesized examples of time fields use, so examples are stable between runs.
+var exampleTime = time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+// maxNearMisses is the number of similar routes suggested when the examples of an unknown route are requested.
+const maxNearMisses = 5
+
+// RouteExample is a struct that contains a synthesized example request and response of a route.
+type RouteExample struct {
+	Method string `json:"method"`
+	// Path is the path of the route with its path parameters replaced by example values.
+	Path string `json:"path"`
+	// Query is the example query string, without the leading question mark.
+	Query string `json:"query"`
+	// Body is the example request body. Null if the route does not have a body.
+	Body json.RawMessage `json:"body"`
+	// Response is the example response body. Null if the route does not respond with JSON, e.g. downloads.
+	Response json.RawMessage `json:"response"`
+}
+
+// ServeExamples serves synthesized example requests and responses of the routes at /.nox/examples/:method/:path, e.g.
+// /.nox/examples/post/users, for manual testing. The path is either the registered path of the route, e.g. /users/:id, or a
+// concrete path like /users/42. Unknown routes are answered with 404 Not Found, suggesting routes with similar paths. Only
+// served in debug mode.
+func (i *Instance) ServeExamples() *Instance {
+	if !i.isDebug {
+		return i
+	}
+
+	i.internal().GET("/examples/:method/*path", func(c *gin.Context) {
+		method, path := strings.ToUpper(c.Param("method")), c.Param("path")
+
+		route := i.findRoute(method, path)
+		if route == nil {
+			message := "unknown route " + method + " " + path
+			if nearMisses := i.nearMissRoutes(method, path); len(nearMisses) > 0 {
+				message += ", did you mean: " + strings.Join(nearMisses, ", ")
+			}
+
+			abortWithError(c, failedRequest{status: http.StatusNotFound, message: message, code: ErrorCodeNotFound})
+			return
+		}
+
+		example, err := i.routeExample(route)
+		if err != nil {
+			panic(err)
+		}
+
+		c.JSON(http.StatusOK, example)
+	})
+
+	return i
+}
+
+// ExampleFor synthesizes an example request body and response body of the given route. Fields tagged with `example:"..."`
+// use the given value, which is parsed as JSON unless the field is a string, and fields tagged with `enum:"a,b"` use the
+// first of the allowed values. All other fields get a placeholder of their type. The request body is nil if the route does
+// not have a body, and the response body is nil if it does not respond with JSON.
+func (i *Instance) ExampleFor(route *Route) (reqJSON, respJSON []byte, err error) {
+	example, err := i.routeExample(route)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return example.Body, example.Response, nil
+}
+
+// routeExample synthesizes the example request and response of the given route.
+func (i *Instance) routeExample(route *Route) (*RouteExample, error) {
+	example := &RouteExample{Method: route.method, Path: route.path}
+	query := url.Values{}
+
+	for n := range route.plan.fields {
+		bf := &route.plan.fields[n]
+
+		switch bf.source {
+		case sourcePath:
+			value, err := exampleParam(bf)
+			if err != nil {
+				return nil, err
+			}
+
+			example.Path = strings.Replace(example.Path, ":"+bf.name, url.PathEscape(value), 1)
+		case sourceQuery:
+			value, err := exampleParam(bf)
+			if err != nil {
+				return nil, err
+			}
+
+			query.Set(bf.name, value)
+		case sourceBody:
+			if route.plan.rawBodyField() != nil {
+				continue
+			}
+
+			body, err := i.exampleJSON(bf.field.Type)
+			if err != nil {
+				return nil, fmt.Errorf("octanox: cannot synthesize example body of %s %s: %w", route.method, route.path, err)
+			}
+			example.Body = body
+		}
+	}
+
+	example.Query = query.Encode()
+
+	if route.responseType != downloadType && route.responseType.Kind() != reflect.Interface {
+		response, err := i.exampleJSON(route.responseType)
+		if err != nil {
+			return nil, fmt.Errorf("octanox: cannot synthesize example response of %s %s: %w", route.method, route.path, err)
+		}
+		example.Response = response
+	}
+
+	return example, nil
+}
+
+// exampleParam returns the example value of the given path or query parameter field.
+func exampleParam(bf *bindingField) (string, error) {
+	if value, ok := bf.field.Tag.Lookup("example"); ok {
+		return value, nil
+	}
+
+	if values := bf.field.Tag.Get("enum"); values != "" {
+		value, _, _ := strings.Cut(values, ",")
+		return value, nil
+	}
+
+	if bf.hasDefault {
+		return bf.def, nil
+	}
+
+	t := bf.field.Type
+	// slice query parameters are sent with a single value
+	if t.Kind() == reflect.Ptr || bf.array != "" {
+		t = t.Elem()
+	}
+
+	v, err := exampleValue(t, "", nil)
+	if err != nil {
+		return "", err
+	}
+
+	if v.Kind() == reflect.String {
+		return v.String(), nil
+	}
+
+	if marshaler, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		return string(text), err
+	}
+
+	return fmt.Sprint(v.Interface()), nil
+}
+
+// exampleJSON synthesizes an example value of the given type and encodes it as JSON.
+func (i *Instance) exampleJSON(t reflect.Type) ([]byte, error) {
+	if isProtoMessage(t) {
+		msg := reflect.New(t.Elem()).Interface().(proto.Message)
+		return i.protoMarshalOptions().Marshal(msg)
+	}
+
+	v, err := exampleValue(t, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(v.Interface())
+}
+
+// exampleValue synthesizes an example value of the given type. The tag is the tag of the field the value is for, and seen
+// are the struct types currently synthesized, so recursive types end in their zero value.
+func exampleValue(t reflect.Type, tag reflect.StructTag, seen map[reflect.Type]bool) (reflect.Value, error) {
+	if raw, ok := tag.Lookup("example"); ok {
+		return parseExample(t, raw)
+	}
+
+	if values := tag.Get("enum"); values != "" {
+		value, _, _ := strings.Cut(values, ",")
+		return parseExample(t, value)
+	}
+
+	v := reflect.New(t).Elem()
+
+	if t == reflect.TypeOf(time.Time{}) {
+		v.Set(reflect.ValueOf(exampleTime))
+		return v, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString("string")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t == reflect.TypeOf(time.Duration(0)) {
+			v.SetInt(int64(time.Second))
+		} else {
+			v.SetInt(1)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1.5)
+	case reflect.Ptr:
+		if seen[t.Elem()] {
+			return v, nil
+		}
+
+		elem, err := exampleValue(t.Elem(), "", seen)
+		if err != nil {
+			return v, err
+		}
+
+		v.Set(reflect.New(t.Elem()))
+		v.Elem().Set(elem)
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte("example"))
+			return v, nil
+		}
+
+		if seen[t.Elem()] {
+			v.Set(reflect.MakeSlice(t, 0, 0))
+			return v, nil
+		}
+
+		elem, err := exampleValue(t.Elem(), "", seen)
+		if err != nil {
+			return v, err
+		}
+
+		v.Set(reflect.Append(reflect.MakeSlice(t, 0, 1), elem))
+	case reflect.Array:
+		for n := 0; n < t.Len(); n++ {
+			elem, err := exampleValue(t.Elem(), "", seen)
+			if err != nil {
+				return v, err
+			}
+
+			v.Index(n).Set(elem)
+		}
+	case reflect.Map:
+		v.Set(reflect.MakeMap(t))
+
+		key, err := exampleValue(t.Key(), "", seen)
+		if err != nil {
+			return v, err
+		}
+
+		elem, err := exampleValue(t.Elem(), "", seen)
+		if err != nil {
+			return v, err
+		}
+
+		v.SetMapIndex(key, elem)
+	case reflect.Struct:
+		if marshalsItself(t) || seen[t] {
+			return v, nil
+		}
+
+		nested := make(map[reflect.Type]bool, len(seen)+1)
+		for s := range seen {
+			nested[s] = true
+		}
+		nested[t] = true
+
+		for _, field := range jsonFields(t) {
+			fv, err := exampleValue(field.t, field.tag, nested)
+			if err != nil {
+				return v, fmt.Errorf("field %s: %w", field.name, err)
+			}
+
+			// fields promoted from embedded pointers need the pointer allocated first, which is impossible for unexported ones
+			if target, ok := allocFieldByIndex(v, field.index); ok {
+				target.Set(fv)
+			}
+		}
+	}
+
+	return v, nil
+}
+
+// parseExample parses the example value of a field of the given type from the value of its example or enum tag.
+func parseExample(t reflect.Type, raw string) (reflect.Value, error) {
+	v := reflect.New(t)
+
+	elem := t
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+
+	if elem.Kind() == reflect.String && !marshalsItself(elem) {
+		target := v.Elem()
+		for target.Kind() == reflect.Ptr {
+			target.Set(reflect.New(target.Type().Elem()))
+			target = target.Elem()
+		}
+
+		target.SetString(raw)
+		return v.Elem(), nil
+	}
+
+	if err := json.Unmarshal([]byte(raw), v.Interface()); err != nil {
+		// values like dates are written without the quotes in the tag
+		if quoted, _ := json.Marshal(raw); json.Unmarshal(quoted, v.Interface()) != nil {
+			return v.Elem(), fmt.Errorf("invalid example %q for %s: %w", raw, t.String(), err)
+		}
+	}
+
+	return v.Elem(), nil
+}
+
+// allocFieldByIndex returns the field of the given struct value with the given index path, allocating nil embedded pointers
+// on the way. Returns false if an embedded pointer cannot be allocated because it is unexported.
+func allocFieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for n, i := range index {
+		if n > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return v, false
+				}
+
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+
+		v = v.Field(i)
+	}
+
+	return v, true
+}
+
+// findRoute returns the route with the given method and path, which is either the registered path of the route or a
+// concrete path matching it. Can be nil.
+func (i *Instance) findRoute(method, path string) *Route {
+	path = "/" + strings.Trim(path, "/")
+
+	for _, route := range i.routes {
+		if route.method == method && "/"+strings.Trim(route.path, "/") == path {
+			return route
+		}
+	}
+
+	for _, route := range i.routes {
+		if route.method == method && matchesRoutePath(route.path, path) {
+			return route
+		}
+	}
+
+	return nil
+}
+
+// matchesRoutePath checks if the given concrete path matches the given registered route path.
+func matchesRoutePath(pattern, path string) bool {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for n, segment := range patternSegments {
+		if strings.HasPrefix(segment, "*") {
+			return true
+		}
+
+		if n >= len(pathSegments) || !strings.HasPrefix(segment, ":") && segment != pathSegments[n] {
+			return false
+		}
+	}
+
+	return len(patternSegments) == len(pathSegments)
+}
+
+// nearMissRoutes returns the routes whose path is similar to the given path, as "METHOD /path", the most similar first.
+func (i *Instance) nearMissRoutes(method, path string) []string {
+	type nearMiss struct {
+		route    string
+		distance int
+	}
+
+	path = "/" + strings.Trim(path, "/")
+	threshold := max(3, len(path)/4)
+
+	misses := make([]nearMiss, 0)
+	for _, route := range i.routes {
+		distance := editDistance(alignPathParams(route.path, path), "/"+strings.Trim(route.path, "/"))
+		if route.method != method {
+			distance++
+		}
+
+		if distance <= threshold {
+			misses = append(misses, nearMiss{route: route.method + " " + route.path, distance: distance})
+		}
+	}
+
+	sort.SliceStable(misses, func(a, b int) bool {
+		return misses[a].distance < misses[b].distance
+	})
+
+	result := make([]string, 0, maxNearMisses)
+	for _, miss := range misses {
+		if len(result) == maxNearMisses {
+			break
+		}
+
+		result = append(result, miss.route)
+	}
+
+	return result
+}
+
+// alignPathParams replaces the segments of the given concrete path at the positions of the path parameters of the given route
+// path with the parameters, so concrete values do not count towards the distance of the paths.
+func alignPathParams(pattern, path string) string {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(patternSegments) != len(pathSegments) {
+		return path
+	}
+
+	for n, segment := range patternSegments {
+		if strings.HasPrefix(segment, ":") {
+			pathSegments[n] = segment
+		}
+	}
+
+	return "/" + strings.Join(pathSegments, "/")
+}
+
+// editDistance returns the Levenshtein distance between the given strings.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for n := range previous {
+		previous[n] = n
+	}
+
+	for x := 1; x <= len(a); x++ {
+		current[0] = x
+		for y := 1; y <= len(b); y++ {
+			cost := 1
+			if a[x-1] == b[y-1] {
+				cost = 0
+			}
+
+			current[y] = min(previous[y]+1, current[y-1]+1, previous[y-1]+cost)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
@@ -0,0 +1,18 @@
+package octanox
+
+import "net/http"
+
+// WithExistenceCheck serves this GET route for HEAD requests as well and generates an additional <name>Exists function in the
+// TypeScript client, which resolves to true for 200 and 304, to false for 404 and throws an ApiError for any other status.
+func (r *Route) WithExistenceCheck() *Route {
+	if r.method != http.MethodGet {
+		panic("octanox: existence checks are only supported on GET routes, got " + r.method + " " + r.path)
+	}
+
+	if !r.existenceCheck {
+		r.existenceCheck = true
+		r.group.Handle(http.MethodHead, r.relativePath, r.serve)
+	}
+
+	return r
+}
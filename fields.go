@@ -0,0 +1,372 @@
+package octanox
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/proto"
+)
+
+// fieldsQueryParam is the query parameter clients select the fields of partial responses with.
+const fieldsQueryParam = "fields"
+
+// PartialResponses lets clients select the fields of the response of this route with the "fields" query parameter, e.g.
+// ?fields=id,name,address.city, using the JSON names of the fields. The response is pruned to the selected fields during
+// serialization, so the handler does not change. Unknown fields are rejected with 400 Bad Request. For ListResult responses
+// the fields select the fields of the items. The generated TypeScript function types the parameter as the valid paths.
+func (r *Route) PartialResponses() *Route {
+	r.partial = true
+	return r
+}
+
+// jsonField is a field of a struct as encoded by encoding/json, with embedded structs flattened.
+type jsonField struct {
+	name  string
+	index []int
+	t     reflect.Type
+	tag   reflect.StructTag
+}
+
+// jsonFields returns the fields of the given struct type in the order encoding/json encodes them.
+func jsonFields(t reflect.Type) []jsonField {
+	fields := make([]jsonField, 0, t.NumField())
+	collectJSONFields(t, nil, &fields, nil)
+	return fields
+}
+
+// collectJSONFields collects the fields of the given struct type, flattening embedded structs in place. Fields of embedded
+// structs are shadowed by the fields of the structs embedding them.
+func collectJSONFields(t reflect.Type, index []int, fields *[]jsonField, shadowed map[string]bool) {
+	direct := make(map[string]bool, len(shadowed)+t.NumField())
+	for name := range shadowed {
+		direct[name] = true
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		if name, ok := jsonFieldOf(t.Field(i)); ok {
+			direct[name] = true
+		}
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Tag.Get("json") == "-" {
+			continue
+		}
+
+		name, ok := jsonFieldOf(field)
+		if !ok {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			if field.Anonymous && ft.Kind() == reflect.Struct {
+				collectJSONFields(ft, append(append([]int{}, index...), i), fields, direct)
+			}
+			continue
+		}
+
+		if shadowed[name] {
+			continue
+		}
+
+		*fields = append(*fields, jsonField{name: name, index: append(append([]int{}, index...), i), t: field.Type, tag: field.Tag})
+	}
+}
+
+// jsonFieldOf returns the JSON name of the given field, if it is encoded as a field of its own and is not an embedded struct
+// whose fields are flattened.
+func jsonFieldOf(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+
+	name, _, _ := strings.Cut(tag, ",")
+	ft := field.Type
+	if ft.Kind() == reflect.Ptr {
+		ft = ft.Elem()
+	}
+
+	if field.Anonymous && name == "" && ft.Kind() == reflect.Struct || !field.IsExported() {
+		return "", false
+	}
+
+	if name == "" {
+		name = field.Name
+	}
+
+	return name, true
+}
+
+// fieldTree is a tree of selected field paths. A field without children selects the whole field.
+type fieldTree map[string]fieldTree
+
+// parseFieldTree parses the comma separated dotted field paths of the fields query parameter.
+func parseFieldTree(fields string) fieldTree {
+	tree := make(fieldTree)
+
+	for _, path := range strings.Split(fields, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		node := tree
+		parts := strings.Split(path, ".")
+		for n, part := range parts {
+			child, exists := node[part]
+			if exists && child == nil {
+				// the whole field is already selected
+				break
+			}
+
+			if n == len(parts)-1 {
+				node[part] = nil
+				break
+			}
+
+			if !exists {
+				child = make(fieldTree)
+				node[part] = child
+			}
+			node = child
+		}
+	}
+
+	return tree
+}
+
+// projection prunes values of a type to the selected fields.
+type projection struct {
+	t       reflect.Type
+	project func(v reflect.Value) reflect.Value
+}
+
+var identityProjection = func(v reflect.Value) reflect.Value { return v }
+
+type projectionKey struct {
+	t      reflect.Type
+	fields string
+}
+
+type projectionResult struct {
+	projection *projection
+	err        error
+}
+
+// maxCachedProjections is the number of compiled projections above which new ones are not cached anymore, as clients
+// choose the field selections.
+const maxCachedProjections = 4096
+
+var (
+	// projections caches the compiled projections by type and normalized field selection.
+	projections       sync.Map
+	cachedProjections atomic.Int64
+)
+
+// compileFields returns the projection of the given type to the given fields query parameter, compiling it on first use.
+// For ListResult types the fields select the fields of the items.
+func compileFields(t reflect.Type, fields string) (*projection, error) {
+	tree := parseFieldTree(fields)
+	key := projectionKey{t: t, fields: tree.String()}
+	if cached, ok := projections.Load(key); ok {
+		result := cached.(projectionResult)
+		return result.projection, result.err
+	}
+
+	if isListResultType(t) {
+		tree = fieldTree{"items": tree, "total": nil, "next_cursor": nil}
+	}
+
+	p, err := compileProjection(t, tree, "")
+	if cachedProjections.Load() < maxCachedProjections {
+		if _, loaded := projections.LoadOrStore(key, projectionResult{projection: p, err: err}); !loaded {
+			cachedProjections.Add(1)
+		}
+	}
+
+	return p, err
+}
+
+// compileProjection compiles the projection of the given type to the given tree. The prefix is the path of the type, used in errors.
+func compileProjection(t reflect.Type, tree fieldTree, prefix string) (*projection, error) {
+	if len(tree) == 0 {
+		return &projection{t: t, project: identityProjection}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem, err := compileProjection(t.Elem(), tree, prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		pt := reflect.PointerTo(elem.t)
+		return &projection{t: pt, project: func(v reflect.Value) reflect.Value {
+			if v.IsNil() {
+				return reflect.Zero(pt)
+			}
+
+			out := reflect.New(elem.t)
+			out.Elem().Set(elem.project(v.Elem()))
+			return out
+		}}, nil
+	case reflect.Slice, reflect.Array:
+		elem, err := compileProjection(t.Elem(), tree, prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		st := reflect.SliceOf(elem.t)
+		return &projection{t: st, project: func(v reflect.Value) reflect.Value {
+			if v.Kind() == reflect.Slice && v.IsNil() {
+				return reflect.Zero(st)
+			}
+
+			out := reflect.MakeSlice(st, v.Len(), v.Len())
+			for n := 0; n < v.Len(); n++ {
+				out.Index(n).Set(elem.project(v.Index(n)))
+			}
+			return out
+		}}, nil
+	case reflect.Struct:
+		if !marshalsItself(t) {
+			return compileStructProjection(t, tree, prefix)
+		}
+	}
+
+	for name := range tree {
+		return nil, errors.New("unknown field " + prefix + name)
+	}
+
+	return nil, nil
+}
+
+// compileStructProjection compiles the projection of the given struct type into a struct type with only the selected fields.
+func compileStructProjection(t reflect.Type, tree fieldTree, prefix string) (*projection, error) {
+	available := jsonFields(t)
+	selected := make([]jsonField, 0, len(tree))
+	children := make([]*projection, 0, len(tree))
+	structFields := make([]reflect.StructField, 0, len(tree))
+
+	for name := range tree {
+		if !containsJSONField(available, name) {
+			return nil, errors.New("unknown field " + prefix + name)
+		}
+	}
+
+	for _, field := range available {
+		subtree, ok := tree[field.name]
+		if !ok {
+			continue
+		}
+
+		child, err := compileProjection(field.t, subtree, prefix+field.name+".")
+		if err != nil {
+			return nil, err
+		}
+
+		selected = append(selected, field)
+		children = append(children, child)
+		structFields = append(structFields, reflect.StructField{
+			Name: "F" + strconv.Itoa(len(structFields)),
+			Type: child.t,
+			Tag:  field.tag,
+		})
+	}
+
+	st := reflect.StructOf(structFields)
+	return &projection{t: st, project: func(v reflect.Value) reflect.Value {
+		out := reflect.New(st).Elem()
+		for n, field := range selected {
+			// fields of nil embedded structs are left zero, just as encoding/json omits them
+			if fv, err := v.FieldByIndexErr(field.index); err == nil {
+				out.Field(n).Set(children[n].project(fv))
+			}
+		}
+		return out
+	}}, nil
+}
+
+// marshalsItself checks if values of the given type are encoded by their own MarshalJSON or MarshalText method, so their
+// fields cannot be selected.
+func marshalsItself(t reflect.Type) bool {
+	pt := reflect.PointerTo(t)
+	return t.Implements(jsonMarshalerType) || pt.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) || pt.Implements(textMarshalerType)
+}
+
+func containsJSONField(fields []jsonField, name string) bool {
+	for _, field := range fields {
+		if field.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String returns the normalized selection of the tree, with the paths sorted.
+func (t fieldTree) String() string {
+	paths := make([]string, 0, len(t))
+	for name, child := range t {
+		if child == nil {
+			paths = append(paths, name)
+			continue
+		}
+
+		for _, path := range strings.Split(child.String(), ",") {
+			paths = append(paths, name+"."+path)
+		}
+	}
+
+	sort.Strings(paths)
+	return strings.Join(paths, ",")
+}
+
+// bindFields validates the fields query parameter of a route with partial responses against its response type. Returns an
+// empty string if the client did not select fields. Unknown fields fail the request with 400 Bad Request.
+func bindFields(c *gin.Context, rt *Route) string {
+	fields := c.Query(fieldsQueryParam)
+	if strings.TrimSpace(fields) == "" {
+		return ""
+	}
+
+	if _, err := compileFields(rt.responseType, fields); err != nil {
+		panic(failedRequest{
+			status:  http.StatusBadRequest,
+			message: "Invalid query parameter: " + fieldsQueryParam + ": " + err.Error(),
+			code:    ErrorCodeInvalidParameter,
+			errors:  []ProblemFieldError{{Source: "query", Name: fieldsQueryParam, Detail: err.Error()}},
+		})
+	}
+
+	return fields
+}
+
+// projectFields prunes the given serialized response to the given fields. Responses of a type the fields do not apply to,
+// e.g. because a serializer changed it, are returned as is.
+func projectFields(out any, fields string) any {
+	if out == nil {
+		return nil
+	}
+
+	if _, ok := out.(proto.Message); ok {
+		return out
+	}
+
+	p, err := compileFields(reflect.TypeOf(out), fields)
+	if err != nil {
+		return out
+	}
+
+	return p.project(reflect.ValueOf(out)).Interface()
+}
@@ -0,0 +1,682 @@
+package octanox
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	defaultFuzzIterations      = 16
+	defaultFuzzMaxStringLength = 1024
+	defaultFuzzMaxDepth        = 8
+	// fuzzReportedBytes is the number of bytes of the request and response bodies quoted in a FuzzFailure.
+	fuzzReportedBytes = 512
+)
+
+var (
+	// fuzzTextRunes are the runes of generated body and query strings, including characters that need escaping.
+	fuzzTextRunes = []rune("aZ09 _-.\"\\/'<>&%+?#{}[]\t\n\r\u0000é中\u200b\ufeff\U0001F600")
+	// fuzzHeaderRunes are the runes of generated header values, which are restricted to printable ASCII.
+	fuzzHeaderRunes = []rune(" !#$%&'*+-.0123456789:;<=>?@ABCXYZ[]^_`abcxyz{|}~\"\\")
+	// fuzzPathRunes are the runes of generated path parameters, which neither contain slashes nor dot segments.
+	fuzzPathRunes = []rune("aZ09-_~é中\U0001F600")
+	// fuzzTimes are the generated times, the extremes JSON can represent.
+	fuzzTimes = []time.Time{{}, time.Unix(0, 0).UTC(), time.Date(9999, 12, 31, 23, 59, 59, 999999999, time.UTC)}
+)
+
+// FuzzOptions is a struct that configures the inputs generated by Instance.FuzzCases.
+type FuzzOptions struct {
+	// Seed seeds the generated inputs. The same seed generates the same cases for the same routes, so failures can be reproduced.
+	Seed int64
+	// Iterations is the number of valid but extreme requests generated per route. Defaults to 16.
+	Iterations int
+	// MaxStringLength is the length of the longest generated strings. Defaults to 1024.
+	MaxStringLength int
+	// MaxDepth is the depth up to which optional nested structs, slices and maps are populated. Defaults to 8.
+	MaxDepth int
+	// Routes restricts the fuzzed routes to the given routes in the form "GET /users/:id". Empty fuzzes all routes.
+	Routes []string
+	// Header is added to every request, e.g. the Authorization header of a test user, so the inputs of authenticated routes
+	// get past the authenticator.
+	Header http.Header
+}
+
+// FuzzCase is a struct that describes a single generated request. Cases are plain values, so they can be stored and replayed.
+type FuzzCase struct {
+	// Route is the fuzzed route in the form "GET /users/:id".
+	Route string
+	// Name describes the input, e.g. "wrong type query limit".
+	Name string
+	// Malformed is a flag that indicates whether the input is invalid, in which case it has to be rejected with 4xx.
+	Malformed bool
+	// Seed is the seed the case was generated with.
+	Seed   int64
+	Method string
+	// Path is the requested path, with the path parameters filled in.
+	Path   string
+	Query  string
+	Header http.Header
+	Body   []byte
+}
+
+// Request returns a new request of the case.
+func (c FuzzCase) Request() *http.Request {
+	req := httptest.NewRequest(c.Method, "/", bytes.NewReader(c.Body))
+
+	// the query is set as is, as fuzzed queries do not have to be valid
+	target, err := url.Parse(c.Path)
+	if err != nil {
+		target = &url.URL{Path: c.Path}
+	}
+	target.RawQuery = c.Query
+	req.URL, req.RequestURI = target, target.RequestURI()
+
+	for name, values := range c.Header {
+		req.Header[name] = append([]string{}, values...)
+	}
+
+	return req
+}
+
+// FuzzFailure is an error describing a case that was answered with 5xx, or a malformed case that was not rejected with 4xx.
+type FuzzFailure struct {
+	Case     FuzzCase
+	Status   int
+	Response []byte
+}
+
+func (f *FuzzFailure) Error() string {
+	problem := "server error"
+	if f.Status < http.StatusInternalServerError {
+		problem = "malformed input not rejected"
+	}
+
+	target := f.Case.Path
+	if f.Case.Query != "" {
+		target += "?" + f.Case.Query
+	}
+
+	message := fmt.Sprintf("%s: %s: %s with status %d (seed %d)\n  request: %s %s", f.Case.Route, f.Case.Name, problem, f.Status, f.Case.Seed, f.Case.Method, target)
+	for name, values := range f.Case.Header {
+		message += "\n  " + name + ": " + strings.Join(values, ", ")
+	}
+	if len(f.Case.Body) > 0 {
+		message += fmt.Sprintf("\n  body: %q", truncateFuzzBytes(f.Case.Body))
+	}
+	if len(f.Response) > 0 {
+		message += fmt.Sprintf("\n  response: %q", truncateFuzzBytes(f.Response))
+	}
+
+	return message
+}
+
+// FuzzCases generates the fuzzing inputs of the registered routes from their binding plans: the synthesized example request,
+// valid but extreme requests with max-length strings, boundary numbers and fully populated optional structs, and malformed
+// requests with parameters and body fields of the wrong type, truncated JSON, invalid UTF-8 and oversized bodies. Versioned
+// routes are fuzzed per variant.
+func (i *Instance) FuzzCases(opts FuzzOptions) ([]FuzzCase, error) {
+	if opts.Iterations <= 0 {
+		opts.Iterations = defaultFuzzIterations
+	}
+	if opts.MaxStringLength <= 0 {
+		opts.MaxStringLength = defaultFuzzMaxStringLength
+	}
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = defaultFuzzMaxDepth
+	}
+
+	maxBodySize := i.decompression.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = defaultMaxDecompressedBodySize
+	}
+	oversized := oversizedFuzzBody(maxBodySize)
+
+	cases := make([]FuzzCase, 0)
+	for _, route := range i.routes {
+		if len(opts.Routes) > 0 && !containsString(opts.Routes, route.method+" "+route.path) {
+			continue
+		}
+
+		if len(route.variants) == 0 {
+			routeCases, err := i.fuzzRoute(route, "", opts, oversized)
+			if err != nil {
+				return nil, err
+			}
+			cases = append(cases, routeCases...)
+			continue
+		}
+
+		// the route itself only serves the declared versions before its first variant
+		targets := map[string]*Route{}
+		if len(i.apiVersions) > 0 && i.apiVersions[0] < route.variants[0].version {
+			targets[i.apiVersions[0]] = route
+		}
+		for _, variant := range route.variants {
+			targets[variant.version] = variant
+		}
+
+		for _, version := range sortedVersions(targets) {
+			routeCases, err := i.fuzzRoute(targets[version], version, opts, oversized)
+			if err != nil {
+				return nil, err
+			}
+			cases = append(cases, routeCases...)
+		}
+	}
+
+	return cases, nil
+}
+
+// RunFuzzCase sends the given case to the instance and returns the failure if it was answered with 5xx, or if it is malformed
+// and was not rejected with 4xx. Returns nil if the case was handled correctly.
+func (i *Instance) RunFuzzCase(c FuzzCase) *FuzzFailure {
+	recorder := httptest.NewRecorder()
+	i.Handler().ServeHTTP(recorder, c.Request())
+
+	status := recorder.Code
+	if status >= http.StatusInternalServerError || (c.Malformed && (status < http.StatusBadRequest || status >= http.StatusInternalServerError)) {
+		return &FuzzFailure{Case: c, Status: status, Response: recorder.Body.Bytes()}
+	}
+
+	return nil
+}
+
+// Fuzz generates the fuzzing inputs of the registered routes, sends them to the instance and returns every failure.
+func (i *Instance) Fuzz(opts FuzzOptions) ([]FuzzFailure, error) {
+	cases, err := i.FuzzCases(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	failures := make([]FuzzFailure, 0)
+	for _, c := range cases {
+		if failure := i.RunFuzzCase(c); failure != nil {
+			failures = append(failures, *failure)
+		}
+	}
+
+	return failures, nil
+}
+
+// fuzzRoute generates the cases of the given route, sent with the given version if it is a variant.
+func (i *Instance) fuzzRoute(route *Route, version string, opts FuzzOptions, oversized []byte) ([]FuzzCase, error) {
+	name := route.method + " " + route.path
+	hash := fnv.New64a()
+	hash.Write([]byte(name + " " + version))
+	fuzzer := &fuzzer{rng: rand.New(rand.NewSource(opts.Seed ^ int64(hash.Sum64()))), opts: opts}
+
+	plan := route.plan
+	bodyField := plan.bodyField()
+	cases := make([]FuzzCase, 0)
+
+	newCase := func(caseName string, malformed bool, params map[int]string, body []byte) FuzzCase {
+		if version != "" {
+			caseName += " (version " + version + ")"
+		}
+
+		c := FuzzCase{Route: name, Name: caseName, Malformed: malformed, Seed: opts.Seed, Method: route.method, Path: route.path, Header: http.Header{}, Body: body}
+		for key, values := range opts.Header {
+			c.Header[key] = append([]string{}, values...)
+		}
+		if version != "" {
+			c.Header.Set(acceptVersionHeader, version)
+		}
+		if body != nil {
+			c.Header.Set("Content-Type", "application/json")
+		}
+
+		query := url.Values{}
+		for n, value := range params {
+			bf := &plan.fields[n]
+			switch bf.source {
+			case sourcePath:
+				c.Path = strings.Replace(c.Path, ":"+bf.name, url.PathEscape(value), 1)
+				c.Path = strings.Replace(c.Path, "*"+bf.name, url.PathEscape(value), 1)
+			case sourceQuery:
+				query.Set(bf.name, value)
+			case sourceHeader:
+				c.Header.Set(bf.name, value)
+			}
+		}
+		c.Query = query.Encode()
+
+		return c
+	}
+
+	// the example request is the valid baseline the malformed requests change a single part of
+	example := map[int]string{}
+	params := make([]int, 0)
+	for n := range plan.fields {
+		bf := &plan.fields[n]
+		if bf.source != sourcePath && bf.source != sourceQuery && bf.source != sourceHeader {
+			continue
+		}
+		params = append(params, n)
+
+		value, err := exampleParam(bf)
+		if err != nil {
+			return nil, fmt.Errorf("octanox: cannot synthesize example %s parameter %s of %s: %w", bf.sourceName(), bf.name, name, err)
+		}
+		example[n] = value
+	}
+
+	var exampleBody []byte
+	switch {
+	case bodyField != nil:
+		body, err := i.exampleJSON(bodyField.field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("octanox: cannot synthesize example body of %s: %w", name, err)
+		}
+		exampleBody = body
+	case plan.rawBodyField() != nil:
+		exampleBody = []byte("{}")
+	}
+
+	cases = append(cases, newCase("example", false, example, exampleBody))
+
+	for n := 1; n <= opts.Iterations; n++ {
+		values := map[int]string{}
+		for _, index := range params {
+			bf := &plan.fields[index]
+			if bf.source == sourcePath || bf.required || fuzzer.rng.Intn(2) == 0 {
+				values[index] = fuzzer.param(bf)
+			}
+		}
+
+		var body []byte
+		switch {
+		case bodyField != nil && isProtoMessage(bodyField.field.Type):
+			body = exampleBody
+		case bodyField != nil:
+			encoded, err := json.Marshal(fuzzer.value(bodyField.field.Type, "", 0).Interface())
+			if err != nil {
+				return nil, fmt.Errorf("octanox: cannot encode fuzzed body of %s: %w", name, err)
+			}
+			body = encoded
+		case plan.rawBodyField() != nil:
+			body = fuzzer.bytes()
+		}
+
+		cases = append(cases, newCase("extreme #"+strconv.Itoa(n), false, values, body))
+	}
+
+	for _, index := range params {
+		bf := &plan.fields[index]
+		t := bf.field.Type
+		if t.Kind() == reflect.Ptr || bf.array != "" {
+			t = t.Elem()
+		}
+
+		if t.Kind() == reflect.String {
+			continue
+		}
+
+		invalid := [][2]string{{"wrong type", "not-a-" + t.Kind().String()}}
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			invalid = append(invalid, [2]string{"overflowing", "-999999999999999999999999"})
+		}
+
+		for _, kind := range invalid {
+			values := copyFuzzParams(example)
+			values[index] = kind[1]
+			cases = append(cases, newCase(kind[0]+" "+bf.sourceName()+" "+bf.name, true, values, exampleBody))
+		}
+	}
+
+	if bodyField != nil && !isProtoMessage(bodyField.field.Type) {
+		var tree any
+		if err := json.Unmarshal(exampleBody, &tree); err != nil {
+			return nil, fmt.Errorf("octanox: cannot decode example body of %s: %w", name, err)
+		}
+
+		for _, mutation := range fuzzMutations(bodyField.field.Type, tree, nil) {
+			// the tree is decoded again, as every mutation changes it in place
+			var mutated any
+			json.Unmarshal(exampleBody, &mutated)
+
+			body, err := json.Marshal(setFuzzPath(mutated, mutation.path, mutation.value))
+			if err != nil {
+				return nil, fmt.Errorf("octanox: cannot encode fuzzed body of %s: %w", name, err)
+			}
+
+			cases = append(cases, newCase("wrong type "+strings.Join(append([]string{"body"}, mutation.path...), "."), true, example, body))
+		}
+
+		if truncated := exampleBody[:len(exampleBody)/2]; !json.Valid(truncated) {
+			cases = append(cases, newCase("truncated body", true, example, truncated))
+		}
+
+		invalidUTF8 := append([]byte{}, exampleBody...)
+		if quote := bytes.IndexByte(invalidUTF8, '"'); quote >= 0 {
+			invalidUTF8 = append(invalidUTF8[:quote+1], append([]byte{0xff, 0xfe}, invalidUTF8[quote+1:]...)...)
+		} else {
+			invalidUTF8 = append([]byte{0xff, 0xfe}, invalidUTF8...)
+		}
+		cases = append(cases, newCase("invalid UTF-8 body", true, example, invalidUTF8))
+	}
+
+	if exampleBody != nil {
+		c := newCase("oversized body", true, example, oversized)
+		c.Header.Set("Content-Encoding", "gzip")
+		cases = append(cases, c)
+	}
+
+	return cases, nil
+}
+
+// fuzzer generates random extreme values.
+type fuzzer struct {
+	rng  *rand.Rand
+	opts FuzzOptions
+}
+
+// param generates the raw value of the given path, query or header parameter.
+func (f *fuzzer) param(bf *bindingField) string {
+	t := bf.field.Type
+	// slice query parameters are sent with a single value
+	if t.Kind() == reflect.Ptr || bf.array != "" {
+		t = t.Elem()
+	}
+
+	if values := bf.field.Tag.Get("enum"); values != "" {
+		options := strings.Split(values, ",")
+		return options[f.rng.Intn(len(options))]
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		switch bf.source {
+		case sourcePath:
+			return f.string(fuzzPathRunes, 1)
+		case sourceHeader:
+			return f.string(fuzzHeaderRunes, 1)
+		}
+
+		return f.string(fuzzTextRunes, 0)
+	case reflect.Bool:
+		return strconv.FormatBool(f.rng.Intn(2) == 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(f.int(t.Bits()), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(f.uint(t.Bits()), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(f.float(t.Bits()), 'g', -1, t.Bits())
+	}
+
+	return ""
+}
+
+// value generates an extreme value of the given type. The tag is the tag of the field the value is for, and depth is the
+// number of pointers, slices and maps the value is nested in.
+func (f *fuzzer) value(t reflect.Type, tag reflect.StructTag, depth int) reflect.Value {
+	if values := tag.Get("enum"); values != "" {
+		options := strings.Split(values, ",")
+		if v, err := parseExample(t, options[f.rng.Intn(len(options))]); err == nil {
+			return v
+		}
+	}
+
+	v := reflect.New(t).Elem()
+
+	if t == reflect.TypeOf(time.Time{}) {
+		v.Set(reflect.ValueOf(fuzzTimes[f.rng.Intn(len(fuzzTimes))]))
+		return v
+	}
+
+	if t.Kind() != reflect.Ptr && marshalsItself(t) {
+		return v
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(f.string(fuzzTextRunes, 0))
+	case reflect.Bool:
+		v.SetBool(f.rng.Intn(2) == 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(f.int(t.Bits()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(f.uint(t.Bits()))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(f.float(t.Bits()))
+	case reflect.Ptr:
+		if depth < f.opts.MaxDepth {
+			v.Set(reflect.New(t.Elem()))
+			v.Elem().Set(f.value(t.Elem(), tag, depth+1))
+		}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			v.SetBytes(f.bytes())
+		} else if depth < f.opts.MaxDepth {
+			n := f.rng.Intn(3)
+			v.Set(reflect.MakeSlice(t, n, n))
+			for k := 0; k < n; k++ {
+				v.Index(k).Set(f.value(t.Elem(), "", depth+1))
+			}
+		}
+	case reflect.Array:
+		for k := 0; k < t.Len(); k++ {
+			v.Index(k).Set(f.value(t.Elem(), "", depth+1))
+		}
+	case reflect.Map:
+		if depth < f.opts.MaxDepth {
+			v.Set(reflect.MakeMap(t))
+			for k := f.rng.Intn(3); k > 0; k-- {
+				v.SetMapIndex(f.value(t.Key(), "", depth+1), f.value(t.Elem(), "", depth+1))
+			}
+		}
+	case reflect.Struct:
+		for _, field := range jsonFields(t) {
+			if target, ok := allocFieldByIndex(v, field.index); ok {
+				target.Set(f.value(field.t, field.tag, depth))
+			}
+		}
+	}
+
+	return v
+}
+
+// string generates an empty, a single rune, a max-length or a random length string of the given runes.
+func (f *fuzzer) string(runes []rune, minLength int) string {
+	lengths := []int{0, 1, f.opts.MaxStringLength, f.rng.Intn(f.opts.MaxStringLength) + 1}
+	length := lengths[f.rng.Intn(len(lengths))]
+	if length < minLength {
+		length = minLength
+	}
+
+	var b strings.Builder
+	for n := 0; n < length; n++ {
+		b.WriteRune(runes[f.rng.Intn(len(runes))])
+	}
+
+	return b.String()
+}
+
+// bytes generates random bytes of an empty, a single byte, a max-length or a random length.
+func (f *fuzzer) bytes() []byte {
+	lengths := []int{0, 1, f.opts.MaxStringLength, f.rng.Intn(f.opts.MaxStringLength) + 1}
+	data := make([]byte, lengths[f.rng.Intn(len(lengths))])
+	f.rng.Read(data)
+	return data
+}
+
+// int generates a boundary value of a signed integer with the given number of bits.
+func (f *fuzzer) int(bits int) int64 {
+	values := []int64{0, 1, -1, math.MaxInt64 >> (64 - bits), math.MinInt64 >> (64 - bits)}
+	return values[f.rng.Intn(len(values))]
+}
+
+// uint generates a boundary value of an unsigned integer with the given number of bits.
+func (f *fuzzer) uint(bits int) uint64 {
+	values := []uint64{0, 1, math.MaxUint64 >> (64 - bits)}
+	return values[f.rng.Intn(len(values))]
+}
+
+// float generates a boundary value of a float with the given number of bits.
+func (f *fuzzer) float(bits int) float64 {
+	values := []float64{0, -1.5, math.MaxFloat64, -math.MaxFloat64, math.SmallestNonzeroFloat64}
+	if bits == 32 {
+		values = []float64{0, -1.5, math.MaxFloat32, -math.MaxFloat32, math.SmallestNonzeroFloat32}
+	}
+
+	return values[f.rng.Intn(len(values))]
+}
+
+// fuzzMutation replaces the JSON value at the given path of object keys and array indices.
+type fuzzMutation struct {
+	path  []string
+	value any
+}
+
+// fuzzMutations returns the mutations replacing the given decoded JSON value of the given type and each of its nested values
+// by a value of the wrong type.
+func fuzzMutations(t reflect.Type, node any, path []string) []fuzzMutation {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	mutations := make([]fuzzMutation, 0)
+	if value, ok := wrongJSONValue(t); ok {
+		mutations = append(mutations, fuzzMutation{path: path, value: value})
+	}
+
+	if marshalsItself(t) {
+		return mutations
+	}
+
+	nested := func(t reflect.Type, node any, key string) {
+		mutations = append(mutations, fuzzMutations(t, node, append(append([]string{}, path...), key))...)
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, _ := node.(map[string]any)
+		for _, field := range jsonFields(t) {
+			if child, ok := object[field.name]; ok {
+				nested(field.t, child, field.name)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if array, ok := node.([]any); ok && len(array) > 0 {
+			nested(t.Elem(), array[0], "0")
+		}
+	case reflect.Map:
+		if object, ok := node.(map[string]any); ok && len(object) > 0 {
+			key := sortedKeys(object)[0]
+			nested(t.Elem(), object[key], key)
+		}
+	}
+
+	return mutations
+}
+
+// wrongJSONValue returns a JSON value that cannot be decoded into the given type, or false if every value can be.
+func wrongJSONValue(t reflect.Type) (any, bool) {
+	if marshalsItself(t) {
+		return nil, false
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return 42, true
+	case reflect.Bool:
+		return "true", true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return "42", true
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return 42, true
+		}
+		return "not an array", true
+	case reflect.Array:
+		return "not an array", true
+	case reflect.Map, reflect.Struct:
+		return []any{}, true
+	}
+
+	return nil, false
+}
+
+// setFuzzPath replaces the value at the given path of the given decoded JSON value and returns the changed value.
+func setFuzzPath(node any, path []string, value any) any {
+	if len(path) == 0 {
+		return value
+	}
+
+	switch n := node.(type) {
+	case map[string]any:
+		n[path[0]] = setFuzzPath(n[path[0]], path[1:], value)
+	case []any:
+		if index, err := strconv.Atoi(path[0]); err == nil && index < len(n) {
+			n[index] = setFuzzPath(n[index], path[1:], value)
+		}
+	}
+
+	return node
+}
+
+// oversizedFuzzBody returns a gzip compressed JSON body inflating beyond the given size.
+func oversizedFuzzBody(size int64) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+
+	w.Write([]byte(`{"padding":"`))
+	io.CopyN(w, fuzzPadding{}, size+1)
+	w.Write([]byte(`"}`))
+	w.Close()
+
+	return buf.Bytes()
+}
+
+// fuzzPadding is an endless reader of the letter a.
+type fuzzPadding struct{}
+
+func (fuzzPadding) Read(p []byte) (int, error) {
+	for n := range p {
+		p[n] = 'a'
+	}
+
+	return len(p), nil
+}
+
+func copyFuzzParams(params map[int]string) map[int]string {
+	result := make(map[int]string, len(params))
+	for index, value := range params {
+		result[index] = value
+	}
+
+	return result
+}
+
+func sortedVersions(targets map[string]*Route) []string {
+	versions := make([]string, 0, len(targets))
+	for version := range targets {
+		versions = append(versions, version)
+	}
+
+	sort.Strings(versions)
+	return versions
+}
+
+func truncateFuzzBytes(data []byte) []byte {
+	if len(data) > fuzzReportedBytes {
+		return data[:fuzzReportedBytes]
+	}
+
+	return data
+}
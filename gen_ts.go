@@ -1,16 +1,51 @@
 package octanox
 
 import (
-	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"reflect"
 	"strings"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 type tsCodeBuilder struct {
-	sb  strings.Builder
-	ind int
+	sb             strings.Builder
+	ind            int
+	opts           TypeScriptGenerationOptions
+	proto          ProtoJSONOptions
+	protoSeen      map[protoreflect.FullName]bool
+	interfaces     map[string]bool
+	warnings       []string
+	tenant         TenantExtractor
+	nilCollections NilCollectionPolicy
+	enums          map[reflect.Type][]string
+	// dictionaries is a flag that indicates whether the server advertises compression dictionaries.
+	dictionaries bool
+	// hiddenFields is the policy of how response fields hidden by their visible tag are encoded.
+	hiddenFields HiddenFieldPolicy
+	// nullableSources are the fields declared as backed by nullable sources, by their struct type.
+	nullableSources map[reflect.Type]map[string]bool
+	// strictNullability is a flag that indicates whether fields backed by nullable sources must not be typed as non-nullable.
+	strictNullability bool
+	// cookieAuth is a flag that indicates whether the requests are authenticated by a cookie, so they are sent with credentials.
+	cookieAuth bool
+	// forms is a flag that indicates whether any route sends a form.
+	forms bool
+	// oauth2 is a flag that indicates whether the server authenticates with OAuth2 bearer tokens.
+	oauth2 bool
+	// refreshesTokens is a flag that indicates whether the client refreshes the OAuth2 bearer token of rejected requests.
+	refreshesTokens bool
+	// typeDescriptions are the descriptions of the named types, emitted as JSDoc of their declarations.
+	typeDescriptions map[reflect.Type]string
+	// authenticator is the Authenticator of the instance, which can be nil.
+	authenticator Authenticator
+	// authenticatorFor returns the authenticator of a route.
+	authenticatorFor func(route *Route) Authenticator
+	// routeAuthenticators are the authenticators of the routes with another scheme than the Authenticator of the instance.
+	routeAuthenticators []Authenticator
 }
 
 func (b *tsCodeBuilder) write(s string) {
@@ -23,6 +58,12 @@ func (b *tsCodeBuilder) writeLine(s string) {
 	b.write("\n")
 }
 
+// writeIndented writes the given string after the indentation, without ending the line.
+func (b *tsCodeBuilder) writeIndented(s string) {
+	b.write(strings.Repeat(" ", b.ind))
+	b.write(s)
+}
+
 func (b *tsCodeBuilder) writeLineNoIdent(s string) {
 	b.write(s)
 	b.write("\n")
@@ -42,177 +83,775 @@ func (b *tsCodeBuilder) unindent() {
 	b.ind -= 2
 }
 
-func (i *Instance) generateTypeScriptClientCode(path string, routes []route) {
+// typeScriptClientCode returns the TypeScript client code of the given routes. Versioned routes are generated as their newest
+// variant, or as the variant of the version pinned with NOX__CLIENT_VERSION.
+func (i *Instance) typeScriptClientCode(routes []*Route) string {
+	routes = clientRoutes(routes, os.Getenv("NOX__CLIENT_VERSION"))
+	checkFunctionNames(routes)
+
 	builder := tsCodeBuilder{
-		ind: 0,
-		sb:  strings.Builder{},
+		ind:            0,
+		sb:             strings.Builder{},
+		opts:           i.tsGenOptions,
+		proto:          i.protoJSON,
+		protoSeen:      make(map[protoreflect.FullName]bool),
+		interfaces:     make(map[string]bool),
+		tenant:         i.clientTenantExtractor(),
+		nilCollections: i.collections.policy,
+		enums:          i.enums,
+		dictionaries:   i.dictionaries != nil,
+		hiddenFields:   i.hiddenFields,
+
+		nullableSources:   i.nullableSources,
+		typeDescriptions:  i.typeDescriptions,
+		strictNullability: i.strictContracts && !i.contractAllowlist[ContractNullabilityMismatch],
+
+		authenticator:       i.Authenticator,
+		authenticatorFor:    i.authenticatorFor,
+		routeAuthenticators: i.routeAuthenticatorsOf(routes),
+		forms:               usesForms(routes),
 	}
+	builder.cookieAuth = builder.usesAuthenticationMethod(AuthenticationMethodCookie)
+	builder.oauth2 = builder.usesAuthenticationMethod(AuthenticationMethodBearerOAuth2)
+	// the class is authenticated by its AuthProvider, which refreshes the token itself
+	builder.refreshesTokens = builder.oauth2 && !builder.classStyle()
 
 	builder.writeLines(
 		"// This file is generated by Octanox. Do not edit this file manually.",
 		"//",
 		"// This file contains the TypeScript client code for the Octanox server.",
+		"// It has no side effects on import, so bundlers can tree-shake every unused export.",
 		"",
-		"let baseUrl = window.location.origin",
-		"let unauthorizedHandler: () => void",
+	)
+
+	if builder.opts.MessagePack {
+		builder.writeLines(
+			"import { encode, decode } from '@msgpack/msgpack'",
+			"",
+		)
+	}
+
+	// the class is authenticated by its AuthProvider instead of the Basic credentials provider
+	basicAuth := builder.usesAuthenticationMethod(AuthenticationMethodBasic) && !builder.classStyle()
+
+	// the client state lives in a lazily created singleton, so importing the module does not touch window or any mutable state
+	builder.writeLines(
+		"interface ClientRuntime {",
+		"  baseUrl: string",
+		"  unauthorizedHandler?: () => void",
+	)
+	if basicAuth {
+		builder.writeLine("  basicCredentials?: string")
+	}
+	builder.generateTenantRuntimeFields()
+	builder.generateDictionaryRuntimeField()
+	builder.generateInterceptorRuntimeFields()
+	builder.generateTokenRefreshRuntimeFields()
+	builder.writeLines(
+		"}",
 		"",
-		"export function setBaseUrl(url: string) {",
-		"  baseUrl = url",
+	)
+
+	// the class keeps its own runtime, so several clients can be used side by side
+	if !builder.classStyle() {
+		builder.writeLines(
+			"let clientRuntime: ClientRuntime | undefined",
+			"",
+			"function runtime(): ClientRuntime {",
+			"  if (!clientRuntime) {",
+			"    clientRuntime = { baseUrl: window.location.origin"+builder.interceptorRuntimeInit()+" }",
+			"  }",
+			"  return clientRuntime",
+			"}",
+			"",
+			"export function setBaseUrl(url: string) {",
+			"  runtime().baseUrl = url",
+			"}",
+			"",
+			"export function setUnauthorizedHandler(handler: () => void) {",
+			"  runtime().unauthorizedHandler = handler",
+			"}",
+			"",
+		)
+	}
+
+	builder.generateInterceptorDeclarations()
+
+	builder.writeLines(
+		"export interface ProblemFieldError {",
+		"  source: string",
+		"  name?: string",
+		"  detail: string",
 		"}",
 		"",
-		"export function setUnauthorizedHandler(handler: () => void) {",
-		"  unauthorizedHandler = handler",
+		"export interface QuotaExceeded {",
+		"  name: string",
+		"  limit: number",
+		"  used: number",
+		"  reset: string",
 		"}",
 		"",
-		"function getBaseConfig(): RequestInit {",
+		"export interface Gone {",
+		"  resource: string",
+		"  deletedAt: string",
+		"}",
+		"",
+		"export interface Problem {",
+		"  type: string",
+		"  title: string",
+		"  status: number",
+		"  detail?: string",
+		"  instance?: string",
+		"  code?: string",
+		"  errors?: ProblemFieldError[]",
+		"  quota?: QuotaExceeded",
+		"  gone?: Gone",
+		"  retryable?: boolean",
+		"  retryAfterMs?: number",
+		"}",
+		"",
+	)
+
+	// the body is the parsed JSON of the error response, typed as the declared error response type
+	if usesErrorTypes(routes) {
+		// routes declaring their own error type reject with an ApiError of their body
+		builder.write("export class ApiError<B = ")
+		if i.ErrorResponseType != nil {
+			builder.typeFromGo(i.ErrorResponseType)
+		} else {
+			builder.write("unknown")
+		}
+		builder.write("> extends Error {\n")
+		builder.write("  constructor(public readonly url: string, public readonly status: number, public readonly statusText: string, public readonly problem: Problem = { type: 'about:blank', title: statusText, status }, public readonly body: B | undefined = undefined) {\n")
+	} else {
+		builder.writeLine("export class ApiError extends Error {")
+		builder.write("  constructor(public readonly url: string, public readonly status: number, public readonly statusText: string, public readonly problem: Problem = { type: 'about:blank', title: statusText, status }, public readonly body: ")
+		if i.ErrorResponseType != nil {
+			builder.typeFromGo(i.ErrorResponseType)
+			builder.write(" | undefined")
+		} else {
+			builder.write("unknown")
+		}
+		builder.write(" = undefined) {\n")
+	}
+
+	builder.writeLines(
+		"    super(`Failed to fetch ${url}: ${problem.detail ?? statusText}`)",
+		"  }",
+		"",
+		"  // quotaExceeded is the exhausted quota, if the request has been rejected because the quota of its principal is exhausted.",
+		"  get quotaExceeded(): QuotaExceeded | undefined {",
+		"    return this.problem.quota",
+		"  }",
+		"",
+		"  // retryable is whether the server marked the request as likely to succeed when it is retried unchanged, e.g. when rate",
+		"  // limited or in maintenance mode. Retry policies should rely on it instead of the status code.",
+		"  get retryable(): boolean {",
+		"    return this.problem.retryable === true",
+		"  }",
+		"",
+		"  // retryAfterMs is the time in milliseconds after which a retryable request may be retried, if the server knows it.",
+		"  get retryAfterMs(): number | undefined {",
+		"    return this.retryable ? this.problem.retryAfterMs : undefined",
+		"  }",
+		"",
+		"  // from normalizes both the problem+json and the {\"error\": \"...\"} error shape into a Problem.",
+		"  static async from(url: string, response: Response): Promise<ApiError> {",
+		"    const problem: Problem = { type: 'about:blank', title: response.statusText, status: response.status }",
+		"    let body: any",
+		"    try {",
+		"      body = await response.json()",
+		"      if (typeof body?.error === 'string') {",
+		"        problem.detail = body.error",
+		"        problem.code = body.code",
+		"        problem.quota = body.quota",
+		"        problem.gone = body.gone",
+		"        problem.retryable = body.retryable",
+		"        problem.retryAfterMs = body.retryAfterMs",
+		"      } else if (body && typeof body === 'object') {",
+		"        Object.assign(problem, body)",
+		"      }",
+		"    } catch {",
+		"      // the error response has no JSON body",
+		"    }",
+		"    return new ApiError(url, response.status, response.statusText, problem, body)",
+		"  }",
+		"}",
+		"",
+		"// isGone narrows the given error to an ApiError of a request for a deleted resource.",
+		"export function isGone(e: unknown): e is ApiError & { problem: Problem & { gone: Gone } } {",
+		"  return e instanceof ApiError && e.status === 410 && e.problem.gone !== undefined",
+		"}",
+		"",
+	)
+
+	if builder.classStyle() {
+		builder.generateRequestOptions()
+		builder.generateAuthProvider()
+		builder.generatePKCEFlow()
+	} else {
+		builder.generateTenantSetter()
+		builder.generateInterceptorRegistration()
+
+		if basicAuth {
+			builder.generateBasicCredentials()
+		}
+
+		builder.generateBaseConfig("getBaseConfig", i.Authenticator)
+		for _, authenticator := range builder.routeAuthenticators {
+			builder.generateBaseConfig(baseConfigName(authenticator), authenticator)
+		}
+		builder.generateTokenRefresh()
+		builder.generatePKCEFlow()
+		builder.generateRequestOptions()
+		builder.generatePrimeDictionary()
+		builder.generateInterceptedFetch()
+		builder.generateFetchJSON()
+	}
+
+	builder.generateCanonicalJSON()
+
+	if usesExistenceChecks(routes) && !builder.classStyle() {
+		builder.generateFetchExists()
+	}
+
+	if usesLists(routes) {
+		builder.generateListDeclarations()
+	}
+
+	if usesDownloads(routes) {
+		builder.generateDownloadDeclarations()
+		if !builder.classStyle() {
+			builder.generateFetchDownload()
+		}
+	}
+
+	if usesPersistedQueries(routes) {
+		builder.generatePersistedDeclarations()
+		if !builder.classStyle() {
+			builder.generateFetchPersisted()
+		}
+	}
+
+	if usesJobs(routes) {
+		builder.generateJobDeclarations()
+		if !builder.classStyle() {
+			builder.generateWaitForJob()
+		}
+	}
+
+	if usesIdempotentRoutes(routes) {
+		builder.generateRetrySafe(routes)
+	}
+
+	builder.generateEnumTypes()
+
+	// Generate declarations for the protobuf messages, read from their descriptors instead of the struct tags
+	builder.generateProtoTypes(routes)
+
+	// Generate interfaces for every named struct reachable from the request bodies and responses, including nested ones
+	var structs []reflect.Type
+	seenStructs := make(map[reflect.Type]bool)
+	if i.ErrorResponseType != nil {
+		collectInterfaceTypes(i.ErrorResponseType, seenStructs, &structs)
+	}
+	for _, route := range routes {
+		if route.requestType != nil {
+			if bf := route.plan.bodyField(); bf != nil {
+				collectInterfaceTypes(bf.field.Type, seenStructs, &structs)
+			}
+		}
+
+		if route.responseType != nil && route.responseType != downloadType {
+			collectInterfaceTypes(route.responseType, seenStructs, &structs)
+		}
+
+		if route.jobs != nil {
+			collectInterfaceTypes(route.jobResult, seenStructs, &structs)
+		}
+
+		if route.errorType != nil {
+			collectInterfaceTypes(route.errorType, seenStructs, &structs)
+		}
+	}
+
+	for _, t := range structs {
+		builder.generateStructInterface(t)
+	}
+
+	if builder.mapsWire() {
+		builder.generateWireMappers(routes)
+	}
+
+	if i.routeStats != nil {
+		builder.generateRouteStatsDeclarations()
+		if !builder.classStyle() {
+			builder.generateRouteStatsClient()
+		}
+	}
+
+	if builder.classStyle() {
+		builder.generateClientClass(routes, i.routeStats != nil)
+	} else {
+		// Generate functions for each route
+		for _, route := range routes {
+			builder.generateRouteDeclarations(route)
+			builder.generateRouteFunctions(route)
+		}
+
+		builder.generateClientGroups(routes)
+	}
+
+	builder.writeLines("// end of generated code")
+
+	for _, warning := range builder.warnings {
+		log.Println("octanox: generation warning: " + warning)
+	}
+
+	return builder.sb.String()
+}
+
+// generateBaseConfig generates the function with the given name returning the headers authenticating the requests with the
+// given authenticator, which can be nil.
+func (tb *tsCodeBuilder) generateBaseConfig(name string, authenticator Authenticator) {
+	tb.writeLines(
+		"function "+name+"(): RequestInit {",
 		"  return {",
 	)
 
-	if i.Authenticator != nil {
-		authMethod := i.Authenticator.Method()
+	if authenticator != nil {
+		authMethod := authenticator.Method()
 		if authMethod == AuthenticationMethodBearer || authMethod == AuthenticationMethodBearerOAuth2 {
-			builder.writeLines(
+			tb.writeLines(
 				"    headers: {",
-				" 		 'Authorization': `Bearer ${localStorage.getItem('token')}`",
+				"      'Authorization': `Bearer ${localStorage.getItem('token')}`",
 				"    },",
 			)
 		} else if authMethod == AuthenticationMethodBasic {
-			builder.writeLines(
-				"    headers: {",
-				"      'Authorization': `Basic ${btoa(`${localStorage.getItem('username')}:${localStorage.getItem('password')}`)}`",
-				"    },",
+			tb.writeLines(
+				"    headers: runtime().basicCredentials ? {",
+				"      'Authorization': `Basic ${runtime().basicCredentials}`",
+				"    } : {},",
 			)
 		} else if authMethod == AuthenticationMethodApiKey {
-			builder.writeLines(
+			tb.writeLines(
 				"    headers: {",
 				"      'X-API-Key': localStorage.getItem('apiKey')",
 				"    },",
 			)
+		} else if authMethod == AuthenticationMethodCookie {
+			tb.writeLines(
+				"    headers: {},",
+				"    credentials: 'include',",
+			)
 		}
 	}
 
-	builder.writeLines(
+	tb.writeLines(
 		"  }",
 		"}",
 		"",
-		"async function fetchJson<T>(url: string, init?: RequestInit): Promise<T> {",
-		"  const baseConfig = getBaseConfig()",
+	)
+}
+
+// generateRequestOptions generates the RequestOptions interface of the options every route function accepts.
+func (tb *tsCodeBuilder) generateRequestOptions() {
+	tb.writeLines(
+		"// RequestOptions are the options every generated function accepts as its last parameter, e.g. the signal aborting the",
+		"// request once a component unmounts.",
+		"export interface RequestOptions {",
+		"  signal?: AbortSignal",
+		"}",
+		"",
+	)
+}
+
+// generateFetchJSON generates the fetchJson function sending the requests of the routes and decoding their responses.
+func (tb *tsCodeBuilder) generateFetchJSON() {
+	if tb.mapsWire() {
+		tb.writeLine(tb.helperDecl("async fetchJson<T>(url: string, init?: RequestInit, fromWire?: (w: any) => T, onResponse?: (response: Response) => void" + tb.baseConfigParam() + "): Promise<T> {"))
+	} else {
+		tb.writeLine(tb.helperDecl("async fetchJson<T>(url: string, init?: RequestInit, onResponse?: (response: Response) => void" + tb.baseConfigParam() + "): Promise<T> {"))
+	}
+
+	// the headers are always plain objects, so they are typed as such to be indexed by name
+	tb.writeLines(
+		"  const rt = "+tb.runtimeRef(),
+		"  const baseConfig = "+tb.baseConfigRef(),
+		"  const baseHeaders = (baseConfig.headers ?? {}) as Record<string, string>",
 		"  const config = init || {}",
-		"  if (!config.headers) {",
-		"    config.headers = {}",
-		"  }",
-		"  if (!config.headers['Content-Type']) {",
-		"    config.headers['Content-Type'] = 'application/json'",
-		"  }",
-		"  if (!config.headers['Accept']) {",
-		"    config.headers['Accept'] = 'application/json'",
+		"  const headers = (config.headers ?? {}) as Record<string, string>",
+		"  config.headers = headers",
+	)
+
+	// the browser sets the Content-Type of forms itself, including the boundary of the parts
+	if tb.forms {
+		tb.writeLine("  if (!headers['Content-Type'] && !(config.body instanceof FormData)) {")
+	} else {
+		tb.writeLine("  if (!headers['Content-Type']) {")
+	}
+
+	tb.writeLines(
+		"    headers['Content-Type'] = '"+tb.wireMediaType()+"'",
 		"  }",
-		"	 if (!config.headers['Authorization'] && baseConfig.headers['Authorization']) {",
-		"    config.headers['Authorization'] = baseConfig.headers['Authorization']",
+		"  if (!headers['Accept']) {",
+		"    headers['Accept'] = '"+tb.wireMediaType()+"'",
 		"  }",
-		"  let response = await fetch(baseUrl + url, config)",
+	)
+
+	if tb.classStyle() || tb.mixedAuth() {
+		// the headers of the AuthProvider and of the schemes of the routes are not restricted to the Authorization header
+		tb.writeLines(
+			"  for (const [name, value] of Object.entries(baseHeaders)) {",
+			"    if (!headers[name]) {",
+			"      headers[name] = value",
+			"    }",
+			"  }",
+		)
+	} else {
+		tb.writeLines(
+			"  if (!headers['Authorization'] && baseHeaders['Authorization']) {",
+			"    headers['Authorization'] = baseHeaders['Authorization']",
+			"  }",
+		)
+	}
+
+	if tb.cookieAuth {
+		tb.writeLines(
+			"  if (!config.credentials) {",
+			"    config.credentials = baseConfig.credentials",
+			"  }",
+		)
+	}
+
+	tb.generateTenantHeader()
+
+	tb.writeLine("  let response = await " + tb.fetchFunc() + "(" + tb.fetchURL() + ", config)")
+	tb.generateTokenRefreshRetry()
+	tb.writeLines(
 		"  if (response.status === 401) {",
-		"    unauthorizedHandler()",
+		"    rt.unauthorizedHandler?.()",
 		"  }",
 		"  if (!response.ok) {",
-		"    throw new Error(`Failed to fetch ${url}: ${response.statusText}`)",
+		"    throw await ApiError.from(url, response)",
+		"  }",
+		"  onResponse?.(response)",
+	)
+
+	if tb.dictionaries {
+		tb.writeLine("  " + tb.ref("primeDictionary") + "(response)")
+	}
+
+	// routes without a response body and handlers returning nil respond with 204 No Content, proxies may turn it into an
+	// empty 200 OK
+	tb.writeLines(
+		"  if (response.status === 204 || response.headers.get('Content-Length') === '0') {",
+		"    return null as T",
 		"  }",
-		"  return await response.json()",
+	)
+
+	if tb.opts.MessagePack {
+		tb.writeLines(
+			"  const contentType = response.headers.get('Content-Type') || ''",
+			"  const data: any = contentType.includes('msgpack') ? decode(new Uint8Array(await response.arrayBuffer())) : await response.json()",
+		)
+	} else if tb.mapsWire() {
+		tb.writeLine("  const data = await response.json()")
+	}
+
+	if tb.mapsWire() {
+		tb.writeLine("  return fromWire ? fromWire(data) : data")
+	} else if tb.opts.MessagePack {
+		tb.writeLine("  return data")
+	} else {
+		tb.writeLine("  return await response.json()")
+	}
+
+	tb.writeLines(
 		"}",
 		"",
 	)
+}
 
-	// Generate interfaces for the structs in the request body
-	for _, route := range routes {
-		if route.requestType != nil && route.responseType.Name() != "" {
-			builder.generateBodyInterface(route.requestType)
-			builder.writeLine("")
-		}
+// generateRouteDeclarations generates the types declared for the given route, which its functions reference.
+func (tb *tsCodeBuilder) generateRouteDeclarations(route *Route) {
+	if route.partial {
+		tb.generateFieldsType(route)
+		tb.writeLine("")
+	}
 
-		if route.responseType != nil && route.responseType.Name() != "" {
-			builder.generateStructInterface(route.responseType)
-			builder.writeLine("")
-		}
+	if len(route.aggregate) > 0 {
+		tb.generateAggregateResponse(route)
+		tb.writeLine("")
 	}
+}
 
-	// Generate functions for each route
-	for _, route := range routes {
-		builder.generateRouteFunction(route)
-		builder.writeLine("")
+// generateRouteFunctions generates the function of the given route and its existence check, WithResponse, Raw and waitFor
+// variants.
+func (tb *tsCodeBuilder) generateRouteFunctions(route *Route) {
+	tb.generateRouteFunction(route)
+	tb.writeLine("")
+
+	if route.existenceCheck {
+		tb.generateExistsFunction(route)
+		tb.writeLine("")
 	}
 
-	builder.writeLines("// end of generated code")
+	if generatesWithResponse(route) {
+		tb.generateWithResponseFunction(route)
+		tb.writeLine("")
+	}
+
+	if tb.generatesRaw(route) {
+		tb.generateRawFunction(route)
+		tb.writeLine("")
+	}
 
-	err := os.WriteFile(path, []byte(builder.sb.String()), 0644)
-	if err != nil {
-		panic(err)
+	if route.jobs != nil {
+		tb.generateWaitForFunction(route)
+		tb.writeLine("")
 	}
 }
 
-func (tb *tsCodeBuilder) generateRouteFunction(route route) {
-	tb.write("export async function " + tb.generateFunctionName(route) + "(")
-	if route.requestType != nil {
-		tb.generateFunctionParameters(route.requestType)
+func (tb *tsCodeBuilder) generateRouteFunction(route *Route) {
+	tb.applyClientOverride(route)
+
+	// the tags of the description, e.g. @deprecated, follow the notes of the route, as JSDoc ends the text at the first tag
+	text, tags := splitJSDocTags(jsDocLines(route.description))
+	docs := make([]string, 0, len(text)+len(tags)+2)
+	for _, line := range text {
+		docs = append(docs, strings.TrimRight(" * "+line, " "))
+	}
+	if route.immutable {
+		docs = append(docs, " * The response is immutable and cached by the browser, so repeated calls are served without reaching the server.")
+	}
+	if route.gone {
+		docs = append(docs, " * Rejects with an ApiError narrowed by isGone if the resource has been deleted.")
+	}
+	if route.idempotent {
+		docs = append(docs, " * Idempotent: repeating the request has the same effect as sending it once, so it is safe to retry.")
+	}
+	for _, line := range tags {
+		docs = append(docs, strings.TrimRight(" * "+line, " "))
 	}
+	if route.errorType != nil {
+		docs = append(docs, " * @throws {ApiError<"+route.errorType.Name()+">} if the request failed, with the "+route.errorType.Name()+" body of the error response.")
+	}
+	if len(docs) > 0 {
+		tb.writeLine("/**")
+		tb.writeLines(docs...)
+		tb.writeLine(" */")
+	}
+
+	tb.writeIndented(tb.routeDecl(route, "async "+tb.generateFunctionName(route)+"("))
+	tb.generateFunctionParameters(route)
 
 	tb.write("): Promise<")
-	tb.typeFromGo(route.responseType)
-	tb.writeLine("> {")
+	tb.writeResponseType(route)
+	tb.writeLineNoIdent("> {")
 
 	tb.indent()
-	tb.writeLine("let url = `" + route.path + "`")
+	if tb.opts.EmbedConstants && route.constant != nil && !route.overridesClientReturnType() {
+		tb.generateEmbeddedConstant(route)
+		tb.unindent()
+		tb.writeLine("}")
+		return
+	}
+
+	tb.generateRequestSetup(route)
 
-	for i := 0; i < route.requestType.NumField(); i++ {
-		field := route.requestType.Field(i)
-		if pathParam := field.Tag.Get("path"); pathParam != "" {
-			tb.writeLine("url = url.replace(`:" + pathParam + "`, encodeURIComponent(" + field.Name + ".toString()))")
+	if route.responseType == downloadType && !route.overridesClientReturnType() {
+		if base := tb.baseConfigArg(route); base != "" {
+			tb.writeLine("return " + tb.ref("fetchDownload") + "(url, config, " + base + ");")
+		} else {
+			tb.writeLine("return " + tb.ref("fetchDownload") + "(url, config);")
 		}
+		tb.unindent()
+		tb.writeLine("}")
+		return
+	}
+
+	tb.writeIndented("return ")
+	tb.generateFetchJSONCall(route, "")
+	tb.unindent()
+	tb.writeLine("}")
+}
+
+// generateRequestSetup generates the url and config variables of the request of the given route, including its parameters, body
+// and the abort signal of the request options.
+func (tb *tsCodeBuilder) generateRequestSetup(route *Route) {
+	tb.writeLine("let url = `" + route.path + "`")
+	tb.generatePathReplacements(route)
+
+	forms := route.requestType != nil && route.plan.formFields()
+	if forms {
+		tb.generateFormData(route)
 	}
 
 	tb.writeLine("const config: RequestInit = {")
 	tb.indent()
 	tb.writeLine("method: '" + strings.ToUpper(route.method) + "',")
+	tb.writeLine("signal: options?.signal,")
+	tb.generateRequestHeaders(route)
 
-	if route.requestType != nil {
-		if route.method != http.MethodGet && route.requestType.NumField() > 0 {
-			tb.writeLine("body: JSON.stringify(" + tb.getBodyParamName(route.requestType) + "),")
+	if forms && route.method != http.MethodGet {
+		tb.writeLine("body: form,")
+	} else if route.requestType != nil {
+		if bf := route.plan.clientBodyField(); route.method != http.MethodGet && bf != nil && !route.omitsClientParam(bf.field.Name) {
+			body := bf.field.Name
+			if bf.source == sourceRawBody {
+				// raw bodies are sent as is, so the exact bytes reach the server
+				tb.writeLine("body: " + body + ",")
+			} else {
+				if tb.mapsWire() {
+					body = tb.wireConversion(bf.field.Type, body, true)
+				}
+
+				tb.writeLine("body: " + tb.bodyEncoder() + "(" + body + "),")
+			}
 		}
 	}
 
 	tb.unindent()
 	tb.writeLine("};")
 
+	tb.generateQueryAppends(route)
+}
+
+// generateRequestHeaders generates the headers of the config variable, the pinned API version and the header parameters of
+// the given route. Header parameters which are null are not sent.
+func (tb *tsCodeBuilder) generateRequestHeaders(route *Route) {
+	var headers []string
+	if route.clientVersion != "" {
+		headers = append(headers, "'"+acceptVersionHeader+"': '"+route.clientVersion+"'")
+	}
+
 	if route.requestType != nil {
-		first := true
-
-		for i := 0; i < route.requestType.NumField(); i++ {
-			field := route.requestType.Field(i)
-			if queryParam := field.Tag.Get("query"); queryParam != "" {
-				tb.write("url += ")
-				if first {
-					tb.write("`?")
-					first = false
-				} else {
-					tb.write("`&")
-				}
+		for _, bf := range route.plan.fields {
+			if bf.source != sourceHeader || route.omitsClientParam(bf.field.Name) {
+				continue
+			}
 
-				tb.writeLineNoIdent(tb.getQueryParamString(queryParam, field.Name) + "`")
+			header := tsStringLiteral(bf.name) + ": " + bf.field.Name + ".toString()"
+			if bf.field.Type.Kind() == reflect.Ptr {
+				header = "...(" + bf.field.Name + " != null ? { " + header + " } : {})"
 			}
+
+			headers = append(headers, header)
 		}
 	}
 
-	tb.write("  return fetchJson<")
-	tb.typeFromGo(route.responseType)
-	tb.unindent()
-	tb.writeLine(">(url, config);")
-	tb.writeLine("}")
+	if len(headers) > 0 {
+		tb.writeLine("headers: { " + strings.Join(headers, ", ") + " },")
+	}
 }
 
-func (tb *tsCodeBuilder) generateFunctionName(route route) string {
-	path := strings.Replace(route.path, os.Getenv("NOX__GEN_OMIT_URL"), "", 1)
+// generateFetchJSONCall generates the fetchJson call of the given route, terminated by a semicolon. The onResponse callback is
+// passed if it is not empty.
+func (tb *tsCodeBuilder) generateFetchJSONCall(route *Route, onResponse string) {
+	if route.persistedQuery {
+		tb.write(tb.ref("fetchPersisted") + "<")
+	} else {
+		tb.write(tb.ref("fetchJson") + "<")
+	}
+	tb.writeResponseType(route)
+	tb.write(">(url, config")
+
+	base := tb.baseConfigArg(route)
+	mapped := tb.mapsWire() && !route.overridesClientReturnType() && route.hasResponseBody() && tb.needsWireMapping(route.responseType)
+	if mapped {
+		tb.write(", " + tb.wireMapperFunc(route.responseType))
+	} else if tb.mapsWire() && (onResponse != "" || base != "") {
+		tb.write(", undefined")
+	}
+
+	if onResponse != "" {
+		tb.write(", " + onResponse)
+	} else if base != "" {
+		tb.write(", undefined")
+	}
+
+	if base != "" {
+		tb.write(", " + base)
+	}
+
+	tb.writeLineNoIdent(");")
+}
+
+// generatePathReplacements generates the replacements of the path parameters in the url variable.
+func (tb *tsCodeBuilder) generatePathReplacements(route *Route) {
+	if route.requestType == nil {
+		return
+	}
+
+	for _, bf := range route.plan.fields {
+		if bf.source == sourcePath && !route.omitsClientParam(bf.field.Name) {
+			tb.writeLine("url = url.replace(`:" + bf.name + "`, encodeURIComponent(" + bf.field.Name + ".toString()))")
+		}
+	}
+}
+
+// generateQueryAppends generates the appending of the query parameters to the url variable.
+func (tb *tsCodeBuilder) generateQueryAppends(route *Route) {
+	if route.requestType == nil {
+		return
+	}
+
+	var params []*bindingField
+	for n := range route.plan.fields {
+		if bf := &route.plan.fields[n]; bf.source == sourceQuery && !route.omitsClientParam(bf.field.Name) {
+			params = append(params, bf)
+		}
+	}
+
+	if len(params) > 0 {
+		tb.writeLine("const params = new URLSearchParams()")
+		for _, bf := range params {
+			name := tsStringLiteral(strings.TrimSpace(bf.name))
+
+			appendParam := "params.append(" + name + ", " + tb.queryParamValue(bf, bf.field.Name) + ")"
+			if bf.array == queryArrayRepeat {
+				appendParam = "for (const value of " + bf.field.Name + ") params.append(" + name + ", " + tb.queryParamValue(bf, "value") + ")"
+			}
+
+			// parameters which are not required are only sent if they are given, comma-joined slices only if they are not empty
+			if bf.array == queryArrayCSV {
+				appendParam = "if (" + bf.field.Name + " !== undefined && " + bf.field.Name + " !== null && " + bf.field.Name + ".length > 0) " + appendParam
+			} else if !bf.required {
+				appendParam = "if (" + bf.field.Name + " !== undefined && " + bf.field.Name + " !== null) " + appendParam
+			}
+
+			tb.writeLine(appendParam)
+		}
+		tb.writeLine("if (params.toString()) url += '?' + params.toString()")
+	}
+
+	if embedsListQuery(route.requestType) {
+		tb.writeLine("url = appendListQuery(url, list)")
+	}
+
+	if route.partial {
+		tb.writeLine("if (fields && fields.length > 0) url += (url.includes('?') ? '&' : '?') + '" + fieldsQueryParam + "=' + encodeURIComponent(fields.join(','))")
+	}
+}
+
+func (tb *tsCodeBuilder) generateFunctionName(route *Route) string {
+	if name := explicitFunctionName(route); name != "" {
+		return name
+	}
+
+	return tb.derivedFunctionName(route.method, strings.Replace(route.path, tb.omitURLPrefix(), "", 1))
+}
+
+// derivedFunctionName returns the name of the function of the route with the given method and path in the function naming style.
+func (tb *tsCodeBuilder) derivedFunctionName(method, path string) string {
+	if tb.opts.FunctionNamingStyle == NamingStyleCamelCase {
+		return camelCaseFunctionName(method, path)
+	}
+
 	path = strings.ReplaceAll(path, "/", "_")
 	path = strings.ReplaceAll(path, ":", "")
-	name := strings.ToLower(route.method) + path
+	name := strings.ToLower(method) + path
 	name = strings.Map(func(r rune) rune {
 		if r == '@' {
 			return -1
@@ -226,63 +865,243 @@ func (tb *tsCodeBuilder) generateFunctionName(route route) string {
 	return name
 }
 
-func (tb *tsCodeBuilder) generateFunctionParameters(t reflect.Type) {
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		if field.Anonymous {
-			continue
+// camelCaseFunctionName returns the camelCase name of the function of the route with the given method and path. The
+// path is split into words at every character which is not a letter or digit, and a letter following a digit starts
+// a new word, so GET /api/v1/users/:id is named getApiV1UsersId.
+func camelCaseFunctionName(method, path string) string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(method))
+
+	upper := true
+	for _, r := range path {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+			if upper && r >= 'a' {
+				r -= 'a' - 'A'
+			}
+			sb.WriteRune(r)
+			upper = false
+		case r >= '0' && r <= '9':
+			sb.WriteRune(r)
+			upper = true
+		default:
+			upper = true
 		}
+	}
+
+	return sb.String()
+}
 
-		pathTag := field.Tag.Get("path")
-		queryTag := field.Tag.Get("query")
-		headerTag := field.Tag.Get("header")
-		bodyTag := field.Tag.Get("body")
+// generateFunctionParameters generates the parameters of the function of the given route, followed by the optional request
+// options, which are always last, so they never shift the other parameters.
+func (tb *tsCodeBuilder) generateFunctionParameters(route *Route) {
+	first := true
 
-		if pathTag == "" && queryTag == "" && headerTag == "" && bodyTag == "" {
-			continue
+	for _, bf := range route.clientParams() {
+		if !first {
+			tb.write(", ")
+		}
+		first = false
+
+		tb.write(bf.field.Name + ": ")
+		if bf.source == sourceRawBody {
+			tb.write("string | Uint8Array")
+		} else if bf.source == sourceFile {
+			tb.write(tsFileType(bf))
+		} else {
+			tb.typeFromGo(bf.field.Type)
+		}
+	}
+
+	if embedsListQuery(route.requestType) {
+		if !first {
+			tb.write(", ")
 		}
+		first = false
 
-		tb.write(field.Name + ": ")
-		tb.typeFromGo(field.Type)
+		tb.write("list?: ListQuery")
+	}
 
-		if i < t.NumField()-1 {
+	if route.partial {
+		if !first {
 			tb.write(", ")
 		}
+		first = false
+
+		tb.write("fields?: Array<" + tb.fieldsTypeName(route) + ">")
+	}
+
+	if !first {
+		tb.write(", ")
+	}
+
+	tb.write("options?: RequestOptions")
+}
+
+// requestOptionsIndex returns the index of the request options among the parameters of the generated function of the route,
+// which follow the client parameters, the list query and the selected fields.
+func (r *Route) requestOptionsIndex() int {
+	n := len(r.clientParams())
+	if embedsListQuery(r.requestType) {
+		n++
+	}
+	if r.partial {
+		n++
 	}
+
+	return n
 }
 
-func (tb *tsCodeBuilder) getBodyParamName(t reflect.Type) string {
-	for i := 0; i < t.NumField(); i++ {
-		if bodyTag := t.Field(i).Tag.Get("body"); bodyTag != "" {
-			return t.Field(i).Name
+// clientParams returns the request fields of the given route which are parameters of its generated function, in their order.
+func (r *Route) clientParams() []*bindingField {
+	if r.requestType == nil {
+		return nil
+	}
+
+	params := make([]*bindingField, 0, len(r.plan.fields))
+	for n := range r.plan.fields {
+		bf := &r.plan.fields[n]
+		if !bf.isClientParam() || r.omitsClientParam(bf.field.Name) {
+			continue
+		}
+
+		// the decoded body is decoded from the raw body the client sends
+		if bf.source == sourceBody && r.plan.rawBodyField() != nil {
+			continue
 		}
+
+		params = append(params, bf)
+	}
+
+	return params
+}
+
+// writeResponseType writes the TypeScript response type of the given route, respecting its client override.
+func (tb *tsCodeBuilder) writeResponseType(route *Route) {
+	if route.overridesClientReturnType() {
+		tb.write(route.clientOverride.ReturnType)
+		return
+	}
+
+	if route.responseType == downloadType {
+		tb.write("Download")
+		return
+	}
+
+	if !route.hasResponseBody() {
+		tb.write("void")
+		return
+	}
+
+	if len(route.aggregate) > 0 {
+		tb.write(tb.aggregateResponseName(route))
+		return
 	}
-	return ""
+
+	tb.typeFromGo(route.responseType)
 }
 
-func (tb *tsCodeBuilder) getQueryParamString(queryParam, fieldName string) string {
-	return fmt.Sprintf("%s=${encodeURIComponent(%s.toString())}", strings.TrimSpace(queryParam), fieldName)
+// queryParamValue returns the string value of the query parameter of the given field with the given value, joining the
+// values of comma separated slice parameters.
+func (tb *tsCodeBuilder) queryParamValue(bf *bindingField, value string) string {
+	if bf.array == queryArrayCSV {
+		return value + ".join(',')"
+	}
+
+	return value + ".toString()"
 }
 
+// generateStructInterface generates the interface of the given named struct, followed by an empty line. Each struct is
+// generated once, identified by its package path and name, even if several routes reference it.
 func (tb *tsCodeBuilder) generateStructInterface(t reflect.Type) {
-	if t.Kind() != reflect.Struct {
+	if t.Kind() != reflect.Struct || isProtoMessage(t) || isListResultType(t) || isKnownTSType(t) {
 		return
 	}
 
+	key := t.PkgPath() + "." + t.Name()
+	if tb.interfaces[key] {
+		return
+	}
+	tb.interfaces[key] = true
+
+	tb.writeDoc(tb.typeDescriptions[t])
 	tb.writeLine("export interface " + t.Name() + " {")
 	tb.generateStructBody(t, false)
-	tb.writeLine("}")
+	tb.writeLines(
+		"}",
+		"",
+	)
 }
 
-func (tb *tsCodeBuilder) generateBodyInterface(t reflect.Type) {
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		if bodyTag := field.Tag.Get("body"); bodyTag != "" {
-			tb.generateStructInterface(field.Type)
+// collectInterfaceTypes collects the named struct types reachable from the given type, which need an interface. The fields
+// are walked transitively, so the interfaces of nested structs are declared as well.
+func collectInterfaceTypes(t reflect.Type, seen map[reflect.Type]bool, out *[]reflect.Type) {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		collectInterfaceTypes(t.Elem(), seen, out)
+	case reflect.Struct:
+		if isProtoMessage(t) || isKnownTSType(t) {
+			return
+		}
+
+		if isListResultType(t) {
+			collectInterfaceTypes(listResultItemType(t), seen, out)
+			return
+		}
+
+		// the visited set also ends recursive types, e.g. a Node with Children []Node
+		if seen[t] {
+			return
+		}
+		seen[t] = true
+
+		if t.Name() != "" {
+			*out = append(*out, t)
+		}
+
+		for _, jf := range jsonFields(t) {
+			collectInterfaceTypes(jf.t, seen, out)
 		}
 	}
 }
 
+// tsKnownTypes are the TypeScript types of well-known Go types, which are encoded differently than their kind suggests.
+var tsKnownTypes = map[reflect.Type]string{
+	reflect.TypeOf(time.Time{}):      "string /* ISO-8601 */",
+	reflect.TypeOf(time.Duration(0)): "number /* nanoseconds */",
+}
+
+// knownType returns the TypeScript type of the given well-known Go type, respecting the generation options.
+func (tb *tsCodeBuilder) knownType(t reflect.Type) (string, bool) {
+	if isTimeType(t) && tb.opts.DateObjects {
+		return "Date", true
+	}
+
+	ts, ok := tsKnownTypes[t]
+	return ts, ok
+}
+
+// isKnownTSType checks if the given type is a well-known Go type with its own TypeScript type.
+func isKnownTSType(t reflect.Type) bool {
+	_, ok := tsKnownTypes[t]
+	return ok
+}
+
+// isTimeType checks if the given type is time.Time, which is encoded as its ISO-8601 string.
+func isTimeType(t reflect.Type) bool {
+	return t == reflect.TypeOf(time.Time{})
+}
+
+func containsType(types []reflect.Type, t reflect.Type) bool {
+	for _, candidate := range types {
+		if candidate == t {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (tb *tsCodeBuilder) generateStructBody(t reflect.Type, inline bool) {
 	if t.Kind() != reflect.Struct {
 		return
@@ -292,33 +1111,47 @@ func (tb *tsCodeBuilder) generateStructBody(t reflect.Type, inline bool) {
 		tb.indent()
 	}
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
+	// the fields of embedded structs are flattened, as encoding/json encodes them
+	for _, jf := range jsonFields(t) {
+		field := t.FieldByIndex(jf.index)
+		_, omitempty, _ := jsonFieldName(field)
 
-		// Skip embedded fields
-		if field.Anonymous {
-			continue
+		// fields with a visible tag are missing, or null, in the responses to the users the rule does not match
+		rule, visible := field.Tag.Lookup("visible")
+		if visible {
+			tb.writeDoc(fieldDescription(field.Tag), "Only visible if "+rule+".")
+		} else {
+			tb.writeDoc(fieldDescription(field.Tag))
 		}
 
-		jsonTag := field.Tag.Get("json")
-		jsonName := field.Name
-		omitempty := false
-		if jsonTag != "" {
-			if jsonTag == "-" {
-				continue
+		tb.write(strings.Repeat(" ", tb.ind))
+		tb.write(tb.propertyName(jf.name))
+		if visible || omitempty && tb.opts.OmitEmpty != OmitEmptyNullable {
+			tb.write("?")
+		}
+		tb.write(": ")
+		if encodesQuoted(field) {
+			tb.write("string")
+			if field.Type.Kind() == reflect.Ptr {
+				tb.write(" | null")
 			}
+		} else {
+			tb.typeFromGo(field.Type)
+		}
 
-			jsonName = jsonTag
-			if strings.Contains(jsonTag, ",omitempty") {
-				omitempty = true
-			}
+		// pointers are typed as nullable by typeFromGo already
+		nullable := field.Type.Kind() == reflect.Slice && isNullableInClient(field, tb.nilCollections) || visible && tb.hiddenFields == HiddenFieldsNull
+		if omitempty && tb.opts.OmitEmpty != OmitEmptyOptional {
+			nullable = true
+		}
+		if nullable && field.Type.Kind() != reflect.Ptr {
+			tb.write(" | null")
 		}
 
-		tb.write(strings.Repeat(" ", tb.ind))
-		tb.write(jsonName + ": ")
-		tb.typeFromGo(field.Type)
-		if omitempty {
-			tb.write(" | undefined")
+		if tb.strictNullability && !nullable && !representsNull(field, tb.nilCollections) {
+			if backed, _ := nullableSource(tb.nullableSources, t, field); backed {
+				panic("octanox: field " + field.Name + " of " + t.String() + " is backed by a nullable source and must not be generated as non-nullable; " + nullabilitySuggestion(field))
+			}
 		}
 
 		tb.write(";")
@@ -330,7 +1163,100 @@ func (tb *tsCodeBuilder) generateStructBody(t reflect.Type, inline bool) {
 	}
 }
 
+// wireMediaType returns the media type the generated client uses for request and response bodies.
+func (tb *tsCodeBuilder) wireMediaType() string {
+	if tb.opts.MessagePack {
+		return "application/msgpack"
+	}
+
+	return "application/json"
+}
+
+// bodyEncoder returns the name of the TypeScript function used to encode request bodies.
+func (tb *tsCodeBuilder) bodyEncoder() string {
+	if tb.opts.MessagePack {
+		return "encode"
+	}
+
+	return "JSON.stringify"
+}
+
+// jsonFieldName returns the JSON name of the given struct field, whether the field is omitempty and whether it is skipped by encoding/json.
+// The name falls back to the name of the field if the tag only has options, e.g. `json:",omitempty"`.
+func jsonFieldName(field reflect.StructField) (name string, omitempty bool, skip bool) {
+	jsonTag := field.Tag.Get("json")
+	if jsonTag == "-" {
+		return "", false, true
+	}
+
+	name, _, _ = strings.Cut(jsonTag, ",")
+	if name == "" {
+		name = field.Name
+	}
+
+	return name, hasJSONOption(field.Tag, "omitempty"), false
+}
+
+// hasJSONOption checks if the JSON tag of a field has the given option, e.g. omitempty in `json:"name,omitempty"`.
+func hasJSONOption(tag reflect.StructTag, option string) bool {
+	_, options, _ := strings.Cut(tag.Get("json"), ",")
+	for options != "" {
+		var opt string
+		opt, options, _ = strings.Cut(options, ",")
+		if opt == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+// encodesQuoted checks if the given field is encoded as a quoted string because of the string option of its JSON tag, e.g.
+// `json:",string"`. Like encoding/json, the option is only respected for numbers and booleans, and pointers to them.
+func encodesQuoted(field reflect.StructField) bool {
+	if !hasJSONOption(field.Tag, "string") {
+		return false
+	}
+
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8,
+		reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr, reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
+
+// propertyName returns the TypeScript property name for the given JSON name, respecting the naming options.
+func (tb *tsCodeBuilder) propertyName(jsonName string) string {
+	if tb.opts.CamelCaseProperties {
+		return toCamelCase(jsonName)
+	}
+
+	return jsonName
+}
+
 func (tb *tsCodeBuilder) typeFromGo(t reflect.Type) {
+	if (t.Kind() == reflect.Ptr || t.Kind() == reflect.Struct) && isProtoMessage(t) {
+		tb.write(protoTypeName(protoDescriptor(t)))
+		return
+	}
+
+	if known, ok := tb.knownType(t); ok {
+		tb.write(known)
+		return
+	}
+
+	if _, ok := tb.enums[t]; ok {
+		tb.write(t.Name())
+		return
+	}
+
 	switch t.Kind() {
 	case reflect.Ptr:
 		tb.typeFromGo(t.Elem())
@@ -346,6 +1272,13 @@ func (tb *tsCodeBuilder) typeFromGo(t reflect.Type) {
 		tb.write("number")
 		return
 	case reflect.Struct:
+		if isListResultType(t) {
+			tb.write("ListResult<")
+			tb.typeFromGo(listResultItemType(t))
+			tb.write(">")
+			return
+		}
+
 		// if it's an anonymous struct, generate an inline interface
 		if t.Name() == "" {
 			tb.write("{")
@@ -356,10 +1289,27 @@ func (tb *tsCodeBuilder) typeFromGo(t reflect.Type) {
 
 		tb.write(t.Name())
 	case reflect.Slice:
+		if tb.opts.MessagePack && t.Elem().Kind() == reflect.Uint8 {
+			tb.write("Uint8Array")
+			return
+		}
+
 		tb.write("Array<")
 		tb.typeFromGo(t.Elem())
 		tb.write(">")
 		return
+	case reflect.Map:
+		// encoding/json encodes integer keys as their decimal string, all other keys are strings or text marshalers
+		key := "string"
+		switch t.Key().Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			key = "number"
+		}
+
+		tb.write("Record<" + key + ", ")
+		tb.typeFromGo(t.Elem())
+		tb.write(">")
+		return
 	default:
 		tb.write("any")
 		return
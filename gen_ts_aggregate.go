@@ -0,0 +1,14 @@
+package octanox
+
+// aggregateResponseName returns the name of the response interface of the given aggregate route.
+func (tb *tsCodeBuilder) aggregateResponseName(route *Route) string {
+	return tb.generateFunctionName(route) + "Response"
+}
+
+// generateAggregateResponse generates the response interface of the given aggregate route, with a field per route it serves
+// typed as the response of that route.
+func (tb *tsCodeBuilder) generateAggregateResponse(route *Route) {
+	tb.writeLine("export interface " + tb.aggregateResponseName(route) + " {")
+	tb.generateStructBody(route.responseType, false)
+	tb.writeLine("}")
+}
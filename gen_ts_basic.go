@@ -0,0 +1,72 @@
+package octanox
+
+// basicCredentialsStorageKey is the localStorage key the generated client persists the encrypted Basic credentials under.
+const basicCredentialsStorageKey = "nox.basicCredentials"
+
+// generateBasicCredentials generates the credentials provider of the Basic authentication. The base64 encoded credentials are
+// only kept in memory, unless they are explicitly persisted, in which case they are encrypted with AES-GCM using a key derived
+// from a passphrase with PBKDF2. The raw password is never written to any storage.
+func (tb *tsCodeBuilder) generateBasicCredentials() {
+	tb.writeLines(
+		"export function setBasicCredentials(username: string, password: string) {",
+		"  runtime().basicCredentials = toBase64(new TextEncoder().encode(`${username}:${password}`))",
+		"}",
+		"",
+		"export function clearBasicCredentials() {",
+		"  runtime().basicCredentials = undefined",
+		"  localStorage.removeItem('"+basicCredentialsStorageKey+"')",
+		"}",
+		"",
+		"function toBase64(bytes: Uint8Array): string {",
+		"  return btoa(String.fromCharCode(...bytes))",
+		"}",
+		"",
+		"function fromBase64(value: string): Uint8Array {",
+		"  return Uint8Array.from(atob(value), (c) => c.charCodeAt(0))",
+		"}",
+		"",
+		"async function deriveCredentialsKey(passphrase: string, salt: Uint8Array): Promise<CryptoKey> {",
+		"  const material = await crypto.subtle.importKey('raw', new TextEncoder().encode(passphrase), 'PBKDF2', false, ['deriveKey'])",
+		"  return crypto.subtle.deriveKey(",
+		"    { name: 'PBKDF2', salt, iterations: 310000, hash: 'SHA-256' },",
+		"    material,",
+		"    { name: 'AES-GCM', length: 256 },",
+		"    false,",
+		"    ['encrypt', 'decrypt'],",
+		"  )",
+		"}",
+		"",
+		"export async function persistBasicCredentials(passphrase: () => Promise<string>): Promise<void> {",
+		"  const credentials = runtime().basicCredentials",
+		"  if (!credentials) {",
+		"    throw new Error('No Basic credentials to persist')",
+		"  }",
+		"  const salt = crypto.getRandomValues(new Uint8Array(16))",
+		"  const iv = crypto.getRandomValues(new Uint8Array(12))",
+		"  const key = await deriveCredentialsKey(await passphrase(), salt)",
+		"  const data = await crypto.subtle.encrypt({ name: 'AES-GCM', iv }, key, new TextEncoder().encode(credentials))",
+		"  localStorage.setItem('"+basicCredentialsStorageKey+"', JSON.stringify({",
+		"    salt: toBase64(salt),",
+		"    iv: toBase64(iv),",
+		"    data: toBase64(new Uint8Array(data)),",
+		"  }))",
+		"}",
+		"",
+		"export async function restoreBasicCredentials(passphrase: () => Promise<string>): Promise<boolean> {",
+		"  const stored = localStorage.getItem('"+basicCredentialsStorageKey+"')",
+		"  if (!stored) {",
+		"    return false",
+		"  }",
+		"  const { salt, iv, data } = JSON.parse(stored)",
+		"  const key = await deriveCredentialsKey(await passphrase(), fromBase64(salt))",
+		"  try {",
+		"    const credentials = await crypto.subtle.decrypt({ name: 'AES-GCM', iv: fromBase64(iv) }, key, fromBase64(data))",
+		"    runtime().basicCredentials = new TextDecoder().decode(credentials)",
+		"    return true",
+		"  } catch {",
+		"    return false",
+		"  }",
+		"}",
+		"",
+	)
+}
@@ -0,0 +1,23 @@
+package octanox
+
+// generateCanonicalJSON generates the canonicalJson function encoding a value as the same canonical JSON as CanonicalJSON on
+// the server, so clients can sign or hash payloads the server verifies. JSON.stringify already formats numbers and escapes
+// strings canonically, only the object keys have to be sorted.
+func (tb *tsCodeBuilder) generateCanonicalJSON() {
+	tb.writeLines(
+		"export function canonicalJson(value: any): string {",
+		"  if (value !== null && typeof value === 'object' && typeof value.toJSON === 'function') {",
+		"    value = value.toJSON()",
+		"  }",
+		"  if (value === null || typeof value !== 'object') {",
+		"    return JSON.stringify(value ?? null)",
+		"  }",
+		"  if (Array.isArray(value)) {",
+		"    return '[' + value.map((item) => typeof item === 'function' ? 'null' : canonicalJson(item)).join(',') + ']'",
+		"  }",
+		"  const keys = Object.keys(value).filter((key) => value[key] !== undefined && typeof value[key] !== 'function').sort()",
+		"  return '{' + keys.map((key) => JSON.stringify(key) + ':' + canonicalJson(value[key])).join(',') + '}'",
+		"}",
+		"",
+	)
+}
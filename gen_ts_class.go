@@ -0,0 +1,181 @@
+package octanox
+
+import "strings"
+
+// classStyle checks if the client is generated as the ApiClient class.
+func (tb *tsCodeBuilder) classStyle() bool {
+	return tb.opts.ClientStyle == ClientStyleClass
+}
+
+// exportedDecl returns the declaration of the exported function with the given signature, e.g. "async get_users(", which
+// is a public method of the ApiClient class in the class style.
+func (tb *tsCodeBuilder) exportedDecl(signature string) string {
+	if tb.classStyle() {
+		return signature
+	}
+
+	if rest, ok := strings.CutPrefix(signature, "async "); ok {
+		return "export async function " + rest
+	}
+
+	return "export function " + signature
+}
+
+// helperDecl returns the declaration of the internal function with the given signature, e.g. "async fetchJson<T>(", which
+// is a private method of the ApiClient class in the class style.
+func (tb *tsCodeBuilder) helperDecl(signature string) string {
+	if tb.classStyle() {
+		return "private " + signature
+	}
+
+	if rest, ok := strings.CutPrefix(signature, "async "); ok {
+		return "async function " + rest
+	}
+
+	return "function " + signature
+}
+
+// ref returns the reference to the generated function with the given name, a method of the ApiClient class in the class
+// style.
+func (tb *tsCodeBuilder) ref(name string) string {
+	if tb.classStyle() {
+		return "this." + name
+	}
+
+	return name
+}
+
+// runtimeRef returns the expression of the client runtime.
+func (tb *tsCodeBuilder) runtimeRef() string {
+	if tb.classStyle() {
+		return "this.rt"
+	}
+
+	return "runtime()"
+}
+
+// baseConfigRef returns the expression of the base config of the requests, which the AuthProvider of the ApiClient class
+// provides asynchronously. If the routes are authenticated by different schemes, the helpers take it as parameter.
+func (tb *tsCodeBuilder) baseConfigRef() string {
+	if tb.mixedAuth() {
+		if tb.classStyle() {
+			return "(await base)"
+		}
+
+		return "base"
+	}
+
+	if tb.classStyle() {
+		return "(await this.getBaseConfig())"
+	}
+
+	return "getBaseConfig()"
+}
+
+// generateAuthProvider generates the AuthProvider interface the ApiClient class is authenticated with.
+func (tb *tsCodeBuilder) generateAuthProvider() {
+	tb.writeLines(
+		"// AuthProvider provides the headers authenticating the requests of an ApiClient, e.g. the Authorization header with the",
+		"// current bearer token. It is asked before every request, so it can refresh expired credentials.",
+	)
+
+	if tb.mixedAuth() {
+		// the routes are authenticated by different schemes, so the provider is asked for the headers of the scheme of the route
+		tb.writeLines(
+			"// The scheme is the authentication scheme of the route, e.g. 'bearer' or 'api key'. Public routes do not ask it.",
+			"export interface AuthProvider {",
+			"  headers(scheme: string): Record<string, string> | Promise<Record<string, string>>",
+			"}",
+			"",
+		)
+		return
+	}
+
+	tb.writeLines(
+		"export interface AuthProvider {",
+		"  headers(): Record<string, string> | Promise<Record<string, string>>",
+		"}",
+		"",
+	)
+}
+
+// generateClassBaseConfig generates the getBaseConfig method returning the headers of the AuthProvider. If the routes are
+// authenticated by different schemes, it takes the scheme of the route, which defaults to the scheme of the instance.
+func (tb *tsCodeBuilder) generateClassBaseConfig() {
+	if !tb.mixedAuth() {
+		tb.writeLines(
+			"private async getBaseConfig(): Promise<RequestInit> {",
+			"  return {"+tb.credentialsInit()+" headers: this.auth ? await this.auth.headers() : {} }",
+			"}",
+			"",
+		)
+		return
+	}
+
+	tb.writeLines(
+		"private async getBaseConfig(scheme: string = "+tsStringLiteral(authenticationScheme(tb.authenticator))+"): Promise<RequestInit> {",
+		"  return {"+tb.credentialsInit()+" headers: this.auth && scheme !== 'none' ? await this.auth.headers(scheme) : {} }",
+		"}",
+		"",
+	)
+}
+
+// generateClientClass generates the ApiClient class with its helpers as private methods and a public method per route.
+// The types the methods reference are declared before the class.
+func (tb *tsCodeBuilder) generateClientClass(routes []*Route, routeStats bool) {
+	for _, route := range routes {
+		tb.generateRouteDeclarations(route)
+	}
+
+	tb.writeLines(
+		"export class ApiClient {",
+		"  private readonly rt: ClientRuntime",
+		"",
+		"  constructor(baseUrl: string, private readonly auth?: AuthProvider) {",
+		"    this.rt = { baseUrl"+tb.interceptorRuntimeInit()+" }",
+		"  }",
+		"",
+	)
+
+	tb.indent()
+	tb.writeLines(
+		"setUnauthorizedHandler(handler: () => void) {",
+		"  this.rt.unauthorizedHandler = handler",
+		"}",
+		"",
+	)
+	tb.generateTenantSetter()
+	tb.generateInterceptorRegistration()
+	tb.generateClassBaseConfig()
+	tb.generatePrimeDictionary()
+	tb.generateInterceptedFetch()
+	tb.generateFetchJSON()
+
+	if usesExistenceChecks(routes) {
+		tb.generateFetchExists()
+	}
+	if usesDownloads(routes) {
+		tb.generateFetchDownload()
+	}
+	if usesPersistedQueries(routes) {
+		tb.generateFetchPersisted()
+	}
+	if usesJobs(routes) {
+		tb.generateWaitForJob()
+	}
+	if routeStats {
+		tb.generateRouteStatsClient()
+	}
+
+	tb.generateClientGroups(routes)
+
+	for _, route := range routes {
+		tb.generateRouteFunctions(route)
+	}
+	tb.unindent()
+
+	tb.writeLines(
+		"}",
+		"",
+	)
+}
@@ -0,0 +1,34 @@
+package octanox
+
+// generateDictionaryRuntimeField generates the field of the client runtime remembering the fetched compression dictionaries.
+func (tb *tsCodeBuilder) generateDictionaryRuntimeField() {
+	if tb.dictionaries {
+		tb.writeLine("  fetchedDictionaries?: Set<string>")
+	}
+}
+
+// generatePrimeDictionary generates the primeDictionary function fetching the compression dictionary advertised by a
+// response once. The browser keeps the dictionary and announces it with the following requests, decoding the responses
+// compressed against it natively. Browsers without dictionary support never announce it and receive the regular responses.
+func (tb *tsCodeBuilder) generatePrimeDictionary() {
+	if !tb.dictionaries {
+		return
+	}
+
+	tb.writeLines(
+		tb.helperDecl("primeDictionary(response: Response) {"),
+		"  const link = response.headers.get('Link')?.match(/<([^>]+)>;\\s*rel=\"compression-dictionary\"/)",
+		"  if (!link) {",
+		"    return",
+		"  }",
+		"  const rt = "+tb.runtimeRef(),
+		"  rt.fetchedDictionaries ??= new Set()",
+		"  if (rt.fetchedDictionaries.has(link[1])) {",
+		"    return",
+		"  }",
+		"  rt.fetchedDictionaries.add(link[1])",
+		"  fetch(rt.baseUrl + link[1]).then((r) => r.arrayBuffer()).catch(() => rt.fetchedDictionaries?.delete(link[1]))",
+		"}",
+		"",
+	)
+}
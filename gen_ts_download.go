@@ -0,0 +1,60 @@
+package octanox
+
+// usesDownloads checks if any of the given routes responds with a Download.
+func usesDownloads(routes []*Route) bool {
+	for _, route := range routes {
+		if route.responseType == downloadType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// generateDownloadDeclarations generates the Download type and the downloadFilename function parsing the file name of the
+// Content-Disposition header.
+func (tb *tsCodeBuilder) generateDownloadDeclarations() {
+	tb.writeLines(
+		"export interface Download {",
+		"  blob: Blob",
+		"  filename: string",
+		"}",
+		"",
+		"function downloadFilename(disposition: string | null): string {",
+		`  const extended = /filename\*\s*=\s*utf-8''([^;]+)/i.exec(disposition ?? '')`,
+		"  if (extended) {",
+		"    return decodeURIComponent(extended[1].trim())",
+		"  }",
+		`  const plain = /filename\s*=\s*(?:"((?:[^"\\]|\\.)*)"|([^;]+))/i.exec(disposition ?? '')`,
+		"  if (plain) {",
+		`    return plain[1] !== undefined ? plain[1].replace(/\\(.)/g, '$1') : plain[2].trim()`,
+		"  }",
+		"  return 'download'",
+		"}",
+		"",
+	)
+}
+
+// generateFetchDownload generates the fetchDownload function, which resolves a download route to the blob and the file name
+// of the Content-Disposition header.
+func (tb *tsCodeBuilder) generateFetchDownload() {
+	tb.writeLines(
+		tb.helperDecl("async fetchDownload(url: string, init: RequestInit"+tb.baseConfigParam()+"): Promise<Download> {"),
+		"  const rt = "+tb.runtimeRef(),
+		"  const headers: Record<string, string> = { ...("+tb.baseConfigRef()+".headers as Record<string, string>), ...(init.headers as Record<string, string>) }",
+		"  const config: RequestInit = {"+tb.credentialsInit()+" ...init, headers }",
+	)
+	tb.generateTenantHeader()
+	tb.writeLines(
+		"  const response = await "+tb.fetchFunc()+"("+tb.fetchURL()+", config)",
+		"  if (response.status === 401) {",
+		"    rt.unauthorizedHandler?.()",
+		"  }",
+		"  if (!response.ok) {",
+		"    throw await ApiError.from(url, response)",
+		"  }",
+		"  return { blob: await response.blob(), filename: downloadFilename(response.headers.get('Content-Disposition')) }",
+		"}",
+		"",
+	)
+}
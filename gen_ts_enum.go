@@ -0,0 +1,30 @@
+package octanox
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// generateEnumTypes generates the union types of the registered enums, sorted by their name.
+func (tb *tsCodeBuilder) generateEnumTypes() {
+	types := make([]reflect.Type, 0, len(tb.enums))
+	for t := range tb.enums {
+		types = append(types, t)
+	}
+
+	sort.Slice(types, func(a, b int) bool {
+		return types[a].Name() < types[b].Name()
+	})
+
+	for _, t := range types {
+		tb.writeDoc(tb.typeDescriptions[t])
+		tb.writeLine("export type " + t.Name() + " = " + strings.Join(tb.enums[t], " | "))
+		tb.writeLine("")
+	}
+}
+
+// tsStringLiteral returns the given string as single-quoted TypeScript string literal.
+func tsStringLiteral(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`, "\r", `\r`).Replace(s) + "'"
+}
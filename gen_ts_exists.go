@@ -0,0 +1,57 @@
+package octanox
+
+// usesExistenceChecks checks if any of the given routes has an existence check.
+func usesExistenceChecks(routes []*Route) bool {
+	for _, route := range routes {
+		if route.existenceCheck {
+			return true
+		}
+	}
+
+	return false
+}
+
+// generateFetchExists generates the fetchExists function issuing the HEAD requests of the existence checks.
+func (tb *tsCodeBuilder) generateFetchExists() {
+	tb.writeLines(
+		tb.helperDecl("async fetchExists(url: string, signal?: AbortSignal"+tb.baseConfigParam()+"): Promise<boolean> {"),
+		"  const rt = "+tb.runtimeRef(),
+		"  const headers: Record<string, string> = { ...("+tb.baseConfigRef()+".headers as Record<string, string>) }",
+		"  const config: RequestInit = { method: 'HEAD', signal,"+tb.credentialsInit()+" headers }",
+	)
+	tb.generateTenantHeader()
+	tb.writeLines(
+		"  const response = await "+tb.fetchFunc()+"("+tb.fetchURL()+", config)",
+		"  if (response.status === 401) {",
+		"    rt.unauthorizedHandler?.()",
+		"  }",
+		"  if (response.status === 200 || response.status === 304) {",
+		"    return true",
+		"  }",
+		"  if (response.status === 404) {",
+		"    return false",
+		"  }",
+		"  throw await ApiError.from(url, response)",
+		"}",
+		"",
+	)
+}
+
+// generateExistsFunction generates the existence check function of the given route, taking the same parameters as the route function.
+func (tb *tsCodeBuilder) generateExistsFunction(route *Route) {
+	tb.writeIndented(tb.routeDecl(route, "async "+tb.generateFunctionName(route)+"Exists("))
+	tb.generateFunctionParameters(route)
+	tb.writeLineNoIdent("): Promise<boolean> {")
+
+	tb.indent()
+	tb.writeLine("let url = `" + route.path + "`")
+	tb.generatePathReplacements(route)
+	tb.generateQueryAppends(route)
+	if base := tb.baseConfigArg(route); base != "" {
+		tb.writeLine("return " + tb.ref("fetchExists") + "(url, options?.signal, " + base + ")")
+	} else {
+		tb.writeLine("return " + tb.ref("fetchExists") + "(url, options?.signal)")
+	}
+	tb.unindent()
+	tb.writeLine("}")
+}
@@ -0,0 +1,52 @@
+package octanox
+
+import (
+	"reflect"
+	"strings"
+)
+
+// fieldPaths returns the dotted JSON paths clients can select in the fields query parameter of a route with the given
+// response type. For ListResult types these are the paths of the items.
+func fieldPaths(t reflect.Type) []string {
+	if isListResultType(t) {
+		t = listResultItemType(t)
+	}
+
+	paths := make([]string, 0)
+	collectFieldPaths(t, "", make(map[reflect.Type]bool), &paths)
+	return paths
+}
+
+func collectFieldPaths(t reflect.Type, prefix string, visiting map[reflect.Type]bool, paths *[]string) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+
+	// recursive types would have infinitely many paths, so their fields are only selectable once per path
+	if t.Kind() != reflect.Struct || marshalsItself(t) || visiting[t] {
+		return
+	}
+
+	visiting[t] = true
+	for _, field := range jsonFields(t) {
+		*paths = append(*paths, prefix+field.name)
+		collectFieldPaths(field.t, prefix+field.name+".", visiting, paths)
+	}
+	delete(visiting, t)
+}
+
+// fieldsTypeName returns the name of the union type of the selectable fields of the given route.
+func (tb *tsCodeBuilder) fieldsTypeName(route *Route) string {
+	return tb.generateFunctionName(route) + "Fields"
+}
+
+// generateFieldsType generates the union type of the paths clients can select in the fields query parameter of the given route.
+func (tb *tsCodeBuilder) generateFieldsType(route *Route) {
+	paths := fieldPaths(route.responseType)
+	if len(paths) == 0 {
+		tb.writeLine("export type " + tb.fieldsTypeName(route) + " = never")
+		return
+	}
+
+	tb.writeLine("export type " + tb.fieldsTypeName(route) + " = '" + strings.Join(paths, "' | '") + "'")
+}
@@ -0,0 +1,147 @@
+package octanox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ClientGroup adds the generated TypeScript function of this route as the given member to the object with the given group name,
+// e.g. invoices.list, in addition to the standalone function.
+func (r *Route) ClientGroup(group, member string) *Route {
+	r.clientGroup = group
+	r.clientMember = member
+	return r
+}
+
+// ClientGroup adds the generated TypeScript functions of the routes registered on this router and its groups afterwards
+// to the object with the given group name. Their member names are derived from the method and the path after the path of
+// this router, e.g. users.get_id for GET /users/:id of a router at /users, unless set with Route.ClientGroup.
+func (r *SubRouter) ClientGroup(group string) *SubRouter {
+	if !tsIdentifier.MatchString(group) {
+		panic(fmt.Sprintf("octanox: client group %q of %s is no valid TypeScript identifier", group, r.url))
+	}
+
+	r.clientGroup, r.clientGroupPath = group, r.url
+	return r
+}
+
+// clientMemberName returns the member name of the given route in its client group, derived from the path after the path
+// of its group router if it is not set.
+func (tb *tsCodeBuilder) clientMemberName(route *Route) string {
+	if route.clientMember != "" {
+		return route.clientMember
+	}
+
+	return tb.derivedFunctionName(route.method, strings.TrimPrefix(route.path, route.clientGroupPath))
+}
+
+// groupedOnly checks if the function of the given route is only generated as member of its client group.
+func (tb *tsCodeBuilder) groupedOnly(route *Route) bool {
+	return tb.opts.GroupedExportsOnly && route.clientGroup != ""
+}
+
+// routeDecl returns the declaration of the function of the given route with the given signature, which is not exported,
+// or a private method in the class style, if it is only a member of its client group.
+func (tb *tsCodeBuilder) routeDecl(route *Route, signature string) string {
+	if tb.groupedOnly(route) {
+		return tb.helperDecl(signature)
+	}
+
+	return tb.exportedDecl(signature)
+}
+
+// clientFunctionRef returns the reference to the function of the given route with the given suffix, e.g. "Exists", from
+// outside of the client, relative to the module or the ApiClient: its member of its client group if it is only generated
+// as member, or its own name.
+func (tb *tsCodeBuilder) clientFunctionRef(route *Route, suffix string) string {
+	if tb.groupedOnly(route) {
+		return route.clientGroup + "." + tb.clientMemberName(route) + suffix
+	}
+
+	return tb.generateFunctionName(route) + suffix
+}
+
+// checkClientGroups returns an error for every two routes of the generated client which are the same member of their client
+// group, naming where their handlers are declared.
+func (i *Instance) checkClientGroups(routes []*Route) error {
+	tb := tsCodeBuilder{opts: i.tsGenOptions}
+	named := make(map[string]*Route)
+	var errs []error
+
+	for _, route := range clientRoutes(routes, os.Getenv("NOX__CLIENT_VERSION")) {
+		if route.clientGroup == "" {
+			continue
+		}
+
+		name := tb.clientMemberName(route)
+		if other, ok := named[route.clientGroup+"."+name]; ok {
+			errs = append(errs, fmt.Errorf("%s %s (%s) and %s %s (%s) are both member %q of the client group %q", other.method, other.path, handlerLocation(other.handlerFunc), route.method, route.path, handlerLocation(route.handlerFunc), name, route.clientGroup))
+			continue
+		}
+
+		named[route.clientGroup+"."+name] = route
+	}
+
+	return errors.Join(errs...)
+}
+
+// generateClientGroups generates an object for every client group, referencing the functions of its member routes. In the
+// class style the objects are properties of the ApiClient class, referencing its bound methods. Panics if two routes of a
+// group have the same member name, which the generator rejects beforehand with checkClientGroups.
+func (tb *tsCodeBuilder) generateClientGroups(routes []*Route) {
+	groups := make([]string, 0)
+	members := make(map[string][]*Route)
+
+	for _, route := range routes {
+		if route.clientGroup == "" {
+			continue
+		}
+
+		if _, ok := members[route.clientGroup]; !ok {
+			groups = append(groups, route.clientGroup)
+		}
+		members[route.clientGroup] = append(members[route.clientGroup], route)
+	}
+
+	member := func(name string) string {
+		if tb.classStyle() {
+			return "this." + name + ".bind(this)"
+		}
+
+		return name
+	}
+
+	for _, group := range groups {
+		if tb.classStyle() {
+			tb.writeLine("readonly " + group + " = {")
+		} else {
+			tb.writeLine("export const " + group + " = {")
+		}
+
+		named := make(map[string]*Route)
+		for _, route := range members[group] {
+			name := tb.clientMemberName(route)
+			if other, ok := named[name]; ok {
+				panic(fmt.Sprintf("octanox: %s %s and %s %s are both member %q of the client group %q", other.method, other.path, route.method, route.path, name, group))
+			}
+			named[name] = route
+
+			tb.writeLine("  " + name + ": " + member(tb.generateFunctionName(route)) + ",")
+			if route.existenceCheck {
+				tb.writeLine("  " + name + "Exists: " + member(tb.generateFunctionName(route)+"Exists") + ",")
+			}
+			if generatesWithResponse(route) {
+				tb.writeLine("  " + name + "WithResponse: " + member(tb.generateFunctionName(route)+"WithResponse") + ",")
+			}
+			if tb.generatesRaw(route) {
+				tb.writeLine("  " + name + "Raw: " + member(tb.generateFunctionName(route)+"Raw") + ",")
+			}
+		}
+		tb.writeLines(
+			"}",
+			"",
+		)
+	}
+}
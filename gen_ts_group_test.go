@@ -0,0 +1,51 @@
+package octanox
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type clientGroupRequest struct {
+	GetRequest
+}
+
+type clientGroupResponse struct {
+	OK bool `json:"ok"`
+}
+
+func listUsers(*clientGroupRequest) *clientGroupResponse {
+	return &clientGroupResponse{OK: true}
+}
+
+func listAccounts(*clientGroupRequest) *clientGroupResponse {
+	return &clientGroupResponse{OK: true}
+}
+
+func TestClientGroupCollisionIsGeneratorError(t *testing.T) {
+	i := newTestInstance(t)
+	t.Setenv("NOX__CLIENT_DIR", filepath.Join(t.TempDir(), "client.ts"))
+	i.Register("/users", listUsers).ClientGroup("directory", "list")
+	i.Register("/accounts", listAccounts).ClientGroup("directory", "list")
+
+	err := i.runGenerators()
+	if err == nil {
+		t.Fatal("expected an error for the duplicate member")
+	}
+
+	for _, want := range []string{"GET /users", "GET /accounts", `"list"`, `"directory"`, "gen_ts_group_test.go:"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+}
+
+func TestClientGroupMembersOfDifferentGroups(t *testing.T) {
+	i := newTestInstance(t)
+	i.Register("/users", listUsers).ClientGroup("users", "list")
+	i.Register("/accounts", listAccounts).ClientGroup("accounts", "list")
+
+	if err := i.checkClientGroups(i.routes); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,80 @@
+package octanox
+
+import "reflect"
+
+// generatesWithResponse checks if the WithResponse variant is generated for the given route, which is the case for every route
+// with declared response headers, except for downloads.
+func generatesWithResponse(route *Route) bool {
+	return len(route.declaredResponseHeaders()) > 0 && route.responseType != downloadType
+}
+
+// generateWithResponseFunction generates the headers interface of the given route and the WithResponse variant of its function,
+// which resolves to the data and the typed declared headers of the response. Missing headers are null.
+func (tb *tsCodeBuilder) generateWithResponseFunction(route *Route) {
+	name := tb.generateFunctionName(route)
+	headers := route.declaredResponseHeaders()
+
+	tb.writeLine("export interface " + name + "Headers {")
+	tb.indent()
+	for _, header := range headers {
+		if header.description != "" {
+			tb.writeLine("/** " + header.description + " */")
+		}
+		tb.writeLine("'" + header.name + "': " + tsResponseHeaderType(header.t) + " | null;")
+	}
+	tb.unindent()
+	tb.writeLines(
+		"}",
+		"",
+	)
+
+	tb.writeIndented(tb.routeDecl(route, "async "+name+"WithResponse("))
+	tb.generateFunctionParameters(route)
+
+	tb.write("): Promise<{ data: ")
+	tb.writeResponseType(route)
+	tb.writeLineNoIdent(", headers: " + name + "Headers }> {")
+
+	tb.indent()
+	tb.generateRequestSetup(route)
+
+	tb.writeLine("let response!: Response")
+	tb.writeIndented("const data = await ")
+	tb.generateFetchJSONCall(route, "(r) => { response = r }")
+	tb.writeLine("return {")
+	tb.writeLine("  data,")
+	tb.writeLine("  headers: {")
+	for _, header := range headers {
+		tb.writeLine("    '" + header.name + "': " + tsResponseHeaderValue(header) + ",")
+	}
+	tb.writeLine("  },")
+	tb.writeLine("}")
+	tb.unindent()
+	tb.writeLine("}")
+}
+
+// tsResponseHeaderType returns the TypeScript type of a response header of the given type.
+func tsResponseHeaderType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	default:
+		return "number"
+	}
+}
+
+// tsResponseHeaderValue returns the TypeScript expression parsing the given header from the response.
+func tsResponseHeaderValue(header responseHeader) string {
+	get := "response.headers.get('" + header.name + "')"
+
+	switch header.t.Kind() {
+	case reflect.String:
+		return get
+	case reflect.Bool:
+		return "response.headers.has('" + header.name + "') ? " + get + " === 'true' : null"
+	default:
+		return "response.headers.has('" + header.name + "') ? Number(" + get + ") : null"
+	}
+}
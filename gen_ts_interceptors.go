@@ -0,0 +1,100 @@
+package octanox
+
+import "strings"
+
+// generateInterceptorRuntimeFields generates the fields of the client runtime holding the registered interceptors, if the
+// client supports interceptors.
+func (tb *tsCodeBuilder) generateInterceptorRuntimeFields() {
+	if tb.opts.InterceptorSupport {
+		tb.writeLines(
+			"  requestInterceptors: RequestInterceptor[]",
+			"  responseInterceptors: ResponseInterceptor[]",
+		)
+	}
+}
+
+// interceptorRuntimeInit returns the initial interceptor fields of the object literal creating the client runtime.
+func (tb *tsCodeBuilder) interceptorRuntimeInit() string {
+	if tb.opts.InterceptorSupport {
+		return ", requestInterceptors: [], responseInterceptors: []"
+	}
+
+	return ""
+}
+
+// generateInterceptorDeclarations generates the types of the request and response interceptors, if the client supports
+// interceptors.
+func (tb *tsCodeBuilder) generateInterceptorDeclarations() {
+	if !tb.opts.InterceptorSupport {
+		return
+	}
+
+	tb.writeLines(
+		"// RequestInterceptor receives the init of every request after the authentication and tenant headers have been set, and",
+		"// returns the init the request is sent with.",
+		"export type RequestInterceptor = (init: RequestInit) => RequestInit | Promise<RequestInit>",
+		"",
+		"// ResponseInterceptor receives every response before the client handles it, and returns the response the client handles,",
+		"// e.g. the response of a retried request.",
+		"export type ResponseInterceptor = (response: Response) => Response | Promise<Response>",
+		"",
+	)
+}
+
+// generateInterceptorRegistration generates the addRequestInterceptor and addResponseInterceptor functions, which return
+// a function removing the interceptor again. The interceptors run in the order they have been added in.
+func (tb *tsCodeBuilder) generateInterceptorRegistration() {
+	if !tb.opts.InterceptorSupport {
+		return
+	}
+
+	for _, kind := range []string{"Request", "Response"} {
+		field := tb.runtimeRef() + "." + strings.ToLower(kind) + "Interceptors"
+		tb.writeLines(
+			tb.exportedDecl("add"+kind+"Interceptor(fn: "+kind+"Interceptor): () => void {"),
+			"  const interceptors = "+field,
+			"  interceptors.push(fn)",
+			"  return () => {",
+			"    const index = interceptors.indexOf(fn)",
+			"    if (index >= 0) {",
+			"      interceptors.splice(index, 1)",
+			"    }",
+			"  }",
+			"}",
+			"",
+		)
+	}
+}
+
+// generateInterceptedFetch generates the interceptedFetch function, which sends a request through the registered
+// interceptors and is called instead of fetch if the client supports interceptors.
+func (tb *tsCodeBuilder) generateInterceptedFetch() {
+	if !tb.opts.InterceptorSupport {
+		return
+	}
+
+	tb.writeLines(
+		tb.helperDecl("async interceptedFetch(url: string, init: RequestInit): Promise<Response> {"),
+		"  const rt = "+tb.runtimeRef(),
+		"  for (const intercept of [...rt.requestInterceptors]) {",
+		"    init = await intercept(init)",
+		"  }",
+		"  let response = await fetch(url, init)",
+		"  for (const intercept of [...rt.responseInterceptors]) {",
+		"    response = await intercept(response)",
+		"  }",
+		"  return response",
+		"}",
+		"",
+	)
+}
+
+// fetchFunc returns the reference to the function the generated client sends its requests with, interceptedFetch if the
+// client supports interceptors.
+func (tb *tsCodeBuilder) fetchFunc() string {
+	if tb.opts.InterceptorSupport {
+		return tb.ref("interceptedFetch")
+	}
+
+	return "fetch"
+}
@@ -0,0 +1,123 @@
+package octanox
+
+import (
+	"strconv"
+	"unicode"
+)
+
+// defaultJobPollIntervalMs is the interval in milliseconds the generated waitFor functions poll the status of a job in.
+const defaultJobPollIntervalMs = 1000
+
+// waitForFunctionName returns the name of the function waiting for the jobs of the given async route, e.g. waitForPostReports.
+func (tb *tsCodeBuilder) waitForFunctionName(route *Route) string {
+	name := []rune(toCamelCase(tb.generateFunctionName(route)))
+	name[0] = unicode.ToUpper(name[0])
+	return "waitFor" + string(name)
+}
+
+// jobSchemaName returns the name of the OpenAPI schema of the status of the jobs of the given async route, e.g. PostReportsJob.
+func (tb *tsCodeBuilder) jobSchemaName(route *Route) string {
+	name := []rune(toCamelCase(tb.generateFunctionName(route)))
+	name[0] = unicode.ToUpper(name[0])
+	return string(name) + "Job"
+}
+
+// generateJobDeclarations generates the types of the status of jobs, the JobFailedError the waitFor functions reject with
+// and the delay between their polls.
+func (tb *tsCodeBuilder) generateJobDeclarations() {
+	tb.writeLines(
+		"export type JobStatus = '"+string(JobPending)+"' | '"+string(JobRunning)+"' | '"+string(JobSucceeded)+"' | '"+string(JobFailed)+"'",
+		"",
+		"export interface JobError {",
+		"  message: string",
+		"  code?: string",
+		"}",
+		"",
+		"export interface Job<T> {",
+		"  id: string",
+		"  status: JobStatus",
+		"  result?: T",
+		"  error?: JobError",
+		"}",
+		"",
+		"// JobFailedError is the error the waitFor functions reject with if the job failed.",
+		"export class JobFailedError extends Error {",
+		"  constructor(public readonly jobId: string, public readonly error: JobError) {",
+		"    super(`Job ${jobId} failed: ${error.message}`)",
+		"  }",
+		"}",
+		"",
+		"// WaitOptions are the options of the waitFor functions, the interval the status of the job is polled in, which defaults",
+		"// to "+strconv.Itoa(defaultJobPollIntervalMs)+" milliseconds, and the signal to stop waiting with.",
+		"export interface WaitOptions {",
+		"  pollIntervalMs?: number",
+		"  signal?: AbortSignal",
+		"}",
+		"",
+		"function pollDelay(ms: number, signal?: AbortSignal): Promise<void> {",
+		"  return new Promise((resolve, reject) => {",
+		"    if (signal?.aborted) {",
+		"      reject(signal.reason)",
+		"      return",
+		"    }",
+		"    const onAbort = () => {",
+		"      clearTimeout(timer)",
+		"      reject(signal!.reason)",
+		"    }",
+		"    const timer = setTimeout(() => {",
+		"      signal?.removeEventListener('abort', onAbort)",
+		"      resolve()",
+		"    }, ms)",
+		"    signal?.addEventListener('abort', onAbort, { once: true })",
+		"  })",
+		"}",
+		"",
+	)
+}
+
+// generateWaitForJob generates the waitForJob function polling the status of a job until it finished, which the waitFor
+// functions of the async routes call with the mapping of their result.
+func (tb *tsCodeBuilder) generateWaitForJob() {
+	tb.writeLines(
+		tb.helperDecl("async waitForJob<T>(jobId: string, options?: WaitOptions, fromWire?: (w: any) => T): Promise<T> {"),
+		"  const url = `"+jobsPath+"/${encodeURIComponent(jobId)}`",
+		"  for (;;) {",
+		"    const job = await "+tb.ref("fetchJson")+"<Job<any>>(url, { method: 'GET', signal: options?.signal })",
+		"    if (job.status === '"+string(JobSucceeded)+"') {",
+		"      return fromWire ? fromWire(job.result) : job.result",
+		"    }",
+		"    if (job.status === '"+string(JobFailed)+"') {",
+		"      throw new JobFailedError(jobId, job.error ?? { message: 'Internal Server Error' })",
+		"    }",
+		"    await pollDelay(options?.pollIntervalMs ?? "+strconv.Itoa(defaultJobPollIntervalMs)+", options?.signal)",
+		"  }",
+		"}",
+		"",
+	)
+}
+
+// generateWaitForFunction generates the waitFor function of the given async route, which resolves with the result of the job
+// the function of the route started.
+func (tb *tsCodeBuilder) generateWaitForFunction(route *Route) {
+	tb.writeLines(
+		"/**",
+		" * Polls the status of the job started by "+tb.generateFunctionName(route)+" until it finished. Resolves with its result, or rejects",
+		" * with a JobFailedError if it failed.",
+		" */",
+	)
+
+	tb.writeIndented(tb.exportedDecl("async " + tb.waitForFunctionName(route) + "(jobId: string, options?: WaitOptions): Promise<"))
+	tb.typeFromGo(route.jobResult)
+	tb.writeLineNoIdent("> {")
+
+	tb.indent()
+	tb.writeIndented("return " + tb.ref("waitForJob") + "<")
+	tb.typeFromGo(route.jobResult)
+	tb.write(">(jobId, options")
+	if tb.mapsWire() && tb.needsWireMapping(route.jobResult) {
+		tb.write(", " + tb.wireMapperFunc(route.jobResult))
+	}
+	tb.writeLineNoIdent(")")
+	tb.unindent()
+	tb.writeLine("}")
+}
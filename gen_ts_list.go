@@ -0,0 +1,125 @@
+package octanox
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// isListResultType checks if the given type is a ListResult, or a pointer to one.
+func isListResultType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Struct && t.PkgPath() == listQueryType.PkgPath() && strings.HasPrefix(t.Name(), "ListResult[")
+}
+
+// listResultItemType returns the item type of the given ListResult type.
+func listResultItemType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	field, _ := t.FieldByName("Items")
+	return field.Type.Elem()
+}
+
+// embedsListQuery checks if the given request type embeds a ListQuery.
+func embedsListQuery(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type == listQueryType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// usesLists checks if any of the given routes uses a ListQuery or a ListResult.
+func usesLists(routes []*Route) bool {
+	for _, route := range routes {
+		if embedsListQuery(route.requestType) || route.responseType != nil && isListResultType(route.responseType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// generateListDeclarations generates the ListQuery and ListResult types, the query builder and the paginated iteration helper.
+func (tb *tsCodeBuilder) generateListDeclarations() {
+	nextCursor := tb.propertyName("next_cursor")
+	defaultLimit := strconv.Itoa(defaultListLimit)
+
+	tb.writeLines(
+		"export interface ListQuery {",
+		"  cursor?: string;",
+		"  page?: number;",
+		"  limit?: number;",
+		"  sort?: Array<string>;",
+		"  filters?: Record<string, string>;",
+		"}",
+		"",
+		"export interface ListResult<T> {",
+		"  items: Array<T>;",
+		"  total?: number;",
+		"  "+nextCursor+"?: string;",
+		"}",
+		"",
+		"function appendListQuery(url: string, list?: ListQuery): string {",
+		"  if (!list) return url",
+		"  const params = new URLSearchParams()",
+		"  if (list.cursor) params.append('cursor', list.cursor)",
+		"  if (list.page !== undefined) params.append('page', list.page.toString())",
+		"  if (list.limit !== undefined) params.append('limit', list.limit.toString())",
+		"  if (list.sort && list.sort.length > 0) params.append('sort', list.sort.join(','))",
+		"  if (list.filters) {",
+		"    for (const [key, value] of Object.entries(list.filters)) {",
+		"      params.append(`filter[${key}]`, value)",
+		"    }",
+		"  }",
+		"  const query = params.toString()",
+		"  if (!query) return url",
+		"  return url + (url.includes('?') ? '&' : '?') + query",
+		"}",
+		"",
+		"export async function* paginate<T>(fetchPage: (list: ListQuery) => Promise<ListResult<T>>, list: ListQuery = {}): AsyncGenerator<T> {",
+		"  let query: ListQuery = { ...list }",
+		"  while (true) {",
+		"    const result = await fetchPage(query)",
+		"    for (const item of result.items) {",
+		"      yield item",
+		"    }",
+		"    const page = query.page ?? 1",
+		"    const limit = query.limit ?? "+defaultLimit,
+		"    if (result."+nextCursor+") {",
+		"      query = { ...query, cursor: result."+nextCursor+", page: undefined }",
+		"    } else if (!query.cursor && result.items.length === limit && (result.total === undefined || page * limit < result.total)) {",
+		"      query = { ...query, page: page + 1 }",
+		"    } else {",
+		"      return",
+		"    }",
+		"  }",
+		"}",
+		"",
+	)
+
+	if tb.mapsWire() {
+		tb.writeLines(
+			"function listResultFromWire<T>(w: any, item: (v: any) => T): ListResult<T> {",
+			"  if (w == null) return w",
+			"  return {",
+			"    items: w['items'] == null ? w['items'] : w['items'].map(item),",
+			"    total: w['total'],",
+			"    "+nextCursor+": w['next_cursor'],",
+			"  }",
+			"}",
+			"",
+		)
+	}
+}
@@ -0,0 +1,49 @@
+package octanox
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// tsIdentifier matches the names which are valid TypeScript identifiers.
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// Name sets the name of the generated TypeScript function of this route, e.g. Name("getUser"), which is used verbatim
+// instead of the name derived from the method and path, so renaming the URL does not rename the function. The generation
+// panics if two routes are given the same name. Panics if the name is no valid TypeScript identifier.
+func (r *Route) Name(name string) *Route {
+	if !tsIdentifier.MatchString(name) {
+		panic(fmt.Sprintf("octanox: name %q of %s %s is no valid TypeScript identifier", name, r.method, r.path))
+	}
+
+	r.clientName = name
+	return r
+}
+
+// explicitFunctionName returns the name of the generated function of the given route given with Name or a client override.
+// Empty if the name is derived from the method and path.
+func explicitFunctionName(route *Route) string {
+	if route.clientOverride != nil && route.clientOverride.Name != "" {
+		return route.clientOverride.Name
+	}
+
+	return route.clientName
+}
+
+// checkFunctionNames panics if two of the given routes have the same explicit name, which would declare the same function
+// twice, listing both routes.
+func checkFunctionNames(routes []*Route) {
+	named := make(map[string]*Route)
+	for _, route := range routes {
+		name := explicitFunctionName(route)
+		if name == "" {
+			continue
+		}
+
+		if other, ok := named[name]; ok {
+			panic(fmt.Sprintf("octanox: %s %s and %s %s are both named %q in the generated client", other.method, other.path, route.method, route.path, name))
+		}
+
+		named[name] = route
+	}
+}
@@ -0,0 +1,171 @@
+package octanox
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TypeScriptGenerationOptions is a struct that configures the TypeScript client code generation.
+type TypeScriptGenerationOptions struct {
+	// CamelCaseProperties makes the generated interfaces use camelCase property names. Per-type fromWire/toWire mapping functions
+	// are generated and applied automatically to responses and request bodies, so call sites only ever see camelCase.
+	// Fields whose JSON name is already camelCase are passed through untouched.
+	CamelCaseProperties bool
+	// MessagePack makes the generated client send and accept application/msgpack bodies, encoded and decoded via @msgpack/msgpack.
+	// JSON responses are still understood, so the client falls back gracefully when the server responds with JSON.
+	// Byte slices are typed as Uint8Array in this mode.
+	MessagePack bool
+	// DateObjects types time.Time fields as Date instead of their ISO-8601 string. The fromWire/toWire mapping functions are
+	// generated and applied as for CamelCaseProperties, parsing the strings of responses and formatting the dates of request bodies.
+	DateObjects bool
+	// OmitURLPrefix is the prefix of the route paths left out of the names of the generated functions, e.g. "/api" names
+	// the function of GET /api/users get_users instead of get_api_users. Defaults to the deprecated NOX__GEN_OMIT_URL environment variable.
+	OmitURLPrefix string
+	// FunctionNamingStyle is the style of the names of the generated functions derived from the method and path. Defaults
+	// to NamingStyleSnakeCase.
+	FunctionNamingStyle NamingStyle
+	// OmitEmpty is how the properties of omitempty fields are typed. Defaults to OmitEmptyOptional.
+	OmitEmpty OmitEmptyStyle
+	// ReactQueryOutputPath is the path of the @tanstack/react-query hooks written next to the client, a useQuery hook for every
+	// GET route and a useMutation hook for every other route, e.g. useGetUsersId wrapping get_users_id. The hooks import
+	// the client relative to their own path. No hooks are written if it is empty.
+	ReactQueryOutputPath string
+	// ClientStyle is the shape of the generated client. Defaults to ClientStyleFunctions.
+	ClientStyle ClientStyle
+	// EmbedConstants makes the functions of constant routes resolve to their response embedded at generation time, instead
+	// of requesting it. The handlers of the constant routes are called by the generation to embed their response.
+	EmbedConstants bool
+	// InterceptorSupport generates the addRequestInterceptor and addResponseInterceptor functions, methods of the ApiClient
+	// class in the class style. Every request of the client is sent through the interceptors in the order they have been
+	// added in, e.g. to log requests, inject tokens or retry failed responses, without editing the generated client.
+	InterceptorSupport bool
+	// RawResponseFunctions generates a Raw variant next to the function of every route except downloads, e.g. get_users_idRaw,
+	// which resolves to the data together with the status and the headers of the response, e.g. to read the Location of
+	// a 201 Created. Routes without a response body resolve to void data.
+	RawResponseFunctions bool
+	// TokenEndpointPath is the path of the route issuing bearer tokens, e.g. "/auth/refresh". With OAuth2 bearer authentication,
+	// requests rejected with 401 Unauthorized are sent again once with the token of the handler set by setTokenRefreshHandler,
+	// except for requests to this path, so the handler can call it with the generated client.
+	TokenEndpointPath string
+	// GroupedExportsOnly generates the functions of routes in a client group only as members of the object of their group,
+	// e.g. users.get, instead of also exporting them as standalone functions, so the names of routes of different groups
+	// do not crowd the autocomplete. In the class style the methods of grouped routes are private.
+	GroupedExportsOnly bool
+}
+
+// ClientStyle is a type that decides the shape of the generated TypeScript client.
+type ClientStyle int
+
+const (
+	// ClientStyleFunctions generates a standalone exported function per route, sharing the base URL and credentials set
+	// with module level functions like setBaseUrl. This is the default.
+	ClientStyleFunctions ClientStyle = iota
+	// ClientStyleClass generates a single ApiClient class with a public method per route, constructed with the base URL
+	// and an optional AuthProvider, so several clients can be injected and used side by side. The React Query hooks take
+	// the client as their first parameter.
+	ClientStyleClass
+)
+
+// OmitEmptyStyle is a type that decides how the properties of fields with the omitempty JSON option are typed in the
+// generated interfaces.
+type OmitEmptyStyle int
+
+const (
+	// OmitEmptyOptional types omitempty fields as optional properties, e.g. nickname?: string. This is the default.
+	OmitEmptyOptional OmitEmptyStyle = iota
+	// OmitEmptyNullable types omitempty fields as required nullable properties, e.g. nickname: string | null, for clients
+	// whose deserialization turns omitted fields into null.
+	OmitEmptyNullable
+	// OmitEmptyOptionalNullable types omitempty fields as optional nullable properties, e.g. nickname?: string | null.
+	OmitEmptyOptionalNullable
+)
+
+// NamingStyle is a type that decides how the names of the generated functions are derived from the method and path of
+// their routes.
+type NamingStyle int
+
+const (
+	// NamingStyleSnakeCase joins the lowercase method and the path segments with underscores, e.g. get_api_v1_users_id for
+	// GET /api/v1/users/:id. This is the default.
+	NamingStyleSnakeCase NamingStyle = iota
+	// NamingStyleCamelCase appends the title-cased path segments to the lowercase method, e.g. getApiV1UsersId for
+	// GET /api/v1/users/:id.
+	NamingStyleCamelCase
+)
+
+// SetTSGenOptions sets the options used for the TypeScript client code generation.
+func (i *Instance) SetTSGenOptions(opts TypeScriptGenerationOptions) *Instance {
+	i.tsGenOptions = opts
+	return i
+}
+
+// omitURLPrefix returns the prefix of the route paths left out of the names of the generated functions.
+func (tb *tsCodeBuilder) omitURLPrefix() string {
+	if tb.opts.OmitURLPrefix != "" {
+		return tb.opts.OmitURLPrefix
+	}
+
+	// Deprecated: NOX__GEN_OMIT_URL is only read for compatibility, set TypeScriptGenerationOptions.OmitURLPrefix instead.
+	return os.Getenv("NOX__GEN_OMIT_URL")
+}
+
+// mapsWire checks if the generated client converts values between the wire and the TypeScript shape.
+func (tb *tsCodeBuilder) mapsWire() bool {
+	return tb.opts.CamelCaseProperties || tb.opts.DateObjects
+}
+
+// ClientOverride is a struct that overrides the defaults of the TypeScript client code generation for a single route.
+type ClientOverride struct {
+	// Name is the name of the generated function. Defaults to the name derived from the method and path.
+	Name string
+	// ReturnType is the TypeScript type the generated function resolves to. Defaults to the type derived from the response type.
+	ReturnType string
+	// OmitParams are the names of the request fields which are not exposed as parameters of the generated function.
+	OmitParams []string
+}
+
+// ClientOverride overrides the defaults of the TypeScript client code generation for this route.
+// Unknown parameter names in the override make the generation fail.
+func (r *Route) ClientOverride(o ClientOverride) *Route {
+	r.clientOverride = &o
+	return r
+}
+
+// omitsClientParam checks if the request field with the given name is omitted from the generated function.
+func (r *Route) omitsClientParam(name string) bool {
+	return r.clientOverride != nil && containsString(r.clientOverride.OmitParams, name)
+}
+
+// overridesClientReturnType checks if the return type of the generated function is overridden.
+func (r *Route) overridesClientReturnType() bool {
+	return r.clientOverride != nil && r.clientOverride.ReturnType != ""
+}
+
+// applyClientOverride validates the client override of the given route and reports it in the generation warnings,
+// so overrides do not silently rot when the route changes. Panics if the override references unknown parameters.
+func (tb *tsCodeBuilder) applyClientOverride(route *Route) {
+	o := route.clientOverride
+	if o == nil {
+		return
+	}
+
+	for _, param := range o.OmitParams {
+		if route.plan == nil || route.plan.clientParam(param) == nil {
+			panic(fmt.Sprintf("octanox: client override of %s %s omits unknown parameter %q", route.method, route.path, param))
+		}
+	}
+
+	details := make([]string, 0, 3)
+	if o.Name != "" {
+		details = append(details, "name="+o.Name)
+	}
+	if o.ReturnType != "" {
+		details = append(details, "returnType="+o.ReturnType)
+	}
+	if len(o.OmitParams) > 0 {
+		details = append(details, "omitParams="+strings.Join(o.OmitParams, ","))
+	}
+
+	tb.warnings = append(tb.warnings, fmt.Sprintf("%s %s uses a client override (%s)", route.method, route.path, strings.Join(details, ", ")))
+}
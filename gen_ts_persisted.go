@@ -0,0 +1,67 @@
+package octanox
+
+// usesPersistedQueries checks if any of the given routes uses persisted queries.
+func usesPersistedQueries(routes []*Route) bool {
+	for _, route := range routes {
+		if route.persistedQuery {
+			return true
+		}
+	}
+
+	return false
+}
+
+// generatePersistedDeclarations generates the canonicalQuery and sha256Hex functions hashing the queries of the routes with
+// persisted queries.
+func (tb *tsCodeBuilder) generatePersistedDeclarations() {
+	tb.writeLines(
+		"// canonicalQuery sorts the parameters of the given query by their name, so the same parameters are persisted under the",
+		"// same hash. The sort is stable, so the values of repeated parameters keep their order.",
+		"function canonicalQuery(query: string): string {",
+		"  const name = (param: string) => param.split('=')[0]",
+		"  return query.split('&').filter((param) => param !== '').sort((a, b) => name(a) < name(b) ? -1 : name(a) > name(b) ? 1 : 0).join('&')",
+		"}",
+		"",
+		"async function sha256Hex(value: string): Promise<string> {",
+		"  const digest = await crypto.subtle.digest('SHA-256', new TextEncoder().encode(value))",
+		"  return Array.from(new Uint8Array(digest), (b) => b.toString(16).padStart(2, '0')).join('')",
+		"}",
+		"",
+	)
+}
+
+// generateFetchPersisted generates the fetchPersisted function sending the requests of the routes with persisted queries by
+// the hash of their query, registering the query if the server does not know the hash yet. It takes the same parameters as
+// fetchJson.
+func (tb *tsCodeBuilder) generateFetchPersisted() {
+	params, args := "url: string, init?: RequestInit, onResponse?: (response: Response) => void", "init, onResponse"
+	if tb.mapsWire() {
+		params, args = "url: string, init?: RequestInit, fromWire?: (w: any) => T, onResponse?: (response: Response) => void", "init, fromWire, onResponse"
+	}
+	if tb.mixedAuth() {
+		params, args = params+tb.baseConfigParam(), args+", base"
+	}
+
+	fetchJSON := tb.ref("fetchJson")
+	tb.writeLines(
+		tb.helperDecl("async fetchPersisted<T>("+params+"): Promise<T> {"),
+		"  const index = url.indexOf('?')",
+		"  if (index < 0) {",
+		"    return "+fetchJSON+"<T>(url, "+args+")",
+		"  }",
+		"  const query = canonicalQuery(url.slice(index + 1))",
+		"  const hash = await sha256Hex(query)",
+		"  const persisted = `${url.slice(0, index)}?"+persistedQueryParam+"=${hash}`",
+		"  try {",
+		"    return await "+fetchJSON+"<T>(persisted, "+args+")",
+		"  } catch (e) {",
+		"    if (!(e instanceof ApiError) || e.problem.code !== '"+ErrorCodePersistedQueryNotFound+"') {",
+		"      throw e",
+		"    }",
+		"  }",
+		"  await "+fetchJSON+"<unknown>('"+persistedQueriesPath+"', { method: 'POST', signal: init?.signal, headers: { 'Content-Type': 'application/json', 'Accept': 'application/json' }, body: JSON.stringify({ hash, query }) })",
+		"  return "+fetchJSON+"<T>(persisted, "+args+")",
+		"}",
+		"",
+	)
+}
@@ -0,0 +1,89 @@
+package octanox
+
+// pkceVerifierStorageKey and pkceStateStorageKey are the sessionStorage keys the generated client keeps the code verifier
+// and the state of a started PKCE flow under until the flow is completed.
+const (
+	pkceVerifierStorageKey = "nox.pkceVerifier"
+	pkceStateStorageKey    = "nox.pkceState"
+)
+
+// generatePKCEFlow generates the startPKCEFlow and completePKCEFlow functions obtaining a token with the OAuth2 authorization
+// code flow with PKCE, if the server authenticates with OAuth2 bearer tokens. The verifier never leaves the sessionStorage
+// of the tab, and the state of the redirect is checked against the state of the started flow. Outside of the class style,
+// the obtained access token is stored as the token the requests are authenticated with.
+func (tb *tsCodeBuilder) generatePKCEFlow() {
+	if !tb.oauth2 {
+		return
+	}
+
+	tb.writeLines(
+		"export interface PKCETokenResponse {",
+		"  access_token: string",
+		"  token_type: string",
+		"  expires_in?: number",
+		"  refresh_token?: string",
+		"  scope?: string",
+		"  id_token?: string",
+		"}",
+		"",
+		"function base64Url(bytes: Uint8Array): string {",
+		"  return btoa(String.fromCharCode(...bytes)).replace(/\\+/g, '-').replace(/\\//g, '_').replace(/=+$/, '')",
+		"}",
+		"",
+		"// startPKCEFlow redirects to the authorization endpoint with the challenge of a new code verifier. The redirect URI",
+		"// completes the flow with completePKCEFlow.",
+		"export async function startPKCEFlow(authorizationUrl: string, clientId: string, redirectUri: string, scopes: string[]): Promise<void> {",
+		"  const verifier = base64Url(crypto.getRandomValues(new Uint8Array(32)))",
+		"  const state = base64Url(crypto.getRandomValues(new Uint8Array(16)))",
+		"  const challenge = base64Url(new Uint8Array(await crypto.subtle.digest('SHA-256', new TextEncoder().encode(verifier))))",
+		"  sessionStorage.setItem('"+pkceVerifierStorageKey+"', verifier)",
+		"  sessionStorage.setItem('"+pkceStateStorageKey+"', state)",
+		"  const url = new URL(authorizationUrl)",
+		"  url.searchParams.set('response_type', 'code')",
+		"  url.searchParams.set('client_id', clientId)",
+		"  url.searchParams.set('redirect_uri', redirectUri)",
+		"  url.searchParams.set('scope', scopes.join(' '))",
+		"  url.searchParams.set('code_challenge', challenge)",
+		"  url.searchParams.set('code_challenge_method', 'S256')",
+		"  url.searchParams.set('state', state)",
+		"  window.location.assign(url.toString())",
+		"}",
+		"",
+		"// completePKCEFlow exchanges the authorization code of the current URL for a token, proving the code verifier of the",
+		"// flow started by startPKCEFlow.",
+		"export async function completePKCEFlow(tokenEndpointUrl: string, clientId: string, redirectUri: string): Promise<PKCETokenResponse> {",
+		"  const params = new URLSearchParams(window.location.search)",
+		"  const verifier = sessionStorage.getItem('"+pkceVerifierStorageKey+"')",
+		"  const state = sessionStorage.getItem('"+pkceStateStorageKey+"')",
+		"  sessionStorage.removeItem('"+pkceVerifierStorageKey+"')",
+		"  sessionStorage.removeItem('"+pkceStateStorageKey+"')",
+		"  const error = params.get('error')",
+		"  if (error) {",
+		"    throw new Error(`OAuth2 authorization failed: ${params.get('error_description') ?? error}`)",
+		"  }",
+		"  const code = params.get('code')",
+		"  if (!code || !verifier) {",
+		"    throw new Error('No PKCE flow to complete')",
+		"  }",
+		"  if (params.get('state') !== state) {",
+		"    throw new Error('The state of the OAuth2 redirect does not match the started PKCE flow')",
+		"  }",
+		"  const response = await fetch(tokenEndpointUrl, {",
+		"    method: 'POST',",
+		"    headers: { 'Content-Type': 'application/x-www-form-urlencoded', 'Accept': 'application/json' },",
+		"    body: new URLSearchParams({ grant_type: 'authorization_code', code, redirect_uri: redirectUri, client_id: clientId, code_verifier: verifier }),",
+		"  })",
+		"  if (!response.ok) {",
+		"    throw await ApiError.from(tokenEndpointUrl, response)",
+		"  }",
+		"  const token: PKCETokenResponse = await response.json()",
+	)
+	if !tb.classStyle() {
+		tb.writeLine("  localStorage.setItem('token', token.access_token)")
+	}
+	tb.writeLines(
+		"  return token",
+		"}",
+		"",
+	)
+}
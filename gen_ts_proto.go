@@ -0,0 +1,239 @@
+package octanox
+
+import (
+	"reflect"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// protoWellKnownTypes maps the well-known protobuf types to their protojson TypeScript representation.
+var protoWellKnownTypes = map[protoreflect.FullName]string{
+	"google.protobuf.Timestamp":   "string",
+	"google.protobuf.Duration":    "string",
+	"google.protobuf.FieldMask":   "string",
+	"google.protobuf.Struct":      "Record<string, any>",
+	"google.protobuf.Value":       "any",
+	"google.protobuf.ListValue":   "Array<any>",
+	"google.protobuf.Empty":       "Record<string, never>",
+	"google.protobuf.Any":         "{ '@type': string; [key: string]: any }",
+	"google.protobuf.DoubleValue": "number | null",
+	"google.protobuf.FloatValue":  "number | null",
+	"google.protobuf.Int32Value":  "number | null",
+	"google.protobuf.UInt32Value": "number | null",
+	"google.protobuf.Int64Value":  "string | null",
+	"google.protobuf.UInt64Value": "string | null",
+	"google.protobuf.BoolValue":   "boolean | null",
+	"google.protobuf.StringValue": "string | null",
+	"google.protobuf.BytesValue":  "string | null",
+}
+
+// protoDescriptor returns the message descriptor of the given protobuf message type.
+func protoDescriptor(t reflect.Type) protoreflect.MessageDescriptor {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return reflect.New(t).Interface().(proto.Message).ProtoReflect().Descriptor()
+}
+
+// protoTypeName returns the TypeScript name of the given protobuf message or enum, matching the name of the generated Go type.
+func protoTypeName(desc protoreflect.Descriptor) string {
+	name := strings.TrimPrefix(string(desc.FullName()), string(desc.ParentFile().Package())+".")
+	return strings.ReplaceAll(name, ".", "_")
+}
+
+// generateProtoTypes generates the TypeScript declarations for every protobuf message used as request body or response by the routes.
+func (tb *tsCodeBuilder) generateProtoTypes(routes []*Route) {
+	for _, route := range routes {
+		if route.requestType != nil {
+			if bf := route.plan.bodyField(); bf != nil && isProtoMessage(bf.field.Type) {
+				tb.generateProtoMessage(protoDescriptor(bf.field.Type))
+			}
+		}
+
+		if route.responseType != nil && isProtoMessage(route.responseType) {
+			tb.generateProtoMessage(protoDescriptor(route.responseType))
+		}
+	}
+}
+
+// generateProtoMessage generates the TypeScript declaration of the given message and all messages and enums it references.
+// Real oneofs are emitted as discriminated unions, so at most one of their fields can be set.
+func (tb *tsCodeBuilder) generateProtoMessage(desc protoreflect.MessageDescriptor) {
+	if _, ok := protoWellKnownTypes[desc.FullName()]; ok || tb.protoSeen[desc.FullName()] {
+		return
+	}
+
+	tb.protoSeen[desc.FullName()] = true
+	deps := make([]protoreflect.Descriptor, 0)
+
+	fields := desc.Fields()
+	props := make([]string, 0, fields.Len())
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if oneof := fd.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() {
+			continue
+		}
+
+		optional := !tb.proto.EmitUnpopulated || fd.HasOptionalKeyword()
+		props = append(props, tb.protoProperty(fd, optional, &deps))
+	}
+
+	unions := make([]string, 0)
+	oneofs := desc.Oneofs()
+	for i := 0; i < oneofs.Len(); i++ {
+		oneof := oneofs.Get(i)
+		if oneof.IsSynthetic() {
+			continue
+		}
+
+		unions = append(unions, tb.protoOneofUnion(oneof, &deps))
+	}
+
+	if len(unions) == 0 {
+		tb.writeLine("export interface " + protoTypeName(desc) + " {")
+		tb.indent()
+		for _, prop := range props {
+			tb.writeLine(prop)
+		}
+		tb.unindent()
+		tb.writeLine("}")
+	} else {
+		tb.writeLine("export type " + protoTypeName(desc) + " = {")
+		tb.indent()
+		for _, prop := range props {
+			tb.writeLine(prop)
+		}
+		tb.unindent()
+		tb.writeLine("} & " + strings.Join(unions, " & "))
+	}
+	tb.writeLine("")
+
+	for _, dep := range deps {
+		switch d := dep.(type) {
+		case protoreflect.MessageDescriptor:
+			tb.generateProtoMessage(d)
+		case protoreflect.EnumDescriptor:
+			tb.generateProtoEnum(d)
+		}
+	}
+}
+
+// generateProtoEnum generates the TypeScript union type of the names of the given enum.
+func (tb *tsCodeBuilder) generateProtoEnum(desc protoreflect.EnumDescriptor) {
+	if tb.protoSeen[desc.FullName()] {
+		return
+	}
+
+	tb.protoSeen[desc.FullName()] = true
+
+	values := desc.Values()
+	names := make([]string, 0, values.Len())
+	for i := 0; i < values.Len(); i++ {
+		names = append(names, "'"+string(values.Get(i).Name())+"'")
+	}
+
+	tb.writeLine("export type " + protoTypeName(desc) + " = " + strings.Join(names, " | "))
+	tb.writeLine("")
+}
+
+// protoOneofUnion returns the discriminated union allowing exactly one or none of the fields of the given oneof to be set.
+func (tb *tsCodeBuilder) protoOneofUnion(oneof protoreflect.OneofDescriptor, deps *[]protoreflect.Descriptor) string {
+	fields := oneof.Fields()
+	variants := make([]string, 0, fields.Len()+1)
+
+	for i := 0; i < fields.Len(); i++ {
+		members := make([]string, 0, fields.Len())
+		for j := 0; j < fields.Len(); j++ {
+			if i == j {
+				members = append(members, tb.protoProperty(fields.Get(j), false, deps))
+			} else {
+				members = append(members, tb.protoJSONName(fields.Get(j))+"?: never;")
+			}
+		}
+
+		variants = append(variants, "{ "+strings.Join(members, " ")+" }")
+	}
+
+	none := make([]string, 0, fields.Len())
+	for i := 0; i < fields.Len(); i++ {
+		none = append(none, tb.protoJSONName(fields.Get(i))+"?: never;")
+	}
+	variants = append(variants, "{ "+strings.Join(none, " ")+" }")
+
+	return "(" + strings.Join(variants, " | ") + ")"
+}
+
+// protoProperty returns the TypeScript property declaration of the given field.
+func (tb *tsCodeBuilder) protoProperty(fd protoreflect.FieldDescriptor, optional bool, deps *[]protoreflect.Descriptor) string {
+	name := tb.protoJSONName(fd)
+	if optional {
+		name += "?"
+	}
+
+	typ := tb.protoFieldType(fd, deps)
+	if tb.proto.EmitUnpopulated && fd.Message() != nil && !fd.IsList() && !fd.IsMap() && !strings.HasSuffix(typ, " | null") {
+		typ += " | null"
+	}
+
+	return name + ": " + typ + ";"
+}
+
+// protoJSONName returns the JSON name of the given field as emitted by protojson.
+func (tb *tsCodeBuilder) protoJSONName(fd protoreflect.FieldDescriptor) string {
+	if tb.proto.UseProtoNames {
+		return string(fd.Name())
+	}
+
+	return fd.JSONName()
+}
+
+// protoFieldType returns the TypeScript type of the given field, collecting all referenced messages and enums into deps.
+func (tb *tsCodeBuilder) protoFieldType(fd protoreflect.FieldDescriptor, deps *[]protoreflect.Descriptor) string {
+	if fd.IsMap() {
+		return "Record<string, " + tb.protoSingularType(fd.MapValue(), deps) + ">"
+	}
+
+	if fd.IsList() {
+		return "Array<" + tb.protoSingularType(fd, deps) + ">"
+	}
+
+	return tb.protoSingularType(fd, deps)
+}
+
+// protoSingularType returns the TypeScript type of a single value of the given field.
+func (tb *tsCodeBuilder) protoSingularType(fd protoreflect.FieldDescriptor, deps *[]protoreflect.Descriptor) string {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return "boolean"
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return "string"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind, protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.FloatKind, protoreflect.DoubleKind:
+		return "number"
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// protojson encodes 64-bit integers as strings to avoid precision loss
+		return "string"
+	case protoreflect.EnumKind:
+		if fd.Enum().FullName() == "google.protobuf.NullValue" {
+			return "null"
+		}
+
+		if tb.proto.UseEnumNumbers {
+			return "number"
+		}
+
+		*deps = append(*deps, fd.Enum())
+		return protoTypeName(fd.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if wellKnown, ok := protoWellKnownTypes[fd.Message().FullName()]; ok {
+			return wellKnown
+		}
+
+		*deps = append(*deps, fd.Message())
+		return protoTypeName(fd.Message())
+	default:
+		return "any"
+	}
+}
@@ -0,0 +1,28 @@
+package octanox
+
+// generatesRaw checks if the Raw variant is generated for the given route, which is the case for every route except for
+// downloads if RawResponseFunctions is set.
+func (tb *tsCodeBuilder) generatesRaw(route *Route) bool {
+	return tb.opts.RawResponseFunctions && route.responseType != downloadType
+}
+
+// generateRawFunction generates the Raw variant of the function of the given route, which resolves to the data, the status
+// and the headers of the response. The data is null if the response has no body, e.g. for 204 No Content.
+func (tb *tsCodeBuilder) generateRawFunction(route *Route) {
+	tb.writeIndented(tb.routeDecl(route, "async "+tb.generateFunctionName(route)+"Raw("))
+	tb.generateFunctionParameters(route)
+
+	tb.write("): Promise<{ data: ")
+	tb.writeResponseType(route)
+	tb.writeLineNoIdent(", status: number, headers: Headers }> {")
+
+	tb.indent()
+	tb.generateRequestSetup(route)
+
+	tb.writeLine("let response!: Response")
+	tb.writeIndented("const data = await ")
+	tb.generateFetchJSONCall(route, "(r) => { response = r }")
+	tb.writeLine("return { data, status: response.status, headers: response.headers }")
+	tb.unindent()
+	tb.writeLine("}")
+}
@@ -0,0 +1,116 @@
+package octanox
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// reactQueryHooksCode returns the React Query hooks of the given routes, wrapping the functions of the TypeScript client
+// imported from the given module. GET routes get a useQuery hook and all other routes a useMutation hook, accepting the same
+// parameters as the function they wrap. The queries pass the signal React Query aborts them with, e.g. once they are no
+// longer used, in the request options of the function.
+func (i *Instance) reactQueryHooksCode(routes []*Route, clientModule string) string {
+	routes = clientRoutes(routes, os.Getenv("NOX__CLIENT_VERSION"))
+	tb := &tsCodeBuilder{opts: i.tsGenOptions}
+
+	tb.writeLines(
+		"// This file is generated by Octanox. Do not edit this file manually.",
+		"",
+		"import { useMutation, useQuery } from '@tanstack/react-query'",
+		"import type { UseMutationResult, UseQueryResult } from '@tanstack/react-query'",
+		"import * as api from '"+clientModule+"'",
+		"",
+	)
+
+	if usesQueryHooks(routes) {
+		tb.writeLines(
+			"// withSignal returns the given parameters of a function with the request options at the given index, with the signal of",
+			"// the query added to the options. A signal passed in the options by the caller is kept.",
+			"function withSignal<P extends unknown[]>(params: P, index: number, signal: AbortSignal): P {",
+			"  const args: unknown[] = params.slice(0, index)",
+			"  args.length = index",
+			"  const options = params[index] as api.RequestOptions | undefined",
+			"  return [...args, { ...options, signal: options?.signal ?? signal }] as P",
+			"}",
+			"",
+		)
+	}
+
+	for _, route := range routes {
+		tb.generateReactQueryHook(route)
+		tb.writeLine("")
+	}
+
+	tb.writeLines("// end of generated code")
+	return tb.sb.String()
+}
+
+// generateReactQueryHook generates the hook of the given route. Queries are keyed by the name of the function and its
+// parameters. In the class style the hooks take the ApiClient to call as their first parameter.
+func (tb *tsCodeBuilder) generateReactQueryHook(route *Route) {
+	name, ref := tb.generateFunctionName(route), tb.clientFunctionRef(route, "")
+	fn, fnType, client := "api."+ref, "typeof api."+ref, ""
+	if tb.classStyle() {
+		fn, fnType, client = "client."+ref, "api.ApiClient['"+strings.ReplaceAll(ref, ".", "']['")+"']", "client: api.ApiClient, "
+	}
+	data := "Awaited<ReturnType<" + fnType + ">>"
+
+	apiError := "api.ApiError"
+	if route.errorType != nil {
+		apiError = "api.ApiError<api." + route.errorType.Name() + ">"
+	}
+
+	if route.method == http.MethodGet {
+		tb.writeLines(
+			"export function "+reactQueryHookName(name)+"("+client+"...params: Parameters<"+fnType+">): UseQueryResult<"+data+", "+apiError+"> {",
+			"  return useQuery({ queryKey: ['"+name+"', ...params], queryFn: ({ signal }) => "+fn+"(...withSignal(params, "+strconv.Itoa(route.requestOptionsIndex())+", signal)) })",
+			"}",
+		)
+		return
+	}
+
+	tb.writeLines(
+		"export function "+reactQueryHookName(name)+"("+client+"...params: Parameters<"+fnType+">): UseMutationResult<"+data+", "+apiError+"> {",
+		"  return useMutation({ mutationFn: () => "+fn+"(...params) })",
+		"}",
+	)
+}
+
+// usesQueryHooks checks if any of the given routes gets a useQuery hook.
+func usesQueryHooks(routes []*Route) bool {
+	for _, route := range routes {
+		if route.method == http.MethodGet {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reactQueryHookName returns the name of the hook wrapping the function with the given name, e.g. useGetUsersId for
+// get_users_id or getUsersId.
+func reactQueryHookName(function string) string {
+	name := []rune(toCamelCase(function))
+	name[0] = unicode.ToUpper(name[0])
+	return "use" + string(name)
+}
+
+// relativeClientModule returns the module a file written to the given path, like the hooks, imports the client written to
+// the given path from, relative to the file, e.g. ./client.
+func relativeClientModule(path, clientPath string) string {
+	module, err := filepath.Rel(filepath.Dir(path), clientPath)
+	if err != nil {
+		module = clientPath
+	}
+
+	module = filepath.ToSlash(strings.TrimSuffix(module, filepath.Ext(module)))
+	if !strings.HasPrefix(module, ".") && !strings.HasPrefix(module, "/") {
+		module = "./" + module
+	}
+
+	return module
+}
@@ -0,0 +1,40 @@
+package octanox
+
+import "reflect"
+
+// generateRouteStatsDeclarations generates the route stats DTOs and their wire mappers.
+func (tb *tsCodeBuilder) generateRouteStatsDeclarations() {
+	tb.generateStructInterface(reflect.TypeOf(RouteStats{}))
+	tb.generateStructInterface(reflect.TypeOf(RouteStatsReport{}))
+
+	if tb.mapsWire() {
+		tb.generateWireMapper(reflect.TypeOf(RouteStats{}))
+		tb.writeLine("")
+		tb.generateWireMapper(reflect.TypeOf(RouteStatsReport{}))
+		tb.writeLine("")
+	}
+}
+
+// generateRouteStatsClient generates the getRouteStats function fetching the route stats from the internal endpoint.
+func (tb *tsCodeBuilder) generateRouteStatsClient() {
+	reportType := reflect.TypeOf(RouteStatsReport{})
+
+	tb.writeLines(
+		tb.exportedDecl("async getRouteStats(token?: string): Promise<RouteStatsReport> {"),
+		"  const headers: Record<string, string> = {}",
+		"  if (token) {",
+		"    headers['X-Nox-Token'] = token",
+		"  }",
+	)
+
+	if tb.mapsWire() {
+		tb.writeLine("  return " + tb.ref("fetchJson") + "<RouteStatsReport>('" + internalBasePath + "/route-stats', { method: 'GET', headers }, " + wireMapperName(reportType, false) + ")")
+	} else {
+		tb.writeLine("  return " + tb.ref("fetchJson") + "<RouteStatsReport>('" + internalBasePath + "/route-stats', { method: 'GET', headers })")
+	}
+
+	tb.writeLines(
+		"}",
+		"",
+	)
+}
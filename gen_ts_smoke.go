@@ -0,0 +1,451 @@
+package octanox
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// smokeTestCode returns the smoke test of the GET routes of the given routes, calling them through the TypeScript client
+// imported from the given module.
+func (i *Instance) smokeTestCode(routes []*Route, clientModule string) (string, error) {
+	routes = clientRoutes(routes, os.Getenv("NOX__CLIENT_VERSION"))
+	tb := &tsCodeBuilder{opts: i.tsGenOptions, tenant: i.clientTenantExtractor()}
+	b := &openAPIBuilder{instance: i, names: tb, schemas: make(map[string]*openAPISchema)}
+
+	var method AuthenticationMethod = -1
+	if i.Authenticator != nil {
+		method = i.Authenticator.Method()
+	}
+
+	tb.writeLines(
+		"// This file is generated by Octanox. Do not edit this file manually.",
+		"//",
+		"// This file is a smoke test of the Octanox server. It calls every GET route through the generated client with example",
+		"// parameters and validates the JSON responses against their schemas. Run it with Node 18 or later, e.g. npx tsx <file>.",
+		"// It exits with 1 if any route failed.",
+		"//",
+		"// It is configured with the following environment variables:",
+		"//   NOX__SMOKE_BASE_URL    the base URL of the server, e.g. https://api.example.com, required",
+	)
+
+	switch method {
+	case AuthenticationMethodBearer, AuthenticationMethodBearerOAuth2:
+		tb.writeLine("//   NOX__SMOKE_TOKEN       the bearer token authenticated routes are called with")
+	case AuthenticationMethodApiKey:
+		tb.writeLine("//   NOX__SMOKE_API_KEY     the API key authenticated routes are called with")
+	case AuthenticationMethodBasic:
+		tb.writeLine("//   NOX__SMOKE_USERNAME    the username authenticated routes are called with")
+		tb.writeLine("//   NOX__SMOKE_PASSWORD    the password authenticated routes are called with")
+	}
+	if tb.tenant != nil {
+		tb.writeLine("//   NOX__SMOKE_TENANT      the tenant the routes are called for")
+	}
+
+	tb.writeLines(
+		"//   NOX__SMOKE_OVERRIDES   the path of a JSON file overriding the example parameters by function and parameter name,",
+		"//                          e.g. {\"get_users_id\": {\"ID\": \"42\"}} for IDs which must exist",
+		"//   NOX__SMOKE_TIMEOUT_MS  the timeout of each request in milliseconds, defaults to 10000",
+		"//",
+		"// Authenticated routes are skipped if no credentials are given.",
+		"",
+		"import { readFile } from 'node:fs/promises'",
+		"import * as api from '"+clientModule+"'",
+		"",
+		"interface Schema {",
+		"  $ref?: string",
+		"  allOf?: Schema[]",
+		"  type?: string",
+		"  nullable?: boolean",
+		"  enum?: unknown[]",
+		"  items?: Schema",
+		"  properties?: Record<string, Schema>",
+		"  additionalProperties?: Schema",
+		"  required?: string[]",
+		"}",
+		"",
+		"interface SmokeRoute {",
+		"  name: string",
+		"  label: string",
+		"  authenticated: boolean",
+		"  params: Record<string, any>",
+		"  schema?: Schema",
+		"  call: (p: Record<string, any>, options: api.RequestOptions) => Promise<unknown>",
+		"}",
+		"",
+	)
+
+	if tb.classStyle() {
+		tb.writeLines(
+			"let client: api.ApiClient",
+			"",
+		)
+	}
+
+	tb.writeLines(
+		"const routes: SmokeRoute[] = [",
+	)
+
+	tb.indent()
+	for _, route := range routes {
+		if !smokeTested(route) {
+			continue
+		}
+
+		if err := i.generateSmokeRoute(tb, b, route); err != nil {
+			return "", err
+		}
+	}
+	tb.unindent()
+	tb.writeLines(
+		"]",
+		"",
+	)
+
+	schemas, err := json.MarshalIndent(b.schemas, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	tb.writeLines(
+		"const schemas: Record<string, Schema> = "+string(schemas),
+		"",
+		"// validate returns the first violation of the given schema by the given value, or undefined if it conforms. Properties",
+		"// which are not required may be missing or null, like fields omitted when empty or hidden from the user.",
+		"function validate(value: unknown, schema: Schema, path: string): string | undefined {",
+		"  if (value === null || value === undefined) {",
+		"    const untyped = !schema.type && !schema.$ref && !schema.allOf && !schema.enum",
+		"    return schema.nullable || untyped ? undefined : `${path} is null`",
+		"  }",
+		"  if (schema.$ref) {",
+		"    return validate(value, schemas[schema.$ref.slice('#/components/schemas/'.length)], path)",
+		"  }",
+		"  for (const part of schema.allOf ?? []) {",
+		"    const violation = validate(value, part, path)",
+		"    if (violation) {",
+		"      return violation",
+		"    }",
+		"  }",
+		"  if (schema.enum && !schema.enum.includes(value)) {",
+		"    return `${path} is ${JSON.stringify(value)}, expected one of ${JSON.stringify(schema.enum)}`",
+		"  }",
+		"  switch (schema.type) {",
+		"    case 'string':",
+		"    case 'boolean':",
+		"      return typeof value === schema.type ? undefined : `${path} is ${typeof value}, expected ${schema.type}`",
+		"    case 'integer':",
+		"      return Number.isInteger(value) ? undefined : `${path} is ${JSON.stringify(value)}, expected an integer`",
+		"    case 'number':",
+		"      return typeof value === 'number' ? undefined : `${path} is ${typeof value}, expected number`",
+		"    case 'array':",
+		"      if (!Array.isArray(value)) {",
+		"        return `${path} is ${typeof value}, expected array`",
+		"      }",
+		"      for (let n = 0; n < value.length; n++) {",
+		"        const violation = schema.items ? validate(value[n], schema.items, `${path}[${n}]`) : undefined",
+		"        if (violation) {",
+		"          return violation",
+		"        }",
+		"      }",
+		"      return undefined",
+		"    case 'object': {",
+		"      if (typeof value !== 'object' || Array.isArray(value)) {",
+		"        return `${path} is ${Array.isArray(value) ? 'array' : typeof value}, expected object`",
+		"      }",
+		"      const object = value as Record<string, unknown>",
+		"      for (const name of schema.required ?? []) {",
+		"        if (!(name in object)) {",
+		"          return `${path}.${name} is missing`",
+		"        }",
+		"      }",
+		"      for (const [name, property] of Object.entries(object)) {",
+		"        const propertySchema = schema.properties?.[name] ?? schema.additionalProperties",
+		"        if (!propertySchema || property === null && !schema.required?.includes(name)) {",
+		"          continue",
+		"        }",
+		"        const violation = validate(property, propertySchema, `${path}.${name}`)",
+		"        if (violation) {",
+		"          return violation",
+		"        }",
+		"      }",
+		"      return undefined",
+		"    }",
+		"  }",
+		"  return undefined",
+		"}",
+		"",
+		"// validateResponse validates the JSON body of the given response against the given schema. Responses without a body,",
+		"// or with a body which is not JSON, e.g. MessagePack, are only checked for their status.",
+		"async function validateResponse(response: Response, schema: Schema): Promise<string | undefined> {",
+		"  if (response.status === 204 || !(response.headers.get('Content-Type') ?? '').includes('json')) {",
+		"    return undefined",
+		"  }",
+		"  return validate(await response.json(), schema, '$')",
+		"}",
+		"",
+		"async function main(): Promise<number> {",
+		"  const env = process.env",
+		"  if (!env.NOX__SMOKE_BASE_URL) {",
+		"    console.error('NOX__SMOKE_BASE_URL is not set')",
+		"    return 2",
+		"  }",
+	)
+
+	if tb.classStyle() {
+		tb.generateSmokeClient(method)
+	} else {
+		tb.generateSmokeFunctionsSetup(method)
+	}
+
+	tb.writeLines(
+		"  const overrides: Record<string, Record<string, any>> = env.NOX__SMOKE_OVERRIDES ? JSON.parse(await readFile(env.NOX__SMOKE_OVERRIDES, 'utf8')) : {}",
+		"  const timeout = Number(env.NOX__SMOKE_TIMEOUT_MS ?? 10000)",
+		"",
+		"  // the responses are captured before the client maps them, so they are validated as sent by the server",
+		"  let response: Response | undefined",
+		"  const fetch = globalThis.fetch",
+		"  globalThis.fetch = async (input, init) => {",
+		"    const r = await fetch(input, init)",
+		"    response = r.clone()",
+		"    return r",
+		"  }",
+		"",
+		"  let passed = 0, failed = 0, skipped = 0",
+		"  for (const route of routes) {",
+		"    if (route.authenticated && !credentials) {",
+		"      skipped++",
+		"      console.log(`SKIP ${route.label}: no credentials given`)",
+		"      continue",
+		"    }",
+		"    response = undefined",
+		"    const started = Date.now()",
+		"    try {",
+		"      await route.call({ ...route.params, ...overrides[route.name] }, { signal: AbortSignal.timeout(timeout) })",
+		"      const violation = route.schema && response ? await validateResponse(response, route.schema) : undefined",
+		"      if (violation) {",
+		"        throw new Error(`response does not match its schema: ${violation}`)",
+		"      }",
+		"      passed++",
+		"      console.log(`PASS ${route.label} (${Date.now() - started} ms)`)",
+		"    } catch (e) {",
+		"      failed++",
+		"      console.log(`FAIL ${route.label} (${Date.now() - started} ms): ${e instanceof Error ? e.message : String(e)}`)",
+		"    }",
+		"  }",
+		"",
+		"  console.log(`${passed} passed, ${failed} failed, ${skipped} skipped`)",
+		"  return failed > 0 ? 1 : 0",
+		"}",
+		"",
+		"main().then((code) => process.exit(code), (e) => {",
+		"  console.error(e)",
+		"  process.exit(2)",
+		"})",
+	)
+
+	return tb.sb.String(), nil
+}
+
+// generateSmokeFunctionsSetup generates the configuration of the standalone functions of the client with the base URL, the
+// tenant and the credentials of the given authentication method.
+func (tb *tsCodeBuilder) generateSmokeFunctionsSetup(method AuthenticationMethod) {
+	tb.writeLine("  api.setBaseUrl(env.NOX__SMOKE_BASE_URL.replace(/\\/$/, ''))")
+	tb.generateSmokeTenant("api")
+
+	tb.writeLine("  let credentials = false")
+	switch method {
+	case AuthenticationMethodBearer, AuthenticationMethodBearerOAuth2, AuthenticationMethodApiKey:
+		env, key := "NOX__SMOKE_TOKEN", "token"
+		if method == AuthenticationMethodApiKey {
+			env, key = "NOX__SMOKE_API_KEY", "apiKey"
+		}
+
+		// the client reads the credentials from the localStorage, which Node does not have
+		tb.writeLines(
+			"  const storage = new Map<string, string>()",
+			"  const global = globalThis as any",
+			"  global.localStorage ??= {",
+			"    getItem: (key: string) => storage.get(key) ?? null,",
+			"    setItem: (key: string, value: string) => storage.set(key, value),",
+			"    removeItem: (key: string) => storage.delete(key),",
+			"  }",
+			"  if (env."+env+") {",
+			"    global.localStorage.setItem('"+key+"', env."+env+")",
+			"    credentials = true",
+			"  }",
+		)
+	case AuthenticationMethodBasic:
+		tb.writeLines(
+			"  if (env.NOX__SMOKE_USERNAME && env.NOX__SMOKE_PASSWORD) {",
+			"    api.setBasicCredentials(env.NOX__SMOKE_USERNAME, env.NOX__SMOKE_PASSWORD)",
+			"    credentials = true",
+			"  }",
+		)
+	}
+}
+
+// generateSmokeClient generates the construction of the ApiClient with the base URL, the tenant and an AuthProvider of the
+// credentials of the given authentication method.
+func (tb *tsCodeBuilder) generateSmokeClient(method AuthenticationMethod) {
+	tb.writeLines(
+		"  const headers: Record<string, string> = {}",
+		"  let credentials = false",
+	)
+
+	switch method {
+	case AuthenticationMethodBearer, AuthenticationMethodBearerOAuth2, AuthenticationMethodApiKey:
+		env, header := "NOX__SMOKE_TOKEN", "'Authorization'] = `Bearer ${env.NOX__SMOKE_TOKEN}`"
+		if method == AuthenticationMethodApiKey {
+			env, header = "NOX__SMOKE_API_KEY", "'X-API-Key'] = env.NOX__SMOKE_API_KEY"
+		}
+
+		tb.writeLines(
+			"  if (env."+env+") {",
+			"    headers["+header,
+			"    credentials = true",
+			"  }",
+		)
+	case AuthenticationMethodBasic:
+		tb.writeLines(
+			"  if (env.NOX__SMOKE_USERNAME && env.NOX__SMOKE_PASSWORD) {",
+			"    headers['Authorization'] = `Basic ${btoa(`${env.NOX__SMOKE_USERNAME}:${env.NOX__SMOKE_PASSWORD}`)}`",
+			"    credentials = true",
+			"  }",
+		)
+	}
+
+	tb.writeLine("  client = new api.ApiClient(env.NOX__SMOKE_BASE_URL.replace(/\\/$/, ''), { headers: () => headers })")
+	tb.generateSmokeTenant("client")
+}
+
+// generateSmokeTenant generates the setting of the tenant on the given target, if the client addresses tenants.
+func (tb *tsCodeBuilder) generateSmokeTenant(target string) {
+	if tb.tenant == nil {
+		return
+	}
+
+	tb.writeLines(
+		"  if (env.NOX__SMOKE_TENANT) {",
+		"    "+target+".setTenant(env.NOX__SMOKE_TENANT)",
+		"  }",
+	)
+}
+
+// generateSmokeRoute generates the entry of the given route in the routes of the smoke test, with the example values of its
+// parameters and the schema of its response.
+func (i *Instance) generateSmokeRoute(tb *tsCodeBuilder, b *openAPIBuilder, route *Route) error {
+	name := tb.generateFunctionName(route)
+	params := make(map[string]json.RawMessage)
+	args := make([]string, 0, len(route.plan.fields)+3)
+
+	for _, bf := range route.clientParams() {
+		example, err := i.smokeParamExample(bf)
+		if err != nil {
+			return fmt.Errorf("cannot synthesize parameter %s of %s %s: %w", bf.field.Name, route.method, route.path, err)
+		}
+
+		params[bf.field.Name] = example
+		args = append(args, tb.smokeArg(bf))
+	}
+
+	if embedsListQuery(route.requestType) {
+		args = append(args, "undefined")
+	}
+	if route.partial {
+		args = append(args, "undefined")
+	}
+	args = append(args, "options")
+
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	tb.writeLine("{")
+	tb.indent()
+	tb.writeLine("name: " + tsStringLiteral(name) + ",")
+	tb.writeLine("label: " + tsStringLiteral(route.method+" "+route.path) + ",")
+	tb.writeLine(fmt.Sprintf("authenticated: %t,", i.requiresAuthentication(route)))
+	tb.writeLine("params: " + string(encoded) + ",")
+
+	if route.hasResponseBody() && route.responseType != downloadType {
+		schema, err := json.Marshal(b.schema(route.responseType))
+		if err != nil {
+			return err
+		}
+
+		tb.writeLine("schema: " + string(schema) + ",")
+	}
+
+	target := "api."
+	if tb.classStyle() {
+		target = "client."
+	}
+	tb.writeLine("call: (p, options) => " + target + tb.clientFunctionRef(route, "") + "(" + strings.Join(args, ", ") + "),")
+	tb.unindent()
+	tb.writeLine("},")
+	return nil
+}
+
+// smokeParamExample returns the example value of the given parameter, encoded as JSON. Path, query and header parameters get
+// the example ServeExamples sends, slices of query parameters with a single value.
+func (i *Instance) smokeParamExample(bf *bindingField) (json.RawMessage, error) {
+	switch bf.source {
+	case sourceRawBody:
+		return json.Marshal("example")
+	case sourceBody:
+		return i.exampleJSON(bf.field.Type)
+	}
+
+	value, err := exampleParam(bf)
+	if err != nil {
+		return nil, err
+	}
+
+	t := bf.field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if bf.array != "" {
+		v, err := parseExample(t.Elem(), value)
+		if err != nil {
+			return nil, err
+		}
+
+		return json.Marshal([]any{v.Interface()})
+	}
+
+	v, err := parseExample(t, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(v.Interface())
+}
+
+// smokeArg returns the argument the smoke test passes to the function of a route for the given parameter, reading its
+// value from the parameters p. Times are passed as Date objects if the client is generated with them.
+func (tb *tsCodeBuilder) smokeArg(bf *bindingField) string {
+	arg := "p." + bf.field.Name
+	if !tb.opts.DateObjects {
+		return arg
+	}
+
+	t := bf.field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case isTimeType(t):
+		return "(" + arg + " == null ? " + arg + " : new Date(" + arg + "))"
+	case t.Kind() == reflect.Slice && isTimeType(t.Elem()):
+		return arg + ".map((value: string) => new Date(value))"
+	}
+
+	return arg
+}
@@ -0,0 +1,60 @@
+package octanox
+
+// generateTenantRuntimeFields generates the fields of the client runtime holding the tenant, if the tenant extractor needs any.
+func (tb *tsCodeBuilder) generateTenantRuntimeFields() {
+	switch tb.tenant.(type) {
+	case *PathPrefixTenantExtractor:
+		tb.writeLine("  tenantPrefix?: string")
+	case *HeaderTenantExtractor:
+		tb.writeLine("  tenant?: string")
+	}
+}
+
+// generateTenantSetter generates the setTenant function, addressing the tenant the same way the server's tenant extractor resolves it.
+func (tb *tsCodeBuilder) generateTenantSetter() {
+	switch extractor := tb.tenant.(type) {
+	case *SubdomainTenantExtractor:
+		tb.writeLines(
+			tb.exportedDecl("setTenant(id: string) {"),
+			"  const url = new URL("+tb.runtimeRef()+".baseUrl)",
+			"  url.hostname = `${id}."+extractor.BaseDomain+"`",
+			"  "+tb.runtimeRef()+".baseUrl = url.origin",
+			"}",
+			"",
+		)
+	case *PathPrefixTenantExtractor:
+		tb.writeLines(
+			tb.exportedDecl("setTenant(id: string) {"),
+			"  "+tb.runtimeRef()+".tenantPrefix = `"+extractor.Prefix+"/${encodeURIComponent(id)}`",
+			"}",
+			"",
+		)
+	case *HeaderTenantExtractor:
+		tb.writeLines(
+			tb.exportedDecl("setTenant(id: string) {"),
+			"  "+tb.runtimeRef()+".tenant = id",
+			"}",
+			"",
+		)
+	}
+}
+
+// generateTenantHeader generates the setting of the tenant header on the config of a fetch, if tenants are resolved from a header.
+func (tb *tsCodeBuilder) generateTenantHeader() {
+	if extractor, ok := tb.tenant.(*HeaderTenantExtractor); ok {
+		tb.writeLines(
+			"  if (rt.tenant) {",
+			"    headers['"+extractor.Header+"'] = rt.tenant",
+			"  }",
+		)
+	}
+}
+
+// fetchURL returns the TypeScript expression of the absolute URL the generated client fetches.
+func (tb *tsCodeBuilder) fetchURL() string {
+	if _, ok := tb.tenant.(*PathPrefixTenantExtractor); ok {
+		return "rt.baseUrl + (rt.tenantPrefix ?? '') + url"
+	}
+
+	return "rt.baseUrl + url"
+}
@@ -0,0 +1,332 @@
+package octanox
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+type tsGenItem struct {
+	ID        string   `json:"id"`
+	UserName  string   `json:"user_name"`
+	Tags      []string `json:"tags"`
+	CreatedAt string   `json:"created_at"`
+}
+
+type tsGenGetRequest struct {
+	GetRequest
+	ID     string `path:"id"`
+	Filter string `query:"filter"`
+}
+
+type tsGenPutRequest struct {
+	PutRequest
+	ID   string    `path:"id"`
+	Body tsGenItem `body:"true"`
+}
+
+type tsGenDownloadRequest struct {
+	GetRequest
+}
+
+// registerTSGenRoutes registers routes using most features of the generated client.
+func registerTSGenRoutes(i *Instance) {
+	i.Register("/items/:id", func(*tsGenGetRequest) *tsGenItem { return nil }).WithExistenceCheck().ClientGroup("items", "get")
+	i.Register("/items/:id", func(*tsGenPutRequest) *tsGenItem { return nil }).Idempotent().ClientGroup("items", "put")
+	i.Register("/export", func(*tsGenDownloadRequest) *Download { return nil })
+}
+
+// topLevelStatements splits the given TypeScript code into its top-level statements, skipping comments, strings, template
+// literals and regular expressions. Returns an error if the brackets are unbalanced or a literal is not terminated.
+func topLevelStatements(code string) ([]string, error) {
+	statements := make([]string, 0)
+	depth := 0
+	// templates are the depths at which the expressions of the open template literals end
+	templates := make([]int, 0)
+	start := 0
+	last := byte(0)
+
+	flush := func(end int) {
+		if statement := strings.TrimSpace(code[start:end]); statement != "" {
+			statements = append(statements, statement)
+		}
+		start = end
+	}
+
+	for n := 0; n < len(code); n++ {
+		ch := code[n]
+		switch {
+		case ch == '/' && n+1 < len(code) && code[n+1] == '/':
+			for n < len(code) && code[n] != '\n' {
+				n++
+			}
+			n--
+			continue
+		case ch == '/' && n+1 < len(code) && code[n+1] == '*':
+			end := strings.Index(code[n+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at %d", n)
+			}
+			n += end + 3
+			continue
+		case ch == '/' && (last == 0 || strings.IndexByte("(,=:[!&|?{};", last) >= 0):
+			inClass := false
+			for n++; n < len(code) && (code[n] != '/' || inClass); n++ {
+				switch code[n] {
+				case '\\':
+					n++
+				case '[':
+					inClass = true
+				case ']':
+					inClass = false
+				case '\n':
+					return nil, fmt.Errorf("unterminated regular expression at %d", n)
+				}
+			}
+		case ch == '\'' || ch == '"':
+			for n++; n < len(code) && code[n] != ch; n++ {
+				if code[n] == '\\' {
+					n++
+				} else if code[n] == '\n' {
+					return nil, fmt.Errorf("unterminated string at %d", n)
+				}
+			}
+		case ch == '`' || ch == '}' && len(templates) > 0 && templates[len(templates)-1] == depth:
+			if ch == '}' {
+				templates = templates[:len(templates)-1]
+			}
+			for n++; n < len(code) && code[n] != '`'; n++ {
+				if code[n] == '\\' {
+					n++
+				} else if code[n] == '$' && n+1 < len(code) && code[n+1] == '{' {
+					n++
+					break
+				}
+			}
+			if n >= len(code) {
+				return nil, fmt.Errorf("unterminated template literal")
+			}
+			if code[n] == '{' {
+				templates = append(templates, depth)
+				last = '{'
+				continue
+			}
+		case ch == '(' || ch == '[' || ch == '{':
+			depth++
+		case ch == ')' || ch == ']' || ch == '}':
+			if depth--; depth < 0 {
+				return nil, fmt.Errorf("unbalanced %c at %d", ch, n)
+			}
+		case ch == ';' && depth == 0:
+			flush(n + 1)
+		case ch == '\n' && depth == 0 && n+1 < len(code) && code[n+1] != ' ' && code[n+1] != '\n' && code[n+1] != '.':
+			flush(n + 1)
+		}
+
+		if ch != ' ' && ch != '\n' && ch != '\t' {
+			last = ch
+		}
+	}
+
+	if depth != 0 || len(templates) > 0 {
+		return nil, fmt.Errorf("unbalanced brackets at the end of the code")
+	}
+
+	flush(len(code))
+	return statements, nil
+}
+
+var (
+	// tsComments matches the comments of a statement.
+	tsComments = regexp.MustCompile(`(?s)//[^\n]*|/\*.*?\*/`)
+	// tsDeclaration matches the start of the top-level statements which declare something without side effects.
+	tsDeclaration = regexp.MustCompile(`^(import |export |interface |type |declare |function |async function |class |(const|let) )`)
+	// tsVariable matches a top-level variable declaration and its initializer, if it has one.
+	tsVariable = regexp.MustCompile(`(?s)^(?:export )?(?:const|let) [A-Za-z_$][\w$]*(?:: [^=]*)?(?:= (.*))?$`)
+	// tsPureInitializer matches the initializers which cannot have side effects.
+	tsPureInitializer = regexp.MustCompile(`(?s)^(\{|\[|'|"|\d|true|false|null|undefined|function|async |\([^()]*\) =>|/\* @__PURE__ \*/)`)
+)
+
+// checkTreeShakable checks that the given generated code only has top-level imports, exports and declarations.
+func checkTreeShakable(t *testing.T, name, code string) {
+	t.Helper()
+
+	if strings.Contains(code, "\t") {
+		t.Errorf("%s: contains a tab", name)
+	}
+
+	statements, err := topLevelStatements(code)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+
+	for _, statement := range statements {
+		if strings.HasPrefix(statement, "/*") || strings.HasPrefix(statement, "//") {
+			statement = strings.TrimSpace(tsComments.ReplaceAllString(statement, ""))
+		}
+		if statement == "" {
+			continue
+		}
+
+		head, _, _ := strings.Cut(statement, "\n")
+		if !tsDeclaration.MatchString(statement) {
+			t.Errorf("%s: top-level statement is no declaration: %s", name, head)
+			continue
+		}
+
+		if m := tsVariable.FindStringSubmatch(statement); m != nil && m[1] != "" && !tsPureInitializer.MatchString(m[1]) {
+			t.Errorf("%s: top-level variable has an initializer which may have side effects: %s", name, head)
+		}
+	}
+}
+
+func TestTopLevelStatements(t *testing.T) {
+	statements, err := topLevelStatements("import { a } from 'b'\n" +
+		"// comment with ( and `\n" +
+		"export function f(s: string) {\n  return `${s.replace(/[}]/g, '')}`\n}\n" +
+		"f('x');\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(statements) != 4 || statements[3] != "f('x');" {
+		t.Errorf("statements %q", statements)
+	}
+
+	if _, err := topLevelStatements("function f() {\n"); err == nil {
+		t.Error("expected an error for unbalanced brackets")
+	}
+}
+
+func TestGeneratedClientIsTreeShakable(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          TypeScriptGenerationOptions
+		authenticator Authenticator
+		tenants       bool
+	}{
+		{"default", TypeScriptGenerationOptions{}, nil, false},
+		{"bearer", TypeScriptGenerationOptions{}, headerAuthenticator{method: AuthenticationMethodBearer}, false},
+		{"basic", TypeScriptGenerationOptions{}, headerAuthenticator{method: AuthenticationMethodBasic}, false},
+		{"api key", TypeScriptGenerationOptions{}, headerAuthenticator{method: AuthenticationMethodApiKey}, false},
+		{"cookie", TypeScriptGenerationOptions{}, headerAuthenticator{method: AuthenticationMethodCookie}, true},
+		{"oauth2", TypeScriptGenerationOptions{TokenEndpointPath: "/auth/refresh"}, headerAuthenticator{method: AuthenticationMethodBearerOAuth2}, false},
+		{"wire mapping", TypeScriptGenerationOptions{CamelCaseProperties: true, DateObjects: true, RawResponseFunctions: true}, nil, true},
+		{"msgpack", TypeScriptGenerationOptions{MessagePack: true, InterceptorSupport: true}, headerAuthenticator{method: AuthenticationMethodBearer}, false},
+		{"class", TypeScriptGenerationOptions{ClientStyle: ClientStyleClass, GroupedExportsOnly: true}, headerAuthenticator{method: AuthenticationMethodBearer}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newTestInstance(t)
+			i.Authenticator = tt.authenticator
+			if tt.tenants {
+				i.Tenants(TenantFromHeader("X-Tenant"))
+			}
+			tt.opts.ReactQueryOutputPath = "hooks.ts"
+			i.SetTSGenOptions(tt.opts)
+			registerTSGenRoutes(i)
+
+			checkTreeShakable(t, "client", i.typeScriptClientCode(i.routes))
+			checkTreeShakable(t, "hooks", i.reactQueryHooksCode(i.routes, "./client"))
+		})
+	}
+}
+
+func TestGeneratedClientTypesHeaders(t *testing.T) {
+	for _, style := range []ClientStyle{ClientStyleFunctions, ClientStyleClass} {
+		i := newTestInstance(t)
+		i.Authenticator = headerAuthenticator{method: AuthenticationMethodBearer}
+		i.SetTSGenOptions(TypeScriptGenerationOptions{ClientStyle: style})
+		registerTSGenRoutes(i)
+
+		// HeadersInit cannot be indexed by name, so only the headers typed as Record<string, string> are
+		code := i.typeScriptClientCode(i.routes)
+		for _, untyped := range []string{"config.headers[", "baseConfig.headers[", "init.headers["} {
+			if strings.Contains(code, untyped) {
+				t.Errorf("style %d: indexes the untyped %s", style, strings.TrimSuffix(untyped, "["))
+			}
+		}
+	}
+}
+
+type tsGenCursor struct {
+	Next  string `json:"next"`
+	Total int    `json:"total"`
+}
+
+type tsGenPagination struct {
+	tsGenCursor
+	Page int `json:"page"`
+	// Total is shadowed by the shallower field of the response
+	Total  int `json:"total,omitempty"`
+	hidden int
+}
+
+type tsGenAudit struct {
+	CreatedBy string `json:"created_by"`
+}
+
+type tsGenPage struct {
+	tsGenPagination
+	*tsGenAudit
+	Items []tsGenItem `json:"items"`
+}
+
+type tsGenPageRequest struct {
+	GetRequest
+}
+
+func TestGeneratedInterfacesFlattenEmbeddedStructs(t *testing.T) {
+	i := newTestInstance(t)
+	i.Register("/pages", func(*tsGenPageRequest) *tsGenPage { return nil })
+
+	body := regexp.MustCompile(`(?s)export interface tsGenPage \{\n(.*?)\n\}`).FindStringSubmatch(i.typeScriptClientCode(i.routes))
+	if body == nil {
+		t.Fatal("client has no interface tsGenPage")
+	}
+
+	properties := make([]string, 0)
+	for _, line := range strings.Split(body[1], "\n") {
+		name, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+		properties = append(properties, strings.TrimSuffix(name, "?"))
+	}
+
+	// the encoded value has exactly the properties of the interface
+	data, err := json.Marshal(tsGenPage{tsGenPagination: tsGenPagination{Total: 1}, tsGenAudit: &tsGenAudit{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var encoded map[string]any
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(properties, ","); got != "next,page,total,created_by,items" {
+		t.Errorf("properties %s, want next,page,total,created_by,items", got)
+	}
+	if len(properties) != len(encoded) {
+		t.Errorf("properties %v, want the encoded %s", properties, data)
+	}
+	for _, property := range properties {
+		if _, ok := encoded[property]; !ok {
+			t.Errorf("property %s is not encoded in %s", property, data)
+		}
+	}
+}
+
+func TestWireMappersFlattenEmbeddedStructs(t *testing.T) {
+	i := newTestInstance(t)
+	i.SetTSGenOptions(TypeScriptGenerationOptions{CamelCaseProperties: true})
+	i.Register("/pages", func(*tsGenPageRequest) *tsGenPage { return nil })
+
+	code := i.typeScriptClientCode(i.routes)
+	for _, want := range []string{"'createdBy': ", "['created_by']", "'created_by': ", "['createdBy']"} {
+		if !strings.Contains(code, want) {
+			t.Errorf("wire mappers do not contain %s", want)
+		}
+	}
+}
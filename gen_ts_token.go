@@ -0,0 +1,76 @@
+package octanox
+
+// generateTokenRefreshRuntimeFields generates the fields of the client runtime holding the token refresh handler and the
+// refresh in flight, if the client refreshes tokens.
+func (tb *tsCodeBuilder) generateTokenRefreshRuntimeFields() {
+	if tb.refreshesTokens {
+		tb.writeLines(
+			"  tokenRefreshHandler?: () => Promise<string>",
+			"  tokenRefresh?: Promise<string | null>",
+		)
+	}
+}
+
+// generateTokenRefresh generates the setTokenRefreshHandler function and the refreshToken function, which shares a single
+// call of the handler between all requests rejected at the same time and stores the refreshed token. The refresh resolves
+// to null if there is no handler or it failed.
+func (tb *tsCodeBuilder) generateTokenRefresh() {
+	if !tb.refreshesTokens {
+		return
+	}
+
+	tb.writeLines(
+		"// setTokenRefreshHandler sets the handler fetching a new bearer token once a request is rejected with 401 Unauthorized.",
+		"// The rejected request is sent again once with the new token.",
+		"export function setTokenRefreshHandler(handler: () => Promise<string>) {",
+		"  runtime().tokenRefreshHandler = handler",
+		"}",
+		"",
+		"function refreshToken(): Promise<string | null> {",
+		"  const rt = runtime()",
+		"  if (!rt.tokenRefreshHandler) {",
+		"    return Promise.resolve(null)",
+		"  }",
+		"  if (!rt.tokenRefresh) {",
+		"    rt.tokenRefresh = rt.tokenRefreshHandler()",
+		"      .then((token) => {",
+		"        localStorage.setItem('token', token)",
+		"        return token",
+		"      })",
+		"      .catch(() => null)",
+		"      .finally(() => {",
+		"        rt.tokenRefresh = undefined",
+		"      })",
+		"  }",
+		"  return rt.tokenRefresh",
+		"}",
+		"",
+	)
+}
+
+// generateTokenRefreshRetry generates the retry of a request of fetchJson rejected with 401 Unauthorized with a refreshed
+// token. Requests to the token endpoint are not retried, so a handler calling it cannot wait for its own refresh.
+func (tb *tsCodeBuilder) generateTokenRefreshRetry() {
+	if !tb.refreshesTokens {
+		return
+	}
+
+	condition := "response.status === 401"
+	if tb.opts.TokenEndpointPath != "" {
+		condition += " && url.split('?')[0] !== " + tsStringLiteral(tb.opts.TokenEndpointPath)
+	}
+	if tb.mixedAuth() {
+		// only requests authenticated by the bearer token are retried
+		condition += " && headers['Authorization']?.startsWith('Bearer ')"
+	}
+
+	tb.writeLines(
+		"  if ("+condition+") {",
+		"    const token = await refreshToken()",
+		"    if (token) {",
+		"      headers['Authorization'] = `Bearer ${token}`",
+		"      response = await "+tb.fetchFunc()+"("+tb.fetchURL()+", config)",
+		"    }",
+		"  }",
+	)
+}
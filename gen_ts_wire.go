@@ -0,0 +1,218 @@
+package octanox
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// toCamelCase converts a snake_case name to camelCase. Names without underscores are returned untouched.
+func toCamelCase(name string) string {
+	if !strings.Contains(name, "_") {
+		return name
+	}
+
+	var sb strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+
+		if sb.Len() == 0 {
+			sb.WriteString(part)
+			continue
+		}
+
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		sb.WriteString(string(runes))
+	}
+
+	return sb.String()
+}
+
+// generateWireMappers generates the fromWire/toWire mapping functions for every struct type reachable from the routes.
+func (tb *tsCodeBuilder) generateWireMappers(routes []*Route) {
+	seen := make(map[reflect.Type]bool)
+	types := make([]reflect.Type, 0)
+
+	for _, route := range routes {
+		if route.requestType != nil {
+			if bf := route.plan.bodyField(); bf != nil {
+				collectWireTypes(bf.field.Type, seen, &types)
+			}
+		}
+
+		if route.responseType != nil {
+			collectWireTypes(route.responseType, seen, &types)
+		}
+
+		if route.jobs != nil {
+			collectWireTypes(route.jobResult, seen, &types)
+		}
+	}
+
+	for _, t := range types {
+		tb.generateWireMapper(t)
+		tb.writeLine("")
+	}
+}
+
+// collectWireTypes collects all named struct types reachable from the given type, which need a mapping function.
+func collectWireTypes(t reflect.Type, seen map[reflect.Type]bool, out *[]reflect.Type) {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		collectWireTypes(t.Elem(), seen, out)
+	case reflect.Struct:
+		if !hasWireFields(t) || isProtoMessage(t) {
+			return
+		}
+
+		if t.Name() != "" && !isListResultType(t) {
+			if seen[t] {
+				return
+			}
+
+			seen[t] = true
+			*out = append(*out, t)
+		}
+
+		for i := 0; i < t.NumField(); i++ {
+			collectWireTypes(t.Field(i).Type, seen, out)
+		}
+	}
+}
+
+// hasWireFields checks if the given struct type has any field which is encoded by encoding/json.
+func hasWireFields(t reflect.Type) bool {
+	return len(jsonFields(t)) > 0
+}
+
+// needsWireMapping checks if values of the given type have to be converted between the wire and the TypeScript shape.
+func (tb *tsCodeBuilder) needsWireMapping(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return tb.needsWireMapping(t.Elem())
+	case reflect.Struct:
+		if isTimeType(t) {
+			return tb.opts.DateObjects
+		}
+
+		// protobuf messages are already encoded with their JSON names by protojson
+		return hasWireFields(t) && !isProtoMessage(t)
+	default:
+		return false
+	}
+}
+
+// wireMapperFunc returns a TypeScript function expression converting a wire value of the given type into its TypeScript shape.
+func (tb *tsCodeBuilder) wireMapperFunc(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() == reflect.Struct && t.Name() != "" && !isListResultType(t) && !isTimeType(t) {
+		return wireMapperName(t, false)
+	}
+
+	return "(w: any) => " + tb.wireConversion(t, "w", false)
+}
+
+// wireMapperName returns the name of the generated mapping function for the given named struct type.
+func wireMapperName(t reflect.Type, toWire bool) string {
+	name := []rune(t.Name())
+	name[0] = unicode.ToLower(name[0])
+
+	if toWire {
+		return string(name) + "ToWire"
+	}
+
+	return string(name) + "FromWire"
+}
+
+// wireConversion returns a TypeScript expression converting expr of the given type from or to the wire shape.
+// If the type does not need any conversion, expr is returned as it is.
+func (tb *tsCodeBuilder) wireConversion(t reflect.Type, expr string, toWire bool) string {
+	if !tb.needsWireMapping(t) {
+		return expr
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return tb.wireConversion(t.Elem(), expr, toWire)
+	case reflect.Slice, reflect.Array:
+		return "(" + expr + " == null ? " + expr + " : " + expr + ".map((v: any) => " + tb.wireConversion(t.Elem(), "v", toWire) + "))"
+	case reflect.Map:
+		return "(" + expr + " == null ? " + expr + " : Object.fromEntries(Object.entries(" + expr + ").map(([k, v]: [string, any]) => [k, " + tb.wireConversion(t.Elem(), "v", toWire) + "])))"
+	case reflect.Struct:
+		if isTimeType(t) {
+			if toWire {
+				return "(" + expr + " == null ? " + expr + " : " + expr + ".toISOString())"
+			}
+
+			return "(" + expr + " == null ? " + expr + " : new Date(" + expr + "))"
+		}
+
+		if isListResultType(t) {
+			if toWire {
+				return expr
+			}
+
+			return "listResultFromWire(" + expr + ", (v: any) => " + tb.wireConversion(listResultItemType(t), "v", false) + ")"
+		}
+
+		if t.Name() != "" {
+			return wireMapperName(t, toWire) + "(" + expr + ")"
+		}
+
+		return "((w: any) => w == null ? w : ({ " + strings.Join(tb.wireProperties(t, "w", toWire), ", ") + " }))(" + expr + ")"
+	default:
+		return expr
+	}
+}
+
+// wireProperties returns the object literal properties converting the fields of the given struct type read from the source expression.
+func (tb *tsCodeBuilder) wireProperties(t reflect.Type, source string, toWire bool) []string {
+	props := make([]string, 0, t.NumField())
+
+	for _, field := range jsonFields(t) {
+		tsName := tb.propertyName(field.name)
+		if toWire {
+			props = append(props, "'"+field.name+"': "+tb.wireConversion(field.t, source+"['"+tsName+"']", toWire))
+		} else {
+			props = append(props, "'"+tsName+"': "+tb.wireConversion(field.t, source+"['"+field.name+"']", toWire))
+		}
+	}
+
+	return props
+}
+
+// generateWireMapper generates the fromWire and toWire mapping functions for the given named struct type.
+func (tb *tsCodeBuilder) generateWireMapper(t reflect.Type) {
+	tb.writeLine("export function " + wireMapperName(t, false) + "(w: any): " + t.Name() + " {")
+	tb.indent()
+	tb.writeLine("if (w == null) return w")
+	tb.writeLine("return {")
+	tb.indent()
+	for _, prop := range tb.wireProperties(t, "w", false) {
+		tb.writeLine(prop + ",")
+	}
+	tb.unindent()
+	tb.writeLine("}")
+	tb.unindent()
+	tb.writeLine("}")
+	tb.writeLine("")
+
+	tb.writeLine("export function " + wireMapperName(t, true) + "(v: " + t.Name() + "): any {")
+	tb.indent()
+	tb.writeLine("if (v == null) return v")
+	tb.writeLine("return {")
+	tb.indent()
+	for _, prop := range tb.wireProperties(t, "v", true) {
+		tb.writeLine(prop + ",")
+	}
+	tb.unindent()
+	tb.writeLine("}")
+	tb.unindent()
+	tb.writeLine("}")
+}
@@ -0,0 +1,161 @@
+package octanox
+
+import (
+	"bytes"
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+type wireOrder struct {
+	OrderID   string               `json:"order_id"`
+	CreatedAt time.Time            `json:"created_at"`
+	ShippedAt *time.Time           `json:"shipped_at"`
+	Lines     []wireLine           `json:"order_lines"`
+	ByRegion  map[string]wireLine  `json:"by_region"`
+	Customer  *wireCustomer        `json:"customer"`
+	Labels    map[string]string    `json:"labels"`
+	Totals    map[string][]float64 `json:"totals"`
+	Note      string               `json:"note,omitempty"`
+}
+
+type wireLine struct {
+	ProductID string     `json:"product_id"`
+	Quantity  int        `json:"quantity"`
+	Delivered *time.Time `json:"delivered_at"`
+}
+
+type wireCustomer struct {
+	wireAudit
+	DisplayName string `json:"display_name"`
+}
+
+type wireAudit struct {
+	LastSeen time.Time `json:"last_seen"`
+}
+
+type wireOrderRequest struct {
+	PutRequest
+	Body wireOrder `body:"true"`
+}
+
+// registerWireRoutes registers a route whose request and response need wire mapping.
+func registerWireRoutes(i *Instance) {
+	i.Register("/orders", func(req *wireOrderRequest) *wireOrder { return &req.Body })
+}
+
+var (
+	// tsParameterTypes matches the typed parameters and return types of the wire mappers.
+	tsParameterTypes = regexp.MustCompile(`\((\w+): [^()]*\): [\w|\[\] ]+ \{`)
+	// tsArrowTypes matches the typed parameters of the arrow functions of the wire mappers, the elements of arrays and the
+	// destructured entries of maps.
+	tsArrowTypes = regexp.MustCompile(`(\w|\]): (any|\[string, any\])\) =>`)
+)
+
+// wireMappersScript returns the wire mappers of the given client code as JavaScript.
+func wireMappersScript(t *testing.T, code string) string {
+	t.Helper()
+
+	var sb strings.Builder
+	for _, start := range regexp.MustCompile(`export function \w+(FromWire|ToWire)\(`).FindAllStringIndex(code, -1) {
+		end := strings.Index(code[start[0]:], "\n}\n")
+		if end < 0 {
+			t.Fatalf("wire mapper at %d is not terminated", start[0])
+		}
+
+		mapper := code[start[0]+len("export ") : start[0]+end+2]
+		mapper = tsParameterTypes.ReplaceAllString(mapper, "($1) {")
+		mapper = tsArrowTypes.ReplaceAllString(mapper, "$1) =>")
+		sb.WriteString(mapper + "\n")
+	}
+
+	return sb.String()
+}
+
+// TestWireMappersRoundTrip checks that values encoded by the server, mapped from the wire and back to it by the generated
+// client, decode to the same values. Needs Node.js and is skipped without it.
+func TestWireMappersRoundTrip(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed")
+	}
+
+	created := time.Date(2026, 10, 14, 12, 30, 15, 123000000, time.UTC)
+	shipped := created.Add(36 * time.Hour)
+	orders := []wireOrder{
+		{OrderID: "empty", CreatedAt: created},
+		{
+			OrderID:   "full",
+			CreatedAt: created,
+			ShippedAt: &shipped,
+			Lines:     []wireLine{{ProductID: "p1", Quantity: 2, Delivered: &shipped}, {ProductID: "p2", Quantity: 1}},
+			ByRegion:  map[string]wireLine{"eu": {ProductID: "p1", Quantity: 2}},
+			Customer:  &wireCustomer{wireAudit: wireAudit{LastSeen: created}, DisplayName: "Alice"},
+			Labels:    map[string]string{"priority": "high"},
+			Totals:    map[string][]float64{"eur": {1.5, 2.25}},
+			Note:      "leave at the door",
+		},
+	}
+
+	for _, opts := range []TypeScriptGenerationOptions{
+		{CamelCaseProperties: true},
+		{DateObjects: true},
+		{CamelCaseProperties: true, DateObjects: true},
+	} {
+		i := newTestInstance(t)
+		i.SetTSGenOptions(opts)
+		registerWireRoutes(i)
+
+		encoded, err := json.Marshal(orders)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		script := wireMappersScript(t, i.typeScriptClientCode(i.routes)) +
+			"let input = ''\n" +
+			"process.stdin.setEncoding('utf8')\n" +
+			"process.stdin.on('data', (chunk) => { input += chunk })\n" +
+			"process.stdin.on('end', () => {\n" +
+			"  const orders = JSON.parse(input).map((order) => wireOrderFromWire(order))\n" +
+			"  if (" + jsBool(opts.CamelCaseProperties) + " !== ('orderId' in orders[0])) throw new Error('property names are not mapped')\n" +
+			"  if (" + jsBool(opts.DateObjects) + " !== (orders[1].customer.lastSeen instanceof Date || orders[1].customer.last_seen instanceof Date)) throw new Error('dates are not mapped')\n" +
+			"  process.stdout.write(JSON.stringify(orders.map((order) => wireOrderToWire(order))))\n" +
+			"})\n"
+
+		cmd := exec.Command(node, "-e", script)
+		cmd.Stdin = bytes.NewReader(encoded)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("options %+v: node: %v: %s", opts, err, stderr.String())
+		}
+
+		var decoded []wireOrder
+		if err := json.Unmarshal(out, &decoded); err != nil {
+			t.Fatalf("options %+v: %v: %s", opts, err, out)
+		}
+
+		// the values are compared by their encoding, as decoded times carry another location than the literal ones
+		again, err := json.Marshal(decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(encoded, again) {
+			t.Errorf("options %+v: round trip changed\n%s\nto\n%s", opts, encoded, again)
+		}
+	}
+}
+
+// jsBool returns the given value as JavaScript literal.
+func jsBool(b bool) string {
+	if b {
+		return "true"
+	}
+
+	return "false"
+}
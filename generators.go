@@ -0,0 +1,145 @@
+package octanox
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+)
+
+// typeScriptGenerator is the name of the built-in generator of the TypeScript client.
+const typeScriptGenerator = "typescript"
+
+// Generator is a function that generates code or documents from the registered routes in dry-run mode. It writes its
+// outputs with GenContext.WriteFile, so later generators can reference them.
+type Generator func(ctx *GenContext) error
+
+// GenOutput is a struct that describes a file written by a generator.
+type GenOutput struct {
+	// Generator is the name of the generator that wrote the file.
+	Generator string
+	// Path is the path the file has been written to.
+	Path string
+	// Hash is the hex encoded SHA-256 hash of the content of the file.
+	Hash string
+}
+
+// GenContext is a struct that is passed to a generator when it runs. It contains the outputs of the generators that ran
+// before it.
+type GenContext struct {
+	// Instance is the Octanox instance whose routes are generated.
+	Instance *Instance
+	// Name is the name of the running generator.
+	Name string
+	// Previous are the outputs of the generators that ran before, in the order they have been written.
+	Previous []GenOutput
+
+	outputs []GenOutput
+}
+
+// WriteFile writes the given content to the given path and records it as an output of the running generator.
+func (ctx *GenContext) WriteFile(path string, content []byte) error {
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256(content)
+	ctx.outputs = append(ctx.outputs, GenOutput{Generator: ctx.Name, Path: path, Hash: hex.EncodeToString(hash[:])})
+	return nil
+}
+
+// Outputs returns the outputs the running generator has written so far.
+func (ctx *GenContext) Outputs() []GenOutput {
+	return ctx.outputs
+}
+
+// registeredGenerator is a struct that contains a generator and the priority it runs with.
+type registeredGenerator struct {
+	name     string
+	generate Generator
+	priority int
+}
+
+// AddGenerator registers a generator that runs in dry-run mode. Generators run in ascending order of the optional priority,
+// which defaults to 0, and in the order of their registration for equal priorities. The built-in TypeScript client
+// generator is registered as "typescript" with priority 0. A failing generator does not stop the others; all failures are
+// reported together. Panics if a generator with the same name is already registered.
+func (i *Instance) AddGenerator(name string, generator Generator, priority ...int) *Instance {
+	for _, registered := range i.generators {
+		if registered.name == name {
+			panic("octanox: generator " + name + " is already registered")
+		}
+	}
+
+	p := 0
+	if len(priority) > 0 {
+		p = priority[0]
+	}
+
+	i.generators = append(i.generators, registeredGenerator{name: name, generate: generator, priority: p})
+	return i
+}
+
+// runGenerators runs all registered generators in the order of their priority and returns the aggregated failures.
+func (i *Instance) runGenerators() error {
+	generators := make([]registeredGenerator, len(i.generators))
+	copy(generators, i.generators)
+	sort.SliceStable(generators, func(a, b int) bool {
+		return generators[a].priority < generators[b].priority
+	})
+
+	var outputs []GenOutput
+	var errs []error
+
+	for _, generator := range generators {
+		ctx := &GenContext{Instance: i, Name: generator.name, Previous: append([]GenOutput(nil), outputs...)}
+		if err := runGenerator(generator.generate, ctx); err != nil {
+			errs = append(errs, fmt.Errorf("generator %s: %w", generator.name, err))
+		}
+
+		outputs = append(outputs, ctx.outputs...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// runGenerator runs the given generator, turning a panic into an error.
+func runGenerator(generator Generator, ctx *GenContext) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = e
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+
+	return generator(ctx)
+}
+
+// generateTypeScriptClient is the built-in generator of the TypeScript client, written to NOX__CLIENT_DIR, and of its React
+// Query hooks, if enabled.
+func generateTypeScriptClient(ctx *GenContext) error {
+	if err := ctx.Instance.checkClientGroups(ctx.Instance.routes); err != nil {
+		return err
+	}
+
+	path := os.Getenv("NOX__CLIENT_DIR")
+	if err := ctx.WriteFile(path, []byte(ctx.Instance.typeScriptClientCode(ctx.Instance.routes))); err != nil {
+		return err
+	}
+
+	if hooks := ctx.Instance.tsGenOptions.ReactQueryOutputPath; hooks != "" {
+		code := ctx.Instance.reactQueryHooksCode(ctx.Instance.routes, relativeClientModule(hooks, path))
+		if err := ctx.WriteFile(hooks, []byte(code)); err != nil {
+			return err
+		}
+	}
+
+	log.Println("TypeScript code generated successfully.")
+	return nil
+}
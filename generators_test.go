@@ -0,0 +1,104 @@
+package octanox
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newGeneratorInstance returns an instance without the built-in TypeScript generator.
+func newGeneratorInstance(t *testing.T) *Instance {
+	i := newTestInstance(t)
+	i.generators = nil
+	return i
+}
+
+func TestGeneratorsRunInPriorityOrder(t *testing.T) {
+	i := newGeneratorInstance(t)
+	dir := t.TempDir()
+
+	order := make([]string, 0)
+	previous := make(map[string][]GenOutput)
+	record := func(name string) Generator {
+		return func(ctx *GenContext) error {
+			order = append(order, name)
+			previous[name] = ctx.Previous
+			return ctx.WriteFile(filepath.Join(dir, name), []byte(name))
+		}
+	}
+
+	i.AddGenerator("markdown", record("markdown"), 10)
+	i.AddGenerator("client", record("client"))
+	i.AddGenerator("openapi", record("openapi"), -10)
+	i.AddGenerator("docs", record("docs"), 10)
+
+	if err := i.runGenerators(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(order, ","); got != "openapi,client,markdown,docs" {
+		t.Errorf("order %s, want openapi,client,markdown,docs", got)
+	}
+
+	if len(previous["openapi"]) != 0 {
+		t.Errorf("first generator got previous outputs %+v", previous["openapi"])
+	}
+	docs := previous["docs"]
+	if len(docs) != 3 || docs[0].Generator != "openapi" || docs[2].Path != filepath.Join(dir, "markdown") {
+		t.Fatalf("previous outputs of the last generator %+v", docs)
+	}
+	if hash := sha256.Sum256([]byte("openapi")); docs[0].Hash != hex.EncodeToString(hash[:]) {
+		t.Errorf("hash %s of the content openapi", docs[0].Hash)
+	}
+}
+
+func TestAddGeneratorRejectsDuplicateNames(t *testing.T) {
+	i := newTestInstance(t)
+
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "generator typescript is already registered") {
+			t.Errorf("recovered %v", r)
+		}
+	}()
+
+	i.AddGenerator(typeScriptGenerator, func(*GenContext) error { return nil })
+}
+
+func TestGeneratorFailuresAreAggregated(t *testing.T) {
+	i := newGeneratorInstance(t)
+
+	ran := make([]string, 0)
+	i.AddGenerator("failing", func(*GenContext) error {
+		ran = append(ran, "failing")
+		return errors.New("cannot write")
+	})
+	i.AddGenerator("panicking", func(*GenContext) error {
+		ran = append(ran, "panicking")
+		panic("unexpected route")
+	})
+	i.AddGenerator("succeeding", func(*GenContext) error {
+		ran = append(ran, "succeeding")
+		return nil
+	})
+
+	err := i.runGenerators()
+	if len(ran) != 3 {
+		t.Errorf("generators %v ran, want all", ran)
+	}
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	for _, want := range []string{"generator failing: cannot write", "generator panicking: unexpected route"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "succeeding") {
+		t.Errorf("error %q contains the succeeding generator", err)
+	}
+}
@@ -0,0 +1,58 @@
+package octanox
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// GoneError is an error that indicates that the requested resource has been soft-deleted. Handlers return or panic with it,
+// and stores of resources registered with Resource return it from Get, Update and Delete. It is answered with 410 Gone,
+// carrying the error in the gone member of the error body.
+type GoneError struct {
+	// Resource is the kind of the deleted resource, e.g. "invoice".
+	Resource string `json:"resource"`
+	// DeletedAt is the time the resource has been deleted at.
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// Gone returns an error that indicates that the given resource has been soft-deleted at the given time.
+func Gone(resource string, deletedAt time.Time) *GoneError {
+	return &GoneError{Resource: resource, DeletedAt: deletedAt}
+}
+
+func (e *GoneError) Error() string {
+	return e.Resource + " has been deleted at " + e.DeletedAt.UTC().Format(time.RFC3339)
+}
+
+// failedRequest returns the failed request the error is answered with.
+func (e *GoneError) failedRequest() failedRequest {
+	return failedRequest{
+		status:  http.StatusGone,
+		message: "Gone: " + e.Error(),
+		code:    ErrorCodeGone,
+		gone:    e,
+	}
+}
+
+// asGoneError returns the GoneError wrapped in the given recovered value. Can be nil.
+func asGoneError(recovered any) *GoneError {
+	err, ok := recovered.(error)
+	if !ok {
+		return nil
+	}
+
+	var gone *GoneError
+	if errors.As(err, &gone) {
+		return gone
+	}
+
+	return nil
+}
+
+// RespondsGone declares that this route answers with 410 Gone for soft-deleted resources. The generated TypeScript function
+// documents that its ApiError can be narrowed with isGone. Routes registered with Resource declare it for get, update and delete.
+func (r *Route) RespondsGone() *Route {
+	r.gone = true
+	return r
+}
@@ -0,0 +1,176 @@
+package octanox
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// groupParamsKey is the key of the Gin context the path parameters bound by the groups of the route are stored under.
+const groupParamsKey = "nox.groupParams"
+
+// PathParameter is a struct that declares a path parameter of a group, which is bound and validated once for all routes of
+// the group. Created with PathParam.
+type PathParameter struct {
+	name    string
+	t       reflect.Type
+	convert paramConverter
+}
+
+// PathParam declares the path parameter with the given name of a group, bound to the given type. Supported are the types of
+// path fields, except for pointers, as path parameters are never missing.
+func PathParam[T any](name string) PathParameter {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() == reflect.Ptr {
+		panic("octanox: path parameter " + name + " cannot be bound to the pointer type " + t.String())
+	}
+
+	convert, ok := converterFor(t)
+	if !ok {
+		panic("octanox: path parameter " + name + " has unsupported type " + t.String())
+	}
+
+	return PathParameter{name: name, t: t, convert: convert}
+}
+
+// Group creates a new router with the given URL prefix, whose routes inherit the given path parameters of the prefix. The
+// parameters are bound once for all routes of the group, which fails the request with 400 Bad Request before it reaches the
+// route if they cannot be converted. Request structs of the routes can still bind them with a path field of the same type,
+// e.g. of an embedded struct shared by the routes, or read them with PathParamFrom. The generators see the inherited
+// parameters as parameters of every route, in the order of the groups declaring them.
+func (r *SubRouter) Group(url string, params ...PathParameter) *SubRouter {
+	declared := pathParams(url)
+	for _, param := range params {
+		if !containsString(declared, param.name) {
+			panic("octanox: group " + r.combineURL(url) + " declares path parameter " + param.name + ", which is not part of its path")
+		}
+	}
+
+	group := r.gin.Group(url)
+	if len(params) > 0 {
+		group.Use(bindGroupParams(params))
+	}
+
+	return &SubRouter{
+		url:    r.combineURL(url),
+		gin:    group,
+		params: append(append([]PathParameter{}, r.params...), params...),
+
+		clientGroup:     r.clientGroup,
+		clientGroupPath: r.clientGroupPath,
+	}
+}
+
+// PathParamFrom returns the value of the path parameter with the given name declared by a group of the route. Panics if no
+// group of the route declares it as T.
+func PathParamFrom[T any](c *gin.Context, name string) T {
+	params, _ := c.Get(groupParamsKey)
+	value, ok := params.(map[string]any)[name].(T)
+	if !ok {
+		var zero T
+		panic("octanox: path parameter " + name + " of type " + reflect.TypeOf(&zero).Elem().String() + " is not declared by a group of " + c.FullPath())
+	}
+
+	return value
+}
+
+// bindGroupParams binds the given path parameters declared by a group, failing the request with 400 Bad Request if one of
+// them cannot be converted.
+func bindGroupParams(params []PathParameter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bound, _ := c.Get(groupParamsKey)
+		values, ok := bound.(map[string]any)
+		if !ok {
+			values = make(map[string]any, len(params))
+			c.Set(groupParamsKey, values)
+		}
+
+		for _, param := range params {
+			value, err := param.convert(c.Param(param.name))
+			if err != nil {
+				abortWithError(c, invalidParamError("path", param.name, param.t, err))
+				return
+			}
+
+			values[param.name] = value.Interface()
+		}
+
+		c.Next()
+	}
+}
+
+// inheriting returns a copy of the plan binding the given path parameters declared by the groups of the route. Path fields
+// binding one of them are bound from the group, all others are added as fields that are not part of the request struct, so
+// the validator and the generators see the parameter anyway.
+func (p *bindingPlan) inheriting(params []PathParameter) (*bindingPlan, error) {
+	if len(params) == 0 {
+		return p, nil
+	}
+
+	own := append([]bindingField{}, p.fields...)
+	plan := &bindingPlan{t: p.t, fields: make([]bindingField, 0, len(params)+len(own))}
+
+	for _, param := range params {
+		declared := false
+		for n := range own {
+			bf := &own[n]
+			if bf.source != sourcePath || bf.name != param.name {
+				continue
+			}
+
+			if t := bf.field.Type; t != param.t && (t.Kind() != reflect.Ptr || t.Elem() != param.t) {
+				return nil, fmt.Errorf("field %s binds path parameter %s as %s, but its group declares it as %s", bf.field.Name, param.name, t.String(), param.t.String())
+			}
+
+			bf.group = true
+			declared = true
+		}
+
+		if !declared {
+			plan.fields = append(plan.fields, bindingField{
+				field:   reflect.StructField{Name: strings.ToUpper(param.name[:1]) + param.name[1:], Type: param.t},
+				source:  sourcePath,
+				name:    param.name,
+				convert: param.convert,
+				group:   true,
+			})
+		}
+	}
+
+	plan.fields = append(plan.fields, own...)
+	return plan, nil
+}
+
+// bindGroupParam binds the given path field to the value bound by the group declaring its parameter.
+func bindGroupParam(c *gin.Context, bf *bindingField, fieldValue reflect.Value) {
+	params, _ := c.Get(groupParamsKey)
+	value := reflect.ValueOf(params.(map[string]any)[bf.name])
+
+	if fieldValue.Kind() == reflect.Ptr {
+		ptr := reflect.New(value.Type())
+		ptr.Elem().Set(value)
+		value = ptr
+	}
+
+	fieldValue.Set(value)
+}
+
+// invalidParamError returns the failure of a request whose parameter with the given source and name cannot be converted into
+// the given type.
+func invalidParamError(source, name string, t reflect.Type, err error) failedRequest {
+	message := "Invalid " + source + " parameter: " + name
+
+	if Current.isDebug {
+		message += ": " + err.Error()
+	}
+
+	return failedRequest{
+		status:  http.StatusBadRequest,
+		message: message,
+		code:    ErrorCodeInvalidParameter,
+		errors:  []ProblemFieldError{{Source: source, Name: name, Detail: "expected " + t.String()}},
+	}
+}
@@ -0,0 +1,73 @@
+package octanox
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// newTestInstance returns a new instance for the test, replacing the current instance until the test finished.
+func newTestInstance(t testing.TB) *Instance {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	Current = nil
+	t.Cleanup(func() {
+		Current = nil
+	})
+
+	return New()
+}
+
+// serveTest sends the given request to the handler of the instance and returns the recorded response.
+func serveTest(i *Instance, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	i.Handler().ServeHTTP(rec, req)
+	return rec
+}
+
+// testUser is the user the test authenticators resolve.
+type testUser struct {
+	name  string
+	roles []string
+}
+
+func (u testUser) ID() uuid.UUID {
+	return uuid.NewSHA1(uuid.Nil, []byte(u.name))
+}
+
+func (u testUser) HasRole(role string) bool {
+	for _, r := range u.roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// headerAuthenticator authenticates requests whose X-Test-User header is set, as the user of that name with the comma
+// separated roles of the X-Test-Roles header.
+type headerAuthenticator struct {
+	method AuthenticationMethod
+}
+
+func (a headerAuthenticator) Method() AuthenticationMethod {
+	return a.method
+}
+
+func (a headerAuthenticator) Authenticate(c *gin.Context) (User, error) {
+	if name := c.GetHeader("X-Test-User"); name != "" {
+		var roles []string
+		if header := c.GetHeader("X-Test-Roles"); header != "" {
+			roles = strings.Split(header, ",")
+		}
+		return testUser{name: name, roles: roles}, nil
+	}
+
+	return nil, nil
+}
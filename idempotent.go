@@ -0,0 +1,94 @@
+package octanox
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// idempotencyKeyHeader is the conventional header clients send a unique key of a mutating request in.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// Idempotent declares that repeating a request of this mutating route has the same effect as sending it once, e.g. an upsert
+// keyed by a client-supplied ID. The generated TypeScript function is documented as safe to retry and the route is part of
+// the generated isRetrySafe. The contract validation warns if the route has neither an Idempotency-Key header parameter, nor a
+// path parameter, nor a body field tagged with `idempotency:"key"` that makes repeated requests recognizable.
+func (r *Route) Idempotent() *Route {
+	r.idempotent = true
+	return r
+}
+
+// hasIdempotencyKey checks if the request of the route carries a client-supplied key that identifies repeated requests.
+func (r *Route) hasIdempotencyKey() bool {
+	if r.plan == nil {
+		return false
+	}
+
+	for n := range r.plan.fields {
+		bf := &r.plan.fields[n]
+
+		switch bf.source {
+		case sourcePath:
+			return true
+		case sourceHeader:
+			if http.CanonicalHeaderKey(bf.name) == idempotencyKeyHeader {
+				return true
+			}
+		case sourceBody:
+			t := bf.field.Type
+			for t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+
+			if t.Kind() == reflect.Struct && !isProtoMessage(t) {
+				for _, field := range jsonFields(t) {
+					if field.tag.Get("idempotency") == "key" {
+						return true
+					}
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// validateIdempotent warns about an idempotent route whose repeated requests cannot be recognized.
+func (v *contractValidator) validateIdempotent() {
+	if !v.route.hasIdempotencyKey() {
+		v.report(ContractIdempotentWithoutKey, "route is marked as idempotent, but has neither an %s header, a path parameter nor a body field tagged with `idempotency:\"key\"`", idempotencyKeyHeader)
+	}
+}
+
+// generateRetrySafe generates isRetrySafe, which checks if a request may be retried, e.g. by a retry policy or when flushing
+// an offline queue: requests of safe methods and of the routes declared idempotent.
+func (tb *tsCodeBuilder) generateRetrySafe(routes []*Route) {
+	keys := make([]string, 0)
+	for _, route := range routes {
+		if route.idempotent {
+			keys = append(keys, "'"+strings.ToUpper(route.method)+" "+route.path+"'")
+		}
+	}
+
+	// the set is annotated as pure, so bundlers drop it together with isRetrySafe if that is not imported
+	tb.writeLines(
+		"const idempotentRoutes: ReadonlySet<string> = /* @__PURE__ */ new Set(["+strings.Join(keys, ", ")+"])",
+		"",
+		"// isRetrySafe checks if a request of the route with the given method and path, e.g. '/users/:id', may be sent repeatedly.",
+		"export function isRetrySafe(method: string, path: string): boolean {",
+		"  method = method.toUpperCase()",
+		"  return method === 'GET' || method === 'HEAD' || idempotentRoutes.has(`${method} ${path}`)",
+		"}",
+		"",
+	)
+}
+
+func usesIdempotentRoutes(routes []*Route) bool {
+	for _, route := range routes {
+		if route.idempotent {
+			return true
+		}
+	}
+
+	return false
+}
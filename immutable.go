@@ -0,0 +1,14 @@
+package octanox
+
+// immutableCacheControl is the Cache-Control header value of immutable routes.
+const immutableCacheControl = "public, max-age=31536000, immutable"
+
+// Immutable marks the route as serving an immutable, content-addressed resource, e.g. /assets/:hash. Successful responses are sent
+// with a Cache-Control header that lets browsers cache them for a year without revalidation. Only GET routes can be immutable.
+// Query parameters are reported by the contract validation, as they usually affect the response, unless they are acknowledged
+// as part of the resource identity with the given names.
+func (r *Route) Immutable(acknowledgedQuery ...string) *Route {
+	r.immutable = true
+	r.immutableQuery = append(r.immutableQuery, acknowledgedQuery...)
+	return r
+}
@@ -0,0 +1,24 @@
+package octanox
+
+import "reflect"
+
+// Provide registers the given services for injection into request structs. A request field tagged with `inject:"true"` is set to
+// the provided service of exactly the field's type. Panics if a service of the same type has already been provided.
+func (i *Instance) Provide(services ...any) *Instance {
+	for _, service := range services {
+		t := reflect.TypeOf(service)
+		if _, ok := i.services[t]; ok {
+			panic("octanox: service of type " + t.String() + " already provided")
+		}
+
+		i.services[t] = reflect.ValueOf(service)
+	}
+
+	return i
+}
+
+// service returns the provided service of the given type.
+func (i *Instance) service(t reflect.Type) (reflect.Value, bool) {
+	service, ok := i.services[t]
+	return service, ok
+}
@@ -0,0 +1,80 @@
+package octanox
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// internalBasePath is the base path all internal endpoints are mounted under. Internal endpoints are never part of the client code generation.
+const internalBasePath = "/.nox"
+
+// internalActorKey is the key of the Gin context the guard stores who accesses an internal endpoint under.
+const internalActorKey = "nox.internalActor"
+
+// InternalOptions is a struct that configures the guard of the internal endpoints mounted under /.nox.
+// If neither a token nor roles are configured, the internal endpoints are not served unless AllowUnauthenticated is set.
+type InternalOptions struct {
+	// Token is the token clients have to send in the X-Nox-Token header to access the internal endpoints.
+	Token string
+	// Roles are the roles of which the authenticated user needs at least one to access the internal endpoints.
+	Roles []string
+	// AllowUnauthenticated serves the internal endpoints to everyone if neither a token nor roles are configured, e.g. for
+	// local development. Never enable it on a server reachable by clients.
+	AllowUnauthenticated bool
+}
+
+// SetInternalOptions sets the options guarding the internal endpoints.
+func (i *Instance) SetInternalOptions(opts InternalOptions) *Instance {
+	i.internalOptions = opts
+	return i
+}
+
+// internal returns the guarded router group of the internal endpoints, creating it on first use.
+func (i *Instance) internal() *gin.RouterGroup {
+	if i.internalGroup == nil {
+		i.internalGroup = i.Gin.Group(internalBasePath, i.guardInternal)
+	}
+
+	return i.internalGroup
+}
+
+// guardInternal aborts requests to the internal endpoints that do not satisfy the internal options.
+func (i *Instance) guardInternal(c *gin.Context) {
+	opts := i.internalOptions
+
+	if opts.Token == "" && len(opts.Roles) == 0 {
+		if !opts.AllowUnauthenticated {
+			abortWithError(c, failedRequest{status: http.StatusNotFound, message: "not found", code: ErrorCodeNotFound})
+			return
+		}
+		c.Set(internalActorKey, "unauthenticated")
+		return
+	}
+
+	if opts.Token != "" && subtle.ConstantTimeCompare([]byte(c.GetHeader("X-Nox-Token")), []byte(opts.Token)) == 1 {
+		c.Set(internalActorKey, "token")
+		return
+	}
+
+	if len(opts.Roles) > 0 && i.Authenticator != nil {
+		user, err := i.Authenticator.Authenticate(c)
+		if err == nil && user != nil {
+			for _, role := range opts.Roles {
+				if user.HasRole(role) {
+					c.Set(internalActorKey, user.ID().String())
+					return
+				}
+			}
+		}
+	}
+
+	abortWithError(c, failedRequest{status: http.StatusForbidden, message: "forbidden", code: ErrorCodeForbidden})
+}
+
+// internalActor returns who accesses the internal endpoint of the given request: the ID of the user, "token" if the internal
+// token has been sent or "unauthenticated" if the internal endpoints are served to everyone.
+func internalActor(c *gin.Context) string {
+	return c.GetString(internalActorKey)
+}
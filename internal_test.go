@@ -0,0 +1,62 @@
+package octanox
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestInternalGuard(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        InternalOptions
+		token, user string
+		roles       string
+		status      int
+		actor       string
+	}{
+		{"unguarded", InternalOptions{}, "", "", "", http.StatusNotFound, ""},
+		{"unguarded token sent", InternalOptions{}, "secret", "", "", http.StatusNotFound, ""},
+		{"unauthenticated allowed", InternalOptions{AllowUnauthenticated: true}, "", "", "", http.StatusOK, "unauthenticated"},
+		{"token", InternalOptions{Token: "secret", AllowUnauthenticated: true}, "secret", "", "", http.StatusOK, "token"},
+		{"wrong token", InternalOptions{Token: "secret", AllowUnauthenticated: true}, "guess", "", "", http.StatusForbidden, ""},
+		{"role", InternalOptions{Roles: []string{"ops"}}, "", "alice", "dev,ops", http.StatusOK, testUser{name: "alice"}.ID().String()},
+		{"missing role", InternalOptions{Roles: []string{"ops"}}, "", "alice", "dev", http.StatusForbidden, ""},
+		{"anonymous user", InternalOptions{Roles: []string{"ops"}}, "", "", "", http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newTestInstance(t)
+			if err := i.ApplyRuntimeConfig(RuntimeConfig{LogLevel: LogLevelOff}); err != nil {
+				t.Fatal(err)
+			}
+			// the guard does not depend on the mode of gin
+			i.isDebug = true
+			i.Authenticator = headerAuthenticator{method: AuthenticationMethodBearer}
+			i.SetInternalOptions(tt.opts)
+			i.internal().GET("/actor", func(c *gin.Context) {
+				c.String(http.StatusOK, internalActor(c))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, internalBasePath+"/actor", nil)
+			if tt.token != "" {
+				req.Header.Set("X-Nox-Token", tt.token)
+			}
+			if tt.user != "" {
+				req.Header.Set("X-Test-User", tt.user)
+				req.Header.Set("X-Test-Roles", tt.roles)
+			}
+
+			rec := serveTest(i, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status == http.StatusOK && rec.Body.String() != tt.actor {
+				t.Errorf("actor %q, want %q", rec.Body.String(), tt.actor)
+			}
+		})
+	}
+}
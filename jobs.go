@@ -0,0 +1,336 @@
+package octanox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+)
+
+// jobsPath is the path the status of the jobs of async routes is served at, followed by the ID of the job.
+const jobsPath = "/jobs"
+
+// JobStatus is a type that represents the state of a job of an async route.
+type JobStatus string
+
+const (
+	// JobPending is the status of jobs which have been started, but are not running yet.
+	JobPending JobStatus = "pending"
+	// JobRunning is the status of running jobs.
+	JobRunning JobStatus = "running"
+	// JobSucceeded is the terminal status of jobs which finished with a result.
+	JobSucceeded JobStatus = "succeeded"
+	// JobFailed is the terminal status of jobs which finished with an error.
+	JobFailed JobStatus = "failed"
+)
+
+// jobStatuses are the statuses of jobs, in the order they are documented in.
+var jobStatuses = []JobStatus{JobPending, JobRunning, JobSucceeded, JobFailed}
+
+// Terminal checks if the job has finished and its status does not change anymore.
+func (s JobStatus) Terminal() bool {
+	return s == JobSucceeded || s == JobFailed
+}
+
+// JobError is a struct that represents the error a job failed with. Return it from the function of a job to fail it with
+// the given message and code, all other errors fail it with a generic message, as their message may leak internals.
+type JobError struct {
+	Message string `json:"message"`
+	// Code is the error code of the failure. Can be empty.
+	Code string `json:"code,omitempty"`
+}
+
+func (e *JobError) Error() string {
+	return e.Message
+}
+
+// Job is a struct that represents a job of an async route as it is kept by the JobStore and served by its status route.
+type Job struct {
+	ID     string    `json:"id"`
+	Status JobStatus `json:"status"`
+	// Result is the JSON encoded result of the job. Only set once the job succeeded.
+	Result json.RawMessage `json:"result,omitempty"`
+	// Error is the error the job failed with. Only set once the job failed.
+	Error *JobError `json:"error,omitempty"`
+}
+
+// JobStore is an interface that keeps the jobs of async routes by their ID. It has to be shared between instances if the
+// status of a job may be requested from another instance than the one which started the job.
+type JobStore interface {
+	// Load returns the job with the given ID. Returns false if the ID is unknown, e.g. because the job has expired.
+	Load(ctx context.Context, id string) (*Job, bool, error)
+	// Save stores the given job under its ID, replacing the previous status of the job.
+	Save(ctx context.Context, job *Job) error
+}
+
+// JobRef is a struct that references a job with a result of type T. Return it from the handler of an async route to
+// respond with 202 Accepted and the Location of the status of the job. It is usually returned by StartJob, but can also
+// reference jobs which are run by workers saving their status into the JobStore themselves.
+type JobRef[T any] struct {
+	ID string
+}
+
+func (r JobRef[T]) jobID() string {
+	return r.ID
+}
+
+func (r JobRef[T]) jobResultType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// jobRef is implemented by every JobRef.
+type jobRef interface {
+	jobID() string
+	jobResultType() reflect.Type
+}
+
+// JobAccepted is a struct that represents the response of async routes, which started the job with the given ID.
+type JobAccepted struct {
+	ID string `json:"id"`
+}
+
+// jobAcceptedType is the response type of async routes.
+var jobAcceptedType = reflect.TypeOf(JobAccepted{})
+
+// jobRefType is the interface implemented by the JobRef results of the handlers of async routes.
+var jobRefType = reflect.TypeOf((*jobRef)(nil)).Elem()
+
+// jobResultType returns the result type of the jobs referenced by the given handler result, if it is a JobRef.
+func jobResultType(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() != reflect.Struct || !t.Implements(jobRefType) {
+		return nil, false
+	}
+
+	return reflect.Zero(t).Interface().(jobRef).jobResultType(), true
+}
+
+// StartJob saves a pending job to the given store and runs the given function as the job in a new goroutine. The function
+// receives the given context without its cancellation, so the job keeps running after the response has been written. Its
+// result is serialized with the registered serializers, but without applying visible tags, as the job has no user. Panics if
+// the job cannot be saved.
+func StartJob[T any](ctx context.Context, store JobStore, run func(ctx context.Context) (T, error)) JobRef[T] {
+	ctx = context.WithoutCancel(ctx)
+	job := &Job{ID: uuid.NewString(), Status: JobPending}
+	if err := store.Save(ctx, job); err != nil {
+		panic(err)
+	}
+
+	go runJob(ctx, store, job.ID, func(ctx context.Context) (any, error) {
+		return run(ctx)
+	})
+
+	return JobRef[T]{ID: job.ID}
+}
+
+// runJob runs the job with the given ID and saves its status to the given store.
+func runJob(ctx context.Context, store JobStore, id string, run func(ctx context.Context) (any, error)) {
+	save := func(job *Job) {
+		if err := store.Save(ctx, job); err != nil {
+			log.Println("octanox: cannot save job " + id + ": " + err.Error())
+		}
+	}
+
+	save(&Job{ID: id, Status: JobRunning})
+
+	result, err := func() (result any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				if failedReq, ok := panicFailure(r); ok {
+					err = &JobError{Message: failedReq.message, Code: failedReq.code}
+					return
+				}
+
+				err = fmt.Errorf("job panicked: %v", r)
+			}
+		}()
+
+		return run(ctx)
+	}()
+
+	if err == nil {
+		var out []byte
+		out, err = json.Marshal(Current.normalizeCollections(Current.Serialize(result, nil)))
+		if err == nil {
+			save(&Job{ID: id, Status: JobSucceeded, Result: out})
+			return
+		}
+	}
+
+	var jobErr *JobError
+	if !errors.As(err, &jobErr) {
+		log.Println("octanox: job " + id + " failed: " + err.Error())
+		jobErr = &JobError{Message: "Internal Server Error", Code: ErrorCodeInternal}
+	}
+
+	save(&Job{ID: id, Status: JobFailed, Error: jobErr})
+}
+
+// Async declares this route as the start of long-running jobs kept by the given store. Its handler has to return a JobRef,
+// e.g. of StartJob, and the route responds with 202 Accepted, the ID of the job and its Location at /jobs/:id, which serves
+// the status of the job, and its result or error once it finished. The generated TypeScript client gets a waitFor function
+// next to the function of the route, which polls the status until the job finished. The job IDs are random and the status
+// route only requires authentication if any async route does, so every authenticated user can read the status of a job
+// whose ID they know.
+func (r *Route) Async(store JobStore) *Route {
+	if r.jobResult == nil {
+		panic("octanox: route " + r.method + " " + r.path + " cannot be async, its handler has to return a JobRef")
+	}
+	if store == nil {
+		panic("octanox: async route " + r.method + " " + r.path + " needs a job store")
+	}
+
+	r.jobs = store
+	Current.jobsAuthenticated = Current.jobsAuthenticated || r.authenticated
+
+	for _, registered := range Current.jobStores {
+		if registered == store {
+			return r
+		}
+	}
+
+	if Current.jobStores == nil {
+		Current.registerJobStatus()
+	}
+	Current.jobStores = append(Current.jobStores, store)
+
+	return r
+}
+
+// usesJobs checks if any of the given routes is async.
+func usesJobs(routes []*Route) bool {
+	for _, route := range routes {
+		if route.jobs != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// respondJobAccepted responds to the request of an async route with 202 Accepted and the Location of the status of the
+// referenced job. The Location keeps the prefix stripped before the routing, e.g. the path prefix of the tenant.
+func respondJobAccepted(c *gin.Context, rt *Route, ref jobRef) {
+	if rt.jobs == nil {
+		panic("octanox: route " + rt.method + " " + rt.path + " returns a JobRef, but is not Async")
+	}
+
+	prefix := ""
+	path, _, _ := strings.Cut(c.Request.RequestURI, "?")
+	if stripped, ok := strings.CutSuffix(path, c.Request.URL.Path); ok {
+		prefix = stripped
+	}
+
+	c.Header("Location", prefix+jobsPath+"/"+ref.jobID())
+	respond(c, http.StatusAccepted, JobAccepted{ID: ref.jobID()})
+}
+
+// registerJobStatus serves the status of the jobs of the async routes at /jobs/:id.
+func (i *Instance) registerJobStatus() {
+	i.Gin.GET(jobsPath+"/:id", func(c *gin.Context) {
+		if i.jobsAuthenticated && i.Authenticator != nil {
+			user, err := i.Authenticator.Authenticate(c)
+			if err != nil {
+				panic(err)
+			}
+
+			if user == nil {
+				abortWithError(c, failedRequest{status: http.StatusUnauthorized, message: "unauthorized", code: ErrorCodeUnauthorized})
+				return
+			}
+		}
+
+		id := c.Param("id")
+		for _, store := range i.jobStores {
+			job, ok, err := store.Load(c.Request.Context(), id)
+			if err != nil {
+				panic(err)
+			}
+
+			if ok {
+				// the status changes until the job finished, so it is never cached
+				c.Header("Cache-Control", "no-store")
+				// the result is already encoded as JSON, so the status is never encoded as MessagePack
+				writeJSON(c, http.StatusOK, job)
+				return
+			}
+		}
+
+		abortWithError(c, failedRequest{status: http.StatusNotFound, message: "Unknown job: " + id, code: ErrorCodeNotFound})
+	})
+}
+
+// defaultJobRetention is the time the finished jobs are kept by NewMemoryJobStore(0).
+const defaultJobRetention = time.Hour
+
+// MemoryJobStore is a JobStore keeping the jobs in memory, until the given retention after they finished has passed.
+type MemoryJobStore struct {
+	mu        sync.Mutex
+	retention time.Duration
+	jobs      map[string]*memoryJob
+}
+
+type memoryJob struct {
+	job Job
+	// finished is the time the job finished at. Zero while it is pending or running.
+	finished time.Time
+}
+
+// NewMemoryJobStore creates a new in-memory store of jobs, which keeps finished jobs for the given retention. Defaults to
+// an hour if the retention is zero.
+func NewMemoryJobStore(retention time.Duration) *MemoryJobStore {
+	if retention < 0 {
+		panic("octanox: job store needs a positive retention")
+	}
+	if retention == 0 {
+		retention = defaultJobRetention
+	}
+
+	return &MemoryJobStore{retention: retention, jobs: make(map[string]*memoryJob)}
+}
+
+func (s *MemoryJobStore) Load(_ context.Context, id string) (*Job, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.jobs[id]
+	if !ok || s.expired(entry, time.Now()) {
+		return nil, false, nil
+	}
+
+	job := entry.job
+	return &job, true, nil
+}
+
+func (s *MemoryJobStore) Save(_ context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := &memoryJob{job: *job}
+	if job.Status.Terminal() {
+		entry.finished = time.Now()
+
+		// the expired jobs are dropped whenever a job finishes, so the store does not grow with the finished jobs
+		for id, other := range s.jobs {
+			if s.expired(other, entry.finished) {
+				delete(s.jobs, id)
+			}
+		}
+	}
+
+	s.jobs[job.ID] = entry
+	return nil
+}
+
+// expired checks if the retention of the given job has passed at the given time.
+func (s *MemoryJobStore) expired(entry *memoryJob, now time.Time) bool {
+	return !entry.finished.IsZero() && now.Sub(entry.finished) > s.retention
+}
@@ -2,9 +2,15 @@ package octanox
 
 import (
 	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -20,7 +26,12 @@ type Instance struct {
 	// Gin is the underlying Gin engine that powers the Octanox framework's web server.
 	Gin *gin.Engine
 	// Authenticator is the underlying authenticator that powers the Octanox framework's authentication operations. Can be nil if no authenticator has been created.
-	Authenticator     Authenticator
+	Authenticator Authenticator
+	// routeAuthenticators are the authenticators overriding the Authenticator for the routes matching their patterns.
+	routeAuthenticators []routeAuthenticator
+	// ErrorResponseType is the type of the bodies of error responses, e.g. of responses written by a custom error handler. Can be nil
+	// to leave them untyped. The generated TypeScript client declares an interface for it and types ApiError.body with it.
+	ErrorResponseType reflect.Type
 	authLoginBasePath string
 	// hooks is a map of hooks to their respective functions.
 	hooks map[Hook][]func(*Instance)
@@ -31,9 +42,103 @@ type Instance struct {
 	// isDryRun is a flag that indicates whether the Octanox framework is running in dry-run mode.
 	isDryRun bool
 	// routes is a list of routes that have been registered in the Octanox framework.
-	routes []route
+	routes []*Route
+	// middlewares are the middlewares registered with Use and UseFor, in the order of their registration.
+	middlewares []routeMiddleware
+	// middlewareChains are the middleware chains of the routes, built by Handler. Routes no middleware applies to have none.
+	// Replaced as a whole, so requests being served while Handler is called again never see a half built chain.
+	middlewareChains atomic.Pointer[map[*Route]http.Handler]
 	// serializers is a map of serializers to their respective functions.
 	serializers serializerRegistry
+	// tsGenOptions are the options used for the TypeScript client code generation.
+	tsGenOptions TypeScriptGenerationOptions
+	// protoJSON are the options used to encode and decode protobuf messages.
+	protoJSON ProtoJSONOptions
+	// transformVersionHeader is the header the API version passed to the body transformers is read from.
+	transformVersionHeader string
+	// transformVersionQuery is the query parameter the API version passed to the body transformers is read from.
+	transformVersionQuery string
+	// strictContracts is a flag that indicates whether Run validates the route contracts before starting.
+	strictContracts bool
+	// contractAllowlist is a set of contract error codes and findings that are ignored by the strict contract validation.
+	contractAllowlist map[string]bool
+	// tenantExtractors is a list of extractors used to resolve the tenant of a request.
+	tenantExtractors []TenantExtractor
+	// internalOptions are the options guarding the internal endpoints.
+	internalOptions InternalOptions
+	// internalGroup is the router group of the internal endpoints. Can be nil if no internal endpoint has been mounted.
+	internalGroup *gin.RouterGroup
+	// drain is the tracker of the in-flight requests. Can be nil if the drain status is not enabled.
+	drain *drainTracker
+	// server is the HTTP server of the runtime, set once it listens.
+	server atomic.Pointer[http.Server]
+	// shutdownTimeout is the time in-flight requests are given to complete during a graceful shutdown.
+	shutdownTimeout time.Duration
+	// deploymentID is the ID every response is tagged with in the X-Deployment-ID header. Can be empty.
+	deploymentID string
+	// keyProvider provides the keys of the encrypted fields. Can be nil if no fields are encrypted.
+	keyProvider KeyProvider
+	// securityHeaders are the security headers sent with every response. Can be nil if they are not enabled.
+	securityHeaders *SecurityHeadersOptions
+	// dictionaries compresses the responses against the shared dictionaries of their route groups. Can be nil if responses
+	// are not compressed against dictionaries.
+	dictionaries *dictionaryCompression
+	// persistedQueries stores the queries of the routes with persisted queries by their hash. Can be nil if no route uses them.
+	persistedQueries PersistedQueryStore
+	// servesPersistedQueries is a flag that indicates whether the registration of persisted queries is served.
+	servesPersistedQueries bool
+	// jobStores are the stores of the jobs of the async routes, whose status is served at /jobs/:id. Can be empty if no
+	// route is async.
+	jobStores []JobStore
+	// jobsAuthenticated is a flag that indicates whether the status of jobs requires authentication, as an async route does.
+	jobsAuthenticated bool
+	// routeStats is the collector of the per-route stats. Can be nil if the route stats are not enabled.
+	routeStats *routeStatsCollector
+	// collections is the normalizer of nil slices and maps in responses, configured with the nil collection policy.
+	collections *collectionNormalizer
+	// hiddenFields decides how response fields hidden from the user by their visible tag are encoded.
+	hiddenFields HiddenFieldPolicy
+	// roles is a set of the declared roles of the users. Can be nil if the roles are not declared.
+	roles map[string]bool
+	// nullableSources are the fields declared as backed by nullable sources with NullableSources, by their struct type.
+	nullableSources map[reflect.Type]map[string]bool
+	// typeDescriptions are the descriptions of the named types declared with DescribeType. Can be nil.
+	typeDescriptions map[reflect.Type]string
+	// decompression are the options of the transparent request body decompression.
+	decompression RequestDecompressionOptions
+	// contentDecoders is a map of content encodings to the decoders of request bodies sent with them.
+	contentDecoders map[string]ContentDecoder
+	// suppressedFindings is a set of the IDs of the findings suppressed in Doctor.
+	suppressedFindings map[string]bool
+	// budgetEnforcement decides what happens when a request exceeds the budget of its route.
+	budgetEnforcement BudgetEnforcement
+	// services is a map of the provided services to inject into request structs by their type.
+	services map[reflect.Type]reflect.Value
+	// enums are the TypeScript literals of the legal values of the registered enum types.
+	enums map[reflect.Type][]string
+	// assumeJSONBodies is a flag that indicates whether request bodies without a Content-Type are decoded as JSON.
+	assumeJSONBodies bool
+	// disallowUnknownFields is a flag that indicates whether JSON request bodies with unknown fields are rejected on all routes.
+	disallowUnknownFields bool
+	// apiVersions are the declared versions of the API, sorted. Can be empty if the versions are not declared.
+	apiVersions []string
+	// defaultAPIVersion is the version served to requests of versioned routes without an Accept-Version header. Can be empty
+	// to serve the newest variant.
+	defaultAPIVersion string
+	// problemDetails are the options of rendering errors as problem details. Can be nil if errors use the default shape.
+	problemDetails *ProblemDetailsOptions
+	// verifyResponseHeaders is a flag that indicates whether undeclared response headers are logged, only set in debug mode.
+	verifyResponseHeaders bool
+	// txBeginner begins the transactions of the transaction middleware. Can be nil if the routes run without transactions.
+	txBeginner TxBeginner
+	// quotas is a map of the declared quotas by their name.
+	quotas map[string]*quota
+	// generators are the generators that run in dry-run mode, in the order of their registration.
+	generators []registeredGenerator
+	// runtimeConfig is the runtime config, which can be changed while serving.
+	runtimeConfig runtimeConfigState
+	// routeGates are the trackers of the requests in flight of the registered routes by their route key, e.g. "GET /users/:id".
+	routeGates map[string]*routeGate
 }
 
 // New creates a new instance of the Octanox framework. If an instance already exists, it will return the existing instance.
@@ -49,21 +154,43 @@ func New() *Instance {
 		SubRouter: &SubRouter{
 			gin: &ginEngine.RouterGroup,
 		},
-		Gin:           ginEngine,
-		hooks:         make(map[Hook][]func(*Instance)),
-		errorHandlers: make([]func(error), 0),
-		isDebug:       gin.Mode() == gin.DebugMode,
-		isDryRun:      os.Getenv("NOX__DRY_RUN") == "true",
-		routes:        make([]route, 0),
-		serializers:   make(serializerRegistry),
+		Gin:                    ginEngine,
+		hooks:                  make(map[Hook][]func(*Instance)),
+		errorHandlers:          make([]func(error), 0),
+		isDebug:                gin.Mode() == gin.DebugMode,
+		isDryRun:               os.Getenv("NOX__DRY_RUN") == "true",
+		routes:                 make([]*Route, 0),
+		serializers:            make(serializerRegistry),
+		transformVersionHeader: "X-API-Version",
+		transformVersionQuery:  "api_version",
+		collections:            &collectionNormalizer{},
+		contentDecoders:        defaultContentDecoders(),
+		suppressedFindings:     make(map[string]bool),
+		services:               make(map[reflect.Type]reflect.Value),
+		enums:                  make(map[reflect.Type][]string),
+		quotas:                 make(map[string]*quota),
+		routeGates:             make(map[string]*routeGate),
+		shutdownTimeout:        defaultShutdownTimeout,
+		deploymentID:           os.Getenv("NOX__DEPLOYMENT_ID"),
 	}
 
+	if err := Current.ApplyRuntimeConfig(defaultRuntimeConfig()); err != nil {
+		panic("octanox: invalid runtime config from the environment: " + err.Error())
+	}
+
+	Current.AddGenerator(typeScriptGenerator, generateTypeScriptClient)
+
 	Current.emitHook(Hook_Init)
 
 	Current.Gin.Use(cors())
+	Current.Gin.Use(deploymentID())
+	Current.Gin.Use(securityHeaders())
 	Current.Gin.Use(logger())
 	Current.Gin.Use(recovery())
+	Current.Gin.Use(maintenance())
+	Current.Gin.Use(rateLimit())
 	Current.Gin.Use(errorCollectorToHandler())
+	Current.Gin.Use(decompressRequest())
 
 	return Current
 }
@@ -82,17 +209,35 @@ func (i *Instance) ErrorHandler(f func(error)) {
 	i.errorHandlers = append(i.errorHandlers, f)
 }
 
+// Handler returns the http.Handler serving the Octanox runtime, including everything that has to run before the route matching.
+// The middleware chains of the routes are built by it, so it has to be called again after registering more middlewares.
+func (i *Instance) Handler() http.Handler {
+	i.applyMiddlewares()
+
+	var handler http.Handler = i.Gin
+
+	if len(i.tenantExtractors) > 0 {
+		handler = i.resolveTenant(handler)
+	}
+
+	return handler
+}
+
 // Run starts the Octanox runtime. This function will block the current goroutine. If any error occurs, it will panic.
+// On an interrupt or SIGTERM the server stops accepting connections and gives the in-flight requests the shutdown timeout to
+// complete. A second signal terminates immediately.
 func (i *Instance) Run() {
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
 	log.Println("Starting Octanox...")
 	go i.runInternally()
 
 	<-ctx.Done()
+	cancel()
 
 	log.Println("Shutting down...")
+	i.shutdown()
 	i.emitHook(Hook_Shutdown)
 }
 
@@ -113,15 +258,44 @@ func (i *Instance) emitError(err error) {
 func (i *Instance) runInternally() {
 	i.emitHook(Hook_BeforeStart)
 
+	if i.strictContracts {
+		if report := i.contractReport(); report != "" {
+			log.Fatal(report)
+		}
+	}
+
+	if os.Getenv("NOX__DOCTOR") == "true" {
+		i.runDoctor()
+		return
+	}
+
 	if i.isDryRun {
-		log.Println("Dry-run mode enabled. Generating TypeScript code...")
-		i.generateTypeScriptClientCode(os.Getenv("NOX__CLIENT_DIR"), i.routes)
-		log.Println("TypeScript code generated successfully.")
+		log.Println("Dry-run mode enabled. Running generators...")
+		if err := i.runGenerators(); err != nil {
+			log.Fatal("octanox: generation failed:\n" + err.Error())
+		}
 		os.Exit(0)
 		return
 	}
 
+	// constant routes are computed before the server is started, so the first requests are served from the cache
+	if err := i.RefreshConstants(); err != nil {
+		log.Fatal("octanox: cannot compute the responses of the constant routes:\n" + err.Error())
+	}
+
 	i.emitHook(Hook_Start)
 
-	i.Gin.Run()
+	addr := ":8080"
+	if port := os.Getenv("PORT"); port != "" {
+		addr = ":" + port
+	}
+
+	i.logStartupSummary(addr)
+
+	server := &http.Server{Addr: addr, Handler: i.Handler()}
+	i.server.Store(server)
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		panic(err)
+	}
 }
@@ -0,0 +1,214 @@
+package octanox
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultListLimit is the limit used if the client does not request a specific one.
+	defaultListLimit = 20
+	// defaultMaxListLimit is the maximum limit a client may request, if the route does not declare its own.
+	defaultMaxListLimit = 100
+)
+
+// SortField is a struct that represents a single field a list is sorted by.
+type SortField struct {
+	// Field is the name of the field to sort by.
+	Field string
+	// Desc is a flag that indicates whether the list is sorted in descending order.
+	Desc bool
+}
+
+// ListQuery is a struct that can be embedded in request structs of list routes. It is bound from the query parameters
+// "cursor", "page", "limit", "sort" (comma separated, prefixed with "-" for descending order) and "filter[<field>]".
+// Sort and filter fields are validated against the allowlists declared with Route.Sortable and Route.Filterable.
+type ListQuery struct {
+	// Cursor is the opaque cursor of the page to return. Empty for the first page or page based pagination.
+	Cursor string
+	// Page is the 1-based number of the page to return. Defaults to 1.
+	Page int
+	// Limit is the maximum number of items to return. Zero on export routes, which return all items.
+	Limit int
+	// Sort are the fields to sort the list by, in order of precedence.
+	Sort []SortField
+	// Filters are the free-form filters, keyed by field name.
+	Filters map[string]string
+}
+
+// Offset returns the number of items to skip for page based pagination.
+func (q ListQuery) Offset() int {
+	return (q.Page - 1) * q.Limit
+}
+
+// ListResult is a struct that wraps the items of a list route. Returning it from a handler automatically sets the
+// X-Total-Count header, if the total is known, and the RFC 8288 Link header pointing to the neighbouring pages.
+type ListResult[T any] struct {
+	// Items are the items of the current page.
+	Items []T `json:"items" nullable:"false"`
+	// Total is the total number of items. Can be nil if the total is unknown.
+	Total *int `json:"total,omitempty"`
+	// NextCursor is the cursor of the next page. Empty if there is no next page or the list is page based.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func (r ListResult[T]) listMeta() (int, *int, string) {
+	return len(r.Items), r.Total, r.NextCursor
+}
+
+// listResult is implemented by every ListResult.
+type listResult interface {
+	listMeta() (count int, total *int, nextCursor string)
+}
+
+// listOptions are the list options declared at the registration of a route.
+type listOptions struct {
+	sortable   []string
+	filterable []string
+	maxLimit   int
+	// export is a flag that indicates whether the route is the export sibling of a list route, which is never paginated.
+	export bool
+}
+
+// Sortable declares the fields the list of this route can be sorted by.
+func (r *Route) Sortable(fields ...string) *Route {
+	r.list.sortable = append(r.list.sortable, fields...)
+	return r
+}
+
+// Filterable declares the fields the list of this route can be filtered by.
+func (r *Route) Filterable(fields ...string) *Route {
+	r.list.filterable = append(r.list.filterable, fields...)
+	return r
+}
+
+// MaxLimit sets the maximum number of items a client may request from the list of this route. Defaults to 100.
+func (r *Route) MaxLimit(limit int) *Route {
+	r.list.maxLimit = limit
+	return r
+}
+
+// bindListQuery binds the list query parameters of the request and validates them against the list options of the route.
+func bindListQuery(c *gin.Context, opts listOptions) ListQuery {
+	query := ListQuery{
+		Cursor:  c.Query("cursor"),
+		Page:    1,
+		Limit:   defaultListLimit,
+		Filters: make(map[string]string),
+	}
+
+	if opts.export {
+		query.Cursor, query.Limit = "", 0
+	}
+
+	maxLimit := opts.maxLimit
+	if maxLimit <= 0 {
+		maxLimit = defaultMaxListLimit
+	}
+
+	if page := c.Query("page"); page != "" && !opts.export {
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 {
+			failList("Invalid page: must be a positive integer")
+		}
+		query.Page = n
+	}
+
+	if limit := c.Query("limit"); limit != "" && !opts.export {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxLimit {
+			failList("Invalid limit: must be between 1 and " + strconv.Itoa(maxLimit))
+		}
+		query.Limit = n
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		for _, field := range strings.Split(sort, ",") {
+			field = strings.TrimSpace(field)
+			desc := strings.HasPrefix(field, "-")
+			field = strings.TrimPrefix(field, "-")
+
+			if !containsString(opts.sortable, field) {
+				failList("Invalid sort field: " + field)
+			}
+
+			query.Sort = append(query.Sort, SortField{Field: field, Desc: desc})
+		}
+	}
+
+	for key, values := range c.Request.URL.Query() {
+		field, ok := strings.CutPrefix(key, "filter[")
+		if !ok || !strings.HasSuffix(field, "]") {
+			continue
+		}
+
+		field = strings.TrimSuffix(field, "]")
+		if !containsString(opts.filterable, field) {
+			failList("Invalid filter field: " + field)
+		}
+
+		query.Filters[field] = values[0]
+	}
+
+	return query
+}
+
+func failList(message string) {
+	panic(failedRequest{
+		status:  http.StatusBadRequest,
+		message: message,
+		code:    ErrorCodeInvalidListQuery,
+	})
+}
+
+// setListHeaders sets the X-Total-Count and Link headers for the given list result.
+func setListHeaders(c *gin.Context, res listResult) {
+	count, total, nextCursor := res.listMeta()
+
+	if total != nil {
+		c.Header("X-Total-Count", strconv.Itoa(*total))
+	}
+
+	links := make([]string, 0, 2)
+	if nextCursor != "" {
+		links = append(links, listLink(c, "next", map[string]string{"cursor": nextCursor}))
+	} else if c.Query("cursor") == "" {
+		page, _ := strconv.Atoi(c.Query("page"))
+		if page < 1 {
+			page = 1
+		}
+
+		limit, _ := strconv.Atoi(c.Query("limit"))
+		if limit < 1 {
+			limit = defaultListLimit
+		}
+
+		if total != nil && page*limit < *total || total == nil && count == limit {
+			links = append(links, listLink(c, "next", map[string]string{"page": strconv.Itoa(page + 1)}))
+		}
+
+		if page > 1 {
+			links = append(links, listLink(c, "prev", map[string]string{"page": strconv.Itoa(page - 1)}))
+		}
+	}
+
+	if len(links) > 0 {
+		// added, as the response may also advertise a compression dictionary
+		c.Writer.Header().Add("Link", strings.Join(links, ", "))
+	}
+}
+
+// listLink returns a Link header entry pointing to the current request with the given query parameters replaced.
+func listLink(c *gin.Context, rel string, params map[string]string) string {
+	u := *c.Request.URL
+	query := u.Query()
+	for key, value := range params {
+		query.Set(key, value)
+	}
+	u.RawQuery = query.Encode()
+
+	return "<" + u.RequestURI() + ">; rel=\"" + rel + "\""
+}
@@ -2,30 +2,39 @@ package octanox
 
 import (
 	"fmt"
-	"os"
+	"net/http"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 )
 
 func logger() gin.HandlerFunc {
-	return gin.Logger()
+	return gin.LoggerWithConfig(gin.LoggerConfig{
+		Skip: func(c *gin.Context) bool {
+			return !requestRuntimeSnapshot(c).logs(c.Writer.Status())
+		},
+	})
 }
 
 func cors() gin.HandlerFunc {
-	corsAllowedOrigin := os.Getenv("NOX__CORS_ALLOWED_ORIGINS")
+	// the header values are built once and shared by all responses, the allowed origin is read from the runtime config
+	allowCredentials := []string{"true"}
+	allowMethods := []string{"GET, PATCH, POST, PUT, DELETE, OPTIONS"}
+	var allowHeaders, exposeHeaders []string
+	var headersOnce sync.Once
 
 	return func(c *gin.Context) {
-		if corsAllowedOrigin == "*" {
-			requestDomain := c.Request.Header.Get("Origin")
-			c.Writer.Header().Set("Access-Control-Allow-Origin", requestDomain)
-		} else {
-			c.Writer.Header().Set("Access-Control-Allow-Origin", corsAllowedOrigin)
-		}
+		headersOnce.Do(func() {
+			allowHeaders = []string{allowedHeaders()}
+			exposeHeaders = []string{exposedResponseHeaders(Current.routes)}
+		})
 
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, PATCH, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Baggage, Accept, Sentry-Trace")
-		c.Writer.Header().Set("Access-Control-Expose-Headers", "Authorization, Content-Type")
+		header := c.Writer.Header()
+		header["Access-Control-Allow-Origin"] = requestRuntimeSnapshot(c).allowOriginFor(c.Request.Header.Get("Origin"))
+		header["Access-Control-Allow-Credentials"] = allowCredentials
+		header["Access-Control-Allow-Methods"] = allowMethods
+		header["Access-Control-Allow-Headers"] = allowHeaders
+		header["Access-Control-Expose-Headers"] = exposeHeaders
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(200)
@@ -40,21 +49,43 @@ func recovery() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if err := recover(); err != nil {
-				failedReq, ok := err.(failedRequest)
-				if ok {
-					c.JSON(failedReq.status, gin.H{"error": failedReq.message})
+				// the response has already been started, net/http aborts the connection
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+
+				if failedReq, ok := panicFailure(err); ok {
+					abortWithError(c, failedReq)
 					return
 				}
 
 				Current.emitError(Error(fmt.Errorf("internal REST Server Error: %v", err)))
 
-				c.JSON(500, gin.H{"error": "Internal Server Error"})
+				abortWithError(c, failedRequest{status: 500, message: "Internal Server Error", code: ErrorCodeInternal})
 			}
 		}()
 		c.Next()
 	}
 }
 
+// panicFailure returns the failure of the request a handler panicked with, e.g. a failedRequest of the binder. Returns false
+// for unexpected panics.
+func panicFailure(err any) (failedRequest, bool) {
+	if failedReq, ok := err.(failedRequest); ok {
+		return failedReq, true
+	}
+
+	if gone := asGoneError(err); gone != nil {
+		return gone.failedRequest(), true
+	}
+
+	if unavailable := asUnavailableError(err); unavailable != nil {
+		return unavailable.failedRequest(), true
+	}
+
+	return failedRequest{}, false
+}
+
 // errorCollectorToHandler emits all collected errors in the Gin context to the error handlers.
 func errorCollectorToHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -65,3 +96,16 @@ func errorCollectorToHandler() gin.HandlerFunc {
 		}
 	}
 }
+
+// allowedHeaders returns the request headers allowed by CORS, including the headers tenants are resolved from.
+func allowedHeaders() string {
+	headers := "Authorization, Content-Type, Baggage, Accept, Accept-Version, Sentry-Trace, X-Nox-Token"
+
+	for _, extractor := range Current.tenantExtractors {
+		if header, ok := extractor.(*HeaderTenantExtractor); ok {
+			headers += ", " + header.Header
+		}
+	}
+
+	return headers
+}
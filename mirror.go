@@ -0,0 +1,457 @@
+package octanox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+)
+
+const (
+	// MirrorHeader is the header marking shadow requests, so downstream systems can exclude them from side effects.
+	MirrorHeader = "X-Nox-Mirror"
+
+	defaultMirrorTimeout       = 5 * time.Second
+	defaultMirrorMaxBodySize   = 1 << 20
+	defaultMirrorMaxConcurrent = 64
+	// maxMirrorDifferences is the maximum number of differences reported per mismatch.
+	maxMirrorDifferences = 20
+)
+
+// mirrorCredentialHeaders are the headers carrying credentials, which are not sent to remote upstreams unless forwarded.
+var mirrorCredentialHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "X-API-Key", "X-Nox-Token", diagnosticsTokenHeader}
+
+// MirrorOptions is a struct that configures the shadowing of the requests of a route to an alternate implementation, whose
+// responses are compared to the responses of the route without affecting them.
+type MirrorOptions struct {
+	// Rate is the fraction of requests which are mirrored, in (0, 1].
+	Rate float64
+	// Handler is the alternate implementation, a handler function of the same request type as the route. Either Handler or
+	// URL has to be set.
+	Handler any
+	// URL is the base URL of a remote upstream, e.g. a staging deployment, the path and query of the request are appended to.
+	URL string
+	// Timeout is the time the shadow is given to answer. Defaults to the latency budget of the route, or 5 seconds.
+	Timeout time.Duration
+	// Volatile are the paths of the JSON fields ignored when comparing the responses, e.g. "id" or "items.created_at", which
+	// match the field in every item of the array.
+	Volatile []string
+	// MaxBodySize is the maximum size of the request and response bodies in bytes. Requests and responses exceeding it are not
+	// mirrored. Defaults to 1 MiB.
+	MaxBodySize int64
+	// MaxConcurrent is the maximum number of shadow requests in flight. Requests sampled while it is reached are not mirrored.
+	// Defaults to 64.
+	MaxConcurrent int
+	// ForwardHeaders are the credential headers sent to the remote upstream, e.g. "Authorization" if it authenticates the
+	// same users. By default the Authorization, Proxy-Authorization, Cookie, X-API-Key and internal token headers are
+	// removed from the requests mirrored to a URL.
+	ForwardHeaders []string
+	// OnMismatch is called with every mirrored request the shadow answered differently. Defaults to logging the mismatch.
+	OnMismatch func(mismatch MirrorMismatch)
+}
+
+// MirrorMismatch is a struct that describes a mirrored request the shadow answered differently than the route.
+type MirrorMismatch struct {
+	Method string
+	Path   string
+	// PrimaryStatus is the status the route answered with.
+	PrimaryStatus int
+	// ShadowStatus is the status the shadow answered with. Zero if it did not answer.
+	ShadowStatus int
+	// Differences are the paths of the JSON values which differ, e.g. "items.0.name", or "body" if the bodies are no JSON.
+	Differences []string
+	// Err is the reason the shadow did not answer, e.g. its timeout. Can be nil.
+	Err error
+}
+
+func (m MirrorMismatch) String() string {
+	if m.Err != nil {
+		return m.Method + " " + m.Path + ": shadow failed: " + m.Err.Error()
+	}
+
+	if m.PrimaryStatus != m.ShadowStatus {
+		return m.Method + " " + m.Path + ": shadow answered " + strconv.Itoa(m.ShadowStatus) + " instead of " + strconv.Itoa(m.PrimaryStatus)
+	}
+
+	return m.Method + " " + m.Path + ": shadow answered differently at " + strings.Join(m.Differences, ", ")
+}
+
+// routeMirror is the mirroring of a route.
+type routeMirror struct {
+	opts     MirrorOptions
+	volatile map[string]bool
+	// shadow is the route of the alternate handler. Can be nil if requests are mirrored to a remote upstream.
+	shadow *Route
+	client *http.Client
+	// slots limits the shadow requests in flight, each holds a value while it is being mirrored.
+	slots chan struct{}
+	// forward are the canonical forms of the credential headers sent to the remote upstream.
+	forward map[string]bool
+}
+
+// Mirror shadows the given fraction of the requests of this route to an alternate handler or a remote upstream, e.g. before
+// cutting over to a rewritten handler. The shadow is sent a copy of the request, marked with the X-Nox-Mirror header, after
+// the route answered and without delaying it. Its status and JSON body are compared to the response of the route, ignoring
+// the volatile fields, and differences are reported to OnMismatch and counted in the route stats. Mirrored requests are
+// never mirrored again.
+func (r *Route) Mirror(opts MirrorOptions) *Route {
+	if opts.Rate <= 0 || opts.Rate > 1 {
+		panic("octanox: mirror rate of " + r.method + " " + r.path + " must be in (0, 1]")
+	}
+
+	if (opts.Handler == nil) == (opts.URL == "") {
+		panic("octanox: mirror of " + r.method + " " + r.path + " needs either a handler or a URL")
+	}
+
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = defaultMirrorMaxBodySize
+	}
+	if opts.MaxConcurrent <= 0 {
+		opts.MaxConcurrent = defaultMirrorMaxConcurrent
+	}
+
+	if opts.OnMismatch == nil {
+		opts.OnMismatch = func(mismatch MirrorMismatch) {
+			log.Println("octanox: mirror mismatch of " + mismatch.String())
+		}
+	}
+
+	m := &routeMirror{opts: opts, volatile: make(map[string]bool, len(opts.Volatile)), client: &http.Client{}, slots: make(chan struct{}, opts.MaxConcurrent)}
+	for _, path := range opts.Volatile {
+		m.volatile[path] = true
+	}
+
+	m.forward = make(map[string]bool, len(opts.ForwardHeaders))
+	for _, header := range opts.ForwardHeaders {
+		m.forward[http.CanonicalHeaderKey(header)] = true
+	}
+
+	if opts.Handler != nil {
+		router := &SubRouter{url: strings.TrimSuffix(r.path, r.relativePath), gin: r.group, params: r.groupParams}
+		m.shadow = router.newRoute(r.relativePath, opts.Handler, r.authenticated, r.roles)
+		m.shadow.defaultAccess = r.defaultAccess
+
+		if m.shadow.requestType != r.requestType {
+			panic("octanox: mirror handler of " + r.method + " " + r.path + " must take *" + r.requestType.String() + ", not *" + m.shadow.requestType.String())
+		}
+	}
+
+	r.mirror = m
+	return r
+}
+
+// timeout returns the time the shadow of the given route is given to answer.
+func (m *routeMirror) timeout(rt *Route) time.Duration {
+	if m.opts.Timeout > 0 {
+		return m.opts.Timeout
+	}
+
+	if rt.budget != nil && rt.budget.MaxLatency > 0 {
+		return rt.budget.MaxLatency
+	}
+
+	return defaultMirrorTimeout
+}
+
+// mirrorResponse is the status and body of a response of the route or its shadow.
+type mirrorResponse struct {
+	status int
+	body   []byte
+}
+
+// mirrorRequest runs the given handler and, for the sampled requests, sends a copy of the request to the shadow of the route
+// in the background once it answered. Sampled requests are not mirrored while the maximum of shadow requests is in flight.
+func mirrorRequest(c *gin.Context, rt *Route, handler func()) {
+	m := rt.mirror
+	if c.GetHeader(MirrorHeader) != "" || rand.Float64() >= m.opts.Rate {
+		handler()
+		return
+	}
+
+	select {
+	case m.slots <- struct{}{}:
+	default:
+		handler()
+		return
+	}
+
+	mirrored := false
+	defer func() {
+		if !mirrored {
+			<-m.slots
+		}
+	}()
+
+	var body []byte
+	if c.Request.Body != nil && c.Request.Body != http.NoBody {
+		original := c.Request.Body
+		buffered, err := io.ReadAll(io.LimitReader(original, m.opts.MaxBodySize+1))
+		if err != nil || int64(len(buffered)) > m.opts.MaxBodySize {
+			// the body cannot be mirrored, the route still reads the whole of it
+			c.Request.Body = bufferedBody{io.MultiReader(bytes.NewReader(buffered), original), original}
+			handler()
+			return
+		}
+
+		body = buffered
+		c.Request.Body = bufferedBody{bytes.NewReader(buffered), original}
+	}
+
+	recorder := &mirrorRecorder{ResponseWriter: c.Writer, limit: m.opts.MaxBodySize}
+	c.Writer = recorder
+	handler()
+	c.Writer = recorder.ResponseWriter
+
+	if recorder.exceeded {
+		return
+	}
+
+	primary := mirrorResponse{status: c.Writer.Status(), body: recorder.body.Bytes()}
+	req := c.Request.Clone(context.Background())
+	params := append(gin.Params{}, c.Params...)
+	groupParams, _ := c.Get(groupParamsKey)
+
+	mirrored = true
+	go m.run(rt, req, body, params, groupParams, primary)
+}
+
+// run sends the given copy of a request to the shadow and reports it if the shadow answers differently than the route. Frees
+// the slot of the request once done.
+func (m *routeMirror) run(rt *Route, req *http.Request, body []byte, params gin.Params, groupParams any, primary mirrorResponse) {
+	defer func() {
+		<-m.slots
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout(rt))
+	defer cancel()
+
+	req = req.WithContext(ctx)
+	req.Header.Set(MirrorHeader, "true")
+
+	var shadow mirrorResponse
+	var err error
+	if m.shadow != nil {
+		shadow, err = m.serveInProcess(req, body, params, groupParams)
+	} else {
+		shadow, err = m.serveRemote(req, body)
+	}
+
+	mismatch := MirrorMismatch{Method: rt.method, Path: rt.path, PrimaryStatus: primary.status, ShadowStatus: shadow.status, Err: err}
+	if err == nil {
+		mismatch.Differences = m.diff(primary.body, shadow.body)
+		if primary.status == shadow.status && len(mismatch.Differences) == 0 {
+			return
+		}
+	}
+
+	if Current.routeStats != nil {
+		Current.routeStats.recordMirrorMismatch(rt.method, rt.path)
+	}
+
+	m.opts.OnMismatch(mismatch)
+}
+
+// serveInProcess serves the given request with the alternate handler of the route.
+func (m *routeMirror) serveInProcess(req *http.Request, body []byte, params gin.Params, groupParams any) (mirrorResponse, error) {
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	recorder := httptest.NewRecorder()
+	c := gin.CreateTestContextOnly(recorder, Current.Gin)
+	c.Request = req
+	c.Params = params
+	if groupParams != nil {
+		c.Set(groupParamsKey, groupParams)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				if failedReq, ok := panicFailure(err); ok {
+					abortWithError(c, failedReq)
+					done <- nil
+					return
+				}
+
+				done <- fmt.Errorf("handler panicked: %v", err)
+			}
+		}()
+
+		m.shadow.handler(c)
+		done <- nil
+	}()
+
+	select {
+	case <-req.Context().Done():
+		return mirrorResponse{}, fmt.Errorf("handler did not answer: %w", req.Context().Err())
+	case err := <-done:
+		if err != nil {
+			return mirrorResponse{}, err
+		}
+	}
+
+	return mirrorResponse{status: c.Writer.Status(), body: recorder.Body.Bytes()}, nil
+}
+
+// serveRemote sends the given request to the remote upstream.
+func (m *routeMirror) serveRemote(req *http.Request, body []byte) (mirrorResponse, error) {
+	remote, err := http.NewRequestWithContext(req.Context(), req.Method, strings.TrimSuffix(m.opts.URL, "/")+req.URL.RequestURI(), bytes.NewReader(body))
+	if err != nil {
+		return mirrorResponse{}, err
+	}
+
+	remote.Header = req.Header.Clone()
+	remote.Header.Del("Content-Length")
+	for _, header := range mirrorCredentialHeaders {
+		if !m.forward[http.CanonicalHeaderKey(header)] {
+			remote.Header.Del(header)
+		}
+	}
+
+	resp, err := m.client.Do(remote)
+	if err != nil {
+		return mirrorResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, m.opts.MaxBodySize+1))
+	if err != nil {
+		return mirrorResponse{}, err
+	}
+
+	if int64(len(data)) > m.opts.MaxBodySize {
+		return mirrorResponse{status: resp.StatusCode}, errors.New("response exceeds the maximum body size of " + strconv.FormatInt(m.opts.MaxBodySize, 10) + " bytes")
+	}
+
+	return mirrorResponse{status: resp.StatusCode, body: data}, nil
+}
+
+// diff returns the paths of the values which differ between the given JSON bodies, ignoring the volatile fields. Bodies which
+// are no JSON are compared as they are.
+func (m *routeMirror) diff(primary, shadow []byte) []string {
+	var a, b any
+	if decodeMirrorBody(primary, &a) != nil || decodeMirrorBody(shadow, &b) != nil {
+		if bytes.Equal(primary, shadow) {
+			return nil
+		}
+
+		return []string{"body"}
+	}
+
+	differences := make([]string, 0)
+	m.diffValues(a, b, "", "", &differences)
+	return differences
+}
+
+func decodeMirrorBody(body []byte, v *any) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		*v = nil
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// diffValues collects the paths of the values which differ between the given decoded JSON values at the given path. The field
+// is the path without the array indices, which the volatile fields are matched against.
+func (m *routeMirror) diffValues(a, b any, path, field string, differences *[]string) {
+	if len(*differences) >= maxMirrorDifferences || (field != "" && m.volatile[field]) {
+		return
+	}
+
+	join := func(prefix, name string) string {
+		if prefix == "" {
+			return name
+		}
+		return prefix + "." + name
+	}
+
+	switch a := a.(type) {
+	case map[string]any:
+		if b, ok := b.(map[string]any); ok {
+			keys := sortedKeys(a)
+			for _, key := range sortedKeys(b) {
+				if _, ok := a[key]; !ok {
+					keys = append(keys, key)
+				}
+			}
+
+			for _, key := range keys {
+				m.diffValues(a[key], b[key], join(path, key), join(field, key), differences)
+			}
+			return
+		}
+	case []any:
+		if b, ok := b.([]any); ok && len(a) == len(b) {
+			for n := range a {
+				m.diffValues(a[n], b[n], join(path, strconv.Itoa(n)), field, differences)
+			}
+			return
+		}
+	default:
+		if reflect.DeepEqual(a, b) {
+			return
+		}
+	}
+
+	if path == "" {
+		path = "body"
+	}
+	*differences = append(*differences, path)
+}
+
+// bufferedBody is a request body read from a buffer, closing the original body.
+type bufferedBody struct {
+	io.Reader
+	closer io.Closer
+}
+
+func (b bufferedBody) Close() error {
+	return b.closer.Close()
+}
+
+// mirrorRecorder records the response written to the underlying writer up to the limit.
+type mirrorRecorder struct {
+	gin.ResponseWriter
+	limit    int64
+	body     bytes.Buffer
+	exceeded bool
+}
+
+func (w *mirrorRecorder) Write(data []byte) (int, error) {
+	w.record(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *mirrorRecorder) WriteString(s string) (int, error) {
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *mirrorRecorder) record(data []byte) {
+	if w.exceeded {
+		return
+	}
+
+	if int64(w.body.Len()+len(data)) > w.limit {
+		w.exceeded = true
+		w.body.Reset()
+		return
+	}
+
+	w.body.Write(data)
+}
@@ -0,0 +1,138 @@
+package octanox
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newMirrorInstance returns an instance whose /users route mirrors every request to the given upstream with the options.
+func newMirrorInstance(t *testing.T, upstream http.HandlerFunc, opts MirrorOptions) (*Instance, *routeMirror) {
+	server := httptest.NewServer(upstream)
+	t.Cleanup(server.Close)
+
+	i := newTestInstance(t)
+	if err := i.ApplyRuntimeConfig(RuntimeConfig{LogLevel: LogLevelOff}); err != nil {
+		t.Fatal(err)
+	}
+
+	opts.Rate, opts.URL = 1, server.URL
+	if opts.OnMismatch == nil {
+		opts.OnMismatch = func(MirrorMismatch) {}
+	}
+	rt := i.Register("/users", disableHandler).Mirror(opts)
+	// the shadow requests read the current instance, which is reset once the test finished
+	t.Cleanup(func() {
+		waitForShadows(t, rt.mirror)
+	})
+
+	return i, rt.mirror
+}
+
+// waitForShadows waits until the shadow requests of the given mirror finished, by taking all of its slots.
+func waitForShadows(t *testing.T, m *routeMirror) {
+	timeout := time.After(time.Second)
+	for n := 0; n < cap(m.slots); n++ {
+		select {
+		case m.slots <- struct{}{}:
+		case <-timeout:
+			t.Fatal("slots of the finished shadow requests have not been freed")
+		}
+	}
+
+	for n := 0; n < cap(m.slots); n++ {
+		<-m.slots
+	}
+}
+
+func TestMirrorStripsCredentialHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		forward  []string
+		received []string
+		stripped []string
+	}{
+		{"default", nil, []string{"X-Request-Id"}, []string{"Authorization", "Proxy-Authorization", "Cookie", "X-Api-Key", "X-Nox-Token"}},
+		{"forwarded", []string{"authorization", "X-API-KEY"}, []string{"X-Request-Id", "Authorization", "X-Api-Key"}, []string{"Cookie", "X-Nox-Token"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := make(chan http.Header, 1)
+			i, _ := newMirrorInstance(t, func(w http.ResponseWriter, r *http.Request) {
+				headers <- r.Header.Clone()
+			}, MirrorOptions{ForwardHeaders: tt.forward})
+
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			for _, header := range []string{"X-Request-Id", "Authorization", "Proxy-Authorization", "Cookie", "X-Api-Key", "X-Nox-Token"} {
+				req.Header.Set(header, "value")
+			}
+			if rec := serveTest(i, req); rec.Code != http.StatusOK {
+				t.Fatalf("status %d, want %d", rec.Code, http.StatusOK)
+			}
+			if req.Header.Get("Authorization") == "" {
+				t.Error("headers of the primary request were changed")
+			}
+
+			var received http.Header
+			select {
+			case received = <-headers:
+			case <-time.After(time.Second):
+				t.Fatal("request has not been mirrored")
+			}
+
+			if received.Get(MirrorHeader) == "" {
+				t.Errorf("shadow request has no %s header", MirrorHeader)
+			}
+			for _, header := range tt.received {
+				if received.Get(header) == "" {
+					t.Errorf("header %s has not been sent", header)
+				}
+			}
+			for _, header := range tt.stripped {
+				if received.Get(header) != "" {
+					t.Errorf("credential header %s has been sent", header)
+				}
+			}
+		})
+	}
+}
+
+func TestMirrorLimitsConcurrentShadows(t *testing.T) {
+	arrived := make(chan struct{}, 10)
+	release := make(chan struct{})
+	i, m := newMirrorInstance(t, func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+	}, MirrorOptions{MaxConcurrent: 2})
+
+	for n := 0; n < 5; n++ {
+		if rec := serveTest(i, httptest.NewRequest(http.MethodGet, "/users", nil)); rec.Code != http.StatusOK {
+			t.Fatalf("status %d, want %d", rec.Code, http.StatusOK)
+		}
+	}
+
+	for n := 0; n < 2; n++ {
+		select {
+		case <-arrived:
+		case <-time.After(time.Second):
+			t.Fatalf("%d shadow requests arrived, want 2", n)
+		}
+	}
+	select {
+	case <-arrived:
+		t.Fatal("more shadow requests than MaxConcurrent are in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	waitForShadows(t, m)
+
+	serveTest(i, httptest.NewRequest(http.MethodGet, "/users", nil))
+	select {
+	case <-arrived:
+	case <-time.After(time.Second):
+		t.Fatal("request has not been mirrored after the shadow requests finished")
+	}
+}
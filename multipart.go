@@ -0,0 +1,152 @@
+package octanox
+
+import (
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fileHeaderType is the type of request fields tagged with `file:"<name>"`, which are bound to the uploaded file of the
+// multipart/form-data part with the given name.
+var fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+
+// formFields checks if the request binds fields of a form, files or form values, instead of a body.
+func (p *bindingPlan) formFields() bool {
+	for n := range p.fields {
+		if p.fields[n].source == sourceForm || p.fields[n].source == sourceFile {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateForm checks that a request binding fields of a form binds no body, as both are read from the request body.
+func (p *bindingPlan) validateForm() error {
+	if !p.formFields() {
+		return nil
+	}
+
+	for _, bf := range p.fields {
+		if bf.source == sourceBody || bf.source == sourceRawBody {
+			return errors.New("field " + bf.field.Name + " binds the body of a request which binds form fields")
+		}
+	}
+
+	return nil
+}
+
+// parseForm parses the form of the request, limited to the MaxBodySize of the request decompression options. Fails with 413
+// Request Entity Too Large if the form exceeds it, and with 415 Unsupported Media Type if the request has no form.
+func parseForm(c *gin.Context) {
+	maxSize := Current.decompression.MaxBodySize
+	if maxSize <= 0 {
+		maxSize = defaultMaxDecompressedBodySize
+	}
+
+	contentType := c.ContentType()
+	if contentType != "multipart/form-data" && contentType != "application/x-www-form-urlencoded" {
+		panic(failedRequest{
+			status:  http.StatusUnsupportedMediaType,
+			message: "Unsupported Content-Type " + contentType + ", expected multipart/form-data",
+			code:    ErrorCodeUnsupportedMediaType,
+		})
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
+
+	var err error
+	if contentType == "multipart/form-data" {
+		err = c.Request.ParseMultipartForm(Current.Gin.MaxMultipartMemory)
+	} else {
+		err = c.Request.ParseForm()
+	}
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		panic(failedRequest{
+			status:  http.StatusRequestEntityTooLarge,
+			message: "Request body too large",
+			code:    ErrorCodeBodyTooLarge,
+		})
+	}
+
+	if err != nil {
+		panic(failedRequest{
+			status:  http.StatusBadRequest,
+			message: "Invalid body: " + err.Error(),
+			code:    ErrorCodeInvalidBody,
+		})
+	}
+}
+
+// bindFile binds the uploaded file of the given field. A missing file fails the request if the field is required.
+func bindFile(c *gin.Context, bf *bindingField, fieldValue reflect.Value) {
+	var file *multipart.FileHeader
+	if form := c.Request.MultipartForm; form != nil && len(form.File[bf.name]) > 0 {
+		file = form.File[bf.name][0]
+	}
+
+	if file == nil {
+		if bf.required {
+			panic(failedRequest{
+				status:  http.StatusBadRequest,
+				message: "Missing required file: " + bf.name,
+				code:    ErrorCodeMissingParameter,
+				errors:  []ProblemFieldError{{Source: bf.sourceName(), Name: bf.name, Detail: "missing"}},
+			})
+		}
+
+		return
+	}
+
+	fieldValue.Set(reflect.ValueOf(file))
+}
+
+// usesForms checks if any of the given routes binds fields of a form.
+func usesForms(routes []*Route) bool {
+	for _, route := range routes {
+		if route.requestType != nil && route.plan.formFields() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// generateFormData generates the form variable of the given route, the FormData of its files and form values. Parameters
+// which are null are not sent.
+func (tb *tsCodeBuilder) generateFormData(route *Route) {
+	tb.writeLine("const form = new FormData()")
+
+	for _, bf := range route.clientParams() {
+		if bf.source != sourceForm && bf.source != sourceFile {
+			continue
+		}
+
+		value := bf.field.Name
+		if bf.source == sourceForm {
+			value += ".toString()"
+		}
+
+		appendField := "form.append(" + tsStringLiteral(strings.TrimSpace(bf.name)) + ", " + value + ")"
+		if !bf.required {
+			appendField = "if (" + bf.field.Name + " !== undefined && " + bf.field.Name + " !== null) " + appendField
+		}
+
+		tb.writeLine(appendField)
+	}
+}
+
+// tsFileType returns the TypeScript type of the parameter of the given file field.
+func tsFileType(bf *bindingField) string {
+	if bf.required {
+		return "File | Blob"
+	}
+
+	return "File | Blob | null"
+}
@@ -0,0 +1,188 @@
+package octanox
+
+import (
+	"bytes"
+	"errors"
+	"mime"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
+	"github.com/goccy/go-json"
+	"google.golang.org/protobuf/proto"
+)
+
+// isMsgPackMediaType checks if the given media type denotes MessagePack.
+func isMsgPackMediaType(mediaType string) bool {
+	return mediaType == binding.MIMEMSGPACK || mediaType == binding.MIMEMSGPACK2
+}
+
+// acceptsMsgPack checks if the client accepts MessagePack encoded responses.
+func acceptsMsgPack(c *gin.Context) bool {
+	for _, accepted := range strings.Split(c.GetHeader("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err == nil && isMsgPackMediaType(mediaType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// respond writes the given data with the given status code in the wire format negotiated with the client.
+// MessagePack is used if the client accepts it, otherwise JSON.
+func respond(c *gin.Context, status int, data any) {
+	if acceptsMsgPack(c) {
+		c.Render(status, render.MsgPack{Data: data})
+		return
+	}
+
+	writeJSON(c, status, data)
+}
+
+// maxPooledBufferSize is the capacity above which response buffers are dropped instead of returned to the pool, so a single
+// huge response does not pin its memory forever.
+const maxPooledBufferSize = 64 << 10
+
+// responseBuffers is a pool of the buffers responses are encoded into.
+var responseBuffers = sync.Pool{
+	New: func() any {
+		return bytes.NewBuffer(make([]byte, 0, 4<<10))
+	},
+}
+
+// writeJSON encodes the given data into a pooled buffer and writes it as JSON response with the given status code.
+func writeJSON(c *gin.Context, status int, data any) {
+	writeJSONAs(c, status, "application/json; charset=utf-8", data)
+}
+
+// writeJSONAs encodes the given data into a pooled buffer and writes it as response of the given JSON based content type.
+func writeJSONAs(c *gin.Context, status int, contentType string, data any) {
+	buf := responseBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledBufferSize {
+			responseBuffers.Put(buf)
+		}
+	}()
+
+	if err := json.NewEncoder(buf).Encode(data); err != nil {
+		panic(err)
+	}
+	// drop the newline the encoder terminates every value with
+	buf.Truncate(buf.Len() - 1)
+
+	c.Data(status, contentType, buf.Bytes())
+}
+
+// bodyFormat is the wire format of a request body.
+type bodyFormat int
+
+const (
+	bodyFormatJSON bodyFormat = iota
+	bodyFormatMsgPack
+)
+
+// supportedBodyMediaTypes are the media types request bodies are accepted in.
+var supportedBodyMediaTypes = []string{binding.MIMEJSON, "application/*+json", binding.MIMEMSGPACK, binding.MIMEMSGPACK2}
+
+// unsupportedMediaTypeError is the error of a request body sent in a media type or charset the binder does not support.
+type unsupportedMediaTypeError struct {
+	message string
+}
+
+func (e *unsupportedMediaTypeError) Error() string {
+	return e.message + ", supported are " + strings.Join(supportedBodyMediaTypes, ", ") + " in UTF-8"
+}
+
+// AcceptBodiesWithoutContentType makes the binder decode request bodies sent without a Content-Type as JSON, instead of rejecting
+// them with 415 Unsupported Media Type.
+func (i *Instance) AcceptBodiesWithoutContentType() *Instance {
+	i.assumeJSONBodies = true
+	return i
+}
+
+// requestBodyFormat parses the Content-Type of the request and returns the wire format of its body. JSON is accepted as
+// application/json and with any +json structured syntax suffix. The only charset accepted is UTF-8.
+func requestBodyFormat(c *gin.Context) (bodyFormat, error) {
+	contentType := c.GetHeader("Content-Type")
+	if strings.TrimSpace(contentType) == "" {
+		if Current.assumeJSONBodies {
+			return bodyFormatJSON, nil
+		}
+
+		return 0, &unsupportedMediaTypeError{"Missing Content-Type"}
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return 0, &unsupportedMediaTypeError{"Invalid Content-Type: " + contentType}
+	}
+
+	if charset, ok := params["charset"]; ok && !strings.EqualFold(charset, "utf-8") && !strings.EqualFold(charset, "utf8") {
+		return 0, &unsupportedMediaTypeError{"Unsupported charset: " + charset}
+	}
+
+	switch {
+	case mediaType == binding.MIMEJSON || strings.HasSuffix(mediaType, "+json"):
+		return bodyFormatJSON, nil
+	case isMsgPackMediaType(mediaType):
+		return bodyFormatMsgPack, nil
+	}
+
+	return 0, &unsupportedMediaTypeError{"Unsupported Content-Type: " + mediaType}
+}
+
+// bindBody reads the request body and decodes it into v, using the wire format denoted by the request's Content-Type.
+// Returns an unsupportedMediaTypeError if the Content-Type is not supported.
+func bindBody(c *gin.Context, v any, disallowUnknownFields bool) error {
+	format, err := requestBodyFormat(c)
+	if err != nil {
+		return err
+	}
+
+	body, err := readBody(c)
+	if err != nil {
+		return err
+	}
+
+	if format == bodyFormatMsgPack {
+		if _, ok := v.(proto.Message); ok {
+			return &unsupportedMediaTypeError{"Protobuf messages cannot be sent as MessagePack"}
+		}
+
+		return binding.MsgPack.BindBody(body, v)
+	}
+
+	// JSON is UTF-8 by definition (RFC 8259), the decoder would silently replace invalid sequences
+	if !utf8.Valid(body) {
+		return errors.New("body is not valid UTF-8")
+	}
+
+	if msg, ok := v.(proto.Message); ok {
+		return Current.unmarshalProto(body, msg)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return err
+	}
+
+	if disallowUnknownFields {
+		return checkUnknownFields(body, reflect.TypeOf(v))
+	}
+
+	return nil
+}
+
+// bodyFormatName returns the human readable name of the wire format of the request body.
+func bodyFormatName(c *gin.Context) string {
+	if format, _ := requestBodyFormat(c); format == bodyFormatMsgPack {
+		return "MessagePack"
+	}
+
+	return "JSON"
+}
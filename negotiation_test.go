@@ -0,0 +1,215 @@
+package octanox
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+)
+
+// benchUser is a representative DTO of a response.
+type benchUser struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Email     string            `json:"email"`
+	Roles     []string          `json:"roles"`
+	Labels    map[string]string `json:"labels"`
+	CreatedAt time.Time         `json:"created_at"`
+	Address   benchAddress      `json:"address"`
+}
+
+type benchAddress struct {
+	Street string `json:"street"`
+	City   string `json:"city"`
+	Zip    string `json:"zip"`
+}
+
+func newBenchUser(n int) benchUser {
+	return benchUser{
+		ID:        fmt.Sprintf("user-%d", n),
+		Name:      "Alice Example",
+		Email:     "alice@example.com",
+		Roles:     []string{"admin", "editor"},
+		Labels:    map[string]string{"team": "platform"},
+		CreatedAt: time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC),
+		Address:   benchAddress{Street: "Main Street 1", City: "Springfield", Zip: "12345"},
+	}
+}
+
+// discardWriter is a response writer which discards everything written to it, so benchmarks only measure the server side.
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+func (w *discardWriter) WriteHeader(int) {}
+
+func (w *discardWriter) Flush() {}
+
+func TestWriteJSONMatchesMarshal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+
+	user := newBenchUser(1)
+	writeJSON(c, http.StatusCreated, user)
+
+	want, err := json.Marshal(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusCreated || !bytes.Equal(rec.Body.Bytes(), want) {
+		t.Errorf("status %d, body %s, want %s", rec.Code, rec.Body.String(), want)
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/json; charset=utf-8" {
+		t.Errorf("Content-Type %q", contentType)
+	}
+}
+
+type bodyFormatRequest struct {
+	PostRequest
+	Body benchAddress `body:"true"`
+}
+
+func TestRequestBodyFormat(t *testing.T) {
+	tests := []struct {
+		name, contentType string
+		assumeJSON        bool
+		// status is the status of the response, 415 if the body is rejected
+		status int
+	}{
+		{"json", "application/json", false, http.StatusOK},
+		{"vendored json", "application/vnd.acme+json; charset=utf-8", false, http.StatusOK},
+		{"problem json", "application/problem+json", false, http.StatusOK},
+		{"upper case charset", "application/json; charset=UTF-8", false, http.StatusOK},
+		{"utf8 charset", "application/json; charset=utf8", false, http.StatusOK},
+		{"latin-1 charset", "application/json; charset=iso-8859-1", false, http.StatusUnsupportedMediaType},
+		{"utf-16 charset", "application/vnd.acme+json; charset=utf-16", false, http.StatusUnsupportedMediaType},
+		{"text", "text/plain", false, http.StatusUnsupportedMediaType},
+		{"invalid", "application/json; charset", false, http.StatusUnsupportedMediaType},
+		{"multipart", "multipart/form-data; boundary=xyz", false, http.StatusUnsupportedMediaType},
+		{"missing", "", false, http.StatusUnsupportedMediaType},
+		{"missing assumed JSON", "", true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newTestInstance(t)
+			if tt.assumeJSON {
+				i.AcceptBodiesWithoutContentType()
+			}
+			i.Register("/addresses", func(req *bodyFormatRequest) *benchAddress { return &req.Body })
+
+			req := httptest.NewRequest(http.MethodPost, "/addresses", strings.NewReader(`{"city":"Springfield"}`))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			rec := serveTest(i, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status == http.StatusOK && !strings.Contains(rec.Body.String(), `"city":"Springfield"`) {
+				t.Errorf("body %s", rec.Body.String())
+			}
+			if tt.status == http.StatusUnsupportedMediaType && !strings.Contains(rec.Body.String(), "application/*+json") {
+				t.Errorf("body %s does not list the supported media types", rec.Body.String())
+			}
+		})
+	}
+}
+
+type formBodyRequest struct {
+	PostRequest
+	City string `form:"city"`
+}
+
+func TestFormBodyWithBoundaryParameter(t *testing.T) {
+	i := newTestInstance(t)
+	i.Register("/addresses", func(req *formBodyRequest) *benchAddress { return &benchAddress{City: req.City} })
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := form.WriteField("city", "Springfield"); err != nil {
+		t.Fatal(err)
+	}
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/addresses", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if rec := serveTest(i, req); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"city":"Springfield"`) {
+		t.Errorf("status %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// BenchmarkWriteJSON measures the pooled buffer JSON writer of the responses.
+func BenchmarkWriteJSON(b *testing.B) {
+	gin.SetMode(gin.TestMode)
+	user := newBenchUser(1)
+	w := &discardWriter{header: make(http.Header)}
+	c, _ := gin.CreateTestContext(w)
+
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		c.Writer.Header().Del("Content-Type")
+		writeJSON(c, http.StatusOK, user)
+	}
+}
+
+// BenchmarkWriteJSONUnpooled measures marshalling into a new byte slice per response, as before the pooled buffers.
+func BenchmarkWriteJSONUnpooled(b *testing.B) {
+	gin.SetMode(gin.TestMode)
+	user := newBenchUser(1)
+	w := &discardWriter{header: make(http.Header)}
+	c, _ := gin.CreateTestContext(w)
+
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		c.Writer.Header().Del("Content-Type")
+		data, err := json.Marshal(user)
+		if err != nil {
+			b.Fatal(err)
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+	}
+}
+
+type benchUserRequest struct {
+	GetRequest
+	ID string `path:"id"`
+}
+
+// BenchmarkServeJSONRoute measures a whole request of a route responding with the representative DTO, including the binding
+// with the cached plan of the route.
+func BenchmarkServeJSONRoute(b *testing.B) {
+	i := newTestInstance(b)
+	if err := i.ApplyRuntimeConfig(RuntimeConfig{LogLevel: LogLevelOff}); err != nil {
+		b.Fatal(err)
+	}
+	i.Register("/users/:id", func(req *benchUserRequest) *benchUser {
+		user := newBenchUser(1)
+		user.ID = req.ID
+		return &user
+	})
+	handler := i.Handler()
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		handler.ServeHTTP(&discardWriter{header: make(http.Header)}, req)
+	}
+}
@@ -0,0 +1,19 @@
+// Package noxtest provides helpers to test services built with the Octanox framework.
+package noxtest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/sevenitynet/octanox"
+)
+
+// AssertBudgets replays the given traffic sample against the instance and fails the test for every request that exceeds the
+// budget of its route.
+func AssertBudgets(t testing.TB, instance *octanox.Instance, sample []*http.Request) {
+	t.Helper()
+
+	for _, violation := range instance.ReplayBudgets(sample) {
+		t.Errorf("budget exceeded: %s", violation.Error())
+	}
+}
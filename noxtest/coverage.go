@@ -0,0 +1,58 @@
+package noxtest
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/sevenitynet/octanox"
+)
+
+// coverageFileEnv is the environment variable the coverage report is written to as JSON, e.g. for dashboards trending it.
+const coverageFileEnv = "NOX__COVERAGE_FILE"
+
+// ReportCoverage reports the routes of the instance which have not been requested by the tests, grouped by the package of
+// their handler. Fails the test if fewer than the given share of the routes, between 0 and 1, have been requested, otherwise
+// the untested routes are only logged. Routes marked with SkipCoverage are excluded. The report is also written as JSON to
+// the file of NOX__COVERAGE_FILE if it is set. Call it after the tests requesting the routes, e.g. in a t.Cleanup.
+func ReportCoverage(t testing.TB, instance *octanox.Instance, threshold float64) {
+	t.Helper()
+
+	report := instance.Coverage()
+
+	if path := os.Getenv(coverageFileEnv); path != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err == nil {
+			err = os.WriteFile(path, data, 0o644)
+		}
+		if err != nil {
+			t.Errorf("cannot write route coverage to %s: %v", path, err)
+		}
+	}
+
+	untested := report.Untested()
+	if len(untested) == 0 {
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("route coverage " + strconv.FormatFloat(report.Ratio*100, 'f', 1, 64) + "% (" + strconv.Itoa(report.Covered) + "/" + strconv.Itoa(report.Total) + "), untested routes:")
+
+	pkg := ""
+	for n, route := range untested {
+		// the routes of the report are sorted by package
+		if n == 0 || route.Package != pkg {
+			pkg = route.Package
+			sb.WriteString("\n  " + pkg)
+		}
+		sb.WriteString("\n    " + route.Method + " " + route.Path)
+	}
+
+	if report.Ratio < threshold {
+		t.Errorf("%s\nroute coverage is below the threshold of %s%%", sb.String(), strconv.FormatFloat(threshold*100, 'f', 1, 64))
+	} else {
+		t.Log(sb.String())
+	}
+}
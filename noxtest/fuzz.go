@@ -0,0 +1,99 @@
+package noxtest
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sevenitynet/octanox"
+)
+
+// fuzzSeedEnv is the environment variable a failed fuzzing run is reproduced with.
+const fuzzSeedEnv = "NOX__FUZZ_SEED"
+
+// Fuzz sends extreme and malformed inputs generated from the binding plans to every route of the instance and fails the test
+// for every input answered with 5xx, or malformed input not rejected with 4xx. Without a seed in the options, the seed is taken
+// from NOX__FUZZ_SEED or chosen randomly, and reported on failure, so the failing inputs can be reproduced.
+func Fuzz(t testing.TB, instance *octanox.Instance, opts octanox.FuzzOptions) {
+	t.Helper()
+
+	opts.Seed = fuzzSeed(t, opts.Seed)
+
+	failures, err := instance.Fuzz(opts)
+	if err != nil {
+		t.Fatalf("fuzzing failed: %v", err)
+	}
+
+	for _, failure := range failures {
+		t.Errorf("fuzzing failure: %s", failure.Error())
+	}
+
+	if len(failures) > 0 {
+		t.Logf("reproduce with %s=%d", fuzzSeedEnv, opts.Seed)
+	}
+}
+
+// FuzzRoute lets the native Go fuzzer drive the query and the body of the given route, e.g. "GET /users/:id", seeded with the
+// inputs generated by Fuzz. The path and headers of the example request of the route are kept. Fails for every input answered
+// with 5xx. Request logging is turned off while fuzzing. Call it from a fuzz test and run it with go test -fuzz.
+func FuzzRoute(f *testing.F, instance *octanox.Instance, route string, opts octanox.FuzzOptions) {
+	f.Helper()
+
+	opts.Seed = fuzzSeed(f, opts.Seed)
+	opts.Routes = []string{route}
+
+	cases, err := instance.FuzzCases(opts)
+	if err != nil {
+		f.Fatalf("fuzzing failed: %v", err)
+	}
+	if len(cases) == 0 {
+		f.Fatalf("route %s is not registered", route)
+	}
+
+	for _, c := range cases {
+		// compressed bodies are sent with a Content-Encoding the fuzzed inputs do not have
+		if c.Header.Get("Content-Encoding") == "" {
+			f.Add(c.Query, c.Body)
+		}
+	}
+
+	// the fuzzing engine runs millions of inputs, logging every request would only slow it down
+	config := instance.RuntimeConfig()
+	quiet := config
+	quiet.LogLevel = octanox.LogLevelOff
+	if err := instance.ApplyRuntimeConfig(quiet); err != nil {
+		f.Fatalf("fuzzing failed: %v", err)
+	}
+	f.Cleanup(func() {
+		instance.ApplyRuntimeConfig(config)
+	})
+
+	example := cases[0]
+	f.Fuzz(func(t *testing.T, query string, body []byte) {
+		c := example
+		c.Name = "fuzzed input"
+		c.Query, c.Body = query, body
+
+		if failure := instance.RunFuzzCase(c); failure != nil {
+			t.Errorf("fuzzing failure: %s", failure.Error())
+		}
+	})
+}
+
+// fuzzSeed returns the given seed, or the seed of NOX__FUZZ_SEED, or a random seed if neither is set.
+func fuzzSeed(t testing.TB, seed int64) int64 {
+	if seed != 0 {
+		return seed
+	}
+
+	if raw := os.Getenv(fuzzSeedEnv); raw != "" {
+		seed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			t.Fatalf("invalid %s: %v", fuzzSeedEnv, err)
+		}
+		return seed
+	}
+
+	return time.Now().UnixNano()
+}
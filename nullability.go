@@ -0,0 +1,98 @@
+package octanox
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// NullableSources declares the fields of the given DTO, e.g. User{}, whose values are read from nullable sources like nullable
+// database columns, as the tag `dbnull:"true"` does on the field itself. The tag `dbnull:"false"` declares a field backed by a
+// non-nullable source. Instance.Validate reports the fields whose type does not match their source, e.g. a string backed by a
+// nullable column, which silently responds with "" instead of null, and with StrictContracts the generated client refuses to
+// type such fields as non-nullable. Panics if the DTO is no struct or has no field with one of the given names.
+func (i *Instance) NullableSources(dto any, fields ...string) *Instance {
+	t := reflect.TypeOf(dto)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("octanox: nullable sources of %T must be declared on a struct", dto))
+	}
+
+	if i.nullableSources == nil {
+		i.nullableSources = make(map[reflect.Type]map[string]bool)
+	}
+	if i.nullableSources[t] == nil {
+		i.nullableSources[t] = make(map[string]bool, len(fields))
+	}
+
+	for _, name := range fields {
+		if _, ok := t.FieldByName(name); !ok {
+			panic("octanox: " + t.String() + " has no field " + name + " to declare as backed by a nullable source")
+		}
+
+		i.nullableSources[t][name] = true
+	}
+
+	return i
+}
+
+// nullableSource returns whether the given field of the given struct is backed by a nullable source, and whether its source
+// has been declared at all, with the dbnull tag or Instance.NullableSources. The tag wins over the declaration.
+func nullableSource(sources map[reflect.Type]map[string]bool, t reflect.Type, field reflect.StructField) (nullable bool, declared bool) {
+	if tag, ok := field.Tag.Lookup("dbnull"); ok {
+		return tag == "true", true
+	}
+
+	if sources[t][field.Name] {
+		return true, true
+	}
+
+	return false, false
+}
+
+// representsNull checks if values of the given field can be null in the response, and are typed as nullable in the generated
+// client with the given nil collection policy.
+func representsNull(field reflect.StructField, policy NilCollectionPolicy) bool {
+	switch field.Type.Kind() {
+	case reflect.Ptr:
+		return !isProtoMessage(field.Type)
+	case reflect.Interface:
+		return true
+	case reflect.Slice:
+		return isNullableInClient(field, policy)
+	}
+
+	return false
+}
+
+// nullabilitySuggestion returns the suggested change of the type of the given field backed by a nullable source, so it can
+// represent null.
+func nullabilitySuggestion(field reflect.StructField) string {
+	if field.Type.Kind() == reflect.Slice {
+		return "add the tag nullable:\"true\" to type it as nullable"
+	}
+
+	return "change its type to *" + field.Type.String()
+}
+
+// validateNullability checks that the type of the given field matches the nullability of the source it is backed by.
+func (v *contractValidator) validateNullability(t reflect.Type, field reflect.StructField) {
+	if tag, ok := field.Tag.Lookup("dbnull"); ok && tag != "true" && tag != "false" {
+		v.report(ContractNullabilityMismatch, "field %s of %s has the invalid dbnull tag %q, must be true or false", field.Name, t.String(), tag)
+		return
+	}
+
+	nullable, declared := nullableSource(v.instance.nullableSources, t, field)
+	if !declared {
+		return
+	}
+
+	switch {
+	case nullable && !representsNull(field, v.instance.collections.policy):
+		v.report(ContractNullabilityMismatch, "field %s of %s is backed by a nullable source, but %s cannot represent null; %s", field.Name, t.String(), field.Type.String(), nullabilitySuggestion(field))
+	case !nullable && field.Type.Kind() == reflect.Ptr:
+		v.report(ContractNullabilityMismatch, "field %s of %s is backed by a non-nullable source, but %s is nullable; change its type to %s", field.Name, t.String(), field.Type.String(), field.Type.Elem().String())
+	}
+}
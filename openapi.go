@@ -0,0 +1,652 @@
+package octanox
+
+import (
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// openAPIVersion is the version of the OpenAPI specification the generated documents conform to.
+const openAPIVersion = "3.0.3"
+
+// openAPIDocument is the root object of an OpenAPI document.
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*openAPISchema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*openAPISecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type openAPIOperation struct {
+	OperationID string                      `json:"operationId"`
+	Description string                      `json:"description,omitempty"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Parameters  []*openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+	Security    []map[string][]string       `json:"security,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required,omitempty"`
+	Style    string         `json:"style,omitempty"`
+	Explode  *bool          `json:"explode,omitempty"`
+	Schema   *openAPISchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                         `json:"required"`
+	Content  map[string]*openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                       `json:"description"`
+	Headers     map[string]*openAPIHeader    `json:"headers,omitempty"`
+	Content     map[string]*openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIHeader struct {
+	Description string         `json:"description,omitempty"`
+	Schema      *openAPISchema `json:"schema"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+// openAPISchema is the OpenAPI subset of a JSON Schema.
+type openAPISchema struct {
+	Ref         string                    `json:"$ref,omitempty"`
+	AllOf       []*openAPISchema          `json:"allOf,omitempty"`
+	OneOf       []*openAPISchema          `json:"oneOf,omitempty"`
+	Type        string                    `json:"type,omitempty"`
+	Format      string                    `json:"format,omitempty"`
+	Description string                    `json:"description,omitempty"`
+	Deprecated  bool                      `json:"deprecated,omitempty"`
+	Nullable    bool                      `json:"nullable,omitempty"`
+	Enum        []any                     `json:"enum,omitempty"`
+	Items       *openAPISchema            `json:"items,omitempty"`
+	Properties  map[string]*openAPISchema `json:"properties,omitempty"`
+	// AdditionalProperties is the *openAPISchema of the values of maps, or false if objects have no other properties.
+	AdditionalProperties any      `json:"additionalProperties,omitempty"`
+	Required             []string `json:"required,omitempty"`
+}
+
+type openAPISecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+}
+
+// openAPIPathParam matches the path parameters and wildcards of Gin paths, e.g. :id and *path.
+var openAPIPathParam = regexp.MustCompile(`[:*]([^/]+)`)
+
+// GenerateOpenAPISpec writes the OpenAPI 3.0 document of the registered routes as JSON to the given path. The schemas are
+// derived from the request and response types as the TypeScript client is, with named structs and registered enums declared
+// as component schemas, and the security scheme is derived from the authenticator.
+func (i *Instance) GenerateOpenAPISpec(outputPath string) error {
+	content, err := i.openAPISpec(i.routes)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(outputPath, content, 0644)
+}
+
+// OpenAPIGenerator returns a generator writing the OpenAPI document of the registered routes to the given path, as
+// GenerateOpenAPISpec does, e.g. to register it with AddGenerator next to the TypeScript client.
+func OpenAPIGenerator(outputPath string) Generator {
+	return func(ctx *GenContext) error {
+		content, err := ctx.Instance.openAPISpec(ctx.Instance.routes)
+		if err != nil {
+			return err
+		}
+
+		return ctx.WriteFile(outputPath, content)
+	}
+}
+
+// openAPISpec returns the OpenAPI document of the given routes, encoded as indented JSON. Versioned routes are documented
+// as their newest variant, or as the variant of the version pinned with NOX__CLIENT_VERSION, like in the client.
+func (i *Instance) openAPISpec(routes []*Route) ([]byte, error) {
+	b := &openAPIBuilder{
+		instance: i,
+		names:    &tsCodeBuilder{opts: i.tsGenOptions},
+		schemas:  make(map[string]*openAPISchema),
+	}
+
+	doc := &openAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info:    openAPIInfo{Title: "Octanox API", Version: "1.0.0"},
+		Paths:   make(map[string]map[string]*openAPIOperation),
+	}
+	if len(i.apiVersions) > 0 {
+		doc.Info.Version = i.apiVersions[len(i.apiVersions)-1]
+	}
+
+	security := b.securityScheme(i.Authenticator)
+	if security != nil {
+		doc.Components.SecuritySchemes = map[string]*openAPISecurityScheme{openAPISecuritySchemeName: security}
+	}
+
+	routes = clientRoutes(routes, os.Getenv("NOX__CLIENT_VERSION"))
+	checkFunctionNames(routes)
+
+	for _, route := range routes {
+		path := openAPIPathParam.ReplaceAllString(route.path, "{$1}")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*openAPIOperation)
+		}
+
+		operation := b.operation(route)
+		if i.requiresAuthentication(route) {
+			if name, scheme := b.routeSecurityScheme(route); scheme != nil {
+				if doc.Components.SecuritySchemes == nil {
+					doc.Components.SecuritySchemes = make(map[string]*openAPISecurityScheme)
+				}
+
+				doc.Components.SecuritySchemes[name] = scheme
+				operation.Security = []map[string][]string{{name: {}}}
+			}
+		}
+
+		doc.Paths[path][strings.ToLower(route.method)] = operation
+	}
+
+	if operation := b.jobStatusOperation(routes); operation != nil {
+		if i.jobsAuthenticated && security != nil {
+			operation.Security = []map[string][]string{{openAPISecuritySchemeName: {}}}
+		}
+
+		doc.Paths[jobsPath+"/{id}"] = map[string]*openAPIOperation{"get": operation}
+	}
+
+	doc.Components.Schemas = b.schemas
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// openAPISecuritySchemeName is the name of the security scheme of the authenticator in the generated documents.
+const openAPISecuritySchemeName = "auth"
+
+// openAPIBuilder builds the operations and schemas of an OpenAPI document.
+type openAPIBuilder struct {
+	instance *Instance
+	// names names the operations as the functions of the TypeScript client.
+	names *tsCodeBuilder
+	// schemas are the component schemas of the named structs and enums, by their name.
+	schemas map[string]*openAPISchema
+	// closed is a flag that indicates whether the schemas of structs being built disallow other properties, as the JSON
+	// bodies of routes rejecting unknown fields do.
+	closed bool
+}
+
+// closedSchemaSuffix is appended to the names of the component schemas of named structs which disallow other properties.
+const closedSchemaSuffix = "Strict"
+
+// securityScheme returns the security scheme of the given authenticator. Can be nil if there is none.
+func (b *openAPIBuilder) securityScheme(authenticator Authenticator) *openAPISecurityScheme {
+	if authenticator == nil {
+		return nil
+	}
+
+	switch authenticator.Method() {
+	case AuthenticationMethodBasic:
+		return &openAPISecurityScheme{Type: "http", Scheme: "basic"}
+	case AuthenticationMethodApiKey:
+		return &openAPISecurityScheme{Type: "apiKey", In: "header", Name: "X-API-Key"}
+	case AuthenticationMethodCookie:
+		name := ""
+		if cookie, ok := authenticator.(*CookieAuthenticator); ok {
+			name = cookie.name
+		}
+
+		return &openAPISecurityScheme{Type: "apiKey", In: "cookie", Name: name}
+	default:
+		// the OAuth2 login also issues its own JWT, which is sent as bearer token
+		return &openAPISecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"}
+	}
+}
+
+// routeSecurityScheme returns the name and the security scheme of the authenticator of the given route. Routes with another
+// scheme than the authenticator of the instance are documented with a security scheme named after their scheme, e.g.
+// "auth-api-key". The scheme is nil if the route is not authenticated.
+func (b *openAPIBuilder) routeSecurityScheme(route *Route) (string, *openAPISecurityScheme) {
+	authenticator := b.instance.authenticatorFor(route)
+	if scheme := authenticationScheme(authenticator); scheme != authenticationScheme(b.instance.Authenticator) {
+		return openAPISecuritySchemeName + "-" + strings.ReplaceAll(scheme, " ", "-"), b.securityScheme(authenticator)
+	}
+
+	return openAPISecuritySchemeName, b.securityScheme(authenticator)
+}
+
+// operation returns the operation of the given route.
+func (b *openAPIBuilder) operation(route *Route) *openAPIOperation {
+	operation := &openAPIOperation{
+		OperationID: b.names.generateFunctionName(route),
+		Responses:   make(map[string]*openAPIResponse),
+	}
+
+	if route.clientGroup != "" {
+		operation.Tags = []string{route.clientGroup}
+	}
+	operation.Description, operation.Deprecated = route.description, deprecatedDescription(route.description)
+	if b.instance.requiresAuthentication(route) && len(route.roles) > 0 {
+		operation.Description = strings.TrimSpace(operation.Description + "\n\nRequires one of the roles " + strings.Join(route.roles, ", ") + ".")
+	}
+
+	declared := make(map[string]bool)
+	for n := range route.plan.fields {
+		bf := &route.plan.fields[n]
+
+		switch bf.source {
+		case sourcePath, sourceQuery, sourceHeader, sourceCookie:
+			operation.Parameters = append(operation.Parameters, b.parameter(bf))
+			if bf.source == sourcePath {
+				declared[bf.name] = true
+			}
+		case sourceList:
+			operation.Parameters = append(operation.Parameters, b.listParameters(route)...)
+		case sourceBody:
+			if route.plan.rawBodyField() == nil {
+				operation.RequestBody = b.bodyRequestBody(route, bf.field.Type)
+			}
+		case sourceRawBody:
+			if body := route.plan.bodyField(); body != nil {
+				operation.RequestBody = b.bodyRequestBody(route, body.field.Type)
+			} else {
+				operation.RequestBody = b.requestBody("application/octet-stream", &openAPISchema{Type: "string", Format: "binary"})
+			}
+		}
+	}
+
+	if route.requestType != nil && route.plan.formFields() {
+		operation.RequestBody = b.requestBody("multipart/form-data", b.formSchema(route.plan))
+	}
+
+	// path parameters of groups the request does not bind are still part of the path
+	for _, match := range openAPIPathParam.FindAllStringSubmatch(route.path, -1) {
+		if !declared[match[1]] {
+			operation.Parameters = append(operation.Parameters, &openAPIParameter{Name: match[1], In: "path", Required: true, Schema: &openAPISchema{Type: "string"}})
+		}
+	}
+
+	switch {
+	case route.jobs != nil:
+		operation.Responses["202"] = &openAPIResponse{
+			Description: "Accepted, the job has been started and its status is served at the Location",
+			Headers: map[string]*openAPIHeader{
+				"Location": {Description: "The path of the status of the job, " + jobsPath + "/{id}.", Schema: &openAPISchema{Type: "string"}},
+			},
+			Content: map[string]*openAPIMediaType{"application/json": {Schema: b.schema(route.responseType)}},
+		}
+	case route.responseType == downloadType:
+		operation.Responses["200"] = &openAPIResponse{
+			Description: "OK",
+			Content:     map[string]*openAPIMediaType{"application/octet-stream": {Schema: &openAPISchema{Type: "string", Format: "binary"}}},
+		}
+	case route.hasResponseBody():
+		operation.Responses["200"] = &openAPIResponse{
+			Description: "OK",
+			Content:     map[string]*openAPIMediaType{"application/json": {Schema: b.schema(route.responseType)}},
+		}
+	default:
+		operation.Responses["204"] = &openAPIResponse{Description: "No Content"}
+	}
+
+	operation.Responses["default"] = b.errorResponse(route.errorType)
+	return operation
+}
+
+// jobStatusOperation returns the operation of the status route of the jobs of the given async routes. The status is the
+// one of the job schemas of the routes, which type the result of their jobs. Returns nil if no route is async.
+func (b *openAPIBuilder) jobStatusOperation(routes []*Route) *openAPIOperation {
+	if !usesJobs(routes) {
+		return nil
+	}
+
+	statuses := make([]any, 0, len(jobStatuses))
+	for _, status := range jobStatuses {
+		statuses = append(statuses, string(status))
+	}
+
+	if _, ok := b.schemas["JobError"]; !ok {
+		b.schemas["JobError"] = b.structSchema(reflect.TypeOf(JobError{}))
+	}
+
+	var jobs []*openAPISchema
+	for _, route := range routes {
+		if route.jobs == nil {
+			continue
+		}
+
+		name := b.names.jobSchemaName(route)
+		b.schemas[name] = &openAPISchema{
+			Type:        "object",
+			Description: "The status of a job of " + b.names.generateFunctionName(route) + ", with its result once it succeeded.",
+			Properties: map[string]*openAPISchema{
+				"id":     {Type: "string"},
+				"status": {Type: "string", Enum: statuses},
+				"result": b.schema(route.jobResult),
+				"error":  {Ref: "#/components/schemas/JobError"},
+			},
+			Required: []string{"id", "status"},
+		}
+		jobs = append(jobs, &openAPISchema{Ref: "#/components/schemas/" + name})
+	}
+
+	schema := jobs[0]
+	if len(jobs) > 1 {
+		schema = &openAPISchema{OneOf: jobs}
+	}
+
+	return &openAPIOperation{
+		OperationID: "getJobStatus",
+		Description: "The status of a job started by an async route. Poll it until the status is succeeded or failed.",
+		Parameters:  []*openAPIParameter{{Name: "id", In: "path", Required: true, Schema: &openAPISchema{Type: "string"}}},
+		Responses: map[string]*openAPIResponse{
+			"200":     {Description: "OK", Content: map[string]*openAPIMediaType{"application/json": {Schema: schema}}},
+			"default": b.errorResponse(nil),
+		},
+	}
+}
+
+// parameter returns the parameter of the given path, query, header or cookie field.
+func (b *openAPIBuilder) parameter(bf *bindingField) *openAPIParameter {
+	param := &openAPIParameter{
+		Name:     bf.name,
+		In:       bf.sourceName(),
+		Required: bf.required || bf.source == sourcePath,
+		Schema:   b.schema(bf.field.Type),
+	}
+
+	if bf.array != "" {
+		explode := bf.array == queryArrayRepeat
+		param.Style, param.Explode = "form", &explode
+	}
+
+	return param
+}
+
+// listParameters returns the query parameters of the ListQuery of the given route.
+func (b *openAPIBuilder) listParameters(route *Route) []*openAPIParameter {
+	params := []*openAPIParameter{
+		{Name: "cursor", In: "query", Schema: &openAPISchema{Type: "string"}},
+		{Name: "page", In: "query", Schema: &openAPISchema{Type: "integer"}},
+		{Name: "limit", In: "query", Schema: &openAPISchema{Type: "integer"}},
+	}
+
+	if len(route.list.sortable) > 0 {
+		params = append(params, &openAPIParameter{
+			Name:   "sort",
+			In:     "query",
+			Schema: &openAPISchema{Type: "string", Description: "Comma separated fields of " + strings.Join(route.list.sortable, ", ") + ", prefixed with - for descending order."},
+		})
+	}
+
+	for _, field := range route.list.filterable {
+		params = append(params, &openAPIParameter{Name: "filter[" + field + "]", In: "query", Schema: &openAPISchema{Type: "string"}})
+	}
+
+	return params
+}
+
+func (b *openAPIBuilder) requestBody(mediaType string, schema *openAPISchema) *openAPIRequestBody {
+	return &openAPIRequestBody{Required: true, Content: map[string]*openAPIMediaType{mediaType: {Schema: schema}}}
+}
+
+// bodyRequestBody returns the request body of the given decoded body type of the route, in every media type the binder
+// accepts. Protobuf messages cannot be sent as MessagePack. The JSON schemas of routes rejecting unknown fields disallow
+// other properties, MessagePack bodies are decoded without that check.
+func (b *openAPIBuilder) bodyRequestBody(route *Route, t reflect.Type) *openAPIRequestBody {
+	schema := b.schema(t)
+	jsonSchema := schema
+	if route.rejectsUnknownFields() {
+		b.closed = true
+		jsonSchema = b.schema(t)
+		b.closed = false
+	}
+
+	body := &openAPIRequestBody{Required: true, Content: make(map[string]*openAPIMediaType)}
+	for _, mediaType := range supportedBodyMediaTypes {
+		switch {
+		case !isMsgPackMediaType(mediaType):
+			body.Content[mediaType] = &openAPIMediaType{Schema: jsonSchema}
+		case !isProtoMessage(t):
+			body.Content[mediaType] = &openAPIMediaType{Schema: schema}
+		}
+	}
+
+	return body
+}
+
+// errorResponse returns the response of failed requests, in the shape errors are rendered in, or of the given error type
+// declared by a route. The type can be nil.
+func (b *openAPIBuilder) errorResponse(errorType reflect.Type) *openAPIResponse {
+	if errorType != nil {
+		return &openAPIResponse{Description: "Error", Content: map[string]*openAPIMediaType{"application/json": {Schema: b.schema(errorType)}}}
+	}
+
+	if b.instance.problemDetails != nil {
+		return &openAPIResponse{
+			Description: "Error",
+			Content:     map[string]*openAPIMediaType{problemMediaType: {Schema: b.schema(reflect.TypeOf(ProblemDetails{}))}},
+		}
+	}
+
+	schema := &openAPISchema{
+		Type: "object",
+		Properties: map[string]*openAPISchema{
+			"error":        {Type: "string"},
+			"quota":        b.schema(reflect.TypeOf(QuotaExceeded{})),
+			"gone":         b.schema(reflect.TypeOf(GoneError{})),
+			"retryable":    {Type: "boolean"},
+			"retryAfterMs": {Type: "integer"},
+		},
+		Required: []string{"error"},
+	}
+	if t := b.instance.ErrorResponseType; t != nil {
+		schema = b.schema(t)
+	}
+
+	return &openAPIResponse{Description: "Error", Content: map[string]*openAPIMediaType{"application/json": {Schema: schema}}}
+}
+
+// schema returns the schema of the given type as it is encoded. Named structs and registered enums are referenced as
+// component schemas.
+func (b *openAPIBuilder) schema(t reflect.Type) *openAPISchema {
+	if (t.Kind() == reflect.Ptr || t.Kind() == reflect.Struct) && isProtoMessage(t) {
+		return &openAPISchema{Type: "object", Description: "The protobuf message " + string(protoDescriptor(t).FullName()) + " encoded as JSON."}
+	}
+
+	switch {
+	case isTimeType(t):
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	case t == reflect.TypeOf(time.Duration(0)):
+		return &openAPISchema{Type: "integer", Format: "int64", Description: "A duration in nanoseconds."}
+	}
+
+	if literals, ok := b.instance.enums[t]; ok {
+		if _, ok := b.schemas[t.Name()]; !ok {
+			b.schemas[t.Name()] = &openAPISchema{Type: openAPIScalarType(t.Kind()), Description: b.instance.typeDescriptions[t], Enum: openAPIEnumValues(t.Kind(), literals)}
+		}
+
+		return &openAPISchema{Ref: "#/components/schemas/" + t.Name()}
+	}
+
+	if t.Kind() != reflect.Ptr && marshalsItself(t) {
+		pt := reflect.PointerTo(t)
+		if !t.Implements(jsonMarshalerType) && !pt.Implements(jsonMarshalerType) {
+			return &openAPISchema{Type: "string"}
+		}
+
+		// the shape of values encoding themselves is unknown
+		return &openAPISchema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := b.schema(t.Elem())
+		if schema.Ref != "" {
+			return &openAPISchema{AllOf: []*openAPISchema{schema}, Nullable: true}
+		}
+
+		schema.Nullable = true
+		return schema
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &openAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &openAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &openAPISchema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		// byte slices are encoded as base64 strings
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return &openAPISchema{Type: "string", Format: "byte"}
+		}
+
+		return &openAPISchema{Type: "array", Items: b.schema(t.Elem())}
+	case reflect.Map:
+		return &openAPISchema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+	case reflect.Struct:
+		// instances of generic types are inlined, as their names are no valid schema names
+		if t.Name() == "" || strings.Contains(t.Name(), "[") {
+			return b.structSchema(t)
+		}
+
+		name := t.Name()
+		if b.closed {
+			name += closedSchemaSuffix
+		}
+
+		if _, ok := b.schemas[name]; !ok {
+			// the placeholder ends recursive types, e.g. a Node with Children []Node
+			b.schemas[name] = &openAPISchema{}
+			*b.schemas[name] = *b.structSchema(t)
+			b.schemas[name].Description = b.instance.typeDescriptions[t]
+		}
+
+		return &openAPISchema{Ref: "#/components/schemas/" + name}
+	}
+
+	return &openAPISchema{}
+}
+
+// structSchema returns the object schema of the given struct type, with the fields of embedded structs flattened. Fields
+// which are not omitted when empty are required.
+func (b *openAPIBuilder) structSchema(t reflect.Type) *openAPISchema {
+	schema := &openAPISchema{Type: "object", Properties: make(map[string]*openAPISchema)}
+	if b.closed {
+		schema.AdditionalProperties = false
+	}
+
+	for _, jf := range jsonFields(t) {
+		field := t.FieldByIndex(jf.index)
+		_, omitempty, _ := jsonFieldName(field)
+
+		property := b.schema(jf.t)
+		if encodesQuoted(field) {
+			property = &openAPISchema{Type: "string", Nullable: field.Type.Kind() == reflect.Ptr}
+		}
+		if field.Type.Kind() == reflect.Slice && isNullableInClient(field, b.instance.collections.policy) {
+			property.Nullable = true
+		}
+
+		// fields with a visible tag are missing, or null, in the responses to the users the rule does not match
+		rule, visible := jf.tag.Lookup("visible")
+		description := fieldDescription(jf.tag)
+		if visible {
+			description = strings.TrimSpace(description + " Only visible if " + rule + ".")
+		}
+		if description != "" {
+			// siblings of a $ref are ignored, so the reference is wrapped
+			if property.Ref != "" {
+				property = &openAPISchema{AllOf: []*openAPISchema{property}}
+			}
+			property.Description = description
+		}
+		if deprecatedDescription(description) {
+			property.Deprecated = true
+		}
+
+		schema.Properties[jf.name] = property
+		if !omitempty && !visible {
+			schema.Required = append(schema.Required, jf.name)
+		}
+	}
+
+	return schema
+}
+
+// formSchema returns the object schema of the form of the given request, its files as binary strings and its form values.
+func (b *openAPIBuilder) formSchema(plan *bindingPlan) *openAPISchema {
+	schema := &openAPISchema{Type: "object", Properties: make(map[string]*openAPISchema)}
+
+	for n := range plan.fields {
+		bf := &plan.fields[n]
+
+		switch bf.source {
+		case sourceFile:
+			schema.Properties[bf.name] = &openAPISchema{Type: "string", Format: "binary"}
+		case sourceForm:
+			schema.Properties[bf.name] = b.schema(bf.field.Type)
+		default:
+			continue
+		}
+
+		if bf.required {
+			schema.Required = append(schema.Required, bf.name)
+		}
+	}
+
+	return schema
+}
+
+// openAPIScalarType returns the schema type of values of the given kind of an enum.
+func openAPIScalarType(kind reflect.Kind) string {
+	if kind == reflect.String {
+		return "string"
+	}
+
+	return "integer"
+}
+
+// openAPIEnumValues returns the values of the given TypeScript literals of an enum of the given kind.
+func openAPIEnumValues(kind reflect.Kind, literals []string) []any {
+	values := make([]any, 0, len(literals))
+	unescape := strings.NewReplacer(`\\`, `\`, `\'`, `'`, `\n`, "\n", `\r`, "\r")
+
+	for _, literal := range literals {
+		if kind == reflect.String {
+			values = append(values, unescape.Replace(literal[1:len(literal)-1]))
+		} else {
+			values = append(values, json.Number(literal))
+		}
+	}
+
+	return values
+}
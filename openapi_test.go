@@ -0,0 +1,113 @@
+package octanox
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/goccy/go-json"
+)
+
+type openAPIAddress struct {
+	Street string `json:"street"`
+}
+
+type openAPIOrder struct {
+	Address  openAPIAddress            `json:"address"`
+	Previous map[string]openAPIAddress `json:"previous"`
+}
+
+type openAPIOrderRequest struct {
+	PostRequest
+	Body openAPIOrder `body:"true"`
+}
+
+type openAPIOrderResponse struct {
+	OK bool `json:"ok"`
+}
+
+func openAPIOrderHandler(*openAPIOrderRequest) *openAPIOrderResponse {
+	return &openAPIOrderResponse{OK: true}
+}
+
+// openAPITestDocument is the part of the OpenAPI document the tests inspect.
+type openAPITestDocument struct {
+	Paths map[string]map[string]struct {
+		RequestBody struct {
+			Content map[string]struct {
+				Schema map[string]any `json:"schema"`
+			} `json:"content"`
+		} `json:"requestBody"`
+	} `json:"paths"`
+	Components struct {
+		Schemas map[string]map[string]any `json:"schemas"`
+	} `json:"components"`
+}
+
+func TestOpenAPIRequestBodies(t *testing.T) {
+	i := newTestInstance(t)
+	i.Register("/open", openAPIOrderHandler)
+	i.Register("/strict", openAPIOrderHandler).DisallowUnknownFields()
+
+	spec, err := i.openAPISpec(i.routes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var document openAPITestDocument
+	if err := json.Unmarshal(spec, &document); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path              string
+		jsonRef, otherRef string
+	}{
+		{"/open", "openAPIOrder", "openAPIOrder"},
+		{"/strict", "openAPIOrderStrict", "openAPIOrder"},
+	}
+
+	for _, tt := range tests {
+		content := document.Paths[tt.path]["post"].RequestBody.Content
+
+		mediaTypes := make([]string, 0, len(content))
+		for mediaType := range content {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+		sort.Strings(mediaTypes)
+		want := append([]string{}, supportedBodyMediaTypes...)
+		sort.Strings(want)
+		if !reflect.DeepEqual(mediaTypes, want) {
+			t.Errorf("%s: media types %v, want %v", tt.path, mediaTypes, want)
+		}
+
+		for mediaType, body := range content {
+			ref := tt.jsonRef
+			if mediaType == binding.MIMEMSGPACK || mediaType == binding.MIMEMSGPACK2 {
+				ref = tt.otherRef
+			}
+			if got := body.Schema["$ref"]; got != "#/components/schemas/"+ref {
+				t.Errorf("%s %s: schema %v, want %s", tt.path, mediaType, body.Schema, ref)
+			}
+		}
+	}
+
+	schemas := document.Components.Schemas
+	for _, name := range []string{"openAPIOrder", "openAPIAddress"} {
+		if additional, ok := schemas[name]["additionalProperties"]; ok {
+			t.Errorf("open schema %s has additionalProperties %v", name, additional)
+		}
+		if additional, ok := schemas[name+"Strict"]["additionalProperties"]; !ok || additional != false {
+			t.Errorf("strict schema %s has additionalProperties %v, want false", name, additional)
+		}
+	}
+
+	properties := schemas["openAPIOrderStrict"]["properties"].(map[string]any)
+	if ref := properties["address"].(map[string]any)["$ref"]; ref != "#/components/schemas/openAPIAddressStrict" {
+		t.Errorf("strict address %v, want the strict schema", ref)
+	}
+	values := properties["previous"].(map[string]any)["additionalProperties"].(map[string]any)
+	if ref := values["$ref"]; ref != "#/components/schemas/openAPIAddressStrict" {
+		t.Errorf("strict map values %v, want the strict schema", ref)
+	}
+}
@@ -0,0 +1,197 @@
+package octanox
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+)
+
+const (
+	// persistedQueriesPath is the path clients register the queries of routes with persisted queries at.
+	persistedQueriesPath = "/persisted-queries"
+	// persistedQueryParam is the query parameter carrying the hash of a persisted query.
+	persistedQueryParam = "pq"
+	// maxPersistedQuerySize is the maximum size of a registered query.
+	maxPersistedQuerySize = 32 << 10
+	// defaultPersistedQueries is the number of queries kept by the default store.
+	defaultPersistedQueries = 1000
+)
+
+// PersistedQueryStore is an interface that stores the queries of the routes with persisted queries by their hash, the hex
+// encoded SHA-256 of the query.
+type PersistedQueryStore interface {
+	// Load returns the query with the given hash. Returns false if the hash is unknown.
+	Load(ctx context.Context, hash string) (string, bool, error)
+	// Store stores the given query under its hash.
+	Store(ctx context.Context, hash, query string) error
+}
+
+// SetPersistedQueryStore sets the store of the persisted queries. Defaults to an in-memory store of the 1000 most recently
+// used queries, which is not shared between instances.
+func (i *Instance) SetPersistedQueryStore(store PersistedQueryStore) *Instance {
+	i.persistedQueries = store
+	return i
+}
+
+// PersistedQuery lets clients send the query of this GET route as the hash of a persisted query, e.g. for long filter
+// expressions which exceed the URL limits of proxies. The generated client first requests the route with ?pq=<hash> and,
+// if the server does not know the hash, registers the query at /persisted-queries and repeats the request. The persisted
+// query replaces the query of the request before the route reads it, so caches and list links see its parameters. Requests
+// with the full query are still served.
+func (r *Route) PersistedQuery() *Route {
+	if r.method != http.MethodGet {
+		panic("octanox: route " + r.method + " " + r.path + " cannot use persisted queries, only GET routes can")
+	}
+
+	if Current.persistedQueries == nil {
+		Current.persistedQueries = NewMemoryPersistedQueryStore(defaultPersistedQueries)
+	}
+
+	if !Current.servesPersistedQueries {
+		Current.servesPersistedQueries = true
+		Current.registerPersistedQueries()
+	}
+
+	r.persistedQuery = true
+	return r
+}
+
+// persistedQueryRegistration is the body clients register a persisted query with.
+type persistedQueryRegistration struct {
+	Hash  string `json:"hash"`
+	Query string `json:"query"`
+}
+
+// registerPersistedQueries serves the registration of persisted queries at /persisted-queries.
+func (i *Instance) registerPersistedQueries() {
+	i.Gin.POST(persistedQueriesPath, func(c *gin.Context) {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPersistedQuerySize+1024))
+		if err != nil {
+			abortWithError(c, failedRequest{status: http.StatusBadRequest, message: "Invalid body: cannot be read", code: ErrorCodeInvalidBody})
+			return
+		}
+
+		var registration persistedQueryRegistration
+		if err := json.Unmarshal(body, &registration); err != nil {
+			abortWithError(c, failedRequest{status: http.StatusBadRequest, message: "Invalid persisted query", code: ErrorCodeInvalidBody})
+			return
+		}
+
+		if len(registration.Query) > maxPersistedQuerySize {
+			abortWithError(c, failedRequest{status: http.StatusRequestEntityTooLarge, message: "Persisted query is too large", code: ErrorCodeBodyTooLarge})
+			return
+		}
+
+		// the hash is verified, so a query cannot be registered under the hash of another one
+		if persistedQueryHash(registration.Query) != registration.Hash {
+			abortWithError(c, failedRequest{status: http.StatusBadRequest, message: "Invalid persisted query: hash does not match the query", code: ErrorCodeInvalidBody})
+			return
+		}
+
+		if err := i.persistedQueries.Store(c.Request.Context(), registration.Hash, registration.Query); err != nil {
+			panic(err)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"hash": registration.Hash})
+	})
+}
+
+// resolvePersistedQuery replaces the query of a request carrying the hash of a persisted query with the persisted query.
+// Fails the request with 404 Not Found if the hash is unknown, so the client registers the query. Returns false if the
+// request has been failed.
+func resolvePersistedQuery(c *gin.Context) bool {
+	query := c.Request.URL.Query()
+	hash := query.Get(persistedQueryParam)
+	if hash == "" || len(query) != 1 {
+		return true
+	}
+
+	persisted, ok, err := Current.persistedQueries.Load(c.Request.Context(), hash)
+	if err != nil {
+		log.Println("octanox: cannot load persisted query " + hash + ": " + err.Error())
+		ok = false
+	}
+
+	if !ok {
+		// the code is also sent in the default error shape, as the client registers the query on it
+		failure := failedRequest{status: http.StatusNotFound, message: "Unknown persisted query: " + hash, code: ErrorCodePersistedQueryNotFound}
+		if Current.problemDetails == nil {
+			c.AbortWithStatusJSON(failure.status, gin.H{"error": failure.message, "code": failure.code})
+		} else {
+			abortWithError(c, failure)
+		}
+		return false
+	}
+
+	c.Request.URL.RawQuery = persisted
+	return true
+}
+
+// persistedQueryHash returns the hash of the given query, the hex encoded SHA-256.
+func persistedQueryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// MemoryPersistedQueryStore is a PersistedQueryStore keeping the most recently used queries in memory.
+type MemoryPersistedQueryStore struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type memoryPersistedQuery struct {
+	hash  string
+	query string
+}
+
+// NewMemoryPersistedQueryStore creates a new in-memory store of the given number of most recently used persisted queries.
+func NewMemoryPersistedQueryStore(size int) *MemoryPersistedQueryStore {
+	if size <= 0 {
+		panic("octanox: persisted query store needs a positive size")
+	}
+
+	return &MemoryPersistedQueryStore{size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (s *MemoryPersistedQueryStore) Load(_ context.Context, hash string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[hash]
+	if !ok {
+		return "", false, nil
+	}
+
+	s.order.MoveToFront(entry)
+	return entry.Value.(*memoryPersistedQuery).query, true, nil
+}
+
+func (s *MemoryPersistedQueryStore) Store(_ context.Context, hash, query string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[hash]; ok {
+		s.order.MoveToFront(entry)
+		return nil
+	}
+
+	s.entries[hash] = s.order.PushFront(&memoryPersistedQuery{hash: hash, query: query})
+
+	for s.order.Len() > s.size {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*memoryPersistedQuery).hash)
+	}
+
+	return nil
+}
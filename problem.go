@@ -0,0 +1,128 @@
+package octanox
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// problemMediaType is the media type of RFC 9457 problem details.
+const problemMediaType = "application/problem+json"
+
+// Error codes of the errors of the Octanox framework, carried in the code member of problem details.
+const (
+	ErrorCodeUnauthorized           = "unauthorized"
+	ErrorCodeForbidden              = "forbidden"
+	ErrorCodeNotFound               = "not_found"
+	ErrorCodeGone                   = "gone"
+	ErrorCodeInvalidCredentials     = "invalid_credentials"
+	ErrorCodeMissingParameter       = "missing_parameter"
+	ErrorCodeInvalidParameter       = "invalid_parameter"
+	ErrorCodeInvalidBody            = "invalid_body"
+	ErrorCodeUnknownField           = "unknown_field"
+	ErrorCodeBodyTooLarge           = "body_too_large"
+	ErrorCodeUnsupportedEncoding    = "unsupported_encoding"
+	ErrorCodeUnsupportedMediaType   = "unsupported_media_type"
+	ErrorCodeUnsupportedVersion     = "unsupported_version"
+	ErrorCodeInvalidListQuery       = "invalid_list_query"
+	ErrorCodeRateLimited            = "rate_limited"
+	ErrorCodeQuotaExceeded          = "quota_exceeded"
+	ErrorCodeMaintenance            = "maintenance"
+	ErrorCodeUnavailable            = "unavailable"
+	ErrorCodeRouteDisabled          = "route_disabled"
+	ErrorCodeBudgetExceeded         = "budget_exceeded"
+	ErrorCodeInvalidConfig          = "invalid_config"
+	ErrorCodePersistedQueryNotFound = "persisted_query_not_found"
+	ErrorCodeInternal               = "internal"
+)
+
+// ProblemDetailsOptions is a struct that configures the rendering of errors as RFC 9457 problem details.
+type ProblemDetailsOptions struct {
+	// TypeBaseURI is the URI the error code is appended to, to build the type of a problem with an error code. If empty, or if
+	// the error has no code, the type is "about:blank".
+	TypeBaseURI string
+}
+
+// ProblemDetails is a struct that represents an error rendered as RFC 9457 problem details.
+type ProblemDetails struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Code is the error code extension member, e.g. ErrorCodeMissingParameter.
+	Code string `json:"code,omitempty"`
+	// Errors is the extension member carrying the details of a failed validation.
+	Errors []ProblemFieldError `json:"errors,omitempty"`
+	// Quota is the extension member carrying the exhausted quota of a request rejected with ErrorCodeQuotaExceeded.
+	Quota *QuotaExceeded `json:"quota,omitempty"`
+	// Gone is the extension member carrying the deleted resource of a request rejected with ErrorCodeGone.
+	Gone *GoneError `json:"gone,omitempty"`
+	// Retryable is the extension member marking errors of requests which may succeed when they are retried unchanged, e.g.
+	// when rate limited or in maintenance mode.
+	Retryable bool `json:"retryable,omitempty"`
+	// RetryAfterMs is the extension member carrying the milliseconds after which a retryable request may be retried. Zero if
+	// the client decides on its own.
+	RetryAfterMs int64 `json:"retryAfterMs,omitempty"`
+}
+
+// ProblemFieldError is a struct that describes why a single parameter or body of a request is invalid.
+type ProblemFieldError struct {
+	// Source is where the invalid value has been sent, e.g. "query" or "body".
+	Source string `json:"source"`
+	// Name is the name of the parameter. Empty for the body.
+	Name   string `json:"name,omitempty"`
+	Detail string `json:"detail"`
+}
+
+// UseProblemDetails renders all errors of the Octanox framework and of failed requests as application/problem+json instead of
+// the default {"error": "..."} shape.
+func (i *Instance) UseProblemDetails(opts ProblemDetailsOptions) *Instance {
+	i.problemDetails = &opts
+	return i
+}
+
+// abortWithError aborts the request with the given error, rendered in the error format of the instance.
+func abortWithError(c *gin.Context, err failedRequest) {
+	setRetryAfter(c, err)
+
+	opts := Current.problemDetails
+	if opts == nil {
+		body := gin.H{"error": err.message}
+		if err.quota != nil {
+			body["quota"] = err.quota
+		}
+		if err.gone != nil {
+			body["gone"] = err.gone
+		}
+		if err.retryable {
+			body["retryable"] = true
+		}
+		if ms := err.retryAfterMs(); ms > 0 {
+			body["retryAfterMs"] = ms
+		}
+
+		c.AbortWithStatusJSON(err.status, body)
+		return
+	}
+
+	problem := ProblemDetails{
+		Type:         "about:blank",
+		Title:        http.StatusText(err.status),
+		Status:       err.status,
+		Detail:       err.message,
+		Instance:     c.Request.URL.Path,
+		Code:         err.code,
+		Errors:       err.errors,
+		Quota:        err.quota,
+		Gone:         err.gone,
+		Retryable:    err.retryable,
+		RetryAfterMs: err.retryAfterMs(),
+	}
+	if opts.TypeBaseURI != "" && err.code != "" {
+		problem.Type = opts.TypeBaseURI + err.code
+	}
+
+	c.Abort()
+	writeJSONAs(c, err.status, problemMediaType, problem)
+}
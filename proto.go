@@ -0,0 +1,66 @@
+package octanox
+
+import (
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtoJSONOptions is a struct that configures how protobuf messages used as request and response DTOs are encoded.
+// Protobuf messages are bound and serialized with protojson instead of encoding/json. This applies to messages used directly
+// as request bodies or responses; messages nested inside plain structs are encoded like any other struct.
+type ProtoJSONOptions struct {
+	// UseProtoNames uses the original proto field names (snake_case) instead of the lowerCamelCase JSON names.
+	UseProtoNames bool
+	// UseEnumNumbers emits enum values as numbers instead of their names.
+	UseEnumNumbers bool
+	// EmitUnpopulated emits fields with their zero value instead of omitting them.
+	EmitUnpopulated bool
+	// DiscardUnknown ignores unknown fields in request bodies instead of rejecting them.
+	DiscardUnknown bool
+}
+
+// SetProtoJSONOptions sets the options used to encode and decode protobuf messages.
+func (i *Instance) SetProtoJSONOptions(opts ProtoJSONOptions) *Instance {
+	i.protoJSON = opts
+	return i
+}
+
+var protoMessageType = reflect.TypeOf((*proto.Message)(nil)).Elem()
+
+// isProtoMessage checks if the given type, or a pointer to it, is a protobuf message.
+func isProtoMessage(t reflect.Type) bool {
+	if t.Kind() != reflect.Ptr {
+		t = reflect.PointerTo(t)
+	}
+
+	return t.Implements(protoMessageType)
+}
+
+// unmarshalProto decodes the given protojson body into the protobuf message.
+func (i *Instance) unmarshalProto(body []byte, msg proto.Message) error {
+	return protojson.UnmarshalOptions{
+		DiscardUnknown: i.protoJSON.DiscardUnknown,
+	}.Unmarshal(body, msg)
+}
+
+// protoMarshalOptions returns the protojson options used to encode protobuf messages.
+func (i *Instance) protoMarshalOptions() protojson.MarshalOptions {
+	return protojson.MarshalOptions{
+		UseProtoNames:   i.protoJSON.UseProtoNames,
+		UseEnumNumbers:  i.protoJSON.UseEnumNumbers,
+		EmitUnpopulated: i.protoJSON.EmitUnpopulated,
+	}
+}
+
+// respondProto writes the given protobuf message encoded as protojson with the given status code.
+func (i *Instance) respondProto(c *gin.Context, status int, msg proto.Message) {
+	data, err := i.protoMarshalOptions().Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+
+	c.Data(status, "application/json; charset=utf-8", data)
+}
@@ -0,0 +1,388 @@
+package octanox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QuotaPeriod is an enum that defines the period after which the usage of a quota is reset. Periods are aligned to UTC.
+type QuotaPeriod int
+
+const (
+	// QuotaMonthly resets the usage at the start of every month. This is the default.
+	QuotaMonthly QuotaPeriod = iota
+	// QuotaDaily resets the usage at the start of every day.
+	QuotaDaily
+)
+
+// start returns the start of the period containing the given time.
+func (p QuotaPeriod) start(now time.Time) time.Time {
+	now = now.UTC()
+	if p == QuotaDaily {
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// reset returns the end of the period containing the given time, at which the usage is reset.
+func (p QuotaPeriod) reset(now time.Time) time.Time {
+	if p == QuotaDaily {
+		return p.start(now).AddDate(0, 0, 1)
+	}
+
+	return p.start(now).AddDate(0, 1, 0)
+}
+
+// key returns the key of the period containing the given time, e.g. "2026-10" or "2026-10-14".
+func (p QuotaPeriod) key(now time.Time) string {
+	if p == QuotaDaily {
+		return p.start(now).Format("2006-01-02")
+	}
+
+	return p.start(now).Format("2006-01")
+}
+
+// QuotaStore is an interface that stores the usage of quotas. The keys contain the quota name, the principal and the period,
+// so a new period starts with a new key and old keys may be dropped once they expire.
+type QuotaStore interface {
+	// Increment atomically adds the given cost to the usage of the given key and returns the new usage. The cost can be negative.
+	// The key is not used anymore after the given expiry.
+	Increment(ctx context.Context, key string, cost int64, expires time.Time) (int64, error)
+	// Usage returns the usage of the given key. Unknown keys have zero usage.
+	Usage(ctx context.Context, key string) (int64, error)
+	// SetUsage sets the usage of the given key.
+	SetUsage(ctx context.Context, key string, usage int64, expires time.Time) error
+}
+
+// QuotaKeyFunc is a function that returns the principal a request is accounted to. The user is nil for unauthenticated requests.
+type QuotaKeyFunc func(c *gin.Context, user User) string
+
+// QuotaOptions is a struct that configures a quota declared with Instance.Quota.
+type QuotaOptions struct {
+	// Limit is the cost every principal may consume per period.
+	Limit int64
+	// LimitFor returns the limit of the given principal, e.g. from the plan of a tenant. Non-positive limits fall back to Limit.
+	// Can be nil.
+	LimitFor func(key string) int64
+	// Period is the period after which the usage is reset. Defaults to QuotaMonthly.
+	Period QuotaPeriod
+	// Store is the store of the usage. Defaults to an in-memory store, which is not shared between instances.
+	Store QuotaStore
+	// Key returns the principal of a request. Defaults to the tenant, the authenticated user or the client IP, in this order.
+	Key QuotaKeyFunc
+	// PaymentRequired answers requests exceeding the quota with 402 Payment Required instead of 429 Too Many Requests.
+	PaymentRequired bool
+}
+
+// QuotaUsage is a struct that contains the usage of a quota by a single principal in the current period.
+type QuotaUsage struct {
+	Name      string    `json:"name"`
+	Key       string    `json:"key"`
+	Limit     int64     `json:"limit"`
+	Used      int64     `json:"used"`
+	Remaining int64     `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+// QuotaExceeded is a struct that is carried in the quota member of the error of requests rejected because the quota of their
+// principal is exhausted.
+type QuotaExceeded struct {
+	Name  string    `json:"name"`
+	Limit int64     `json:"limit"`
+	Used  int64     `json:"used"`
+	Reset time.Time `json:"reset"`
+}
+
+// quota is a quota declared with Instance.Quota.
+type quota struct {
+	name string
+	opts QuotaOptions
+}
+
+// routeQuota is the quota consumed by a route and the cost of a request.
+type routeQuota struct {
+	name string
+	cost int64
+}
+
+// quotaResponseHeaders are the headers every route consuming a quota declares implicitly.
+var quotaResponseHeaders = []responseHeader{
+	{name: "X-Quota-Limit", description: "cost the principal may consume in the current period", t: reflect.TypeOf(int64(0))},
+	{name: "X-Quota-Remaining", description: "cost the principal may still consume in the current period", t: reflect.TypeOf(int64(0))},
+	{name: "X-Quota-Reset", description: "unix time in seconds at which the usage is reset", t: reflect.TypeOf(int64(0))},
+}
+
+// Quota declares a quota with the given name, which routes consume with Route.Quota. The usage of every principal is
+// reported in the X-Quota-Limit, X-Quota-Remaining and X-Quota-Reset headers, and can be read and adjusted with
+// Instance.QuotaUsage and Instance.SetQuotaUsage or at /.nox/quotas/:name/:key, guarded by the internal options.
+func (i *Instance) Quota(name string, opts QuotaOptions) *Instance {
+	if _, ok := i.quotas[name]; ok {
+		panic("octanox: quota " + name + " already declared")
+	}
+
+	if opts.Limit <= 0 && opts.LimitFor == nil {
+		panic("octanox: quota " + name + " needs a limit")
+	}
+
+	if opts.Store == nil {
+		opts.Store = NewMemoryQuotaStore()
+	}
+
+	if opts.Key == nil {
+		opts.Key = defaultQuotaKey
+	}
+
+	if len(i.quotas) == 0 {
+		i.mountQuotaAdmin()
+	}
+
+	i.quotas[name] = &quota{name: name, opts: opts}
+	return i
+}
+
+// Quota makes every request of this route consume the given cost of the quota with the given name, which has to be declared
+// with Instance.Quota before. Requests exceeding the quota of their principal are rejected before the handler is called.
+func (r *Route) Quota(name string, cost int64) *Route {
+	if _, ok := Current.quotas[name]; !ok {
+		panic("octanox: route " + r.method + " " + r.path + " consumes the undeclared quota " + name)
+	}
+
+	if cost <= 0 {
+		panic("octanox: route " + r.method + " " + r.path + " must consume a positive cost of quota " + name)
+	}
+
+	r.quota = &routeQuota{name: name, cost: cost}
+	return r
+}
+
+// QuotaUsage returns the usage of the quota with the given name by the given principal in the current period.
+func (i *Instance) QuotaUsage(ctx context.Context, name, key string) (QuotaUsage, error) {
+	q, ok := i.quotas[name]
+	if !ok {
+		return QuotaUsage{}, errors.New("octanox: unknown quota " + name)
+	}
+
+	now := time.Now()
+	used, err := q.opts.Store.Usage(ctx, q.storeKey(key, now))
+	if err != nil {
+		return QuotaUsage{}, err
+	}
+
+	return q.usage(key, used, now), nil
+}
+
+// SetQuotaUsage sets the usage of the quota with the given name by the given principal in the current period, e.g. to reset
+// it to zero, and returns the new usage.
+func (i *Instance) SetQuotaUsage(ctx context.Context, name, key string, used int64) (QuotaUsage, error) {
+	q, ok := i.quotas[name]
+	if !ok {
+		return QuotaUsage{}, errors.New("octanox: unknown quota " + name)
+	}
+
+	now := time.Now()
+	if err := q.opts.Store.SetUsage(ctx, q.storeKey(key, now), used, q.opts.Period.reset(now)); err != nil {
+		return QuotaUsage{}, err
+	}
+
+	return q.usage(key, used, now), nil
+}
+
+// mountQuotaAdmin serves the usage of the quotas at /.nox/quotas/:name/:key. GET reads the usage, PUT sets it from a
+// {"used": n} body.
+func (i *Instance) mountQuotaAdmin() {
+	quotas := i.internal().Group("/quotas")
+
+	respond := func(c *gin.Context, usage QuotaUsage, err error) {
+		if err != nil {
+			panic(err)
+		}
+
+		c.JSON(http.StatusOK, usage)
+	}
+
+	quotas.Use(func(c *gin.Context) {
+		if _, ok := i.quotas[c.Param("name")]; !ok {
+			abortWithError(c, failedRequest{status: http.StatusNotFound, message: "unknown quota " + c.Param("name"), code: ErrorCodeNotFound})
+		}
+	})
+
+	quotas.GET("/:name/:key", func(c *gin.Context) {
+		usage, err := i.QuotaUsage(c.Request.Context(), c.Param("name"), c.Param("key"))
+		respond(c, usage, err)
+	})
+
+	quotas.PUT("/:name/:key", func(c *gin.Context) {
+		var body struct {
+			Used *int64 `json:"used"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil || body.Used == nil || *body.Used < 0 {
+			abortWithError(c, failedRequest{status: http.StatusBadRequest, message: "Invalid body: expected {\"used\": n} with n >= 0", code: ErrorCodeInvalidBody})
+			return
+		}
+
+		usage, err := i.SetQuotaUsage(c.Request.Context(), c.Param("name"), c.Param("key"), *body.Used)
+		respond(c, usage, err)
+	})
+}
+
+// consumeQuota consumes the cost of the quota of the route for the principal of the request and sets the quota headers. It
+// fails the request if the quota is exhausted, in which case the cost is not consumed.
+func (i *Instance) consumeQuota(c *gin.Context, rq *routeQuota, user User) {
+	q := i.quotas[rq.name]
+	key := q.opts.Key(c, user)
+	now := time.Now()
+	storeKey := q.storeKey(key, now)
+	reset := q.opts.Period.reset(now)
+
+	used, err := q.opts.Store.Increment(c.Request.Context(), storeKey, rq.cost, reset)
+	if err != nil {
+		panic(err)
+	}
+
+	exceeded := used > q.limit(key)
+	if exceeded {
+		if used, err = q.opts.Store.Increment(c.Request.Context(), storeKey, -rq.cost, reset); err != nil {
+			panic(err)
+		}
+	}
+
+	usage := q.usage(key, used, now)
+	c.Header("X-Quota-Limit", strconv.FormatInt(usage.Limit, 10))
+	c.Header("X-Quota-Remaining", strconv.FormatInt(usage.Remaining, 10))
+	c.Header("X-Quota-Reset", strconv.FormatInt(reset.Unix(), 10))
+
+	if !exceeded {
+		return
+	}
+
+	// requests exceeding a quota which has to be paid for do not succeed by waiting for the next period
+	failure := failedRequest{
+		status:  http.StatusTooManyRequests,
+		message: "Quota exceeded: " + q.name,
+		code:    ErrorCodeQuotaExceeded,
+		quota:   &QuotaExceeded{Name: q.name, Limit: usage.Limit, Used: usage.Used, Reset: reset},
+	}
+	if q.opts.PaymentRequired {
+		failure.status = http.StatusPaymentRequired
+	} else {
+		failure.retryable, failure.retryAfter = true, time.Until(reset)
+	}
+
+	panic(failure)
+}
+
+// storeKey returns the key of the usage of the given principal in the period containing the given time.
+func (q *quota) storeKey(key string, now time.Time) string {
+	return q.name + ":" + key + ":" + q.opts.Period.key(now)
+}
+
+// limit returns the limit of the given principal.
+func (q *quota) limit(key string) int64 {
+	if q.opts.LimitFor != nil {
+		if limit := q.opts.LimitFor(key); limit > 0 {
+			return limit
+		}
+	}
+
+	return q.opts.Limit
+}
+
+// usage returns the usage of the given principal with the given used cost.
+func (q *quota) usage(key string, used int64, now time.Time) QuotaUsage {
+	limit := q.limit(key)
+	return QuotaUsage{
+		Name:      q.name,
+		Key:       key,
+		Limit:     limit,
+		Used:      used,
+		Remaining: max(limit-used, 0),
+		Reset:     q.opts.Period.reset(now),
+	}
+}
+
+// defaultQuotaKey accounts requests to their tenant, their authenticated user or their client IP, in this order.
+func defaultQuotaKey(c *gin.Context, user User) string {
+	if tenant := TenantFrom(c); tenant != "" {
+		return "tenant:" + tenant
+	}
+
+	if user != nil {
+		return "user:" + user.ID().String()
+	}
+
+	return "ip:" + c.ClientIP()
+}
+
+// MemoryQuotaStore is a QuotaStore keeping the usage in memory. Expired keys are dropped on the next increment.
+type MemoryQuotaStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryQuotaEntry
+	pruned  time.Time
+}
+
+type memoryQuotaEntry struct {
+	used    int64
+	expires time.Time
+}
+
+// NewMemoryQuotaStore creates a new in-memory quota store.
+func NewMemoryQuotaStore() *MemoryQuotaStore {
+	return &MemoryQuotaStore{entries: make(map[string]*memoryQuotaEntry)}
+}
+
+func (s *MemoryQuotaStore) Increment(_ context.Context, key string, cost int64, expires time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(time.Now())
+
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &memoryQuotaEntry{expires: expires}
+		s.entries[key] = entry
+	}
+
+	entry.used += cost
+	return entry.used, nil
+}
+
+func (s *MemoryQuotaStore) Usage(_ context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok {
+		return entry.used, nil
+	}
+
+	return 0, nil
+}
+
+func (s *MemoryQuotaStore) SetUsage(_ context.Context, key string, usage int64, expires time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = &memoryQuotaEntry{used: usage, expires: expires}
+	return nil
+}
+
+// prune drops the expired entries, at most once a minute.
+func (s *MemoryQuotaStore) prune(now time.Time) {
+	if now.Sub(s.pruned) < time.Minute {
+		return
+	}
+
+	s.pruned = now
+	for key, entry := range s.entries {
+		if now.After(entry.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package octanox
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rawBodyKey is the key of the Gin context the captured raw request body is stored under.
+const rawBodyKey = "nox.rawBody"
+
+// rawBodyType is the type of raw request body fields.
+var rawBodyType = reflect.TypeOf(RawBody(nil))
+
+// RawBody is the type of request fields tagged with `body:"raw"`, which are bound to the exact bytes of the request body
+// without decoding them, e.g. to verify the signature of a webhook. A second field tagged with `body:"true"` is bound to
+// the body decoded from the same bytes. The generated TypeScript client sends a string or Uint8Array as is.
+type RawBody []byte
+
+// RawBodyFrom returns the exact bytes of the request body. The body is only read once and replaced by a reader of the
+// captured bytes, so authenticators can verify signatures over it before the binder decodes it. Bodies larger than the
+// MaxBodySize of the request decompression options fail with an error.
+func RawBodyFrom(c *gin.Context) ([]byte, error) {
+	if raw, ok := c.Get(rawBodyKey); ok {
+		c.Request.Body = io.NopCloser(bytes.NewReader(raw.([]byte)))
+		return raw.([]byte), nil
+	}
+
+	var raw []byte
+	if c.Request.Body != nil && c.Request.Body != http.NoBody {
+		maxSize := Current.decompression.MaxBodySize
+		if maxSize <= 0 {
+			maxSize = defaultMaxDecompressedBodySize
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(body)) > maxSize {
+			return nil, errRequestBodyTooLarge
+		}
+
+		raw = body
+	}
+
+	c.Set(rawBodyKey, raw)
+	c.Request.Body = io.NopCloser(bytes.NewReader(raw))
+	return raw, nil
+}
+
+// bindRawBody binds the raw request body, failing with 413 Request Entity Too Large if it exceeds the maximum size.
+func bindRawBody(c *gin.Context) RawBody {
+	raw, err := RawBodyFrom(c)
+	if errors.Is(err, errRequestBodyTooLarge) {
+		panic(failedRequest{
+			status:  http.StatusRequestEntityTooLarge,
+			message: "Request body too large",
+			code:    ErrorCodeBodyTooLarge,
+		})
+	}
+
+	if err != nil {
+		panic(failedRequest{
+			status:  http.StatusBadRequest,
+			message: "Invalid body: cannot be read",
+			code:    ErrorCodeInvalidBody,
+		})
+	}
+
+	return raw
+}
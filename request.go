@@ -1,26 +1,44 @@
 package octanox
 
 import (
-	"io"
+	"errors"
 	"net/http"
 	"reflect"
-
-	"github.com/goccy/go-json"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type Request struct{}
 
+var listQueryType = reflect.TypeOf(ListQuery{})
+
 type failedRequest struct {
 	status  int
 	message string
+	// code is the error code carried in problem details. Can be empty.
+	code string
+	// errors are the validation details carried in problem details. Can be nil.
+	errors []ProblemFieldError
+	// quota is the exhausted quota carried in the error. Can be nil.
+	quota *QuotaExceeded
+	// gone is the deleted resource carried in the error. Can be nil.
+	gone *GoneError
+	// retryable is a flag that indicates whether the request may succeed when it is retried unchanged.
+	retryable bool
+	// retryAfter is the time after which a retryable request may be retried. Zero if it is unknown.
+	retryAfter time.Duration
 }
 
 // Failed is a function that can be called to indicate that the request has failed and should abort with a specific status code and message.
 // This function will panic with a failedRequest struct that will be caught by the Octanox framework.
 func (r Request) Failed(status int, message string) {
-	panic(failedRequest{status, message})
+	panic(failedRequest{status: status, message: message})
+}
+
+// FailedWithCode is like Failed, but additionally carries the given error code in the code member of problem details.
+func (r Request) FailedWithCode(status int, code, message string) {
+	panic(failedRequest{status: status, message: message, code: code})
 }
 
 // GetRequest is a struct that represents a GET request.
@@ -63,30 +81,39 @@ type TraceRequest struct {
 	Request
 }
 
-// populateRequest is a function that extracts the request data from the Gin context, creates a new empty request struct from the given type, and populates it with the extracted data.
-func populateRequest(c *gin.Context, reqType reflect.Type, user User) any {
-	reqValue := reflect.New(reqType).Elem()
+// populateRequest is a function that extracts the request data from the Gin context, creates a new empty request struct from the given plan, and populates it with the extracted data.
+func populateRequest(c *gin.Context, rt *Route, plan *bindingPlan, user User) any {
+	reqValue := reflect.New(plan.t).Elem()
 
-	for i := 0; i < reqType.NumField(); i++ {
-		field := reqType.Field(i)
-		fieldValue := reqValue.Field(i)
+	// the raw body is captured before any field decodes it, so both see the same bytes
+	if plan.rawBodyField() != nil {
+		bindRawBody(c)
+	}
+	if plan.formFields() {
+		parseForm(c)
+	}
 
-		if !fieldValue.CanSet() {
-			continue
-		}
+	for n := range plan.fields {
+		bf := &plan.fields[n]
+		field := bf.field
 
-		if field.Anonymous {
-			embeddedReq := populateRequest(c, field.Type, user)
-			fieldValue.Set(reflect.ValueOf(embeddedReq).Elem())
+		// inherited path parameters the request struct does not bind are only read with PathParamFrom
+		if bf.index == nil {
 			continue
 		}
 
-		if userTag := field.Tag.Get("user"); userTag != "" {
+		fieldValue := reqValue.FieldByIndex(bf.index)
+
+		switch bf.source {
+		case sourceList:
+			fieldValue.Set(reflect.ValueOf(bindListQuery(c, rt.list)))
+		case sourceUser:
 			if user == nil || reflect.DeepEqual(user, reflect.Zero(reflect.TypeOf(user)).Interface()) {
-				if userTag != "optional" {
+				if bf.required {
 					panic(failedRequest{
 						status:  http.StatusUnauthorized,
 						message: "Unauthorized: User is required but not provided",
+						code:    ErrorCodeUnauthorized,
 					})
 				}
 
@@ -98,87 +125,159 @@ func populateRequest(c *gin.Context, reqType reflect.Type, user User) any {
 			} else {
 				fieldValue.Set(reflect.ValueOf(user))
 			}
-
-			continue
-		}
-
-		if ginTag := field.Tag.Get("gin"); ginTag != "" {
-			if fieldValue.Kind() == reflect.Ptr {
-				fieldValue.Set(reflect.ValueOf(c))
-			} else {
-				panic("field with 'gin' tag must be a pointer to a gin.Context")
+		case sourceGin:
+			fieldValue.Set(reflect.ValueOf(c))
+		case sourceInject:
+			service, ok := Current.service(field.Type)
+			if !ok {
+				panic("octanox: no service of type " + field.Type.String() + " provided for field " + field.Name)
 			}
 
-			continue
-		}
-
-		if pathParam := field.Tag.Get("path"); pathParam != "" {
-			fieldValue.SetString(c.Param(pathParam))
-		} else if queryParam := field.Tag.Get("query"); queryParam != "" {
-			queryValue := c.Query(queryParam)
-			if queryValue == "" && field.Tag.Get("optional") != "true" {
-				panic(failedRequest{
-					status:  http.StatusBadRequest,
-					message: "Missing required query parameter: " + queryParam,
-				})
-			}
-			fieldValue.SetString(queryValue)
-		} else if headerParam := field.Tag.Get("header"); headerParam != "" {
-			headerValue := c.GetHeader(headerParam)
-			if headerValue == "" && field.Tag.Get("optional") != "true" {
-				panic(failedRequest{
-					status:  http.StatusBadRequest,
-					message: "Missing required header: " + headerParam,
-				})
+			fieldValue.Set(service)
+		case sourceTenant:
+			fieldValue.SetString(TenantFrom(c))
+		case sourcePath, sourceQuery, sourceHeader, sourceCookie, sourceClaim, sourceForm:
+			if bf.group {
+				bindGroupParam(c, bf, fieldValue)
+				continue
 			}
-			fieldValue.SetString(headerValue)
-		} else if bodyParam := field.Tag.Get("body"); bodyParam != "" {
+
+			bindParam(c, bf, fieldValue, user)
+		case sourceBody:
 			if field.Type.Kind() == reflect.Ptr {
 				bodyInstance := reflect.New(field.Type.Elem()).Interface()
-
-				if err := bindJsonFast(c, bodyInstance); err != nil {
-					message := "Invalid JSON body"
-
-					if Current.isDebug {
-						message += ": " + err.Error()
-					}
-
-					panic(failedRequest{
-						status:  http.StatusBadRequest,
-						message: message,
-					})
-				}
-
+				bindBodyOrFail(c, bodyInstance, rt.rejectsUnknownFields())
 				fieldValue.Set(reflect.ValueOf(bodyInstance))
 			} else {
 				bodyInstance := reflect.New(field.Type).Interface()
+				bindBodyOrFail(c, bodyInstance, rt.rejectsUnknownFields())
+				fieldValue.Set(reflect.ValueOf(bodyInstance).Elem())
+			}
+		case sourceRawBody:
+			fieldValue.Set(reflect.ValueOf(bindRawBody(c)))
+		case sourceFile:
+			bindFile(c, bf, fieldValue)
+		}
+	}
 
-				if err := bindJsonFast(c, bodyInstance); err != nil {
-					message := "Invalid JSON body"
+	return reqValue.Addr().Interface()
+}
 
-					if Current.isDebug {
-						message += ": " + err.Error()
-					}
+// bindParam binds the path, query, header, cookie, claim or form parameter of the given field. Missing parameters fall back to the
+// default of the field, or fail the request if they are required. Values that cannot be converted fail with 400 Bad Request.
+func bindParam(c *gin.Context, bf *bindingField, fieldValue reflect.Value, user User) {
+	if bf.array != "" {
+		bindQueryArray(c, bf, fieldValue)
+		return
+	}
 
-					panic(failedRequest{
-						status:  http.StatusBadRequest,
-						message: message,
-					})
-				}
+	var raw string
+	status, code, missing := http.StatusBadRequest, ErrorCodeMissingParameter, "Missing required "+bf.sourceName()+" parameter: "+bf.name
+
+	switch bf.source {
+	case sourcePath:
+		raw = c.Param(bf.name)
+	case sourceQuery:
+		raw = c.Query(bf.name)
+	case sourceHeader:
+		raw = c.GetHeader(bf.name)
+		missing = "Missing required header: " + bf.name
+	case sourceCookie:
+		raw, _ = c.Cookie(bf.name)
+		missing = "Missing required cookie: " + bf.name
+	case sourceForm:
+		raw = c.Request.PostFormValue(bf.name)
+		missing = "Missing required form field: " + bf.name
+	case sourceClaim:
+		if claims, ok := user.(ClaimProvider); ok {
+			raw, _ = claims.Claim(bf.name)
+		}
+		status, code, missing = http.StatusForbidden, ErrorCodeForbidden, "Forbidden: Missing required claim: "+bf.name
+	}
 
-				fieldValue.Set(reflect.ValueOf(bodyInstance).Elem())
-			}
+	if raw == "" {
+		switch {
+		case bf.hasDefault:
+			raw = bf.def
+		case bf.required:
+			panic(failedRequest{
+				status:  status,
+				message: missing,
+				code:    code,
+				errors:  []ProblemFieldError{{Source: bf.sourceName(), Name: bf.name, Detail: "missing"}},
+			})
+		default:
+			return
 		}
 	}
 
-	return reqValue.Addr().Interface()
+	value, err := bf.convert(raw)
+	if err != nil {
+		panic(invalidParamError(bf.sourceName(), bf.name, bf.field.Type, err))
+	}
+
+	fieldValue.Set(value)
 }
 
-func bindJsonFast(c *gin.Context, v any) error {
-	body, err := io.ReadAll(c.Request.Body)
+// bindQueryArray binds the slice query parameter of the given field, sent repeated or comma separated as declared by its tag.
+// A missing parameter falls back to the default of the field, or leaves the slice empty.
+func bindQueryArray(c *gin.Context, bf *bindingField, fieldValue reflect.Value) {
+	var raw []string
+	if bf.array == queryArrayCSV {
+		raw = splitCSV(c.Query(bf.name))
+	} else {
+		raw = c.QueryArray(bf.name)
+	}
+
+	if len(raw) == 0 {
+		if !bf.hasDefault {
+			return
+		}
+		raw = bf.defaultValues()
+	}
+
+	value, err := bf.convertAll(raw)
 	if err != nil {
-		return err
+		panic(invalidParamError(bf.sourceName(), bf.name, bf.field.Type, err))
 	}
 
-	return json.Unmarshal(body, v)
+	fieldValue.Set(value)
+}
+
+// bindBodyOrFail binds the request body into v and fails the request with 415 Unsupported Media Type if its Content-Type is not
+// supported, or with 400 Bad Request if it cannot be decoded or contains unknown fields while they are disallowed.
+func bindBodyOrFail(c *gin.Context, v any, disallowUnknownFields bool) {
+	if err := bindBody(c, v, disallowUnknownFields); err != nil {
+		var unsupported *unsupportedMediaTypeError
+		if errors.As(err, &unsupported) {
+			panic(failedRequest{
+				status:  http.StatusUnsupportedMediaType,
+				message: unsupported.Error(),
+				code:    ErrorCodeUnsupportedMediaType,
+			})
+		}
+
+		// integrators need to know the unknown fields to fix their requests, also outside of debug mode
+		var unknown *unknownFieldsError
+		if errors.As(err, &unknown) {
+			panic(failedRequest{
+				status:  http.StatusBadRequest,
+				message: "Invalid JSON body: " + unknown.Error(),
+				code:    ErrorCodeUnknownField,
+				errors:  unknown.problemFieldErrors(),
+			})
+		}
+
+		message := "Invalid " + bodyFormatName(c) + " body"
+
+		if Current.isDebug {
+			message += ": " + err.Error()
+		}
+
+		panic(failedRequest{
+			status:  http.StatusBadRequest,
+			message: message,
+			code:    ErrorCodeInvalidBody,
+		})
+	}
 }
@@ -0,0 +1,247 @@
+package octanox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrResourceNotFound is the error a ResourceStore returns if the resource with the requested ID does not exist. It is answered
+// with 404 Not Found.
+var ErrResourceNotFound = errors.New("resource not found")
+
+// ResourceOperation is one of the operations registered by Resource.
+type ResourceOperation string
+
+const (
+	ResourceList   ResourceOperation = "list"
+	ResourceGet    ResourceOperation = "get"
+	ResourceCreate ResourceOperation = "create"
+	ResourceUpdate ResourceOperation = "update"
+	ResourceDelete ResourceOperation = "delete"
+)
+
+// ResourceStore is an interface that defines the storage of the resources of type T, created from C and updated from U.
+type ResourceStore[T, C, U any] interface {
+	// List returns the page of resources requested by the given list query.
+	List(ctx context.Context, query ListQuery) (ListResult[T], error)
+	// Get returns the resource with the given ID, or ErrResourceNotFound. Soft-deleted resources are reported with Gone.
+	Get(ctx context.Context, id string) (T, error)
+	// Create creates a new resource from the given request and returns it.
+	Create(ctx context.Context, req C) (T, error)
+	// Update updates the resource with the given ID from the given request and returns it, or ErrResourceNotFound or Gone.
+	Update(ctx context.Context, id string, req U) (T, error)
+	// Delete deletes the resource with the given ID, or returns ErrResourceNotFound or Gone.
+	Delete(ctx context.Context, id string) error
+}
+
+// ResourceAuthorizer is a function that checks if the given user may run the given operation on the resource with the given ID.
+// The ID is empty for the list and create operations. The user is nil for unauthenticated requests.
+type ResourceAuthorizer func(c *gin.Context, user User, op ResourceOperation, id string) bool
+
+// ResourceOptions is a struct that configures the routes registered by Resource.
+type ResourceOptions[T, C, U any] struct {
+	// Disable are the operations which are not registered.
+	Disable []ResourceOperation
+	// Authorize is called before every operation. Requests it rejects are answered with 403 Forbidden. Can be nil.
+	Authorize ResourceAuthorizer
+	// Roles are the roles of which the user needs at least one for every operation, as with Register.
+	Roles []string
+
+	// List overrides the List operation of the store. Can be nil.
+	List func(ctx context.Context, query ListQuery) (ListResult[T], error)
+	// Get overrides the Get operation of the store. Can be nil.
+	Get func(ctx context.Context, id string) (T, error)
+	// Create overrides the Create operation of the store. Can be nil.
+	Create func(ctx context.Context, req C) (T, error)
+	// Update overrides the Update operation of the store. Can be nil.
+	Update func(ctx context.Context, id string, req U) (T, error)
+	// Delete overrides the Delete operation of the store. Can be nil.
+	Delete func(ctx context.Context, id string) error
+}
+
+// ResourceRoutes is a struct that contains the routes registered by Resource. Routes of disabled operations are nil.
+type ResourceRoutes struct {
+	List   *Route
+	Get    *Route
+	Create *Route
+	Update *Route
+	Delete *Route
+}
+
+// ResourceDeleted is the response of the delete operation of a resource.
+type ResourceDeleted struct {
+	ID string `json:"id"`
+}
+
+// Registrar is an interface that is implemented by the Instance and every SubRouter routes can be registered on.
+type Registrar interface {
+	RegisterManually(path string, handler interface{}, authenticated bool, roles ...string) *Route
+}
+
+type resourceListRequest struct {
+	GetRequest
+	ListQuery
+	Gin  *gin.Context `gin:"true"`
+	User User         `user:"optional"`
+}
+
+type resourceGetRequest struct {
+	GetRequest
+	ID   string       `path:"id"`
+	Gin  *gin.Context `gin:"true"`
+	User User         `user:"optional"`
+}
+
+type resourceCreateRequest[C any] struct {
+	PostRequest
+	Body C            `body:"true"`
+	Gin  *gin.Context `gin:"true"`
+	User User         `user:"optional"`
+}
+
+type resourceUpdateRequest[U any] struct {
+	PutRequest
+	ID   string       `path:"id"`
+	Body U            `body:"true"`
+	Gin  *gin.Context `gin:"true"`
+	User User         `user:"optional"`
+}
+
+type resourceDeleteRequest struct {
+	DeleteRequest
+	ID   string       `path:"id"`
+	Gin  *gin.Context `gin:"true"`
+	User User         `user:"optional"`
+}
+
+// Resource registers the list, get, create, update and delete routes of a resource at the given path against the given store:
+//
+//	GET    <path>      list, with the ListQuery helper
+//	GET    <path>/:id  get
+//	POST   <path>      create
+//	PUT    <path>/:id  update
+//	DELETE <path>/:id  delete
+//
+// The routes are registered like hand-written ones, so they are protected if an authenticator is set, validated and generated,
+// and the generated TypeScript functions are additionally grouped in an object named after the last path segment. Stores
+// report soft-deleted resources with Gone, which get, update and delete answer with 410 Gone.
+// Only the first options are used.
+func Resource[T, C, U any](r Registrar, path string, store ResourceStore[T, C, U], opts ...ResourceOptions[T, C, U]) *ResourceRoutes {
+	var o ResourceOptions[T, C, U]
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	list, get, create, update, remove := store.List, store.Get, store.Create, store.Update, store.Delete
+	if o.List != nil {
+		list = o.List
+	}
+	if o.Get != nil {
+		get = o.Get
+	}
+	if o.Create != nil {
+		create = o.Create
+	}
+	if o.Update != nil {
+		update = o.Update
+	}
+	if o.Delete != nil {
+		remove = o.Delete
+	}
+
+	path = strings.TrimSuffix(path, "/")
+	group := resourceClientGroup(path)
+	authenticated := Current.Authenticator != nil
+	routes := &ResourceRoutes{}
+
+	register := func(op ResourceOperation, path string, handler interface{}) *Route {
+		if slices.Contains(o.Disable, op) {
+			return nil
+		}
+
+		route := r.RegisterManually(path, handler, authenticated, o.Roles...).ClientGroup(group, string(op))
+		route.defaultAccess = true
+		if op == ResourceGet || op == ResourceUpdate || op == ResourceDelete {
+			route.RespondsGone()
+		}
+
+		return route
+	}
+
+	routes.List = register(ResourceList, path, func(req *resourceListRequest) ListResult[T] {
+		o.authorize(req.Gin, req.User, ResourceList, "")
+		return resourceResult(list(req.Gin.Request.Context(), req.ListQuery))
+	})
+
+	routes.Get = register(ResourceGet, path+"/:id", func(req *resourceGetRequest) T {
+		o.authorize(req.Gin, req.User, ResourceGet, req.ID)
+		return resourceResult(get(req.Gin.Request.Context(), req.ID))
+	})
+
+	routes.Create = register(ResourceCreate, path, func(req *resourceCreateRequest[C]) T {
+		o.authorize(req.Gin, req.User, ResourceCreate, "")
+		return resourceResult(create(req.Gin.Request.Context(), req.Body))
+	})
+
+	routes.Update = register(ResourceUpdate, path+"/:id", func(req *resourceUpdateRequest[U]) T {
+		o.authorize(req.Gin, req.User, ResourceUpdate, req.ID)
+		return resourceResult(update(req.Gin.Request.Context(), req.ID, req.Body))
+	})
+
+	routes.Delete = register(ResourceDelete, path+"/:id", func(req *resourceDeleteRequest) ResourceDeleted {
+		o.authorize(req.Gin, req.User, ResourceDelete, req.ID)
+		return resourceResult(ResourceDeleted{ID: req.ID}, remove(req.Gin.Request.Context(), req.ID))
+	})
+
+	return routes
+}
+
+// authorize fails the request with 403 Forbidden if the authorizer of the options rejects the operation.
+func (o *ResourceOptions[T, C, U]) authorize(c *gin.Context, user User, op ResourceOperation, id string) {
+	if o.Authorize != nil && !o.Authorize(c, user, op, id) {
+		panic(failedRequest{
+			status:  http.StatusForbidden,
+			message: "forbidden",
+			code:    ErrorCodeForbidden,
+		})
+	}
+}
+
+// resourceResult returns the given result of a store operation, failing the request with 404 Not Found for ErrResourceNotFound
+// and with 410 Gone for a GoneError.
+func resourceResult[V any](v V, err error) V {
+	if errors.Is(err, ErrResourceNotFound) {
+		panic(failedRequest{
+			status:  http.StatusNotFound,
+			message: "not found",
+			code:    ErrorCodeNotFound,
+		})
+	}
+
+	if gone := asGoneError(err); gone != nil {
+		panic(gone.failedRequest())
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+// resourceClientGroup returns the name of the TypeScript client group of the resource at the given path.
+func resourceClientGroup(path string) string {
+	name := path[strings.LastIndex(path, "/")+1:]
+
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,137 @@
+package octanox
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// responseHeader is a header declared in the response of a route.
+type responseHeader struct {
+	name        string
+	description string
+	t           reflect.Type
+}
+
+// listResponseHeaders are the headers every route responding with a ListResult declares implicitly.
+var listResponseHeaders = []responseHeader{
+	{name: "X-Total-Count", description: "total number of items", t: reflect.TypeOf(0)},
+	{name: "Link", description: "RFC 8288 links to the neighbouring pages", t: reflect.TypeOf("")},
+}
+
+// ResponseHeader declares a header of the response of this route with its description and type, e.g.
+// ResponseHeader("X-Total-Count", "total number of items", reflect.TypeOf(0)). The type has to be a string, bool or numeric
+// type. Declared headers are exposed by CORS and can be read typed with the WithResponse variant of the generated TypeScript
+// function. Routes responding with a ListResult declare X-Total-Count and Link implicitly, routes consuming a quota the
+// X-Quota headers.
+func (r *Route) ResponseHeader(name, description string, t reflect.Type) *Route {
+	if t == nil || !isResponseHeaderKind(t.Kind()) {
+		panic(fmt.Sprintf("octanox: response header %s of %s %s must have a string, bool or numeric type, got %v", name, r.method, r.path, t))
+	}
+
+	name = http.CanonicalHeaderKey(name)
+	for i, header := range r.responseHeaders {
+		if header.name == name {
+			r.responseHeaders[i] = responseHeader{name: name, description: description, t: t}
+			return r
+		}
+	}
+
+	r.responseHeaders = append(r.responseHeaders, responseHeader{name: name, description: description, t: t})
+	return r
+}
+
+// VerifyResponseHeaders logs a warning, in debug mode only, for every custom X- header a route responds with without declaring
+// it with Route.ResponseHeader. Every header is only reported once per route.
+func (i *Instance) VerifyResponseHeaders() *Instance {
+	i.verifyResponseHeaders = i.isDebug
+	return i
+}
+
+// declaredResponseHeaders returns the headers declared in the response of the route, including the implicit ones.
+func (r *Route) declaredResponseHeaders() []responseHeader {
+	implicit := make([]responseHeader, 0, len(listResponseHeaders)+len(quotaResponseHeaders))
+	if r.responseType != nil && isListResultType(r.responseType) {
+		implicit = append(implicit, listResponseHeaders...)
+	}
+	if r.quota != nil {
+		implicit = append(implicit, quotaResponseHeaders...)
+	}
+
+	if len(implicit) == 0 {
+		return r.responseHeaders
+	}
+
+	headers := append([]responseHeader(nil), r.responseHeaders...)
+	for _, header := range implicit {
+		if !containsResponseHeader(r.responseHeaders, header.name) {
+			headers = append(headers, header)
+		}
+	}
+
+	return headers
+}
+
+// undeclaredHeaderWarnings is a set of the routes and headers which undeclared header warnings have been logged for.
+var undeclaredHeaderWarnings sync.Map
+
+// verifyResponseHeaders logs a warning for every custom header of the response which is not declared by the route.
+func (r *Route) verifyResponseHeaders(c *gin.Context) {
+	declared := r.declaredResponseHeaders()
+
+	for name := range c.Writer.Header() {
+		if !strings.HasPrefix(name, "X-") || containsResponseHeader(declared, name) {
+			continue
+		}
+
+		if _, logged := undeclaredHeaderWarnings.LoadOrStore(r.method+" "+r.path+" "+name, true); !logged {
+			log.Println("octanox: " + r.method + " " + r.path + " responds with the undeclared header " + name + ", declare it with ResponseHeader")
+		}
+	}
+}
+
+// exposedResponseHeaders returns the headers exposed by CORS: the headers set by the framework and the headers declared by
+// the given routes.
+func exposedResponseHeaders(routes []*Route) string {
+	exposed := []string{"Authorization", "Content-Type", "Content-Disposition", "X-Total-Count", "Link"}
+	if Current.deploymentID != "" {
+		exposed = append(exposed, deploymentIDHeader)
+	}
+
+	for _, route := range routes {
+		for _, header := range route.declaredResponseHeaders() {
+			if !containsString(exposed, header.name) {
+				exposed = append(exposed, header.name)
+			}
+		}
+	}
+
+	return strings.Join(exposed, ", ")
+}
+
+func containsResponseHeader(headers []responseHeader, name string) bool {
+	for _, header := range headers {
+		if header.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isResponseHeaderKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
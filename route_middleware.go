@@ -0,0 +1,130 @@
+package octanox
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type middlewareContextKey struct{}
+
+// Middleware is a function that wraps the handler of a route with cross-cutting behaviour, e.g. logging, tracing or enriching
+// the request context, like the middlewares of net/http routers. It calls next to continue with the route, or responds
+// itself to stop the request.
+type Middleware func(next http.Handler) http.Handler
+
+// routeMiddleware is a middleware applied to the routes whose path is in its prefix.
+type routeMiddleware struct {
+	// prefix is the path prefix of the routes the middleware is applied to. Empty for all routes.
+	prefix     string
+	middleware Middleware
+}
+
+// Use registers the given middlewares for every route. Middlewares run in the order they are registered in, together with
+// the ones of UseFor, the first being the outermost. The chains of the routes are built when the server starts, so
+// middlewares registered after the routes are applied as well. They run after the global middlewares of Octanox, e.g.
+// the recovery, and before everything of the route, e.g. its authentication.
+func (i *Instance) Use(middleware ...Middleware) *Instance {
+	return i.UseFor("", middleware...)
+}
+
+// UseFor registers the given middlewares for the routes whose path is in the given prefix, e.g. "/admin" for /admin and
+// /admin/users but not /administrators. Otherwise like Use.
+func (i *Instance) UseFor(pattern string, middleware ...Middleware) *Instance {
+	pattern = strings.TrimSuffix(pattern, "/")
+	for _, m := range middleware {
+		if m == nil {
+			panic("octanox: nil middleware registered for the routes in " + pattern + "/")
+		}
+
+		i.middlewares = append(i.middlewares, routeMiddleware{prefix: pattern, middleware: m})
+	}
+
+	return i
+}
+
+// applyMiddlewares builds the middleware chain of every registered route and replaces the chains served with them.
+func (i *Instance) applyMiddlewares() {
+	chains := make(map[*Route]http.Handler)
+	for _, rt := range i.routes {
+		route := rt
+		var chain http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serveBehindMiddlewares(w, r, route)
+		})
+
+		applied := false
+		for n := len(i.middlewares) - 1; n >= 0; n-- {
+			if m := i.middlewares[n]; m.applies(route.path) {
+				chain, applied = m.middleware(chain), true
+			}
+		}
+
+		if applied {
+			chains[route] = chain
+		}
+	}
+
+	i.middlewareChains.Store(&chains)
+}
+
+// applies checks if the middleware is applied to the route with the given path.
+func (m routeMiddleware) applies(path string) bool {
+	return m.prefix == "" || path == m.prefix || strings.HasPrefix(path, m.prefix+"/")
+}
+
+// serve is the handler the route is registered with, which runs the route behind its middlewares.
+func (r *Route) serve(c *gin.Context) {
+	var chain http.Handler
+	if chains := Current.middlewareChains.Load(); chains != nil {
+		chain = (*chains)[r]
+	}
+
+	if chain == nil {
+		r.handler(c)
+		return
+	}
+
+	writer := c.Writer
+	defer func() {
+		c.Writer = writer
+	}()
+
+	chain.ServeHTTP(c.Writer, c.Request.WithContext(context.WithValue(c.Request.Context(), middlewareContextKey{}, c)))
+}
+
+// serveBehindMiddlewares serves the given route with the request and the writer passed on by its innermost middleware.
+func serveBehindMiddlewares(w http.ResponseWriter, r *http.Request, route *Route) {
+	c := r.Context().Value(middlewareContextKey{}).(*gin.Context)
+	c.Request = r
+	if w != http.ResponseWriter(c.Writer) {
+		c.Writer = &middlewareWriter{ResponseWriter: c.Writer, w: w}
+	}
+
+	route.handler(c)
+}
+
+// middlewareWriter is the writer of a route whose middlewares wrapped the writer, e.g. to record the status. The response
+// is written through the writer of the middlewares, which writes to the writer of Gin in the end.
+type middlewareWriter struct {
+	gin.ResponseWriter
+	w http.ResponseWriter
+}
+
+func (mw *middlewareWriter) Header() http.Header {
+	return mw.w.Header()
+}
+
+func (mw *middlewareWriter) WriteHeader(code int) {
+	mw.w.WriteHeader(code)
+}
+
+func (mw *middlewareWriter) Write(data []byte) (int, error) {
+	return mw.w.Write(data)
+}
+
+func (mw *middlewareWriter) WriteString(s string) (int, error) {
+	return io.WriteString(mw.w, s)
+}
@@ -0,0 +1,106 @@
+package octanox
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type middlewareRequest struct {
+	GetRequest
+}
+
+type middlewareResponse struct {
+	OK bool `json:"ok"`
+}
+
+func middlewareHandler(*middlewareRequest) *middlewareResponse {
+	return &middlewareResponse{OK: true}
+}
+
+// traceMiddleware appends the given name to the X-Trace header of the response before calling the next handler.
+func traceMiddleware(name string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Trace", name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestMiddlewaresRunInDeclaredOrder(t *testing.T) {
+	i := newTestInstance(t)
+	i.Use(traceMiddleware("first"))
+	i.UseFor("/admin", traceMiddleware("admin"))
+	i.Use(traceMiddleware("last"))
+	i.Register("/admin/users", middlewareHandler)
+	i.Register("/admin", middlewareHandler)
+	i.Register("/administrators", middlewareHandler)
+
+	tests := []struct {
+		path, trace string
+	}{
+		{"/admin/users", "first,admin,last"},
+		{"/admin", "first,admin,last"},
+		{"/administrators", "first,last"},
+	}
+
+	for _, tt := range tests {
+		rec := serveTest(i, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d: %s", tt.path, rec.Code, rec.Body.String())
+		}
+
+		if trace := strings.Join(rec.Header().Values("X-Trace"), ","); trace != tt.trace {
+			t.Errorf("GET %s: trace %q, want %q", tt.path, trace, tt.trace)
+		}
+	}
+}
+
+func TestMiddlewareRespondingStopsRoute(t *testing.T) {
+	i := newTestInstance(t)
+	i.UseFor("/admin/", func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})
+	})
+	i.Register("/admin/users", middlewareHandler)
+
+	if rec := serveTest(i, httptest.NewRequest(http.MethodGet, "/admin/users", nil)); rec.Code != http.StatusTeapot {
+		t.Errorf("status %d, want %d", rec.Code, http.StatusTeapot)
+	}
+}
+
+// TestMiddlewareChainsRebuiltWhileServing calls Handler again while requests are served. Run it with -race.
+func TestMiddlewareChainsRebuiltWhileServing(t *testing.T) {
+	i := newTestInstance(t)
+	i.Use(traceMiddleware("trace"))
+	i.Register("/users", middlewareHandler)
+	handler := i.Handler()
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				i.Handler()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+				if rec.Code != http.StatusOK || rec.Header().Get("X-Trace") != "trace" {
+					t.Errorf("status %d, trace %q", rec.Code, rec.Header().Get("X-Trace"))
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
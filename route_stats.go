@@ -0,0 +1,286 @@
+package octanox
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// routeStatsSlots is the number of slots the rolling window of the route stats is divided into.
+	routeStatsSlots = 12
+	// latencyBuckets is the number of logarithmic latency buckets, covering 50µs up to roughly two minutes.
+	latencyBuckets = 64
+	// latencyBucketBase is the upper bound of the first latency bucket in seconds.
+	latencyBucketBase = 50e-6
+	// latencyBucketGrowth is the factor each latency bucket is larger than the previous one.
+	latencyBucketGrowth = 1.26
+)
+
+// RouteStatsOptions is a struct that configures the route stats collection.
+type RouteStatsOptions struct {
+	// Window is the duration of the rolling window the stats are summarized over. Defaults to 1 minute.
+	Window time.Duration
+}
+
+// RouteStats is a struct that contains the summary of a single route over the rolling window.
+type RouteStats struct {
+	Method    string  `json:"method"`
+	Path      string  `json:"path"`
+	Requests  int     `json:"requests"`
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+	P50Ms     float64 `json:"p50_ms"`
+	P95Ms     float64 `json:"p95_ms"`
+	P99Ms     float64 `json:"p99_ms"`
+	// BudgetViolations is the number of requests that exceeded the budget of the route.
+	BudgetViolations int `json:"budget_violations"`
+	// MirrorMismatches is the number of mirrored requests the shadow answered differently.
+	MirrorMismatches int `json:"mirror_mismatches"`
+	// Rejected is the number of requests rejected because the route was disabled.
+	Rejected int `json:"rejected"`
+	// Disabled is how and by whom the route is disabled. Can be nil if the route is enabled.
+	Disabled *DisabledRoute `json:"disabled,omitempty"`
+}
+
+// RouteStatsReport is a struct that contains the summary of all routes over the rolling window.
+type RouteStatsReport struct {
+	WindowSeconds float64      `json:"window_seconds"`
+	Routes        []RouteStats `json:"routes"`
+}
+
+// EnableRouteStats enables the collection of per-route stats and serves them at /.nox/route-stats, guarded by the internal options.
+// The generated TypeScript client gains a getRouteStats function. Must be called before any route is registered.
+func (i *Instance) EnableRouteStats(opts RouteStatsOptions) *Instance {
+	if i.routeStats != nil {
+		panic("octanox: route stats already enabled")
+	}
+
+	if opts.Window <= 0 {
+		opts.Window = time.Minute
+	}
+
+	i.routeStats = &routeStatsCollector{
+		slotDuration: opts.Window / routeStatsSlots,
+		routes:       make(map[string]*routeStatsEntry),
+	}
+
+	i.Gin.Use(i.routeStats.middleware())
+	i.internal().GET("/route-stats", func(c *gin.Context) {
+		c.JSON(http.StatusOK, i.routeStats.report())
+	})
+
+	return i
+}
+
+// RouteStats returns the summary of all routes over the rolling window. Returns nil if the route stats are not enabled.
+func (i *Instance) RouteStats() *RouteStatsReport {
+	if i.routeStats == nil {
+		return nil
+	}
+
+	report := i.routeStats.report()
+	return &report
+}
+
+// routeStatsCollector collects the stats of all routes in a rolling window of fixed size slots.
+type routeStatsCollector struct {
+	mu           sync.Mutex
+	slotDuration time.Duration
+	routes       map[string]*routeStatsEntry
+}
+
+// routeStatsEntry is the rolling window of a single route.
+type routeStatsEntry struct {
+	method string
+	path   string
+	slots  [routeStatsSlots]routeStatsSlot
+}
+
+// routeStatsSlot contains the stats of a single slot. The latencies are kept in a logarithmic histogram, which is a streaming
+// estimator with bounded memory. Histograms of different slots can simply be summed to get the quantiles of the whole window.
+type routeStatsSlot struct {
+	epoch     int64
+	requests  int
+	errors    int
+	budget    int
+	mirror    int
+	rejected  int
+	latencies [latencyBuckets]uint32
+}
+
+func (s *routeStatsCollector) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		if path == "" || strings.HasPrefix(path, internalBasePath) {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		defer func() {
+			// the recovery middleware runs before this one, so a panicking handler has not written its status yet
+			if err := recover(); err != nil {
+				s.record(c.Request.Method, path, http.StatusInternalServerError, time.Since(start))
+				panic(err)
+			}
+
+			s.record(c.Request.Method, path, c.Writer.Status(), time.Since(start))
+		}()
+
+		c.Next()
+	}
+}
+
+func (s *routeStatsCollector) record(method, path string, status int, latency time.Duration) {
+	epoch := time.Now().UnixNano() / int64(s.slotDuration)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	slot := s.slot(method, path, epoch)
+	slot.requests++
+	if status >= 500 {
+		slot.errors++
+	}
+	slot.latencies[latencyBucket(latency)]++
+}
+
+// recordBudgetViolation counts a request of the given route that exceeded the budget of the route.
+func (s *routeStatsCollector) recordBudgetViolation(method, path string) {
+	epoch := time.Now().UnixNano() / int64(s.slotDuration)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.slot(method, path, epoch).budget++
+}
+
+// recordMirrorMismatch counts a mirrored request of the given route the shadow answered differently.
+func (s *routeStatsCollector) recordMirrorMismatch(method, path string) {
+	epoch := time.Now().UnixNano() / int64(s.slotDuration)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.slot(method, path, epoch).mirror++
+}
+
+// recordRejected counts a request of the given route rejected because the route was disabled.
+func (s *routeStatsCollector) recordRejected(method, path string) {
+	epoch := time.Now().UnixNano() / int64(s.slotDuration)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.slot(method, path, epoch).rejected++
+}
+
+// slot returns the slot of the given route for the given epoch, resetting it if it still holds an expired epoch. Must be called with the lock held.
+func (s *routeStatsCollector) slot(method, path string, epoch int64) *routeStatsSlot {
+	key := method + " " + path
+
+	entry, ok := s.routes[key]
+	if !ok {
+		entry = &routeStatsEntry{method: method, path: path}
+		s.routes[key] = entry
+	}
+
+	slot := &entry.slots[epoch%routeStatsSlots]
+	if slot.epoch != epoch {
+		*slot = routeStatsSlot{epoch: epoch}
+	}
+
+	return slot
+}
+
+func (s *routeStatsCollector) report() RouteStatsReport {
+	epoch := time.Now().UnixNano() / int64(s.slotDuration)
+	disabled := Current.runtimeSnapshot().config.DisabledRoutes
+	report := RouteStatsReport{
+		WindowSeconds: (s.slotDuration * routeStatsSlots).Seconds(),
+		Routes:        make([]RouteStats, 0, len(s.routes)),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range s.routes {
+		stats := RouteStats{Method: entry.method, Path: entry.path}
+
+		var latencies [latencyBuckets]uint32
+		for _, slot := range entry.slots {
+			if epoch-slot.epoch >= routeStatsSlots {
+				continue
+			}
+
+			stats.Requests += slot.requests
+			stats.Errors += slot.errors
+			stats.BudgetViolations += slot.budget
+			stats.MirrorMismatches += slot.mirror
+			stats.Rejected += slot.rejected
+			for b, n := range slot.latencies {
+				latencies[b] += n
+			}
+		}
+
+		if stats.Requests == 0 {
+			continue
+		}
+
+		if route, ok := disabled[routeKey(entry.method, entry.path)]; ok {
+			stats.Disabled = &route
+		}
+
+		stats.ErrorRate = float64(stats.Errors) / float64(stats.Requests)
+		stats.P50Ms = latencyQuantile(latencies, stats.Requests, 0.5)
+		stats.P95Ms = latencyQuantile(latencies, stats.Requests, 0.95)
+		stats.P99Ms = latencyQuantile(latencies, stats.Requests, 0.99)
+
+		report.Routes = append(report.Routes, stats)
+	}
+
+	sort.Slice(report.Routes, func(a, b int) bool {
+		if report.Routes[a].Path == report.Routes[b].Path {
+			return report.Routes[a].Method < report.Routes[b].Method
+		}
+		return report.Routes[a].Path < report.Routes[b].Path
+	})
+
+	return report
+}
+
+// latencyBucket returns the index of the histogram bucket the given latency falls into.
+func latencyBucket(latency time.Duration) int {
+	seconds := latency.Seconds()
+	if seconds <= latencyBucketBase {
+		return 0
+	}
+
+	b := int(math.Ceil(math.Log(seconds/latencyBucketBase) / math.Log(latencyBucketGrowth)))
+	if b >= latencyBuckets {
+		return latencyBuckets - 1
+	}
+
+	return b
+}
+
+// latencyQuantile estimates the given quantile in milliseconds from the histogram, using the upper bound of the bucket it falls into.
+func latencyQuantile(latencies [latencyBuckets]uint32, total int, q float64) float64 {
+	rank := uint32(math.Ceil(q * float64(total)))
+
+	var seen uint32
+	for b, n := range latencies {
+		seen += n
+		if seen >= rank {
+			return latencyBucketBase * math.Pow(latencyBucketGrowth, float64(b)) * 1000
+		}
+	}
+
+	return latencyBucketBase * math.Pow(latencyBucketGrowth, latencyBuckets-1) * 1000
+}
@@ -6,6 +6,7 @@ import (
 	"reflect"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/proto"
 )
 
 // Router is a struct that represents a router in the Octanox framework. It wraps around a Gin router group with the only two differences
@@ -13,34 +14,162 @@ import (
 type SubRouter struct {
 	url string
 	gin *gin.RouterGroup
+	// params are the path parameters declared by the groups of the router, in the order of the groups.
+	params []PathParameter
+	// clientGroup is the client group the routes of the router are added to, with their path after clientGroupPath as
+	// member name. Can be empty.
+	clientGroup     string
+	clientGroupPath string
 }
 
 func (s *SubRouter) combineURL(path string) string {
 	return s.url + path
 }
 
-// route is a struct containing metadata about a route in the Octanox framework.
-type route struct {
+// Route is a struct containing metadata about a route in the Octanox framework. It is returned by the registration functions
+// and allows configuring the route further.
+type Route struct {
 	method       string
 	path         string
 	requestType  reflect.Type
 	responseType reflect.Type
+	// streamType is the type of the iterator or channel the handler returns, whose items are streamed as JSON array of the
+	// response type. Can be nil if the response is not streamed.
+	streamType reflect.Type
+	// jobResult is the result type of the jobs the handler returns a JobRef of, whose response type is JobAccepted. Can be
+	// nil if the route is not async.
+	jobResult reflect.Type
+	// jobs is the store of the jobs of the route. Can be nil if the route is not async.
+	jobs JobStore
+	// plan is the parsed binding of the request type, shared by the binder, the validator and the generators.
+	plan *bindingPlan
+	// transformRequest is called with the raw request body before it is bound. Can be nil.
+	transformRequest BodyTransformer
+	// transformResponse is called with the serialized response body before it is written. Can be nil.
+	transformResponse BodyTransformer
+	// clientName is the name of the generated TypeScript function declared with Name. Can be empty to derive it from the
+	// method and path.
+	clientName string
+	// description is the description of the route declared with Description. Can be empty.
+	description string
+	// clientOverride overrides the defaults of the TypeScript client code generation. Can be nil.
+	clientOverride *ClientOverride
+	// clientGroup is the name of the object the generated TypeScript function is grouped in, as clientMember. Can be empty.
+	clientGroup  string
+	clientMember string
+	// clientGroupPath is the path of the router the route has been added to its client group by. The member name is derived
+	// from the rest of the path if clientMember is empty.
+	clientGroupPath string
+	// quota is the quota consumed by every request of the route. Can be nil.
+	quota *routeQuota
+	// disallowUnknownFields is a flag that indicates whether JSON request bodies with unknown fields are rejected.
+	disallowUnknownFields bool
+	// partial is a flag that indicates whether clients can select the fields of the response with the fields query parameter.
+	partial bool
+	// variants are the variants of the route serving later versions, ordered by their version.
+	variants []*Route
+	// version is the version the route serves if it is a variant. Empty for routes that are not variants.
+	version string
+	// versioned is the route this route is a variant of. Can be nil if the route is not a variant.
+	versioned *Route
+	// clientVersion is the version the generated client requests the route with. Only set on the routes of clientRoutes.
+	clientVersion string
+	// idempotent is a flag that indicates whether repeated requests of the route have the same effect as a single one.
+	idempotent bool
+	// gone is a flag that indicates whether the route answers with 410 Gone for soft-deleted resources.
+	gone bool
+	// tx decides whether the route runs in a transaction of the transaction middleware.
+	tx txMode
+	// errorType is the type of the bodies of the error responses of the route, overriding the ErrorResponseType of the
+	// instance. Can be nil.
+	errorType reflect.Type
+	// responseHeaders are the headers declared in the response of the route.
+	responseHeaders []responseHeader
+	// list are the list options used to validate an embedded ListQuery.
+	list listOptions
+	// authenticated is a flag that indicates whether the route requires an authenticated user.
+	authenticated bool
+	// defaultAccess is a flag that indicates whether the route has been registered with Register, so it requires an
+	// authenticated user if it has an authenticator, instead of being explicitly public or protected.
+	defaultAccess bool
+	// roles are the roles of which the authenticated user needs one to access the route.
+	roles []string
+	// budget is the expected request size, response size and latency of the route. Can be nil.
+	budget *Budget
+	// constant is the cached response of the route if it is constant. Can be nil.
+	constant *constantResponse
+	// immutable is a flag that indicates whether the route serves an immutable resource.
+	immutable bool
+	// immutableQuery are the query parameters acknowledged as part of the identity of the immutable resource.
+	immutableQuery []string
+	// securityHeaders overrides the security headers of the instance for the route. Can be nil.
+	securityHeaders *SecurityHeadersOptions
+	// persistedQuery is a flag that indicates whether the query of the route can be sent as the hash of a persisted query.
+	persistedQuery bool
+	// mirror shadows the requests of the route to an alternate implementation. Can be nil.
+	mirror *routeMirror
+	// skipCoverage is a flag that indicates whether the route is excluded from the coverage report.
+	skipCoverage bool
+	// skipSmokeTest is a flag that indicates whether the route is excluded from the generated smoke test.
+	skipSmokeTest bool
+	// covered is set to 1 once the route has been requested, read and written atomically.
+	covered uint32
+	// handlerPackage is the import path of the package declaring the handler of the route.
+	handlerPackage string
+	// existenceCheck is a flag that indicates whether the route is also served for HEAD requests and gets an existence check in the client.
+	existenceCheck bool
+	// group is the router group the route is registered in.
+	group *gin.RouterGroup
+	// groupParams are the path parameters declared by the groups the route is registered in.
+	groupParams []PathParameter
+	// relativePath is the path of the route relative to its router group.
+	relativePath string
+	// handler is the Gin handler serving the route.
+	handler gin.HandlerFunc
+	// handlerFunc is the handler function the route has been registered with.
+	handlerFunc reflect.Value
+	// aggregate are the routes served by the route if it is an aggregate route. Empty for all other routes.
+	aggregate []aggregateComponent
+	// gate tracks the requests in flight of the route and rejects them while it is disabled. Can be nil if the route is a
+	// variant or shadow, which is not registered on its own.
+	gate *routeGate
+}
+
+// noResponseType is the response type of the routes whose handler returns nothing.
+var noResponseType = reflect.TypeOf(struct{}{})
+
+// hasResponseBody checks if the route responds with a body. Routes whose handler returns nothing or an empty struct respond
+// with 204 No Content.
+func (r *Route) hasResponseBody() bool {
+	return r.responseType != nil && (r.responseType.Kind() != reflect.Struct || r.responseType.NumField() > 0)
 }
 
 // Router creates a new router with the given URL prefix.
 func (r *SubRouter) Router(url string) *SubRouter {
-	return &SubRouter{
-		url: url,
-		gin: r.gin.Group(url),
-	}
+	return r.Group(url)
 }
 
 // RegisterManually registers a new route handler. The function automatically detects the method, request and response type. If any of these detection fails, it will panic.
-func (r *SubRouter) RegisterManually(path string, handler interface{}, authenticated bool, roles ...string) {
+func (r *SubRouter) RegisterManually(path string, handler interface{}, authenticated bool, roles ...string) *Route {
+	rt := r.newRoute(path, handler, authenticated, roles)
+	rt.gate = newRouteGate()
+	Current.routeGates[routeKey(rt.method, rt.path)] = rt.gate
+	Current.routes = append(Current.routes, rt)
+	r.gin.Handle(rt.method, path, rt.serve)
+
+	if r.clientGroup != "" {
+		rt.clientGroup, rt.clientGroupPath = r.clientGroup, r.clientGroupPath
+	}
+
+	return rt
+}
+
+// newRoute creates the route of the given handler without registering it.
+func (r *SubRouter) newRoute(path string, handler interface{}, authenticated bool, roles []string) *Route {
 	handlerType := reflect.TypeOf(handler)
 
-	if handlerType.Kind() != reflect.Func || handlerType.NumIn() != 1 || handlerType.NumOut() < 1 {
-		panic("Handler function must have one input parameter and at least one return value, in: " + fmt.Sprintf("%d", handlerType.NumIn()) + ", out: " + fmt.Sprintf("%d", handlerType.NumOut()))
+	if handlerType.Kind() != reflect.Func || handlerType.NumIn() != 1 {
+		panic("Handler function must have one input parameter, in: " + fmt.Sprintf("%d", handlerType.NumIn()))
 	}
 
 	reqType := handlerType.In(0)
@@ -50,39 +179,142 @@ func (r *SubRouter) RegisterManually(path string, handler interface{}, authentic
 		panic("Handler function input parameter must be a pointer")
 	}
 
-	resType := handlerType.Out(0)
+	// handlers returning nothing respond without a body
+	resType := noResponseType
+	if handlerType.NumOut() > 0 {
+		resType = handlerType.Out(0)
+	}
+
+	// streamed results are generated and validated as the JSON array they are encoded as
+	var streamType reflect.Type
+	if itemType, ok := streamItemType(resType); ok {
+		streamType, resType = resType, reflect.SliceOf(itemType)
+	}
+
+	// async routes are generated and validated as the JobAccepted they respond with
+	var jobResult reflect.Type
+	if resultType, ok := jobResultType(resType); ok {
+		jobResult, resType = resultType, jobAcceptedType
+	}
 
 	method := detectHTTPMethod(reqType)
 
-	if Current.isDryRun {
-		Current.routes = append(Current.routes, route{
-			method:       method,
-			path:         r.combineURL(path),
-			requestType:  reqType,
-			responseType: resType,
-		})
+	plan, err := planBinding(reqType)
+	if err == nil {
+		plan, err = plan.inheriting(r.params)
+	}
+	if err != nil {
+		panic("octanox: cannot bind request of " + method + " " + r.combineURL(path) + ": " + err.Error())
+	}
+
+	rt := &Route{
+		method:        method,
+		path:          r.combineURL(path),
+		requestType:   reqType,
+		plan:          plan,
+		responseType:  resType,
+		streamType:    streamType,
+		jobResult:     jobResult,
+		authenticated: authenticated,
+		roles:         roles,
+	}
+
+	rt.group = r.gin
+	rt.groupParams = r.params
+	rt.relativePath = path
+	rt.handlerPackage = handlerPackage(handler)
+	rt.handlerFunc = reflect.ValueOf(handler)
+	rt.handler = func(c *gin.Context) {
+		// only registered routes have a gate, their variants and shadows are served through them
+		if rt.gate != nil {
+			done, ok := rt.gate.admit(c, rt)
+			if !ok {
+				return
+			}
+			defer done()
+		}
+
+		rt.markCovered()
+
+		if rt.constant != nil {
+			serveConstant(c, rt)
+			return
+		}
+
+		// the persisted query is resolved first, so everything reading the query sees its parameters instead of the hash
+		if rt.persistedQuery && !resolvePersistedQuery(c) {
+			return
+		}
+
+		if len(rt.variants) > 0 {
+			variant := rt.selectVariant(c)
+			if variant == nil {
+				return
+			}
+
+			if variant != rt {
+				variant.handler(c)
+				return
+			}
+		}
+
+		if Current.verifyResponseHeaders {
+			defer rt.verifyResponseHeaders(c)
+		}
+
+		serve := func() {
+			wrapHandler(c, rt, reflect.ValueOf(handler), Current.requiresAuthentication(rt), roles)
+		}
+
+		if rt.transactional() {
+			inner := serve
+			serve = func() {
+				runInTransaction(c, rt, inner)
+			}
+		}
+
+		if rt.mirror != nil {
+			inner := serve
+			serve = func() {
+				mirrorRequest(c, rt, inner)
+			}
+		}
+
+		if rt.usesDictionary() {
+			inner := serve
+			serve = func() {
+				compressWithDictionary(c, rt, inner)
+			}
+		}
+
+		if rt.budget == nil {
+			serve()
+			return
+		}
+
+		enforceBudget(c, rt, serve)
 	}
 
-	r.gin.Handle(method, path, func(c *gin.Context) {
-		wrapHandler(c, reqType, reflect.ValueOf(handler), authenticated, roles)
-	})
+	return rt
 }
 
 // Register registers a new route handler. The function automatically detects the method, request and response type. If any of these detection fails, it will panic.
-// If an authenticator is set, the route will be protected.
+// If an authenticator is set, or a route authenticator matches the route, the route will be protected.
 // Should return the response. Can return a Context to set the serializer context.
-func (r *SubRouter) Register(path string, handler interface{}, roles ...string) {
-	r.RegisterManually(path, handler, Current.Authenticator != nil, roles...)
+func (r *SubRouter) Register(path string, handler interface{}, roles ...string) *Route {
+	rt := r.RegisterManually(path, handler, Current.Authenticator != nil, roles...)
+	rt.defaultAccess = true
+	return rt
 }
 
 // RegisterPublic registers a new public route handler. The function automatically detects the method, request and response type. If any of these detection fails, it will panic.
-func (r *SubRouter) RegisterPublic(path string, handler interface{}, roles ...string) {
-	r.RegisterManually(path, handler, false, roles...)
+func (r *SubRouter) RegisterPublic(path string, handler interface{}, roles ...string) *Route {
+	return r.RegisterManually(path, handler, false, roles...)
 }
 
 // RegisterProtected registers a new protected route handler. The function automatically detects the method, request and response type. If any of these detection fails, it will panic.
-func (r *SubRouter) RegisterProtected(path string, handler interface{}, roles ...string) {
-	r.RegisterManually(path, handler, true, roles...)
+func (r *SubRouter) RegisterProtected(path string, handler interface{}, roles ...string) *Route {
+	return r.RegisterManually(path, handler, true, roles...)
 }
 
 // detectHTTPMethod determines the HTTP method from the embedded struct in the request type.
@@ -116,17 +348,22 @@ func detectHTTPMethod(reqType reflect.Type) string {
 }
 
 // wrapHandler wraps the gin context and the handler function to call the handler function with the correct parameters and handle the response.
-func wrapHandler(c *gin.Context, reqType reflect.Type, handler reflect.Value, authenticated bool, roles []string) {
+func wrapHandler(c *gin.Context, rt *Route, handler reflect.Value, authenticated bool, roles []string) {
 	var user User
-	if Current.Authenticator != nil {
-		usr, err := Current.Authenticator.Authenticate(c)
+	if Current.rejectsWithoutAuthenticator(rt, authenticated) {
+		abortWithError(c, failedRequest{status: 401, message: "unauthorized", code: ErrorCodeUnauthorized})
+		return
+	}
+
+	if authenticator := Current.authenticatorFor(rt); authenticator != nil {
+		usr, err := authenticator.Authenticate(c)
 		if err != nil {
 			panic(err)
 		}
 
 		if authenticated {
 			if usr == nil {
-				c.JSON(401, gin.H{"error": "unauthorized"})
+				abortWithError(c, failedRequest{status: 401, message: "unauthorized", code: ErrorCodeUnauthorized})
 				return
 			}
 		}
@@ -141,14 +378,32 @@ func wrapHandler(c *gin.Context, reqType reflect.Type, handler reflect.Value, au
 					}
 				}
 
-				c.JSON(403, gin.H{"error": "forbidden"})
+				abortWithError(c, failedRequest{status: 403, message: "forbidden", code: ErrorCodeForbidden})
 				return
 			}
 		}
 	}
 
-	req := populateRequest(c, reqType, user)
+	if rt.quota != nil {
+		Current.consumeQuota(c, rt.quota, user)
+	}
+
+	if rt.transformRequest != nil {
+		transformRequestBody(c, rt.transformRequest)
+	}
+
+	var fields string
+	if rt.partial {
+		fields = bindFields(c, rt)
+	}
+
+	req := populateRequest(c, rt, rt.plan, user)
 	rv := handler.Call([]reflect.Value{reflect.ValueOf(req)})
+	if !rt.hasResponseBody() {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
 	res := rv[0].Interface()
 
 	var sc Context
@@ -165,5 +420,49 @@ func wrapHandler(c *gin.Context, reqType reflect.Type, handler reflect.Value, au
 		panic(res)
 	}
 
-	c.JSON(200, Current.Serialize(res, sc))
+	if ref, ok := res.(jobRef); ok {
+		respondJobAccepted(c, rt, ref)
+		return
+	}
+
+	if download, ok := res.(*Download); ok {
+		download.write(c)
+		return
+	}
+
+	if list, ok := res.(listResult); ok {
+		setListHeaders(c, list)
+	}
+
+	if rt.immutable {
+		c.Header("Cache-Control", immutableCacheControl)
+	}
+
+	if rt.streamType != nil {
+		// transformers and MessagePack need the whole response, so the items are collected instead
+		if rt.transformResponse == nil && !acceptsMsgPack(c) {
+			writeStream(c, rv[0], sc, fields, user)
+			return
+		}
+
+		res = collectStream(c.Request.Context(), rv[0], rt.responseType.Elem())
+	}
+
+	out := Current.normalizeCollections(Current.Serialize(res, sc))
+	if fields != "" {
+		out = projectFields(out, fields)
+	}
+	out = Current.applyVisibility(out, user)
+
+	if rt.transformResponse != nil {
+		respondTransformed(c, 200, out, rt.transformResponse)
+		return
+	}
+
+	if msg, ok := out.(proto.Message); ok {
+		Current.respondProto(c, 200, msg)
+		return
+	}
+
+	respond(c, 200, out)
 }
@@ -0,0 +1,507 @@
+package octanox
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+)
+
+// LogLevel is the level of the request logging.
+type LogLevel string
+
+const (
+	// LogLevelInfo logs every request. This is the default.
+	LogLevelInfo LogLevel = "info"
+	// LogLevelWarn only logs requests which failed with a 4xx or 5xx status.
+	LogLevelWarn LogLevel = "warn"
+	// LogLevelError only logs requests which failed with a 5xx status.
+	LogLevelError LogLevel = "error"
+	// LogLevelOff does not log any request.
+	LogLevelOff LogLevel = "off"
+)
+
+// RateLimit is a struct that configures the rate limit applied per client IP.
+type RateLimit struct {
+	// RequestsPerSecond is the number of requests a client may send per second on average. Zero disables the rate limit.
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	// Burst is the number of requests a client may send at once. Defaults to RequestsPerSecond rounded up.
+	Burst int `json:"burst"`
+}
+
+// RuntimeConfig is a struct that contains the settings which can be changed while the Octanox runtime is serving, without
+// restarting it. Settings like the listener address and the routes are fixed at startup and cannot be changed with it.
+type RuntimeConfig struct {
+	// RateLimit is the rate limit applied per client IP to all routes but the internal endpoints.
+	RateLimit RateLimit `json:"rate_limit"`
+	// Maintenance is a flag that makes all routes but the internal endpoints respond with 503 Service Unavailable.
+	Maintenance bool `json:"maintenance"`
+	// MaintenanceMessage is the error message of the responses in maintenance mode.
+	MaintenanceMessage string `json:"maintenance_message,omitempty"`
+	// CORSAllowedOrigins are the origins allowed by CORS. "*" reflects every origin. Defaults to NOX__CORS_ALLOWED_ORIGINS.
+	CORSAllowedOrigins []string `json:"cors_allowed_origins"`
+	// LogLevel is the level of the request logging. Defaults to LogLevelInfo.
+	LogLevel LogLevel `json:"log_level"`
+	// DisabledRoutes are the routes switched off by their method and path, e.g. "GET /users/:id". Use Instance.DisableRoute
+	// and Instance.EnableRoute to change them.
+	DisabledRoutes map[string]DisabledRoute `json:"disabled_routes,omitempty"`
+}
+
+// RuntimeConfigChange is a struct that describes an applied change of the runtime config, passed to the audit hooks.
+type RuntimeConfigChange struct {
+	// Actor is who applied the change through the internal endpoint: the ID of the user, "token" if the internal token has been
+	// sent or "unauthenticated" if the internal endpoints are not guarded. Empty if the change has been applied from code.
+	Actor string
+	// At is the time the change was applied.
+	At       time.Time
+	Previous RuntimeConfig
+	Applied  RuntimeConfig
+}
+
+// staticSettings are the settings that are fixed at startup. Changing them through the internal endpoint is rejected.
+var staticSettings = map[string]string{
+	"port":       "the listener address",
+	"addr":       "the listener address",
+	"listeners":  "the listeners",
+	"routes":     "the routes",
+	"client_dir": "the client generation",
+	"internal":   "the internal options",
+}
+
+// runtimeSnapshot is an immutable snapshot of the runtime config, together with everything the middlewares derive from it.
+// A request loads the snapshot once, so it never sees half of an applied change.
+type runtimeSnapshot struct {
+	config         RuntimeConfig
+	reflectOrigin  bool
+	allowOrigin    []string
+	allowedOrigins map[string]bool
+	limiter        *rateLimiter
+}
+
+// runtimeConfigState is the currently applied runtime config and its audit hooks.
+type runtimeConfigState struct {
+	// mu serializes the changes, so the previous config passed to the audit hooks is exact.
+	mu       sync.Mutex
+	snapshot atomic.Pointer[runtimeSnapshot]
+	audits   []func(RuntimeConfigChange)
+}
+
+// defaultRuntimeConfig returns the runtime config the Octanox runtime starts with.
+func defaultRuntimeConfig() RuntimeConfig {
+	config := RuntimeConfig{LogLevel: LogLevelInfo}
+
+	// origins of the environment are only normalized and skipped if invalid, so deployments configured before the origins
+	// have been validated still start
+	for _, origin := range strings.Split(os.Getenv("NOX__CORS_ALLOWED_ORIGINS"), ",") {
+		origin = strings.TrimSuffix(strings.TrimSpace(origin), "/")
+		if origin == "" {
+			continue
+		}
+
+		if !validCORSOrigin(origin) {
+			log.Println("octanox: ignoring invalid CORS origin " + strconv.Quote(origin) + " of NOX__CORS_ALLOWED_ORIGINS, must be \"*\" or of the form scheme://host[:port]")
+			continue
+		}
+
+		config.CORSAllowedOrigins = append(config.CORSAllowedOrigins, origin)
+	}
+
+	return config
+}
+
+// RuntimeConfig returns the currently applied runtime config.
+func (i *Instance) RuntimeConfig() RuntimeConfig {
+	config := i.runtimeSnapshot().config
+	config.CORSAllowedOrigins = append([]string(nil), config.CORSAllowedOrigins...)
+	config.DisabledRoutes = copyDisabledRoutes(config.DisabledRoutes)
+
+	return config
+}
+
+// ApplyRuntimeConfig validates the given runtime config and atomically replaces the applied one with it. Requests which are
+// already being served keep the config they started with. Returns an error and keeps the applied config if it is invalid.
+func (i *Instance) ApplyRuntimeConfig(config RuntimeConfig) error {
+	return i.applyRuntimeConfig(config, "")
+}
+
+// AuditRuntimeConfig registers a hook function to be called after a change of the runtime config has been applied.
+func (i *Instance) AuditRuntimeConfig(f func(RuntimeConfigChange)) *Instance {
+	i.runtimeConfig.mu.Lock()
+	defer i.runtimeConfig.mu.Unlock()
+
+	i.runtimeConfig.audits = append(i.runtimeConfig.audits, f)
+	return i
+}
+
+// EnableRuntimeConfig serves the runtime config at /.nox/runtime-config, guarded by the internal options. GET returns the applied
+// config. POST merges the given JSON form of RuntimeConfig into the applied config and applies the result. The disabled routes
+// are served at /.nox/disabled-routes.
+func (i *Instance) EnableRuntimeConfig() *Instance {
+	i.mountDisabledRoutes()
+
+	i.internal().GET("/runtime-config", func(c *gin.Context) {
+		c.JSON(http.StatusOK, i.RuntimeConfig())
+	})
+
+	i.internal().POST("/runtime-config", func(c *gin.Context) {
+		body, err := readBody(c)
+		if err != nil {
+			abortWithError(c, failedRequest{status: http.StatusBadRequest, message: "Invalid body: cannot be read", code: ErrorCodeInvalidBody})
+			return
+		}
+
+		// the body is merged into the config applied while holding the lock, so no concurrent change is overwritten
+		var mergeErr error
+		err = i.updateRuntimeConfig(internalActor(c), func(config *RuntimeConfig) error {
+			*config, mergeErr = mergeRuntimeConfig(*config, body)
+			return mergeErr
+		})
+		if mergeErr != nil {
+			abortWithError(c, failedRequest{status: http.StatusBadRequest, message: mergeErr.Error(), code: ErrorCodeInvalidConfig})
+			return
+		}
+		if err != nil {
+			abortWithError(c, failedRequest{status: http.StatusUnprocessableEntity, message: err.Error(), code: ErrorCodeInvalidConfig})
+			return
+		}
+
+		c.JSON(http.StatusOK, i.RuntimeConfig())
+	})
+
+	return i
+}
+
+// mergeRuntimeConfig decodes the given JSON form of RuntimeConfig over the given config. Settings that cannot be changed at
+// runtime and unknown settings are rejected.
+func mergeRuntimeConfig(config RuntimeConfig, body []byte) (RuntimeConfig, error) {
+	var settings map[string]json.RawMessage
+	if err := json.Unmarshal(body, &settings); err != nil {
+		return config, fmt.Errorf("invalid runtime config: %w", err)
+	}
+
+	for name := range settings {
+		if what, ok := staticSettings[name]; ok {
+			return config, fmt.Errorf("%s cannot be changed at runtime, the runtime has to be restarted to change %q", what, name)
+		}
+	}
+
+	// the decoder reuses the backing arrays of slices and maps, which must not be shared with the applied config
+	config.CORSAllowedOrigins = append([]string(nil), config.CORSAllowedOrigins...)
+	config.DisabledRoutes = copyDisabledRoutes(config.DisabledRoutes)
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return config, fmt.Errorf("invalid runtime config: %w", err)
+	}
+
+	return config, nil
+}
+
+func (i *Instance) applyRuntimeConfig(config RuntimeConfig, actor string) error {
+	i.runtimeConfig.mu.Lock()
+	defer i.runtimeConfig.mu.Unlock()
+
+	return i.swapRuntimeConfig(config, actor)
+}
+
+// updateRuntimeConfig applies the changes the given function makes to a copy of the applied runtime config. The copy, the
+// changes and the swap happen under the lock, so concurrent updates do not overwrite each other. Returns the error of the
+// function and keeps the applied config if it fails.
+func (i *Instance) updateRuntimeConfig(actor string, update func(config *RuntimeConfig) error) error {
+	i.runtimeConfig.mu.Lock()
+	defer i.runtimeConfig.mu.Unlock()
+
+	config := i.RuntimeConfig()
+	if err := update(&config); err != nil {
+		return err
+	}
+
+	return i.swapRuntimeConfig(config, actor)
+}
+
+// swapRuntimeConfig validates the given runtime config and replaces the applied one with it. Must be called with the lock
+// of the runtime config held.
+func (i *Instance) swapRuntimeConfig(config RuntimeConfig, actor string) error {
+	snapshot, err := newRuntimeSnapshot(config, i.runtimeSnapshot())
+	if err != nil {
+		return err
+	}
+
+	for key := range snapshot.config.DisabledRoutes {
+		if _, ok := i.routeGates[key]; !ok {
+			return fmt.Errorf("cannot disable route %s, which is not registered", key)
+		}
+	}
+
+	previous := i.runtimeConfig.snapshot.Swap(snapshot)
+
+	change := RuntimeConfigChange{
+		Actor:   actor,
+		At:      time.Now(),
+		Applied: snapshot.config,
+	}
+	if previous != nil {
+		change.Previous = previous.config
+	}
+
+	i.cancelDisabledRequests(change.Previous.DisabledRoutes, snapshot.config.DisabledRoutes)
+
+	for _, f := range i.runtimeConfig.audits {
+		f(change)
+	}
+
+	return nil
+}
+
+// runtimeSnapshot returns the snapshot of the applied runtime config.
+func (i *Instance) runtimeSnapshot() *runtimeSnapshot {
+	return i.runtimeConfig.snapshot.Load()
+}
+
+// newRuntimeSnapshot validates the given runtime config and derives the snapshot of it. The rate limiter of the previous
+// snapshot is kept if the rate limit did not change, so the clients keep their buckets. The previous snapshot can be nil.
+func newRuntimeSnapshot(config RuntimeConfig, previous *runtimeSnapshot) (*runtimeSnapshot, error) {
+	if config.LogLevel == "" {
+		config.LogLevel = LogLevelInfo
+	}
+
+	switch config.LogLevel {
+	case LogLevelInfo, LogLevelWarn, LogLevelError, LogLevelOff:
+	default:
+		return nil, fmt.Errorf("invalid log level %q", config.LogLevel)
+	}
+
+	limit := config.RateLimit
+	if limit.RequestsPerSecond < 0 || math.IsNaN(limit.RequestsPerSecond) || math.IsInf(limit.RequestsPerSecond, 0) {
+		return nil, errors.New("rate limit must be a finite number of requests per second not below zero")
+	}
+	if limit.Burst < 0 {
+		return nil, errors.New("rate limit burst must not be below zero")
+	}
+	if limit.RequestsPerSecond > 0 && limit.Burst == 0 {
+		config.RateLimit.Burst = int(math.Ceil(limit.RequestsPerSecond))
+	}
+
+	if err := validateDisabledRoutes(config.DisabledRoutes); err != nil {
+		return nil, err
+	}
+	config.DisabledRoutes = copyDisabledRoutes(config.DisabledRoutes)
+
+	snapshot := &runtimeSnapshot{
+		allowedOrigins: make(map[string]bool, len(config.CORSAllowedOrigins)),
+	}
+
+	config.CORSAllowedOrigins = append([]string(nil), config.CORSAllowedOrigins...)
+	for _, origin := range config.CORSAllowedOrigins {
+		if origin == "*" {
+			snapshot.reflectOrigin = true
+			continue
+		}
+
+		if !validCORSOrigin(origin) {
+			return nil, fmt.Errorf("invalid CORS origin %q, must be \"*\" or of the form scheme://host[:port]", origin)
+		}
+		snapshot.allowedOrigins[origin] = true
+	}
+
+	if !snapshot.reflectOrigin && len(config.CORSAllowedOrigins) == 1 {
+		snapshot.allowOrigin = []string{config.CORSAllowedOrigins[0]}
+	}
+	if len(config.CORSAllowedOrigins) == 0 {
+		snapshot.allowOrigin = []string{""}
+	}
+
+	if config.RateLimit.RequestsPerSecond > 0 {
+		if previous != nil && previous.limiter != nil && previous.config.RateLimit == config.RateLimit {
+			snapshot.limiter = previous.limiter
+		} else {
+			snapshot.limiter = newRateLimiter(config.RateLimit)
+		}
+	}
+
+	snapshot.config = config
+	return snapshot, nil
+}
+
+// validCORSOrigin checks if the given origin is "*" or of the form scheme://host[:port].
+func validCORSOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	return err == nil && u.Scheme != "" && u.Host != "" && u.Path == "" && u.RawQuery == "" && u.Fragment == ""
+}
+
+// runtimeSnapshotKey is the key of the Gin context the snapshot of the runtime config a request is served with is stored under.
+const runtimeSnapshotKey = "nox.runtimeSnapshot"
+
+// requestRuntimeSnapshot returns the snapshot of the runtime config the given request is served with. The first middleware
+// asking loads the applied snapshot, so all middlewares of the request see the same config even if it is changed meanwhile.
+func requestRuntimeSnapshot(c *gin.Context) *runtimeSnapshot {
+	if snapshot, ok := c.Get(runtimeSnapshotKey); ok {
+		return snapshot.(*runtimeSnapshot)
+	}
+
+	snapshot := Current.runtimeSnapshot()
+	c.Set(runtimeSnapshotKey, snapshot)
+	return snapshot
+}
+
+// allowOriginFor returns the value of the Access-Control-Allow-Origin header for a request from the given origin.
+func (s *runtimeSnapshot) allowOriginFor(origin string) []string {
+	if s.allowOrigin != nil {
+		return s.allowOrigin
+	}
+
+	if s.reflectOrigin || s.allowedOrigins[origin] {
+		return []string{origin}
+	}
+
+	return []string{""}
+}
+
+// logs checks if a request which has been answered with the given status is logged at the log level of the snapshot.
+func (s *runtimeSnapshot) logs(status int) bool {
+	switch s.config.LogLevel {
+	case LogLevelWarn:
+		return status >= 400
+	case LogLevelError:
+		return status >= 500
+	case LogLevelOff:
+		return false
+	}
+
+	return true
+}
+
+// maintenance makes all routes but the internal endpoints respond with 503 Service Unavailable in maintenance mode.
+func maintenance() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		snapshot := requestRuntimeSnapshot(c)
+		if !snapshot.config.Maintenance || strings.HasPrefix(c.Request.URL.Path, internalBasePath) {
+			c.Next()
+			return
+		}
+
+		message := snapshot.config.MaintenanceMessage
+		if message == "" {
+			message = "Service Unavailable: Maintenance"
+		}
+
+		abortWithError(c, failedRequest{status: http.StatusServiceUnavailable, message: message, code: ErrorCodeMaintenance, retryable: true})
+	}
+}
+
+// rateLimit rejects requests of clients exceeding the rate limit with 429 Too Many Requests.
+func rateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limiter := requestRuntimeSnapshot(c).limiter
+		if limiter == nil || strings.HasPrefix(c.Request.URL.Path, internalBasePath) {
+			c.Next()
+			return
+		}
+
+		if wait, ok := limiter.allow(c.ClientIP(), time.Now()); !ok {
+			abortWithError(c, failedRequest{status: http.StatusTooManyRequests, message: "Too Many Requests", code: ErrorCodeRateLimited, retryable: true, retryAfter: wait})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimiterMaxClients is the number of clients above which the rate limiter forgets clients whose buckets are full again,
+// and then the longest idle clients until a quarter of the buckets is free.
+const rateLimiterMaxClients = 10000
+
+// rateLimiter is a token bucket rate limiter per client. Applying a new rate limit starts with fresh buckets, other changes
+// of the runtime config keep them.
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	return &rateLimiter{
+		rate:    limit.RequestsPerSecond,
+		burst:   float64(limit.Burst),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes a token of the bucket of the given client. Returns how long the client has to wait if there is none.
+func (l *rateLimiter) allow(client string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[client]
+	if !ok {
+		if len(l.buckets) >= rateLimiterMaxClients {
+			l.forgetFull(now)
+		}
+		if len(l.buckets) >= rateLimiterMaxClients {
+			l.forgetIdle(rateLimiterMaxClients * 3 / 4)
+		}
+
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[client] = bucket
+	}
+
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second)), false
+	}
+
+	bucket.tokens--
+	return 0, true
+}
+
+// forgetFull removes the buckets which are full again, as those clients are indistinguishable from new ones.
+func (l *rateLimiter) forgetFull(now time.Time) {
+	for client, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// forgetIdle removes the buckets of the longest idle clients until the given number of buckets is left, so clients that keep
+// their buckets from filling up cannot grow the limiter without bound.
+func (l *rateLimiter) forgetIdle(keep int) {
+	clients := make([]string, 0, len(l.buckets))
+	for client := range l.buckets {
+		clients = append(clients, client)
+	}
+
+	sort.Slice(clients, func(a, b int) bool {
+		return l.buckets[clients[a]].last.Before(l.buckets[clients[b]].last)
+	})
+
+	for _, client := range clients[:len(clients)-keep] {
+		delete(l.buckets, client)
+	}
+}
@@ -0,0 +1,243 @@
+package octanox
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestDefaultRuntimeConfigSkipsInvalidOrigins(t *testing.T) {
+	t.Setenv("NOX__CORS_ALLOWED_ORIGINS", " https://app.example.com/ ,not an origin,https://example.com/path,http://localhost:3000")
+
+	config := defaultRuntimeConfig()
+	want := []string{"https://app.example.com", "http://localhost:3000"}
+	if len(config.CORSAllowedOrigins) != len(want) {
+		t.Fatalf("origins = %v, want %v", config.CORSAllowedOrigins, want)
+	}
+	for idx, origin := range want {
+		if config.CORSAllowedOrigins[idx] != origin {
+			t.Fatalf("origins = %v, want %v", config.CORSAllowedOrigins, want)
+		}
+	}
+}
+
+func TestApplyRuntimeConfigRejectsInvalidOrigins(t *testing.T) {
+	i := newTestInstance(t)
+
+	if err := i.ApplyRuntimeConfig(RuntimeConfig{CORSAllowedOrigins: []string{"https://example.com/path"}}); err == nil {
+		t.Fatal("expected an error for an origin with a path")
+	}
+}
+
+// TestRuntimeConfigSwapHammer swaps the runtime config while requests are served and checks that every request has been
+// served with one config only. Run it with -race.
+func TestRuntimeConfigSwapHammer(t *testing.T) {
+	i := newTestInstance(t)
+
+	configs := []RuntimeConfig{
+		{Maintenance: true, MaintenanceMessage: "config a", CORSAllowedOrigins: []string{"https://a.example.com"}},
+		{Maintenance: true, MaintenanceMessage: "config b", CORSAllowedOrigins: []string{"https://b.example.com"}},
+	}
+	origins := map[string]string{"config a": "https://a.example.com", "config b": "https://b.example.com"}
+	if err := i.ApplyRuntimeConfig(configs[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := i.Handler()
+	stop := make(chan struct{})
+	var swaps sync.WaitGroup
+	swaps.Add(1)
+	go func() {
+		defer swaps.Done()
+		for n := 0; ; n++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if err := i.ApplyRuntimeConfig(configs[n%2]); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	var requests sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		requests.Add(1)
+		go func() {
+			defer requests.Done()
+			for n := 0; n < 200; n++ {
+				req := httptest.NewRequest(http.MethodGet, "/hammer", nil)
+				req.Header.Set("Origin", "https://a.example.com")
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				if rec.Code != http.StatusServiceUnavailable {
+					t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+					return
+				}
+
+				allowed := rec.Header().Get("Access-Control-Allow-Origin")
+				switch body := rec.Body.String(); {
+				case strings.Contains(body, "config a"):
+					if allowed != origins["config a"] {
+						t.Errorf("config a served with Access-Control-Allow-Origin %q", allowed)
+						return
+					}
+				case strings.Contains(body, "config b"):
+					if allowed == origins["config a"] {
+						t.Errorf("config b served with Access-Control-Allow-Origin %q", allowed)
+						return
+					}
+				default:
+					t.Errorf("unexpected body %q", body)
+					return
+				}
+			}
+		}()
+	}
+
+	requests.Wait()
+	close(stop)
+	swaps.Wait()
+}
+
+func TestRateLimiterKeptWhileRateLimitUnchanged(t *testing.T) {
+	i := newTestInstance(t)
+	limited := RuntimeConfig{LogLevel: LogLevelOff, RateLimit: RateLimit{RequestsPerSecond: 0.001, Burst: 1}}
+	if err := i.ApplyRuntimeConfig(limited); err != nil {
+		t.Fatal(err)
+	}
+	i.Register("/limited", disableHandler)
+
+	get := func() int {
+		return serveTest(i, httptest.NewRequest(http.MethodGet, "/limited", nil)).Code
+	}
+	if status := get(); status != http.StatusOK {
+		t.Fatalf("first request: status %d, want %d", status, http.StatusOK)
+	}
+
+	// the burst defaults to the rate rounded up, so an explicit burst of 1 is the same rate limit
+	limited.MaintenanceMessage = "back soon"
+	limited.RateLimit.Burst = 0
+	if err := i.ApplyRuntimeConfig(limited); err != nil {
+		t.Fatal(err)
+	}
+	if status := get(); status != http.StatusTooManyRequests {
+		t.Errorf("request after an unrelated change: status %d, want %d", status, http.StatusTooManyRequests)
+	}
+
+	limited.RateLimit.Burst = 2
+	if err := i.ApplyRuntimeConfig(limited); err != nil {
+		t.Fatal(err)
+	}
+	if status := get(); status != http.StatusOK {
+		t.Errorf("request after changing the rate limit: status %d, want %d", status, http.StatusOK)
+	}
+}
+
+func TestRateLimiterForgetsIdleClients(t *testing.T) {
+	limiter := newRateLimiter(RateLimit{RequestsPerSecond: 0.001, Burst: 1})
+	start := time.Now()
+
+	for n := 0; n <= rateLimiterMaxClients; n++ {
+		limiter.allow("client"+strconv.Itoa(n), start.Add(time.Duration(n)*time.Millisecond))
+	}
+
+	if len(limiter.buckets) > rateLimiterMaxClients {
+		t.Fatalf("%d buckets, want at most %d", len(limiter.buckets), rateLimiterMaxClients)
+	}
+	if _, ok := limiter.buckets["client0"]; ok {
+		t.Error("bucket of the longest idle client was kept")
+	}
+	if _, ok := limiter.buckets["client"+strconv.Itoa(rateLimiterMaxClients)]; !ok {
+		t.Error("bucket of the latest client was forgotten")
+	}
+}
+
+func TestRuntimeConfigEndpoint(t *testing.T) {
+	i := newTestInstance(t)
+	if err := i.ApplyRuntimeConfig(RuntimeConfig{LogLevel: LogLevelOff}); err != nil {
+		t.Fatal(err)
+	}
+	i.UseProblemDetails(ProblemDetailsOptions{})
+	i.SetInternalOptions(InternalOptions{AllowUnauthenticated: true})
+	i.EnableRuntimeConfig()
+
+	tests := []struct {
+		name   string
+		body   io.Reader
+		status int
+		code   string
+	}{
+		{"merged", strings.NewReader(`{"maintenance_message":"back soon"}`), http.StatusOK, ""},
+		{"unreadable body", iotest.ErrReader(errors.New("connection reset")), http.StatusBadRequest, ErrorCodeInvalidBody},
+		{"invalid JSON", strings.NewReader(`{"maintenance":`), http.StatusBadRequest, ErrorCodeInvalidConfig},
+		{"invalid config", strings.NewReader(`{"log_level":"verbose"}`), http.StatusUnprocessableEntity, ErrorCodeInvalidConfig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveTest(i, httptest.NewRequest(http.MethodPost, internalBasePath+"/runtime-config", tt.body))
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.code != "" && !strings.Contains(rec.Body.String(), `"code":"`+tt.code+`"`) {
+				t.Errorf("body %s, want code %s", rec.Body.String(), tt.code)
+			}
+		})
+	}
+
+	if message := i.RuntimeConfig().MaintenanceMessage; message != "back soon" {
+		t.Errorf("maintenance message %q, want the merged one", message)
+	}
+}
+
+// TestRuntimeConfigEndpointKeepsConcurrentChanges checks that a config posted while a route is disabled does not bring back
+// the config read before the route was disabled.
+func TestRuntimeConfigEndpointKeepsConcurrentChanges(t *testing.T) {
+	i := newTestInstance(t)
+	if err := i.ApplyRuntimeConfig(RuntimeConfig{LogLevel: LogLevelOff}); err != nil {
+		t.Fatal(err)
+	}
+	i.SetInternalOptions(InternalOptions{AllowUnauthenticated: true})
+	i.EnableRuntimeConfig()
+	for n := 0; n < 10; n++ {
+		i.Register("/route"+strconv.Itoa(n), disableHandler)
+	}
+	// a slow audit hook widens the window in which a change read before the lock would be lost
+	i.AuditRuntimeConfig(func(RuntimeConfigChange) {
+		time.Sleep(time.Millisecond)
+	})
+
+	var wg sync.WaitGroup
+	for n := 0; n < 10; n++ {
+		wg.Add(2)
+		go func(n int) {
+			defer wg.Done()
+			if err := i.DisableRoute(http.MethodGet, "/route"+strconv.Itoa(n), DisableUnavailable); err != nil {
+				t.Error(err)
+			}
+		}(n)
+		go func(n int) {
+			defer wg.Done()
+			body := strings.NewReader(`{"maintenance_message":"change ` + strconv.Itoa(n) + `"}`)
+			if rec := serveTest(i, httptest.NewRequest(http.MethodPost, internalBasePath+"/runtime-config", body)); rec.Code != http.StatusOK {
+				t.Errorf("status %d: %s", rec.Code, rec.Body.String())
+			}
+		}(n)
+	}
+	wg.Wait()
+
+	if disabled := i.DisabledRoutes(); len(disabled) != 10 {
+		t.Errorf("%d routes disabled, want 10", len(disabled))
+	}
+}
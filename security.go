@@ -0,0 +1,330 @@
+package octanox
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+)
+
+const (
+	// cspReportPath is the path browsers post the violations of the Content-Security-Policy to.
+	cspReportPath = "/csp-report"
+	// cspNonceKey is the key of the Gin context the CSP nonce of the request is stored under.
+	cspNonceKey = "nox.cspNonce"
+	// cspNonceSource is the placeholder of the nonce in the sources of a directive, replaced by the nonce of the request.
+	cspNonceSource = "'nonce'"
+	// maxCSPReportSize is the maximum size of the body of a CSP violation report.
+	maxCSPReportSize = 64 << 10
+
+	defaultFrameOptions   = "DENY"
+	defaultReferrerPolicy = "strict-origin-when-cross-origin"
+	defaultHSTSMaxAge     = 365 * 24 * time.Hour
+)
+
+// defaultCSP is the policy of API responses, which never load anything nor are framed.
+var defaultCSP = NewCSP().Add("default-src", "'none'").Add("frame-ancestors", "'none'")
+
+// internalCSP is the policy of the internal endpoints, whose pages, e.g. of pprof, use inline styles and scripts.
+var internalCSP = NewCSP().
+	Add("default-src", "'self'").
+	Add("script-src", "'self'", "'unsafe-inline'").
+	Add("style-src", "'self'", "'unsafe-inline'").
+	Add("img-src", "'self'", "data:").
+	Add("frame-ancestors", "'self'")
+
+// SecurityHeadersOptions is a struct that configures the security headers sent with every response. Zero values select the
+// defaults.
+type SecurityHeadersOptions struct {
+	// FrameOptions is the value of the X-Frame-Options header. Defaults to DENY.
+	FrameOptions string
+	// ReferrerPolicy is the value of the Referrer-Policy header. Defaults to strict-origin-when-cross-origin.
+	ReferrerPolicy string
+	// HSTSMaxAge is the max-age of the Strict-Transport-Security header, which is only sent with responses to TLS requests,
+	// including requests forwarded with X-Forwarded-Proto: https. Defaults to one year. Negative values disable the header.
+	HSTSMaxAge time.Duration
+	// HSTSIncludeSubdomains is a flag that indicates whether the Strict-Transport-Security header covers the subdomains.
+	HSTSIncludeSubdomains bool
+	// CSP is the Content-Security-Policy. Defaults to "default-src 'none'; frame-ancestors 'none'", which suits API responses.
+	CSP *CSP
+	// ReportOnly is a flag that indicates whether the policy is only reported in Content-Security-Policy-Report-Only instead
+	// of being enforced. Violations are reported to /csp-report.
+	ReportOnly bool
+	// OnReport is called with every violation reported to /csp-report. Defaults to logging the violation.
+	OnReport func(report CSPReport)
+}
+
+// CSP is a struct that builds a Content-Security-Policy from its directives and their sources.
+type CSP struct {
+	directives []cspDirective
+}
+
+type cspDirective struct {
+	name    string
+	sources []string
+}
+
+// CSPReport is a struct that contains a violation of the Content-Security-Policy reported by a browser.
+type CSPReport struct {
+	DocumentURI        string `json:"document-uri"`
+	Referrer           string `json:"referrer"`
+	ViolatedDirective  string `json:"violated-directive"`
+	EffectiveDirective string `json:"effective-directive"`
+	OriginalPolicy     string `json:"original-policy"`
+	Disposition        string `json:"disposition"`
+	BlockedURI         string `json:"blocked-uri"`
+	SourceFile         string `json:"source-file"`
+	LineNumber         int    `json:"line-number"`
+	ColumnNumber       int    `json:"column-number"`
+	StatusCode         int    `json:"status-code"`
+	ScriptSample       string `json:"script-sample"`
+}
+
+// cspReportBody is the body browsers post violations in.
+type cspReportBody struct {
+	Report CSPReport `json:"csp-report"`
+}
+
+// NewCSP creates an empty Content-Security-Policy.
+func NewCSP() *CSP {
+	return &CSP{}
+}
+
+// Add adds the given sources to the directive with the given name, e.g. Add("script-src", "'self'").
+func (p *CSP) Add(directive string, sources ...string) *CSP {
+	for n := range p.directives {
+		if p.directives[n].name == directive {
+			for _, source := range sources {
+				if !containsString(p.directives[n].sources, source) {
+					p.directives[n].sources = append(p.directives[n].sources, source)
+				}
+			}
+			return p
+		}
+	}
+
+	p.directives = append(p.directives, cspDirective{name: directive, sources: append([]string{}, sources...)})
+	return p
+}
+
+// Nonce allows the elements carrying the nonce of the request, returned by CSPNonce, in the given directives, e.g.
+// Nonce("script-src", "style-src"). The nonce is generated per request.
+func (p *CSP) Nonce(directives ...string) *CSP {
+	for _, directive := range directives {
+		p.Add(directive, cspNonceSource)
+	}
+
+	return p
+}
+
+// String returns the policy, with the nonce placeholder if it uses nonces.
+func (p *CSP) String() string {
+	return p.render("")
+}
+
+// usesNonce checks if the policy allows elements by the nonce of the request.
+func (p *CSP) usesNonce() bool {
+	for _, directive := range p.directives {
+		if containsString(directive.sources, cspNonceSource) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// render returns the policy with the given nonce, followed by the given report URIs.
+func (p *CSP) render(nonce string, reportURI ...string) string {
+	directives := make([]string, 0, len(p.directives)+1)
+	for _, directive := range p.directives {
+		parts := []string{directive.name}
+		for _, source := range directive.sources {
+			if source == cspNonceSource && nonce != "" {
+				source = "'nonce-" + nonce + "'"
+			}
+			parts = append(parts, source)
+		}
+
+		directives = append(directives, strings.Join(parts, " "))
+	}
+
+	for _, uri := range reportURI {
+		directives = append(directives, "report-uri "+uri)
+	}
+
+	return strings.Join(directives, "; ")
+}
+
+// EnableSecurityHeaders sends X-Content-Type-Options, X-Frame-Options, Referrer-Policy, Strict-Transport-Security on TLS
+// requests and the Content-Security-Policy with every response. Routes can override the options with Route.SecurityHeaders.
+// The internal endpoints get a policy allowing their pages. Violations of the policy are collected at /csp-report if it is
+// only reported or OnReport is set.
+func (i *Instance) EnableSecurityHeaders(opts SecurityHeadersOptions) *Instance {
+	if i.securityHeaders != nil {
+		panic("octanox: security headers are already enabled")
+	}
+
+	i.securityHeaders = &opts
+
+	if opts.ReportOnly || opts.OnReport != nil {
+		i.collectCSPReports(opts.OnReport)
+	}
+
+	return i
+}
+
+// SecurityHeaders overrides the security headers enabled with Instance.EnableSecurityHeaders for this route, e.g. to allow
+// framing a widget or to serve an HTML page with its own policy.
+func (r *Route) SecurityHeaders(opts SecurityHeadersOptions) *Route {
+	r.securityHeaders = &opts
+	return r
+}
+
+// CSPNonce returns the nonce of the request to put in the nonce attribute of the inline scripts and styles of a served HTML
+// page. Empty if the policy does not use nonces.
+func CSPNonce(c *gin.Context) string {
+	return c.GetString(cspNonceKey)
+}
+
+// InjectCSPNonce adds the nonce of the request to every script and style element of the given HTML page, e.g. of the served
+// index.html of a single page app, so they are allowed by a policy using nonces. Returns the page as is without a nonce.
+func InjectCSPNonce(c *gin.Context, html []byte) []byte {
+	nonce := CSPNonce(c)
+	if nonce == "" {
+		return html
+	}
+
+	attribute := []byte(` nonce="` + nonce + `"`)
+	for _, tag := range [][]byte{[]byte("<script"), []byte("<style")} {
+		html = bytes.ReplaceAll(html, tag, append(append([]byte{}, tag...), attribute...))
+	}
+
+	return html
+}
+
+// collectCSPReports serves the collection endpoint of violation reports at /csp-report.
+func (i *Instance) collectCSPReports(onReport func(CSPReport)) {
+	if onReport == nil {
+		onReport = func(report CSPReport) {
+			log.Println("octanox: CSP violation of " + report.EffectiveDirective + " on " + report.DocumentURI + ": blocked " + report.BlockedURI)
+		}
+	}
+
+	i.Gin.POST(cspReportPath, func(c *gin.Context) {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxCSPReportSize))
+		if err != nil {
+			abortWithError(c, failedRequest{status: http.StatusBadRequest, message: "Invalid body: cannot be read", code: ErrorCodeInvalidBody})
+			return
+		}
+
+		var report cspReportBody
+		if err := json.Unmarshal(body, &report); err != nil {
+			abortWithError(c, failedRequest{status: http.StatusBadRequest, message: "Invalid CSP report", code: ErrorCodeInvalidBody})
+			return
+		}
+
+		onReport(report.Report)
+		c.Status(http.StatusNoContent)
+	})
+}
+
+// securityHeaders sends the security headers enabled with Instance.EnableSecurityHeaders, or overridden by the route.
+func securityHeaders() gin.HandlerFunc {
+	var routes map[string]*SecurityHeadersOptions
+	var routesOnce sync.Once
+
+	return func(c *gin.Context) {
+		opts := Current.securityHeaders
+		if opts == nil {
+			c.Next()
+			return
+		}
+
+		// the routes are complete once requests are served
+		routesOnce.Do(func() {
+			routes = make(map[string]*SecurityHeadersOptions)
+			for _, route := range Current.routes {
+				if route.securityHeaders != nil {
+					routes[route.method+" "+route.path] = route.securityHeaders
+				}
+			}
+		})
+
+		if override, ok := routes[c.Request.Method+" "+c.FullPath()]; ok {
+			opts = override
+		}
+
+		policy := opts.CSP
+		if policy == nil {
+			policy = defaultCSP
+		}
+
+		frameOptions := opts.FrameOptions
+		if frameOptions == "" {
+			frameOptions = defaultFrameOptions
+		}
+
+		if strings.HasPrefix(c.Request.URL.Path, internalBasePath+"/") {
+			policy, frameOptions = internalCSP, "SAMEORIGIN"
+		}
+
+		header := c.Writer.Header()
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("X-Frame-Options", frameOptions)
+
+		referrerPolicy := opts.ReferrerPolicy
+		if referrerPolicy == "" {
+			referrerPolicy = defaultReferrerPolicy
+		}
+		header.Set("Referrer-Policy", referrerPolicy)
+
+		hstsMaxAge := opts.HSTSMaxAge
+		if hstsMaxAge == 0 {
+			hstsMaxAge = defaultHSTSMaxAge
+		}
+		if hstsMaxAge > 0 && (c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https") {
+			hsts := "max-age=" + strconv.FormatInt(int64(hstsMaxAge/time.Second), 10)
+			if opts.HSTSIncludeSubdomains {
+				hsts += "; includeSubDomains"
+			}
+			header.Set("Strict-Transport-Security", hsts)
+		}
+
+		var nonce string
+		if policy.usesNonce() {
+			nonce = newCSPNonce()
+			c.Set(cspNonceKey, nonce)
+		}
+
+		var reportURI []string
+		if Current.securityHeaders.ReportOnly || Current.securityHeaders.OnReport != nil {
+			reportURI = append(reportURI, cspReportPath)
+		}
+
+		if opts.ReportOnly {
+			header.Set("Content-Security-Policy-Report-Only", policy.render(nonce, reportURI...))
+		} else {
+			header.Set("Content-Security-Policy", policy.render(nonce, reportURI...))
+		}
+
+		c.Next()
+	}
+}
+
+func newCSPNonce() string {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		panic(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(nonce)
+}
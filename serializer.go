@@ -16,6 +16,12 @@ func (i *Instance) Serialize(obj interface{}, c Context) any {
 	return serializer(obj, c)
 }
 
+// hasSerializer checks if a serializer is registered for the given type.
+func (i *Instance) hasSerializer(t reflect.Type) bool {
+	_, ok := i.serializers[t]
+	return ok
+}
+
 // RegisterSerializer is a function that registers a serializer for a given type.
 func (i *Instance) RegisterSerializer(obj interface{}, serializer interface{}) *Instance {
 	typeOfObj := reflect.TypeOf(obj)
@@ -0,0 +1,48 @@
+package octanox
+
+import (
+	"errors"
+	"net/http"
+)
+
+// SkipSmokeTest excludes the route from the generated smoke test, e.g. for GET routes with side effects or which are too
+// expensive to call after every deployment.
+func (r *Route) SkipSmokeTest() *Route {
+	r.skipSmokeTest = true
+	return r
+}
+
+// SmokeTestGenerator returns a generator writing a standalone TypeScript smoke test to the given path. The smoke test calls
+// every GET route through the generated client, with the example parameters ServeExamples synthesizes, and validates the
+// responses against the schemas of the OpenAPI document. It prints a line per route and exits with 1 if any of them failed.
+//
+// The parameters of routes, e.g. IDs which must exist, are overridden by a JSON file, and the base URL and credentials
+// are read from environment variables, all documented in the header of the written file. It imports the client relative to
+// its own path, so it has to run after the TypeScript generator, e.g. by registering it with a priority above 0.
+func SmokeTestGenerator(outputPath string) Generator {
+	return func(ctx *GenContext) error {
+		var clientPath string
+		for _, output := range ctx.Previous {
+			if output.Generator == typeScriptGenerator {
+				clientPath = output.Path
+				break
+			}
+		}
+
+		if clientPath == "" {
+			return errors.New("the smoke test imports the TypeScript client, which has not been generated before")
+		}
+
+		code, err := ctx.Instance.smokeTestCode(ctx.Instance.routes, relativeClientModule(outputPath, clientPath))
+		if err != nil {
+			return err
+		}
+
+		return ctx.WriteFile(outputPath, []byte(code))
+	}
+}
+
+// smokeTested checks if the given route is called by the smoke test.
+func smokeTested(route *Route) bool {
+	return route.method == http.MethodGet && !route.skipSmokeTest
+}
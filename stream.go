@@ -0,0 +1,162 @@
+package octanox
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+)
+
+const (
+	// streamBufferSize is the size of the buffer streamed items are encoded into before they are written to the connection.
+	streamBufferSize = 32 << 10
+	// streamFlushInterval is the time after which the streamed items are flushed to the client at the latest.
+	streamFlushInterval = 100 * time.Millisecond
+)
+
+// streamItemType returns the item type of handler results that are streamed as JSON array: iter.Seq[T], or any other func of
+// the form func(yield func(T) bool), and channels of T. The handler keeps producing items until the client disconnects, in which
+// case yield returns false. Producers sending to a channel must stop once the request context is cancelled, as the channel
+// is not received from anymore.
+func streamItemType(t reflect.Type) (reflect.Type, bool) {
+	switch t.Kind() {
+	case reflect.Func:
+		if t.NumIn() != 1 || t.NumOut() != 0 {
+			return nil, false
+		}
+
+		yield := t.In(0)
+		if yield.Kind() == reflect.Func && yield.NumIn() == 1 && yield.NumOut() == 1 && yield.Out(0).Kind() == reflect.Bool {
+			return yield.In(0), true
+		}
+	case reflect.Chan:
+		if t.ChanDir()&reflect.RecvDir != 0 {
+			return t.Elem(), true
+		}
+	}
+
+	return nil, false
+}
+
+// eachStreamItem calls the given function with every item of the given streamed result until it returns false or the given
+// context is cancelled.
+func eachStreamItem(ctx context.Context, result reflect.Value, f func(item reflect.Value) bool) {
+	if result.IsNil() {
+		return
+	}
+
+	if result.Kind() == reflect.Chan {
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: result},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		}
+
+		for {
+			chosen, item, ok := reflect.Select(cases)
+			if chosen == 1 || !ok || !f(item) {
+				return
+			}
+		}
+	}
+
+	yieldType := result.Type().In(0)
+	yield := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+		more := ctx.Err() == nil && f(args[0])
+		return []reflect.Value{reflect.ValueOf(more).Convert(yieldType.Out(0))}
+	})
+
+	result.Call([]reflect.Value{yield})
+}
+
+// collectStream collects all items of the given streamed result into a slice, for responses that cannot be streamed.
+func collectStream(ctx context.Context, result reflect.Value, itemType reflect.Type) any {
+	items := reflect.MakeSlice(reflect.SliceOf(itemType), 0, 0)
+	eachStreamItem(ctx, result, func(item reflect.Value) bool {
+		items = reflect.Append(items, item)
+		return true
+	})
+
+	return items.Interface()
+}
+
+// writeStream writes the items of the given streamed result as JSON array, encoding and flushing them incrementally, so the
+// memory used does not grow with the number of items. Every item is serialized and pruned to the selected and visible fields on its own.
+// As the status has already been sent, a failure while streaming aborts the connection, so the client cannot mistake the
+// truncated array for a complete one.
+func writeStream(c *gin.Context, result reflect.Value, sc Context, fields string, user User) {
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	w := bufio.NewWriterSize(c.Writer, streamBufferSize)
+	var item bytes.Buffer
+	encoder := json.NewEncoder(&item)
+	lastFlush := time.Now()
+
+	flush := func() error {
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		c.Writer.Flush()
+		lastFlush = time.Now()
+		return nil
+	}
+
+	// encoding failures are reported, failed writes mean that the client disconnected
+	var encodeErr, writeErr error
+	writeErr = w.WriteByte('[')
+
+	if writeErr == nil {
+		first := true
+		eachStreamItem(c.Request.Context(), result, func(v reflect.Value) bool {
+			out := Current.normalizeCollections(Current.Serialize(v.Interface(), sc))
+			if fields != "" {
+				out = projectFields(out, fields)
+			}
+			out = Current.applyVisibility(out, user)
+
+			item.Reset()
+			if !first {
+				item.WriteByte(',')
+			}
+			first = false
+
+			if encodeErr = encoder.Encode(out); encodeErr != nil {
+				return false
+			}
+			// drop the newline the encoder terminates every value with
+			item.Truncate(item.Len() - 1)
+
+			if _, writeErr = w.Write(item.Bytes()); writeErr != nil {
+				return false
+			}
+
+			if time.Since(lastFlush) >= streamFlushInterval {
+				writeErr = flush()
+			}
+
+			return writeErr == nil
+		})
+	}
+
+	if encodeErr != nil {
+		Current.emitError(Error(fmt.Errorf("streaming the response of %s %s failed: %w", c.Request.Method, c.FullPath(), encodeErr)))
+	}
+
+	if writeErr == nil && encodeErr == nil && c.Request.Context().Err() == nil {
+		if writeErr = w.WriteByte(']'); writeErr == nil {
+			writeErr = flush()
+		}
+	}
+
+	if writeErr != nil || encodeErr != nil || c.Request.Context().Err() != nil {
+		// the status has already been sent, aborting the connection lets the client notice the truncated array
+		panic(http.ErrAbortHandler)
+	}
+}
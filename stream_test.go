@@ -0,0 +1,143 @@
+package octanox
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+// benchRows is the number of rows of the streaming benchmarks.
+const benchRows = 100_000
+
+type streamRow struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type streamRequest struct {
+	GetRequest
+}
+
+// seqRows returns an iterator of the given number of rows, which calls last before the last row is produced.
+func seqRows(n int, last func()) func(yield func(streamRow) bool) {
+	return func(yield func(streamRow) bool) {
+		for id := 0; id < n; id++ {
+			if id == n-1 {
+				last()
+			}
+			if !yield(streamRow{ID: id, Name: "row"}) {
+				return
+			}
+		}
+	}
+}
+
+// chanRows returns a channel of the given number of rows, which is closed once all are sent. Calls last before the last
+// row is produced.
+func chanRows(n int, last func()) <-chan streamRow {
+	rows := make(chan streamRow, 64)
+	go func() {
+		defer close(rows)
+		for id := 0; id < n; id++ {
+			if id == n-1 {
+				last()
+			}
+			rows <- streamRow{ID: id, Name: "row"}
+		}
+	}()
+
+	return rows
+}
+
+// newStreamInstance returns an instance serving the given number of rows at /seq as iterator, at /chan as channel and at
+// /slice as slice. The given function is called before the last row of a response is produced.
+func newStreamInstance(t testing.TB, rows int, last func()) http.Handler {
+	i := newTestInstance(t)
+	if err := i.ApplyRuntimeConfig(RuntimeConfig{LogLevel: LogLevelOff}); err != nil {
+		t.Fatal(err)
+	}
+
+	i.Register("/seq", func(*streamRequest) func(yield func(streamRow) bool) {
+		return seqRows(rows, last)
+	})
+	i.Register("/chan", func(*streamRequest) <-chan streamRow {
+		return chanRows(rows, last)
+	})
+	i.Register("/slice", func(*streamRequest) []streamRow {
+		items := make([]streamRow, 0, rows)
+		seqRows(rows, last)(func(row streamRow) bool {
+			items = append(items, row)
+			return true
+		})
+		return items
+	})
+
+	return i.Handler()
+}
+
+func TestStreamResponses(t *testing.T) {
+	handler := newStreamInstance(t, 1000, func() {})
+
+	for _, path := range []string{"/seq", "/chan", "/slice"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d: %s", path, rec.Code, rec.Body.String())
+		}
+
+		var rows []streamRow
+		if err := json.Unmarshal(rec.Body.Bytes(), &rows); err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		if len(rows) != 1000 || rows[999].ID != 999 {
+			t.Errorf("GET %s: %d rows, want 1000", path, len(rows))
+		}
+	}
+}
+
+// benchmarkStream measures the responses of the given path. Besides the allocations, it reports as live-B/op the memory in
+// use once all but the last row have been produced, which is what the server holds at once.
+func benchmarkStream(b *testing.B, path string) {
+	var stats runtime.MemStats
+	var before, live uint64
+	handler := newStreamInstance(b, benchRows, func() {
+		runtime.GC()
+		runtime.ReadMemStats(&stats)
+		if stats.HeapAlloc > before {
+			live += stats.HeapAlloc - before
+		}
+	})
+
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		// twice, so the pooled buffers of the previous response are freed as well
+		runtime.GC()
+		runtime.GC()
+		runtime.ReadMemStats(&stats)
+		before = stats.HeapAlloc
+		b.StartTimer()
+
+		handler.ServeHTTP(&discardWriter{header: make(http.Header)}, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	b.ReportMetric(float64(live)/float64(b.N), "live-B/op")
+}
+
+// BenchmarkStreamIterator measures streaming 100k rows of an iterator, whose memory does not grow with the number of rows.
+func BenchmarkStreamIterator(b *testing.B) {
+	benchmarkStream(b, "/seq")
+}
+
+// BenchmarkStreamChannel measures streaming 100k rows of a channel.
+func BenchmarkStreamChannel(b *testing.B) {
+	benchmarkStream(b, "/chan")
+}
+
+// BenchmarkStreamSlice measures responding with 100k rows collected into a slice first, as handlers did before streaming.
+func BenchmarkStreamSlice(b *testing.B) {
+	benchmarkStream(b, "/slice")
+}
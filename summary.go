@@ -0,0 +1,102 @@
+package octanox
+
+import (
+	"log"
+	"os"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// handlerNameSuffix matches the suffix the compiler appends to the names of closures.
+var handlerNameSuffix = regexp.MustCompile(`(\.func\d+)+$`)
+
+// logStartupSummary logs the listener address, the routes, the authentication, the generators, the middleware chain and the
+// doctor findings, so misconfigurations are visible at a glance.
+func (i *Instance) logStartupSummary(addr string) {
+	log.Println("octanox: listening on " + addr)
+	log.Println("octanox: " + strconv.Itoa(len(i.routes)) + " routes registered" + routeGroupSummary(i.routes))
+
+	if i.Authenticator != nil {
+		log.Println("octanox: authentication via " + i.Authenticator.Method().String() + " at " + i.authLoginBasePath)
+	} else {
+		log.Println("octanox: no authentication configured")
+	}
+	for _, ra := range i.routeAuthenticators {
+		log.Println("octanox: authentication of " + ra.pattern + " via " + authenticationScheme(ra.authenticator))
+	}
+
+	if path := os.Getenv("NOX__CLIENT_DIR"); path != "" {
+		log.Println("octanox: TypeScript client generated in dry-run mode to " + path)
+	} else {
+		log.Println("octanox: no client generator configured")
+	}
+
+	log.Println("octanox: middleware chain: " + strings.Join(i.middlewareNames(), " -> "))
+	if len(i.middlewares) > 0 {
+		log.Println("octanox: " + strconv.Itoa(len(i.middlewares)) + " route middlewares registered with Use and UseFor")
+	}
+
+	for _, finding := range i.Doctor() {
+		log.Println("octanox: " + finding.String())
+	}
+}
+
+// runDoctor prints the doctor findings and exits, with a non-zero exit code if any finding is an error.
+func (i *Instance) runDoctor() {
+	findings := i.Doctor()
+	if len(findings) == 0 {
+		log.Println("octanox: no findings")
+		os.Exit(0)
+	}
+
+	code := 0
+	for _, finding := range findings {
+		log.Println(finding.String())
+		if finding.Severity == SeverityError {
+			code = 1
+		}
+	}
+
+	os.Exit(code)
+}
+
+// routeGroupSummary returns the route count per first path segment, e.g. " (/users: 4, /orders: 2)".
+func routeGroupSummary(routes []*Route) string {
+	counts := make(map[string]int)
+	for _, route := range routes {
+		counts[routeGroup(route.path)]++
+	}
+
+	if len(counts) == 0 {
+		return ""
+	}
+
+	groups := make([]string, 0, len(counts))
+	for group := range counts {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	for n, group := range groups {
+		groups[n] = group + ": " + strconv.Itoa(counts[group])
+	}
+
+	return " (" + strings.Join(groups, ", ") + ")"
+}
+
+// middlewareNames returns the names of the global middlewares in the order they run.
+func (i *Instance) middlewareNames() []string {
+	names := make([]string, 0, len(i.Gin.Handlers))
+	for _, handler := range i.Gin.Handlers {
+		name := runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+		name = handlerNameSuffix.ReplaceAllString(name, "")
+		name = strings.TrimPrefix(name[strings.LastIndex(name, "/")+1:], "octanox.")
+		names = append(names, name)
+	}
+
+	return names
+}
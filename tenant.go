@@ -0,0 +1,130 @@
+package octanox
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type tenantContextKey struct{}
+
+// TenantExtractor is an interface that extracts the tenant ID from a request.
+type TenantExtractor interface {
+	// Extract extracts the tenant ID from the given request. It returns the tenant ID and the request path with any tenant specific
+	// prefix removed, so handlers and the route table stay tenant-agnostic. If no tenant is found, ok should be false.
+	Extract(r *http.Request) (tenant string, path string, ok bool)
+}
+
+// SubdomainTenantExtractor extracts the tenant ID from the subdomain of the given base domain, e.g. acme.api.example.com.
+type SubdomainTenantExtractor struct {
+	BaseDomain string
+}
+
+// PathPrefixTenantExtractor extracts the tenant ID from the path segment following the given prefix, e.g. /t/acme/... and strips both.
+type PathPrefixTenantExtractor struct {
+	Prefix string
+}
+
+// HeaderTenantExtractor extracts the tenant ID from the given request header.
+type HeaderTenantExtractor struct {
+	Header string
+}
+
+// TenantFromSubdomain creates a tenant extractor reading the tenant ID from the subdomain of the given base domain.
+func TenantFromSubdomain(baseDomain string) *SubdomainTenantExtractor {
+	return &SubdomainTenantExtractor{BaseDomain: strings.TrimPrefix(baseDomain, ".")}
+}
+
+// TenantFromPathPrefix creates a tenant extractor consuming the path segment following the given prefix as tenant ID.
+func TenantFromPathPrefix(prefix string) *PathPrefixTenantExtractor {
+	return &PathPrefixTenantExtractor{Prefix: "/" + strings.Trim(prefix, "/")}
+}
+
+// TenantFromHeader creates a tenant extractor reading the tenant ID from the given request header.
+func TenantFromHeader(header string) *HeaderTenantExtractor {
+	return &HeaderTenantExtractor{Header: header}
+}
+
+func (e *SubdomainTenantExtractor) Extract(r *http.Request) (string, string, bool) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	tenant, found := strings.CutSuffix(host, "."+e.BaseDomain)
+	if !found || tenant == "" || strings.Contains(tenant, ".") {
+		return "", r.URL.Path, false
+	}
+
+	return tenant, r.URL.Path, true
+}
+
+func (e *PathPrefixTenantExtractor) Extract(r *http.Request) (string, string, bool) {
+	rest, found := strings.CutPrefix(r.URL.Path, e.Prefix+"/")
+	if !found {
+		return "", r.URL.Path, false
+	}
+
+	tenant, path, _ := strings.Cut(rest, "/")
+	if tenant == "" {
+		return "", r.URL.Path, false
+	}
+
+	return tenant, "/" + path, true
+}
+
+func (e *HeaderTenantExtractor) Extract(r *http.Request) (string, string, bool) {
+	tenant := r.Header.Get(e.Header)
+	return tenant, r.URL.Path, tenant != ""
+}
+
+// Tenants enables the multi-tenant resolution using the given extractors, which are tried in order until one finds a tenant.
+// The first built-in extractor also decides how the generated TypeScript client's setTenant function addresses the tenant.
+func (i *Instance) Tenants(extractors ...TenantExtractor) *Instance {
+	i.tenantExtractors = append(i.tenantExtractors, extractors...)
+	return i
+}
+
+// TenantFrom returns the tenant ID of the request. Returns an empty string if no tenant was resolved.
+func TenantFrom(c *gin.Context) string {
+	tenant, _ := c.Request.Context().Value(tenantContextKey{}).(string)
+	return tenant
+}
+
+// resolveTenant wraps the given handler with the tenant resolution, which runs before the route matching.
+func (i *Instance) resolveTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, extractor := range i.tenantExtractors {
+			tenant, path, ok := extractor.Extract(r)
+			if !ok {
+				continue
+			}
+
+			r = r.WithContext(context.WithValue(r.Context(), tenantContextKey{}, tenant))
+			if path != r.URL.Path {
+				u := *r.URL
+				u.Path = path
+				u.RawPath = ""
+				r.URL = &u
+			}
+			break
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientTenantExtractor returns the first built-in tenant extractor, which the generated client addresses tenants with. Can be nil.
+func (i *Instance) clientTenantExtractor() TenantExtractor {
+	for _, extractor := range i.tenantExtractors {
+		switch extractor.(type) {
+		case *SubdomainTenantExtractor, *PathPrefixTenantExtractor, *HeaderTenantExtractor:
+			return extractor
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package octanox
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+	"github.com/goccy/go-json"
+	"google.golang.org/protobuf/proto"
+)
+
+// BodyTransformer is a function that transforms a raw JSON body for the given API version. It is used to declare shims
+// for older API shapes in one place instead of inside the handlers.
+type BodyTransformer func(raw json.RawMessage, version string) (json.RawMessage, error)
+
+// SetTransformVersionKeys sets the header and query parameter the API version passed to the body transformers is read from.
+// The header takes precedence over the query parameter. Defaults to the "X-API-Version" header and the "api_version" query parameter.
+func (i *Instance) SetTransformVersionKeys(header, query string) *Instance {
+	i.transformVersionHeader = header
+	i.transformVersionQuery = query
+	return i
+}
+
+// TransformRequest registers a transformer that is called with the raw request body before it is bound to the request struct.
+// The generated client code always describes the canonical shape, the transformer is only applied at runtime.
+func (r *Route) TransformRequest(f BodyTransformer) *Route {
+	r.transformRequest = f
+	return r
+}
+
+// TransformResponse registers a transformer that is called with the serialized response body before it is written to the client.
+// The generated client code always describes the canonical shape, the transformer is only applied at runtime.
+func (r *Route) TransformResponse(f BodyTransformer) *Route {
+	r.transformResponse = f
+	return r
+}
+
+// transformVersion returns the API version requested by the client. Can be empty if the client did not request any version.
+func transformVersion(c *gin.Context) string {
+	if version := c.GetHeader(Current.transformVersionHeader); version != "" {
+		return version
+	}
+
+	return c.Query(Current.transformVersionQuery)
+}
+
+// transformRequestBody replaces the request body with the transformed one.
+func transformRequestBody(c *gin.Context, f BodyTransformer) {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return
+	}
+
+	raw, err := readBody(c)
+	if err != nil {
+		panic(err)
+	}
+
+	transformed, err := f(raw, transformVersion(c))
+	if err != nil {
+		message := "Invalid request body"
+
+		if Current.isDebug {
+			message += ": " + err.Error()
+		}
+
+		panic(failedRequest{
+			status:  http.StatusBadRequest,
+			message: message,
+			code:    ErrorCodeInvalidBody,
+		})
+	}
+
+	c.Request.Body = io.NopCloser(bytes.NewReader(transformed))
+	c.Request.ContentLength = int64(len(transformed))
+}
+
+// respondTransformed serializes the given data as JSON, transforms it and writes it with the given status code.
+func respondTransformed(c *gin.Context, status int, data any, f BodyTransformer) {
+	var raw []byte
+	var err error
+	if msg, ok := data.(proto.Message); ok {
+		raw, err = Current.protoMarshalOptions().Marshal(msg)
+	} else {
+		raw, err = json.Marshal(data)
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	transformed, err := f(raw, transformVersion(c))
+	if err != nil {
+		panic(Error(err))
+	}
+
+	if acceptsMsgPack(c) {
+		var decoded any
+		if err := json.Unmarshal(transformed, &decoded); err != nil {
+			panic(Error(err))
+		}
+
+		c.Render(status, render.MsgPack{Data: decoded})
+		return
+	}
+
+	c.Data(status, "application/json; charset=utf-8", transformed)
+}
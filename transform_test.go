@@ -0,0 +1,123 @@
+package octanox
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+)
+
+type renameBody struct {
+	FullName string `json:"full_name"`
+}
+
+type renameRequest struct {
+	PostRequest
+	Body renameBody `body:"true"`
+}
+
+type renameResponse struct {
+	FullName string `json:"full_name"`
+}
+
+func renameHandler(req *renameRequest) *renameResponse {
+	return &renameResponse{FullName: req.Body.FullName}
+}
+
+// renameOldRequest moves the name field of version 1 bodies to full_name.
+func renameOldRequest(raw json.RawMessage, version string) (json.RawMessage, error) {
+	if version != "1" {
+		return raw, nil
+	}
+
+	var old struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(raw, &old); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(renameBody{FullName: old.Name})
+}
+
+// renameOldResponse adds the name field of version 1 to the responses.
+func renameOldResponse(raw json.RawMessage, version string) (json.RawMessage, error) {
+	if version != "1" {
+		return raw, nil
+	}
+
+	var res map[string]any
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, err
+	}
+
+	res["name"] = res["full_name"]
+	return json.Marshal(res)
+}
+
+func TestTransformOldShapeAgainstNewHandler(t *testing.T) {
+	tests := []struct {
+		name, header, query, body string
+		status                    int
+		want                      map[string]string
+	}{
+		{"old shape by header", "1", "", `{"name":"alice"}`, http.StatusOK, map[string]string{"full_name": "alice", "name": "alice"}},
+		{"old shape by query", "", "1", `{"name":"alice"}`, http.StatusOK, map[string]string{"full_name": "alice", "name": "alice"}},
+		{"new shape", "", "", `{"full_name":"alice"}`, http.StatusOK, map[string]string{"full_name": "alice"}},
+		{"invalid old shape", "1", "", `{"name":`, http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newTestInstance(t)
+			i.Register("/users", renameHandler).TransformRequest(renameOldRequest).TransformResponse(renameOldResponse)
+
+			target := "/users"
+			if tt.query != "" {
+				target += "?api_version=" + tt.query
+			}
+			req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.header != "" {
+				req.Header.Set("X-API-Version", tt.header)
+			}
+
+			rec := serveTest(i, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.want == nil {
+				return
+			}
+
+			var got map[string]string
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("body %v, want %v", got, tt.want)
+			}
+			for key, value := range tt.want {
+				if got[key] != value {
+					t.Errorf("body %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestTransformVersionKeys(t *testing.T) {
+	i := newTestInstance(t)
+	i.SetTransformVersionKeys("X-Version", "v")
+	i.Register("/users", renameHandler).TransformRequest(renameOldRequest)
+
+	req := httptest.NewRequest(http.MethodPost, "/users?v=1", strings.NewReader(`{"name":"alice"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	rec := serveTest(i, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"full_name":"alice"`) {
+		t.Errorf("status %d, body %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,193 @@
+package octanox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type txContextKey struct{}
+
+// Tx is an interface that represents a database transaction begun by the transaction middleware. The pgx.Tx interface
+// satisfies it as is.
+type Tx interface {
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// TxOptions is a struct that contains the options a transaction is begun with.
+type TxOptions struct {
+	// ReadOnly is a flag that indicates whether the transaction is read-only.
+	ReadOnly bool
+}
+
+// TxBeginner is an interface that begins the transactions of the transaction middleware. Use SQLTransactions for a *sql.DB.
+// A pgx pool only needs a thin adapter, which maps the options to pgx.TxOptions{AccessMode: pgx.ReadOnly} and returns the
+// pgx.Tx of pool.BeginTx.
+type TxBeginner interface {
+	BeginTx(ctx context.Context, opts TxOptions) (Tx, error)
+}
+
+// txMode is an enum that defines whether a route runs in a transaction.
+type txMode int
+
+const (
+	// txDefault runs mutating routes in a read-write transaction and all other routes without one.
+	txDefault txMode = iota
+	txReadWrite
+	txReadOnly
+)
+
+// Transactions runs every request of a mutating route (POST, PUT, PATCH and DELETE), and of the routes opting in with
+// Route.Transactional or Route.ReadOnlyTransaction, in a transaction begun with the given beginner. Handlers retrieve it with
+// TxFrom. The transaction is committed if the handler responds with a 2xx status, and rolled back if it fails, panics,
+// responds with any other status or the client disconnects. The response is held back until the transaction is committed,
+// so a failed commit is still answered with 500 Internal Server Error.
+func (i *Instance) Transactions(beginner TxBeginner) *Instance {
+	i.txBeginner = beginner
+	return i
+}
+
+// Transactional runs every request of this route in a read-write transaction, even if the route is not mutating.
+func (r *Route) Transactional() *Route {
+	r.tx = txReadWrite
+	return r
+}
+
+// ReadOnlyTransaction runs every request of this route in a read-only transaction.
+func (r *Route) ReadOnlyTransaction() *Route {
+	r.tx = txReadOnly
+	return r
+}
+
+// TxFrom returns the transaction of the request with the given context, which is either the Gin context or the context of
+// the request. Returns nil if the request does not run in a transaction.
+func TxFrom(ctx context.Context) Tx {
+	if c, ok := ctx.(*gin.Context); ok {
+		ctx = c.Request.Context()
+	}
+
+	tx, _ := ctx.Value(txContextKey{}).(Tx)
+	return tx
+}
+
+// SQLTxFrom returns the *sql.Tx of the request with the given context, if the transactions are begun with SQLTransactions.
+// Returns nil if the request does not run in a transaction.
+func SQLTxFrom(ctx context.Context) *sql.Tx {
+	if tx, ok := TxFrom(ctx).(*SQLTx); ok {
+		return tx.Tx
+	}
+
+	return nil
+}
+
+// SQLTransactions returns a TxBeginner beginning the transactions on the given database.
+func SQLTransactions(db *sql.DB) TxBeginner {
+	return sqlTxBeginner{db: db}
+}
+
+// SQLTx is a struct that adapts a *sql.Tx to the Tx interface.
+type SQLTx struct {
+	*sql.Tx
+}
+
+func (tx *SQLTx) Commit(context.Context) error {
+	return tx.Tx.Commit()
+}
+
+func (tx *SQLTx) Rollback(context.Context) error {
+	return tx.Tx.Rollback()
+}
+
+type sqlTxBeginner struct {
+	db *sql.DB
+}
+
+func (b sqlTxBeginner) BeginTx(ctx context.Context, opts TxOptions) (Tx, error) {
+	tx, err := b.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: opts.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SQLTx{Tx: tx}, nil
+}
+
+// transactional checks if the route runs in a transaction.
+func (r *Route) transactional() bool {
+	if Current.txBeginner == nil {
+		return false
+	}
+
+	if r.tx != txDefault {
+		return true
+	}
+
+	switch r.method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+// runInTransaction runs the given handler in a transaction. If the request already runs in a transaction, e.g. because the
+// route is mounted in another one, the handler joins it and the outer owner commits or rolls it back.
+func runInTransaction(c *gin.Context, rt *Route, handler func()) {
+	if TxFrom(c) != nil {
+		handler()
+		return
+	}
+
+	tx, err := Current.txBeginner.BeginTx(c.Request.Context(), TxOptions{ReadOnly: rt.tx == txReadOnly})
+	if err != nil {
+		panic(fmt.Errorf("octanox: cannot begin transaction: %w", err))
+	}
+
+	ctx := c.Request.Context()
+	c.Request = c.Request.WithContext(context.WithValue(ctx, txContextKey{}, tx))
+
+	buffered := &bufferedResponseWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+	c.Writer = buffered
+
+	// the transaction is also finished if the client disconnected, so the rollback must not use the request context
+	finish := context.WithoutCancel(ctx)
+	done := false
+
+	defer func() {
+		c.Writer = buffered.ResponseWriter
+		if done {
+			return
+		}
+
+		// let the recovery middleware answer the panicking handler after the rollback
+		err := recover()
+		if rollbackErr := tx.Rollback(finish); rollbackErr != nil {
+			Current.emitError(fmt.Errorf("octanox: cannot roll back transaction: %w", rollbackErr))
+		}
+		if err != nil {
+			panic(err)
+		}
+
+		buffered.flush()
+	}()
+
+	handler()
+
+	if status := buffered.Status(); status < 200 || status >= 300 || len(c.Errors) > 0 || ctx.Err() != nil {
+		return
+	}
+
+	done = true
+	c.Writer = buffered.ResponseWriter
+
+	if err := tx.Commit(finish); err != nil {
+		Current.emitError(fmt.Errorf("octanox: cannot commit transaction: %w", err))
+		abortWithError(c, failedRequest{status: http.StatusInternalServerError, message: "Internal Server Error", code: ErrorCodeInternal})
+		return
+	}
+
+	buffered.flush()
+}
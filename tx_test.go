@@ -0,0 +1,152 @@
+package octanox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeTx is a transaction which records how it was finished.
+type fakeTx struct {
+	committed, rolledBack bool
+	commitErr             error
+}
+
+func (tx *fakeTx) Commit(context.Context) error {
+	tx.committed = true
+	return tx.commitErr
+}
+
+func (tx *fakeTx) Rollback(context.Context) error {
+	tx.rolledBack = true
+	return nil
+}
+
+// fakeBeginner begins fake transactions, which fail to commit with commitErr, and records them.
+type fakeBeginner struct {
+	txs       []*fakeTx
+	commitErr error
+}
+
+func (b *fakeBeginner) BeginTx(context.Context, TxOptions) (Tx, error) {
+	tx := &fakeTx{commitErr: b.commitErr}
+	b.txs = append(b.txs, tx)
+	return tx, nil
+}
+
+type txRequest struct {
+	PostRequest
+	Panic bool `query:"panic"`
+}
+
+type txResponse struct {
+	OK bool `json:"ok"`
+}
+
+func TestTransactionsFinishRequests(t *testing.T) {
+	tests := []struct {
+		name string
+		// user is the X-Test-User of the request, the route rejects requests without one
+		user      string
+		query     string
+		cancelled bool
+		commitErr error
+		status    int
+		commit    bool
+	}{
+		{"success", "alice", "?panic=false", false, nil, http.StatusOK, true},
+		{"handler panic", "alice", "?panic=true", false, nil, http.StatusInternalServerError, false},
+		{"non-2xx status", "", "?panic=false", false, nil, http.StatusUnauthorized, false},
+		{"client disconnect", "alice", "?panic=false", true, nil, http.StatusOK, false},
+		{"commit failure", "alice", "?panic=false", false, errors.New("serialization failure"), http.StatusInternalServerError, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			beginner := &fakeBeginner{commitErr: tt.commitErr}
+			i := newTestInstance(t)
+			if err := i.ApplyRuntimeConfig(RuntimeConfig{LogLevel: LogLevelOff}); err != nil {
+				t.Fatal(err)
+			}
+			i.Authenticator = headerAuthenticator{method: AuthenticationMethodBearer}
+			i.Transactions(beginner)
+			i.Register("/orders", func(req *txRequest) *txResponse {
+				if req.Panic {
+					panic("handler failed")
+				}
+				return &txResponse{OK: true}
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/orders"+tt.query, nil)
+			if tt.user != "" {
+				req.Header.Set("X-Test-User", tt.user)
+			}
+			if tt.cancelled {
+				ctx, cancel := context.WithCancel(req.Context())
+				cancel()
+				req = req.WithContext(ctx)
+			}
+
+			rec := serveTest(i, req)
+			if rec.Code != tt.status {
+				t.Errorf("status %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.commitErr != nil && strings.Contains(rec.Body.String(), `"ok"`) {
+				t.Errorf("body %s of the uncommitted response was sent", rec.Body.String())
+			}
+
+			if len(beginner.txs) != 1 {
+				t.Fatalf("%d transactions begun, want 1", len(beginner.txs))
+			}
+			tx := beginner.txs[0]
+			if tx.committed != tt.commit || tx.rolledBack == tt.commit {
+				t.Errorf("committed %t, rolled back %t", tx.committed, tx.rolledBack)
+			}
+		})
+	}
+}
+
+// serveInTransaction runs the given handler in a transaction of a test context and returns the transactions begun.
+func serveInTransaction(t *testing.T, handler func(c *gin.Context)) []*fakeTx {
+	beginner := &fakeBeginner{}
+	i := newTestInstance(t)
+	i.Transactions(beginner)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/orders", nil)
+	runInTransaction(c, &Route{method: http.MethodPost}, func() {
+		handler(c)
+	})
+
+	return beginner.txs
+}
+
+func TestTransactionRolledBackOnContextErrors(t *testing.T) {
+	txs := serveInTransaction(t, func(c *gin.Context) {
+		c.JSON(http.StatusOK, txResponse{OK: true})
+		_ = c.Error(errors.New("handler failed"))
+	})
+
+	if len(txs) != 1 || txs[0].committed || !txs[0].rolledBack {
+		t.Errorf("transactions %+v, want one rolled back", txs)
+	}
+}
+
+func TestNestedTransactionJoinsOuter(t *testing.T) {
+	var inner Tx
+	txs := serveInTransaction(t, func(c *gin.Context) {
+		runInTransaction(c, &Route{method: http.MethodPost}, func() {
+			inner = TxFrom(c)
+		})
+		c.Status(http.StatusOK)
+	})
+
+	if len(txs) != 1 || inner != Tx(txs[0]) || !txs[0].committed {
+		t.Errorf("transactions %+v, want the inner handler to join the committed outer one", txs)
+	}
+}
@@ -0,0 +1,83 @@
+package octanox
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UnavailableError is an error that indicates that the request cannot be served right now, but may succeed when it is
+// retried, e.g. because a dependency is down. Handlers return or panic with it. It is answered with 503 Service Unavailable,
+// marked as retryable in the error body.
+type UnavailableError struct {
+	// Message is the message of the error response.
+	Message string
+	// After is the time after which the request may be retried. Zero if the client decides on its own.
+	After time.Duration
+}
+
+// Unavailable returns an error that indicates that the request cannot be served right now, but may be retried.
+func Unavailable(message string) *UnavailableError {
+	return &UnavailableError{Message: message}
+}
+
+// RetryAfter sets the time after which the request may be retried, sent in the Retry-After header and the error body.
+func (e *UnavailableError) RetryAfter(d time.Duration) *UnavailableError {
+	e.After = d
+	return e
+}
+
+func (e *UnavailableError) Error() string {
+	return e.Message
+}
+
+// failedRequest returns the failed request the error is answered with.
+func (e *UnavailableError) failedRequest() failedRequest {
+	message := e.Message
+	if message == "" {
+		message = "Service Unavailable"
+	}
+
+	return failedRequest{
+		status:     http.StatusServiceUnavailable,
+		message:    message,
+		code:       ErrorCodeUnavailable,
+		retryable:  true,
+		retryAfter: e.After,
+	}
+}
+
+// asUnavailableError returns the UnavailableError wrapped in the given recovered value. Can be nil.
+func asUnavailableError(recovered any) *UnavailableError {
+	err, ok := recovered.(error)
+	if !ok {
+		return nil
+	}
+
+	var unavailable *UnavailableError
+	if errors.As(err, &unavailable) {
+		return unavailable
+	}
+
+	return nil
+}
+
+// setRetryAfter sets the Retry-After header of a retryable failed request which knows when it may be retried.
+func setRetryAfter(c *gin.Context, err failedRequest) {
+	if err.retryable && err.retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(err.retryAfter.Seconds()))))
+	}
+}
+
+// retryAfterMs returns the time after which the failed request may be retried in milliseconds, as carried in the error body.
+func (e failedRequest) retryAfterMs() int64 {
+	if !e.retryable || e.retryAfter <= 0 {
+		return 0
+	}
+
+	return int64(math.Ceil(float64(e.retryAfter) / float64(time.Millisecond)))
+}
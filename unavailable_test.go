@@ -0,0 +1,119 @@
+package octanox
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+type retryRequest struct {
+	GetRequest
+	// Fail is how the handler fails: unavailable, wrapped or none
+	Fail string `query:"fail"`
+}
+
+type retryResponse struct {
+	OK bool `json:"ok"`
+}
+
+func retryHandler(req *retryRequest) *retryResponse {
+	switch req.Fail {
+	case "unavailable":
+		panic(Unavailable("database down").RetryAfter(1500 * time.Millisecond))
+	case "wrapped":
+		panic(fmt.Errorf("loading the user: %w", Unavailable("")))
+	}
+
+	return &retryResponse{OK: true}
+}
+
+func TestRetryableErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(i *Instance)
+		// path is requested the given number of times, the last response is checked
+		path      string
+		requests  int
+		status    int
+		retryable bool
+		// maxRetryAfter is the maximum seconds of the Retry-After header and the retryAfterMs of the body, zero if neither is set
+		maxRetryAfter int64
+	}{
+		{"rate limiter", func(i *Instance) {
+			i.ApplyRuntimeConfig(RuntimeConfig{LogLevel: LogLevelOff, RateLimit: RateLimit{RequestsPerSecond: 1, Burst: 1}})
+		}, "/retry?fail=none", 2, http.StatusTooManyRequests, true, 1},
+		{"maintenance", func(i *Instance) {
+			i.ApplyRuntimeConfig(RuntimeConfig{LogLevel: LogLevelOff, Maintenance: true})
+		}, "/retry?fail=none", 1, http.StatusServiceUnavailable, true, 0},
+		{"quota", func(i *Instance) {
+			i.Quota("calls", QuotaOptions{Limit: 1, Period: QuotaDaily})
+		}, "/quota?fail=none", 2, http.StatusTooManyRequests, true, 86400},
+		{"paid quota", func(i *Instance) {
+			i.Quota("calls", QuotaOptions{Limit: 1, PaymentRequired: true})
+		}, "/quota?fail=none", 2, http.StatusPaymentRequired, false, 0},
+		{"unavailable handler", nil, "/retry?fail=unavailable", 1, http.StatusServiceUnavailable, true, 2},
+		{"wrapped unavailable handler", nil, "/retry?fail=wrapped", 1, http.StatusServiceUnavailable, true, 0},
+		{"invalid request", nil, "/retry", 1, http.StatusBadRequest, false, 0},
+	}
+
+	for _, problemDetails := range []bool{false, true} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s problem details %t", tt.name, problemDetails), func(t *testing.T) {
+				i := newTestInstance(t)
+				if err := i.ApplyRuntimeConfig(RuntimeConfig{LogLevel: LogLevelOff}); err != nil {
+					t.Fatal(err)
+				}
+				if problemDetails {
+					i.UseProblemDetails(ProblemDetailsOptions{})
+				}
+				if tt.setup != nil {
+					tt.setup(i)
+				}
+				i.Register("/retry", retryHandler)
+				if _, ok := i.quotas["calls"]; ok {
+					i.Register("/quota", retryHandler).Quota("calls", 1)
+				}
+
+				var rec *httptest.ResponseRecorder
+				for n := 0; n < tt.requests; n++ {
+					rec = serveTest(i, httptest.NewRequest(http.MethodGet, tt.path, nil))
+				}
+
+				if rec.Code != tt.status {
+					t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+				}
+
+				var body struct {
+					Retryable    *bool  `json:"retryable"`
+					RetryAfterMs *int64 `json:"retryAfterMs"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+					t.Fatal(err)
+				}
+
+				if retryable := body.Retryable != nil && *body.Retryable; retryable != tt.retryable {
+					t.Errorf("retryable %t, want %t: %s", retryable, tt.retryable, rec.Body.String())
+				}
+				header := rec.Header().Get("Retry-After")
+				if tt.maxRetryAfter == 0 {
+					if header != "" || body.RetryAfterMs != nil {
+						t.Errorf("Retry-After %q, retryAfterMs %v, want none", header, body.RetryAfterMs)
+					}
+					return
+				}
+
+				if seconds, err := strconv.ParseInt(header, 10, 64); err != nil || seconds <= 0 || seconds > tt.maxRetryAfter {
+					t.Errorf("Retry-After %q, want up to %d", header, tt.maxRetryAfter)
+				}
+				if body.RetryAfterMs == nil || *body.RetryAfterMs <= 0 || *body.RetryAfterMs > tt.maxRetryAfter*1000 {
+					t.Errorf("retryAfterMs %v, want up to %d", body.RetryAfterMs, tt.maxRetryAfter*1000)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package octanox
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// DisallowUnknownFields rejects JSON request bodies of all routes containing fields that the body type does not have, e.g.
+// misspelled ones, with 400 Bad Request listing every unknown field and the closest known name. By default unknown fields
+// are ignored.
+func (i *Instance) DisallowUnknownFields() *Instance {
+	i.disallowUnknownFields = true
+	return i
+}
+
+// DisallowUnknownFields rejects JSON request bodies of this route containing fields that the body type does not have. See
+// Instance.DisallowUnknownFields.
+func (r *Route) DisallowUnknownFields() *Route {
+	r.disallowUnknownFields = true
+	return r
+}
+
+// rejectsUnknownFields checks if the JSON request bodies of the route are rejected if they contain unknown fields.
+func (r *Route) rejectsUnknownFields() bool {
+	return r.disallowUnknownFields || Current.disallowUnknownFields
+}
+
+// unknownField is a field of a JSON request body that the body type does not have.
+type unknownField struct {
+	// path is the dotted path of the field, e.g. items[0].name.
+	path string
+	// suggestion is the closest known name of the field. Empty if no known name is similar.
+	suggestion string
+}
+
+// unknownFieldsError is an error that indicates that a JSON request body contains unknown fields.
+type unknownFieldsError struct {
+	fields []unknownField
+}
+
+func (e *unknownFieldsError) Error() string {
+	descriptions := make([]string, len(e.fields))
+	for n, field := range e.fields {
+		descriptions[n] = field.path
+		if field.suggestion != "" {
+			descriptions[n] += " (did you mean " + field.suggestion + "?)"
+		}
+	}
+
+	return "unknown fields: " + strings.Join(descriptions, ", ")
+}
+
+// problemFieldErrors returns the field errors of the unknown fields.
+func (e *unknownFieldsError) problemFieldErrors() []ProblemFieldError {
+	fieldErrors := make([]ProblemFieldError, len(e.fields))
+	for n, field := range e.fields {
+		detail := "unknown field"
+		if field.suggestion != "" {
+			detail += ", did you mean " + field.suggestion + "?"
+		}
+
+		fieldErrors[n] = ProblemFieldError{Source: "body", Name: field.path, Detail: detail}
+	}
+
+	return fieldErrors
+}
+
+// checkUnknownFields returns an unknownFieldsError if the given JSON body contains fields that the given type does not have.
+// The body must already have been decoded into the type successfully.
+func checkUnknownFields(body []byte, t reflect.Type) error {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return err
+	}
+
+	var fields []unknownField
+	collectUnknownFields(value, t, "", &fields)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return &unknownFieldsError{fields: fields}
+}
+
+// collectUnknownFields collects the fields of the given decoded JSON value that the given type does not have. Field names
+// match case-insensitively, just as the decoder matches them.
+func collectUnknownFields(value any, t reflect.Type, path string, fields *[]unknownField) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if isProtoMessage(t) || marshalsItself(t) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+
+		known := jsonFields(t)
+		for _, name := range sortedKeys(object) {
+			field, ok := matchJSONField(known, name)
+			if !ok {
+				*fields = append(*fields, unknownField{path: joinFieldPath(path, name), suggestion: closestJSONField(known, name)})
+				continue
+			}
+
+			collectUnknownFields(object[name], field.t, joinFieldPath(path, name), fields)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]any)
+		if !ok {
+			return
+		}
+
+		for n, item := range items {
+			collectUnknownFields(item, t.Elem(), path+"["+strconv.Itoa(n)+"]", fields)
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+
+		for _, name := range sortedKeys(object) {
+			collectUnknownFields(object[name], t.Elem(), joinFieldPath(path, name), fields)
+		}
+	}
+}
+
+// matchJSONField returns the field with the given name, preferring an exact match over a case-insensitive one.
+func matchJSONField(fields []jsonField, name string) (jsonField, bool) {
+	for _, field := range fields {
+		if field.name == name {
+			return field, true
+		}
+	}
+
+	for _, field := range fields {
+		if strings.EqualFold(field.name, name) {
+			return field, true
+		}
+	}
+
+	return jsonField{}, false
+}
+
+// closestJSONField returns the name of the field closest to the given unknown name, or an empty string if no field is
+// similar enough to be a likely misspelling.
+func closestJSONField(fields []jsonField, name string) string {
+	closest, closestDistance := "", max(2, len(name)/2)+1
+
+	for _, field := range fields {
+		if distance := editDistance(strings.ToLower(name), strings.ToLower(field.name)); distance < closestDistance {
+			closest, closestDistance = field.name, distance
+		}
+	}
+
+	return closest
+}
+
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+func sortedKeys(object map[string]any) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,396 @@
+package octanox
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	// ContractPathParamWithoutField is reported when the route path contains a parameter that no request field binds.
+	ContractPathParamWithoutField = "NOX001"
+	// ContractFieldWithoutPathParam is reported when a request field binds a path parameter that the route path does not contain.
+	ContractFieldWithoutPathParam = "NOX002"
+	// ContractBodyOnGet is reported when a GET route declares a body field.
+	ContractBodyOnGet = "NOX003"
+	// ContractDuplicateQueryParam is reported when two request fields bind the same query parameter.
+	ContractDuplicateQueryParam = "NOX004"
+	// ContractUnsupportedKind is reported when a body or response field has a kind that cannot be serialized. Parameters of
+	// unsupported kinds already fail at registration.
+	ContractUnsupportedKind = "NOX005"
+	// ContractDuplicateJSONName is reported when two fields of a struct end up with the same JSON name after applying the naming strategy.
+	ContractDuplicateJSONName = "NOX006"
+	// ContractEmptyResponse is reported when a response struct only has unexported fields and would serialize to {}.
+	ContractEmptyResponse = "NOX007"
+	// ContractInvalidNullable is reported when a nullable tag is not "true" or "false", or is set on a field that is neither a slice nor a map.
+	ContractInvalidNullable = "NOX008"
+	// ContractImmutableNotGet is reported when a route that is not a GET route is marked as immutable.
+	ContractImmutableNotGet = "NOX009"
+	// ContractImmutableQuery is reported when an immutable route binds query parameters that are not acknowledged.
+	ContractImmutableQuery = "NOX010"
+	// ContractMissingService is reported when a request field is injected with a service of a type that has not been provided.
+	ContractMissingService = "NOX011"
+	// ContractIdempotentWithoutKey is reported when a route marked as idempotent has no client-supplied key recognizing repeated
+	// requests. It is only a warning, which does not fail the strict contract validation.
+	ContractIdempotentWithoutKey = "NOX012"
+	// ContractInvalidEncryptedField is reported when an encrypt tag names an unsupported encryption, or is set on a field that is
+	// neither a string nor a byte slice.
+	ContractInvalidEncryptedField = "NOX013"
+	// ContractInvalidVisibility is reported when a visible tag is not a valid expression, references a role which is not
+	// declared with Instance.Roles, or is part of the response of an immutable route, which shared caches serve to everyone.
+	ContractInvalidVisibility = "NOX014"
+	// ContractNullabilityMismatch is reported when a field backed by a nullable source, declared with the dbnull tag or
+	// Instance.NullableSources, cannot represent null, when a field backed by a non-nullable source is a pointer, or when a
+	// dbnull tag is not "true" or "false".
+	ContractNullabilityMismatch = "NOX015"
+	// ContractJobWithoutStore is reported when a handler returns a JobRef, but its route is not declared Async, so the status
+	// of its jobs is not served.
+	ContractJobWithoutStore = "NOX016"
+	// ContractConstantWithParameters is reported when a constant route has request parameters, which its handler, called once
+	// without a request, never sees.
+	ContractConstantWithParameters = "NOX017"
+)
+
+// contractWarnings are the codes of the findings which are only logged by the strict contract validation.
+var contractWarnings = map[string]bool{
+	ContractIdempotentWithoutKey: true,
+}
+
+// ContractError is an error describing an incoherent route or DTO contract found by Instance.Validate.
+type ContractError struct {
+	// Code is the stable identifier of the check that produced the error. Can be used to allowlist findings.
+	Code string
+	// Method is the HTTP method of the route the error was found in.
+	Method string
+	// Path is the path of the route the error was found in.
+	Path string
+	// Message is the human readable description of the error.
+	Message string
+}
+
+func (e *ContractError) Error() string {
+	return fmt.Sprintf("%s %s %s: %s", e.Code, e.Method, e.Path, e.Message)
+}
+
+// key returns the identifier used to allowlist this specific finding, which is the code followed by the route.
+func (e *ContractError) key() string {
+	return e.Code + " " + e.Method + " " + e.Path
+}
+
+// StrictContracts makes Run validate all routes after the before_start hook and abort with a report if the contract is incoherent.
+// The allowlist may contain error codes (e.g. "NOX003") or specific findings in the form "NOX003 GET /path", which are ignored.
+func (i *Instance) StrictContracts(allow ...string) *Instance {
+	i.strictContracts = true
+	i.contractAllowlist = make(map[string]bool, len(allow))
+	for _, a := range allow {
+		i.contractAllowlist[a] = true
+	}
+
+	return i
+}
+
+// Validate checks all registered routes and their DTOs for an incoherent contract and returns every finding as *ContractError.
+func (i *Instance) Validate() []error {
+	errs := make([]error, 0)
+
+	for _, route := range i.routes {
+		for _, rt := range append([]*Route{route}, route.variants...) {
+			v := contractValidator{instance: i, route: rt, seen: make(map[reflect.Type]bool)}
+			v.validate()
+			errs = append(errs, v.errs...)
+		}
+	}
+
+	return errs
+}
+
+// contractReport runs the contract validation and returns a report grouped by route of every finding which is not allowlisted.
+// Returns an empty string if there are no such findings. Findings which are only warnings are logged instead.
+func (i *Instance) contractReport() string {
+	findings := make(map[string][]string)
+	routes := make([]string, 0)
+
+	for _, err := range i.Validate() {
+		cerr := err.(*ContractError)
+		if i.contractAllowlist[cerr.Code] || i.contractAllowlist[cerr.key()] {
+			continue
+		}
+
+		if contractWarnings[cerr.Code] {
+			log.Println("octanox: contract warning: " + cerr.Error())
+			continue
+		}
+
+		route := cerr.Method + " " + cerr.Path
+		if _, ok := findings[route]; !ok {
+			routes = append(routes, route)
+		}
+
+		findings[route] = append(findings[route], "    ["+cerr.Code+"] "+cerr.Message)
+	}
+
+	if len(routes) == 0 {
+		return ""
+	}
+
+	sort.Strings(routes)
+
+	var sb strings.Builder
+	sb.WriteString("octanox: strict contract validation failed:\n")
+	for _, route := range routes {
+		sb.WriteString("  " + route + "\n")
+		sb.WriteString(strings.Join(findings[route], "\n") + "\n")
+	}
+
+	return sb.String()
+}
+
+// contractValidator collects the contract errors of a single route.
+type contractValidator struct {
+	instance *Instance
+	route    *Route
+	seen     map[reflect.Type]bool
+	errs     []error
+}
+
+func (v *contractValidator) report(code, format string, args ...any) {
+	v.errs = append(v.errs, &ContractError{
+		Code:    code,
+		Method:  v.route.method,
+		Path:    v.route.path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *contractValidator) validate() {
+	queryParams := make(map[string]string)
+	pathFields := make(map[string]bool)
+
+	if v.route.plan != nil {
+		v.validateRequestFields(v.route.plan, queryParams, pathFields)
+	}
+
+	for _, param := range pathParams(v.route.path) {
+		if !pathFields[param] {
+			v.report(ContractPathParamWithoutField, "path parameter %q has no request field binding it", param)
+		}
+	}
+
+	if v.route.immutable {
+		v.validateImmutable(queryParams)
+	}
+
+	if v.route.idempotent {
+		v.validateIdempotent()
+	}
+
+	if v.route.immutable && v.route.responseType != nil && containsVisibleTags(v.route.responseType, false, map[reflect.Type]bool{}) {
+		v.report(ContractInvalidVisibility, "immutable route responds with fields with a visible tag, which shared caches would serve to every user")
+	}
+
+	if v.route.responseType != nil && v.route.responseType != downloadType && !v.instance.hasSerializer(v.route.responseType) {
+		t := v.route.responseType
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		if t.Kind() == reflect.Struct && t.NumField() > 0 && !isProtoMessage(t) && !hasExportedFields(t) {
+			v.report(ContractEmptyResponse, "response type %s only has unexported fields and serializes to {}", t.String())
+		}
+
+		v.validateDTO(v.route.responseType, "response")
+	}
+
+	if v.route.constant != nil && v.route.requestOptionsIndex() > 0 {
+		v.report(ContractConstantWithParameters, "constant route has request parameters, but its handler is only called once without a request")
+	}
+
+	if v.route.jobResult != nil {
+		if v.route.jobs == nil {
+			v.report(ContractJobWithoutStore, "handler returns a JobRef, but the route is not Async, so the status of its jobs is not served")
+		}
+
+		v.validateDTO(v.route.jobResult, "job result")
+	}
+}
+
+func (v *contractValidator) validateRequestFields(plan *bindingPlan, queryParams map[string]string, pathFields map[string]bool) {
+	params := pathParams(v.route.path)
+
+	for n := range plan.fields {
+		bf := &plan.fields[n]
+		field := bf.field
+
+		switch bf.source {
+		case sourcePath:
+			pathFields[bf.name] = true
+			if !containsString(params, bf.name) {
+				v.report(ContractFieldWithoutPathParam, "field %s binds path parameter %q which is not part of the route path", field.Name, bf.name)
+			}
+		case sourceInject:
+			if _, ok := v.instance.service(field.Type); !ok {
+				v.report(ContractMissingService, "field %s injects a service of type %s, which has not been provided", field.Name, field.Type.String())
+			}
+		case sourceQuery:
+			if other, ok := queryParams[bf.name]; ok {
+				v.report(ContractDuplicateQueryParam, "fields %s and %s both bind query parameter %q", other, field.Name, bf.name)
+			}
+			queryParams[bf.name] = field.Name
+		case sourceBody:
+			if v.route.method == http.MethodGet {
+				v.report(ContractBodyOnGet, "field %s binds the request body on a GET route", field.Name)
+			}
+			v.validateDTO(field.Type, "body")
+		case sourceRawBody:
+			if v.route.method == http.MethodGet {
+				v.report(ContractBodyOnGet, "field %s binds the raw request body on a GET route", field.Name)
+			}
+		case sourceForm, sourceFile:
+			if v.route.method == http.MethodGet {
+				v.report(ContractBodyOnGet, "field %s binds a field of the form of the request body on a GET route", field.Name)
+			}
+		}
+	}
+}
+
+// validateImmutable checks that an immutable route is a GET route whose response only depends on its path and acknowledged query parameters.
+func (v *contractValidator) validateImmutable(queryParams map[string]string) {
+	if v.route.method != http.MethodGet {
+		v.report(ContractImmutableNotGet, "%s route is marked as immutable, but only GET routes can be", v.route.method)
+	}
+
+	params := make([]string, 0, len(queryParams))
+	for param := range queryParams {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+
+	for _, param := range params {
+		if !containsString(v.route.immutableQuery, param) {
+			v.report(ContractImmutableQuery, "immutable route binds query parameter %q, which is not acknowledged by Immutable", param)
+		}
+	}
+
+	if embedsListQuery(v.route.requestType) {
+		v.report(ContractImmutableQuery, "immutable route embeds a ListQuery, whose query parameters change the response")
+	}
+}
+
+// validateDTO walks the given body or response type and reports unsupported kinds and duplicate JSON names.
+func (v *contractValidator) validateDTO(t reflect.Type, location string) {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		v.validateDTO(t.Elem(), location)
+	case reflect.Map:
+		switch t.Key().Kind() {
+		case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			v.report(ContractUnsupportedKind, "%s type %s has an unsupported map key type %s", location, t.String(), t.Key().String())
+		}
+		v.validateDTO(t.Elem(), location)
+	case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		v.report(ContractUnsupportedKind, "%s contains the unsupported type %s", location, t.String())
+	case reflect.Struct:
+		if v.seen[t] || isProtoMessage(t) {
+			return
+		}
+		v.seen[t] = true
+
+		tb := &tsCodeBuilder{opts: v.instance.tsGenOptions}
+		names := make(map[string]string)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Anonymous || !field.IsExported() {
+				continue
+			}
+
+			jsonName, _, skip := jsonFieldName(field)
+			if skip {
+				continue
+			}
+
+			name := tb.propertyName(jsonName)
+			if other, ok := names[name]; ok {
+				v.report(ContractDuplicateJSONName, "fields %s and %s of %s both use the JSON name %q", other, field.Name, t.String(), name)
+			}
+			names[name] = field.Name
+
+			if nullable, ok := field.Tag.Lookup("nullable"); ok {
+				if nullable != "true" && nullable != "false" {
+					v.report(ContractInvalidNullable, "field %s of %s has the invalid nullable tag %q", field.Name, t.String(), nullable)
+				} else if field.Type.Kind() != reflect.Slice && field.Type.Kind() != reflect.Map {
+					v.report(ContractInvalidNullable, "field %s of %s has a nullable tag but is neither a slice nor a map", field.Name, t.String())
+				}
+			}
+
+			if algorithm, ok := field.Tag.Lookup("encrypt"); ok {
+				if err := checkEncryptedField(field, algorithm); err != nil {
+					v.report(ContractInvalidEncryptedField, "field %s of %s: %s", field.Name, t.String(), err.Error())
+				}
+			}
+
+			if source, ok := field.Tag.Lookup("visible"); ok {
+				v.validateVisibility(t, field, source)
+			}
+
+			v.validateNullability(t, field)
+
+			v.validateDTO(field.Type, location)
+		}
+	}
+}
+
+// validateVisibility checks that the visible tag of the given field parses and only references declared roles.
+func (v *contractValidator) validateVisibility(t reflect.Type, field reflect.StructField, source string) {
+	rule, err := parseVisibilityRule(source)
+	if err != nil {
+		v.report(ContractInvalidVisibility, "field %s of %s: %s", field.Name, t.String(), err.Error())
+		return
+	}
+
+	if v.instance.roles == nil {
+		return
+	}
+
+	for _, role := range rule.roles() {
+		if !v.instance.roles[role] {
+			v.report(ContractInvalidVisibility, "field %s of %s is visible to the undeclared role %q", field.Name, t.String(), role)
+		}
+	}
+}
+
+// pathParams returns the names of all parameters in the given route path.
+func pathParams(path string) []string {
+	params := make([]string, 0)
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			params = append(params, segment[1:])
+		}
+	}
+
+	return params
+}
+
+// hasExportedFields checks if the given struct type has at least one exported field.
+func hasExportedFields(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
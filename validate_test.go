@@ -0,0 +1,145 @@
+package octanox
+
+import (
+	"strings"
+	"testing"
+)
+
+type validRequest struct {
+	GetRequest
+	ID     string `path:"id"`
+	Filter string `query:"filter"`
+}
+
+type validResponse struct {
+	Name string `json:"name"`
+}
+
+type pathParamWithoutFieldRequest struct {
+	GetRequest
+}
+
+type fieldWithoutPathParamRequest struct {
+	GetRequest
+	ID string `path:"id"`
+}
+
+type bodyOnGetRequest struct {
+	GetRequest
+	Body validResponse `body:"true"`
+}
+
+type duplicateQueryParamRequest struct {
+	GetRequest
+	Filter string `query:"filter"`
+	Search string `query:"filter"`
+}
+
+type unsupportedKindBody struct {
+	Done chan bool `json:"done"`
+}
+
+type unsupportedKindRequest struct {
+	PostRequest
+	Body unsupportedKindBody `body:"true"`
+}
+
+type duplicateJSONNameResponse struct {
+	UserID       string `json:"user_id"`
+	LegacyUserID string `json:"userId"`
+}
+
+type emptyResponse struct {
+	name string
+}
+
+// contractCodes returns the codes of the contract errors of the instance.
+func contractCodes(t *testing.T, i *Instance) []string {
+	t.Helper()
+
+	codes := make([]string, 0)
+	for _, err := range i.Validate() {
+		cerr, ok := err.(*ContractError)
+		if !ok {
+			t.Fatalf("error %v is no *ContractError", err)
+		}
+		codes = append(codes, cerr.Code)
+	}
+
+	return codes
+}
+
+func TestValidateCoherentContract(t *testing.T) {
+	i := newTestInstance(t)
+	i.Register("/users/:id", func(*validRequest) *validResponse { return nil })
+
+	if codes := contractCodes(t, i); len(codes) != 0 {
+		t.Errorf("codes %v, want none", codes)
+	}
+}
+
+func TestValidateChecks(t *testing.T) {
+	tests := []struct {
+		name, code string
+		register   func(i *Instance)
+	}{
+		{"path parameter without field", ContractPathParamWithoutField, func(i *Instance) {
+			i.Register("/users/:id", func(*pathParamWithoutFieldRequest) *validResponse { return nil })
+		}},
+		{"field without path parameter", ContractFieldWithoutPathParam, func(i *Instance) {
+			i.Register("/users", func(*fieldWithoutPathParamRequest) *validResponse { return nil })
+		}},
+		{"body on GET", ContractBodyOnGet, func(i *Instance) {
+			i.Register("/users", func(*bodyOnGetRequest) *validResponse { return nil })
+		}},
+		{"duplicate query parameter", ContractDuplicateQueryParam, func(i *Instance) {
+			i.Register("/users", func(*duplicateQueryParamRequest) *validResponse { return nil })
+		}},
+		{"unsupported kind", ContractUnsupportedKind, func(i *Instance) {
+			i.Register("/users", func(*unsupportedKindRequest) *validResponse { return nil })
+		}},
+		{"duplicate JSON name after the naming strategy", ContractDuplicateJSONName, func(i *Instance) {
+			i.SetTSGenOptions(TypeScriptGenerationOptions{CamelCaseProperties: true})
+			i.Register("/users", func(*pathParamWithoutFieldRequest) *duplicateJSONNameResponse { return nil })
+		}},
+		{"empty response", ContractEmptyResponse, func(i *Instance) {
+			i.Register("/users", func(*pathParamWithoutFieldRequest) *emptyResponse { return nil })
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newTestInstance(t)
+			tt.register(i)
+
+			codes := contractCodes(t, i)
+			if len(codes) != 1 || codes[0] != tt.code {
+				t.Errorf("codes %v, want [%s]", codes, tt.code)
+			}
+		})
+	}
+}
+
+func TestContractReportAllowlist(t *testing.T) {
+	i := newTestInstance(t)
+	i.Register("/users", func(*bodyOnGetRequest) *validResponse { return nil })
+	i.Register("/teams", func(*bodyOnGetRequest) *validResponse { return nil })
+
+	i.StrictContracts()
+	report := i.contractReport()
+	for _, want := range []string{"GET /teams", "GET /users", "[" + ContractBodyOnGet + "]"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report %q does not contain %q", report, want)
+		}
+	}
+
+	i.StrictContracts(ContractBodyOnGet + " GET /users")
+	if report := i.contractReport(); strings.Contains(report, "GET /users") || !strings.Contains(report, "GET /teams") {
+		t.Errorf("report %q, want only GET /teams", report)
+	}
+
+	i.StrictContracts(ContractBodyOnGet)
+	if report := i.contractReport(); report != "" {
+		t.Errorf("report %q, want none", report)
+	}
+}
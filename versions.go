@@ -0,0 +1,166 @@
+package octanox
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// acceptVersionHeader is the request header clients select the version of versioned routes with, e.g. "2023-10-01".
+const acceptVersionHeader = "Accept-Version"
+
+// APIVersions declares all versions of the API. Versions are compared lexicographically, so dates like "2023-10-01" order as
+// expected. Requests of versioned routes for versions that are not declared are answered with 406 Not Acceptable. Without
+// declared versions, only versions newer than the newest variant of the route are rejected.
+func (i *Instance) APIVersions(versions ...string) *Instance {
+	i.apiVersions = append(i.apiVersions, versions...)
+	sort.Strings(i.apiVersions)
+	return i
+}
+
+// SetDefaultAPIVersion sets the version versioned routes serve to requests without an Accept-Version header. Defaults to the
+// newest variant of each route.
+func (i *Instance) SetDefaultAPIVersion(version string) *Instance {
+	i.defaultAPIVersion = version
+	return i
+}
+
+// Variant registers the given handler as the variant of this route serving the given version and all later versions up to
+// the next variant, selected with the Accept-Version header. The handler has its own request and response types, but must
+// have the same method. The handler of this route serves the versions before the first variant. Returns the route of the
+// variant, which is configured on its own; calling Variant on it adds another variant of the versioned route. The generated
+// TypeScript client uses the newest variant, or the variant of the version pinned with NOX__CLIENT_VERSION, and sends its version.
+func (r *Route) Variant(version string, handler interface{}) *Route {
+	if r.versioned != nil {
+		return r.versioned.Variant(version, handler)
+	}
+
+	if version == "" {
+		panic("octanox: variant of " + r.method + " " + r.path + " needs a version")
+	}
+
+	for _, variant := range r.variants {
+		if variant.version == version {
+			panic("octanox: variant " + version + " of " + r.method + " " + r.path + " already registered")
+		}
+	}
+
+	router := &SubRouter{url: strings.TrimSuffix(r.path, r.relativePath), gin: r.group, params: r.groupParams}
+	variant := router.newRoute(r.relativePath, handler, r.authenticated, r.roles)
+	if variant.method != r.method {
+		panic("octanox: variant " + version + " of " + r.method + " " + r.path + " must be a " + r.method + " route, not " + variant.method)
+	}
+
+	variant.version = version
+	variant.defaultAccess = r.defaultAccess
+	variant.versioned = r
+	variant.group = r.group
+	variant.relativePath = r.relativePath
+
+	r.variants = append(r.variants, variant)
+	sort.Slice(r.variants, func(a, b int) bool {
+		return r.variants[a].version < r.variants[b].version
+	})
+
+	return variant
+}
+
+// selectVariant returns the variant serving the version requested by the given request, which is the route itself for versions
+// before the first variant. Versions that are not supported are answered with 406 Not Acceptable, in which case nil is returned.
+func (r *Route) selectVariant(c *gin.Context) *Route {
+	c.Header("Vary", acceptVersionHeader)
+
+	version := c.GetHeader(acceptVersionHeader)
+	if version == "" {
+		version = Current.defaultAPIVersion
+	}
+	if version == "" {
+		return r.variants[len(r.variants)-1]
+	}
+
+	if !r.supportsVersion(version) {
+		supported := strings.Join(r.supportedVersions(), ", ")
+		abortWithError(c, failedRequest{
+			status:  http.StatusNotAcceptable,
+			message: "Unsupported version " + version + ", supported versions: " + supported,
+			code:    ErrorCodeUnsupportedVersion,
+			errors:  []ProblemFieldError{{Source: "header", Name: acceptVersionHeader, Detail: "supported versions: " + supported}},
+		})
+		return nil
+	}
+
+	return r.variantFor(version)
+}
+
+// variantFor returns the newest variant not newer than the given version, or the route itself if all variants are newer.
+func (r *Route) variantFor(version string) *Route {
+	selected := r
+	for _, variant := range r.variants {
+		if variant.version > version {
+			break
+		}
+
+		selected = variant
+	}
+
+	return selected
+}
+
+// supportsVersion checks if the given version is declared, or not newer than the newest variant if no versions are declared.
+func (r *Route) supportsVersion(version string) bool {
+	if len(Current.apiVersions) > 0 {
+		return containsString(Current.apiVersions, version)
+	}
+
+	return version <= r.variants[len(r.variants)-1].version
+}
+
+// supportedVersions returns the versions the route can be requested with: the declared versions, or the versions of its variants.
+func (r *Route) supportedVersions() []string {
+	if len(Current.apiVersions) > 0 {
+		return Current.apiVersions
+	}
+
+	versions := make([]string, len(r.variants))
+	for n, variant := range r.variants {
+		versions[n] = variant.version
+	}
+
+	return versions
+}
+
+// clientRoutes returns the routes the client is generated of, replacing versioned routes by their variant of the given version,
+// or by their newest variant if the version is empty.
+func clientRoutes(routes []*Route, version string) []*Route {
+	result := make([]*Route, len(routes))
+	for n, route := range routes {
+		if len(route.variants) == 0 {
+			result[n] = route
+			continue
+		}
+
+		clientVersion := version
+		if clientVersion == "" {
+			clientVersion = route.variants[len(route.variants)-1].version
+		}
+
+		// the client settings are made on the versioned route, the variant only differs in its handler
+		variant := *route.variantFor(clientVersion)
+		variant.clientVersion = clientVersion
+		variant.clientGroup, variant.clientMember = route.clientGroup, route.clientMember
+		variant.clientGroupPath = route.clientGroupPath
+		variant.clientName = route.clientName
+		variant.persistedQuery = route.persistedQuery
+		if variant.clientOverride == nil {
+			variant.clientOverride = route.clientOverride
+		}
+		if variant.description == "" {
+			variant.description = route.description
+		}
+		result[n] = &variant
+	}
+
+	return result
+}
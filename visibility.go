@@ -0,0 +1,583 @@
+package octanox
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// HiddenFieldPolicy is a type that decides how response fields whose `visible` tag does not match the user are encoded.
+type HiddenFieldPolicy int
+
+const (
+	// HiddenFieldsOmit leaves hidden fields out of the response, as if the struct did not have them. This is the default.
+	HiddenFieldsOmit HiddenFieldPolicy = iota
+	// HiddenFieldsNull encodes hidden fields as null, so every response has the same keys.
+	HiddenFieldsNull
+)
+
+// maxVisibilityRules is the number of fields with a visible tag a single struct can have.
+const maxVisibilityRules = 64
+
+// PermissionProvider is an optional interface of users exposing permissions, which the `visible:"permission=<name>"` rules
+// of response fields are checked against.
+type PermissionProvider interface {
+	// HasPermission checks if the user has the given permission.
+	HasPermission(permission string) bool
+}
+
+// SetHiddenFieldPolicy sets the policy of how response fields hidden from the user by their visible tag are encoded.
+func (i *Instance) SetHiddenFieldPolicy(policy HiddenFieldPolicy) *Instance {
+	i.hiddenFields = policy
+	return i
+}
+
+// Roles declares all roles of the users. With declared roles, Validate reports visible tags of response fields referencing
+// any other role, which catches typos hiding a field from everyone.
+func (i *Instance) Roles(roles ...string) *Instance {
+	if i.roles == nil {
+		i.roles = make(map[string]bool, len(roles))
+	}
+
+	for _, role := range roles {
+		i.roles[role] = true
+	}
+
+	return i
+}
+
+// visibilityRule is a parsed `visible` tag, an expression over the roles and permissions of the user. Terms are "role=<name>"
+// and "permission=<name>", combined with "&" binding tighter than "|", negated with "!" and grouped with parentheses, e.g.
+// `visible:"role=admin|role=billing&permission=prices.read"`. Anonymous users have no roles and permissions.
+type visibilityRule struct {
+	source string
+	expr   visibilityExpr
+}
+
+type visibilityExpr interface {
+	eval(user User) bool
+}
+
+type (
+	visibilityOr         []visibilityExpr
+	visibilityAnd        []visibilityExpr
+	visibilityNot        struct{ expr visibilityExpr }
+	visibilityRole       string
+	visibilityPermission string
+)
+
+func (e visibilityOr) eval(user User) bool {
+	for _, expr := range e {
+		if expr.eval(user) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (e visibilityAnd) eval(user User) bool {
+	for _, expr := range e {
+		if !expr.eval(user) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (e visibilityNot) eval(user User) bool {
+	return !e.expr.eval(user)
+}
+
+func (e visibilityRole) eval(user User) bool {
+	return user != nil && user.HasRole(string(e))
+}
+
+func (e visibilityPermission) eval(user User) bool {
+	permissions, ok := user.(PermissionProvider)
+	return ok && permissions.HasPermission(string(e))
+}
+
+type visibilityRuleResult struct {
+	rule *visibilityRule
+	err  error
+}
+
+// visibilityRules caches the parsed rules by their source, as every tag is parsed once.
+var visibilityRules sync.Map
+
+// parseVisibilityRule returns the rule of the given visible tag, parsing it on first use.
+func parseVisibilityRule(source string) (*visibilityRule, error) {
+	if cached, ok := visibilityRules.Load(source); ok {
+		result := cached.(visibilityRuleResult)
+		return result.rule, result.err
+	}
+
+	p := &visibilityParser{source: source}
+	expr, err := p.parseOr()
+	if err == nil && p.skipSpace() < len(source) {
+		err = p.fail("unexpected " + strconv.QuoteRune(rune(source[p.pos])))
+	}
+
+	var rule *visibilityRule
+	if err == nil {
+		rule = &visibilityRule{source: source, expr: expr}
+	}
+
+	visibilityRules.Store(source, visibilityRuleResult{rule: rule, err: err})
+	return rule, err
+}
+
+// roles returns the roles referenced by the rule, sorted.
+func (r *visibilityRule) roles() []string {
+	var roles []string
+	var collect func(expr visibilityExpr)
+	collect = func(expr visibilityExpr) {
+		switch e := expr.(type) {
+		case visibilityOr:
+			for _, expr := range e {
+				collect(expr)
+			}
+		case visibilityAnd:
+			for _, expr := range e {
+				collect(expr)
+			}
+		case visibilityNot:
+			collect(e.expr)
+		case visibilityRole:
+			if !containsString(roles, string(e)) {
+				roles = append(roles, string(e))
+			}
+		}
+	}
+
+	collect(r.expr)
+	sort.Strings(roles)
+	return roles
+}
+
+// visibilityParser is a recursive descent parser of visible tags.
+type visibilityParser struct {
+	source string
+	pos    int
+}
+
+func (p *visibilityParser) fail(message string) error {
+	return errors.New("invalid visible tag " + strconv.Quote(p.source) + ": " + message)
+}
+
+// skipSpace skips the whitespace at the current position and returns the new position.
+func (p *visibilityParser) skipSpace() int {
+	for p.pos < len(p.source) && p.source[p.pos] == ' ' {
+		p.pos++
+	}
+
+	return p.pos
+}
+
+// consume consumes the given operator at the current position, if it is there.
+func (p *visibilityParser) consume(op byte) bool {
+	if p.skipSpace() < len(p.source) && p.source[p.pos] == op {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *visibilityParser) parseOr() (visibilityExpr, error) {
+	or := visibilityOr{}
+	for {
+		expr, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		or = append(or, expr)
+		if !p.consume('|') {
+			break
+		}
+	}
+
+	if len(or) == 1 {
+		return or[0], nil
+	}
+
+	return or, nil
+}
+
+func (p *visibilityParser) parseAnd() (visibilityExpr, error) {
+	and := visibilityAnd{}
+	for {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		and = append(and, expr)
+		if !p.consume('&') {
+			break
+		}
+	}
+
+	if len(and) == 1 {
+		return and[0], nil
+	}
+
+	return and, nil
+}
+
+func (p *visibilityParser) parseUnary() (visibilityExpr, error) {
+	if p.consume('!') {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return visibilityNot{expr: expr}, nil
+	}
+
+	if p.consume('(') {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.consume(')') {
+			return nil, p.fail("missing )")
+		}
+
+		return expr, nil
+	}
+
+	kind := p.parseName()
+	if !p.consume('=') {
+		return nil, p.fail("expected role=<name> or permission=<name>")
+	}
+
+	name := p.parseName()
+	if name == "" {
+		return nil, p.fail("missing name of " + kind)
+	}
+
+	switch kind {
+	case "role":
+		return visibilityRole(name), nil
+	case "permission":
+		return visibilityPermission(name), nil
+	}
+
+	return nil, p.fail("unknown term " + strconv.Quote(kind) + ", expected role or permission")
+}
+
+// parseName parses a role, permission or term name, which consists of letters, digits and the characters "_.:-".
+func (p *visibilityParser) parseName() string {
+	start := p.skipSpace()
+	for p.pos < len(p.source) {
+		ch := p.source[p.pos]
+		if !(ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || strings.IndexByte("_.:-", ch) >= 0) {
+			break
+		}
+		p.pos++
+	}
+
+	return p.source[start:p.pos]
+}
+
+// visibilityPlan is the visible tags of a struct type: its JSON fields and their rules, nil for the fields visible to
+// everyone. It is computed once per type, the struct types the values are encoded as are built once per set of hidden fields.
+type visibilityPlan struct {
+	fields []jsonField
+	rules  []*visibilityRule
+	// types caches the struct types values are encoded as by the mask of the visible fields.
+	types sync.Map
+}
+
+var (
+	// visibilityPlans caches the plans of all struct types with visible tags by type.
+	visibilityPlans sync.Map
+	// visibilityNeeded caches per type whether a value of it can contain a field with a visible tag.
+	visibilityNeeded sync.Map
+)
+
+var anyType = reflect.TypeOf((*any)(nil)).Elem()
+
+// visibilityPlanFor returns the visibility plan of the given struct type, computing it on first use. Invalid visible tags
+// panic, Validate reports them before.
+func visibilityPlanFor(t reflect.Type) *visibilityPlan {
+	if cached, ok := visibilityPlans.Load(t); ok {
+		return cached.(*visibilityPlan)
+	}
+
+	plan := &visibilityPlan{fields: jsonFields(t)}
+	plan.rules = make([]*visibilityRule, len(plan.fields))
+
+	count := 0
+	for n, field := range plan.fields {
+		source, ok := field.tag.Lookup("visible")
+		if !ok {
+			continue
+		}
+
+		rule, err := parseVisibilityRule(source)
+		if err != nil {
+			panic("octanox: field " + field.name + " of " + t.String() + ": " + err.Error())
+		}
+
+		if count++; count > maxVisibilityRules {
+			panic("octanox: " + t.String() + " has more than 64 fields with a visible tag")
+		}
+		plan.rules[n] = rule
+	}
+
+	cached, _ := visibilityPlans.LoadOrStore(t, plan)
+	return cached.(*visibilityPlan)
+}
+
+// needsVisibility checks if a value of the given type can contain a field with a visible tag, caching the result.
+func needsVisibility(t reflect.Type) bool {
+	if cached, ok := visibilityNeeded.Load(t); ok {
+		return cached.(bool)
+	}
+
+	needed := containsVisibleTags(t, true, map[reflect.Type]bool{})
+	visibilityNeeded.Store(t, needed)
+	return needed
+}
+
+// containsVisibleTags checks if a value of the given type can contain a field with a visible tag. Interfaces can contain
+// any value, they only count if dynamic is set. Recursive types are cut off at their first repetition, which does not
+// change the result.
+func containsVisibleTags(t reflect.Type, dynamic bool, visiting map[reflect.Type]bool) bool {
+	if visiting[t] || marshalsItself(t) || (t.Kind() == reflect.Ptr || t.Kind() == reflect.Struct) && isProtoMessage(t) {
+		return false
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return containsVisibleTags(t.Elem(), dynamic, visiting)
+	case reflect.Interface:
+		return dynamic
+	case reflect.Struct:
+		for _, field := range jsonFields(t) {
+			if _, ok := field.tag.Lookup("visible"); ok || containsVisibleTags(field.t, dynamic, visiting) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// visibilityFilter hides the fields of a response whose visible tag does not match the user, evaluating every rule once.
+type visibilityFilter struct {
+	user    User
+	policy  HiddenFieldPolicy
+	visible map[*visibilityRule]bool
+}
+
+// applyVisibility returns the given serialized response with the fields whose visible tag does not match the given user
+// hidden according to the hidden field policy. The value itself is never modified. Values without any visible tag and
+// protobuf messages are returned as they are.
+func (i *Instance) applyVisibility(out any, user User) any {
+	if out == nil {
+		return nil
+	}
+
+	if _, ok := out.(proto.Message); ok {
+		return out
+	}
+
+	rv := reflect.ValueOf(out)
+	if !needsVisibility(rv.Type()) {
+		return out
+	}
+
+	f := &visibilityFilter{user: user, policy: i.hiddenFields, visible: make(map[*visibilityRule]bool)}
+	return f.filter(rv).Interface()
+}
+
+func (f *visibilityFilter) isVisible(rule *visibilityRule) bool {
+	visible, ok := f.visible[rule]
+	if !ok {
+		visible = rule.expr.eval(f.user)
+		f.visible[rule] = visible
+	}
+
+	return visible
+}
+
+// filter returns a value encoding like the given one without the hidden fields. Values containing hidden fields are
+// returned as values of built types, which are stored in fields and elements of type any.
+func (f *visibilityFilter) filter(v reflect.Value) reflect.Value {
+	t := v.Type()
+	if !needsVisibility(t) {
+		return v
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+
+		return f.filter(v.Elem())
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && v.IsNil() {
+			return v
+		}
+
+		out := reflect.MakeSlice(reflect.SliceOf(anyType), v.Len(), v.Len())
+		for n := 0; n < v.Len(); n++ {
+			out.Index(n).Set(f.filter(v.Index(n)))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+
+		out := reflect.MakeMapWithSize(reflect.MapOf(t.Key(), anyType), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), f.filter(iter.Value()))
+		}
+		return out
+	case reflect.Struct:
+		return f.filterStruct(v, visibilityPlanFor(t))
+	}
+
+	return v
+}
+
+func (f *visibilityFilter) filterStruct(v reflect.Value, plan *visibilityPlan) reflect.Value {
+	var mask uint64
+	bit := uint64(1)
+	for _, rule := range plan.rules {
+		if rule == nil {
+			continue
+		}
+
+		if f.isVisible(rule) {
+			mask |= bit
+		}
+		bit <<= 1
+	}
+
+	st := plan.structType(mask, f.policy)
+	out := reflect.New(st).Elem()
+
+	n := 0
+	bit = 1
+	for i, field := range plan.fields {
+		visible := true
+		if plan.rules[i] != nil {
+			visible = mask&bit != 0
+			bit <<= 1
+		}
+
+		if !visible && f.policy == HiddenFieldsOmit {
+			continue
+		}
+
+		// hidden fields are left nil, fields of nil embedded structs are left zero, just as encoding/json omits them
+		if fv, err := v.FieldByIndexErr(field.index); visible && err == nil && !(st.Field(n).Type == anyType && omitsAsAny(fv, field.tag)) {
+			out.Field(n).Set(f.filter(fv))
+		}
+		n++
+	}
+
+	return out
+}
+
+// structType returns the struct type values are encoded as if the fields of the given mask are visible, building it on
+// first use. Hidden fields are left out, or turned into nullable fields by the null policy.
+func (p *visibilityPlan) structType(mask uint64, policy HiddenFieldPolicy) reflect.Type {
+	key := visibilityTypeKey{mask, policy}
+	if cached, ok := p.types.Load(key); ok {
+		return cached.(reflect.Type)
+	}
+
+	fields := make([]reflect.StructField, 0, len(p.fields))
+	bit := uint64(1)
+	for n, field := range p.fields {
+		visible := true
+		if p.rules[n] != nil {
+			visible = mask&bit != 0
+			bit <<= 1
+		}
+
+		ft := field.t
+		switch {
+		case !visible && policy == HiddenFieldsOmit:
+			continue
+		case !visible:
+			if !isNillable(ft) {
+				ft = reflect.PointerTo(ft)
+			}
+		case needsVisibility(ft):
+			ft = anyType
+		}
+
+		fields = append(fields, reflect.StructField{Name: "F" + strconv.Itoa(len(fields)), Type: ft, Tag: withJSONName(field.tag, field.name)})
+	}
+
+	st := reflect.StructOf(fields)
+	p.types.Store(key, st)
+	return st
+}
+
+type visibilityTypeKey struct {
+	mask   uint64
+	policy HiddenFieldPolicy
+}
+
+// omitsAsAny checks if the given value of a field retyped to any has to be left nil, so it is encoded as null or omitted
+// just as in the original field, whose omitempty option also omits empty collections.
+func omitsAsAny(v reflect.Value, tag reflect.StructTag) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Slice, reflect.Map:
+		return v.IsNil() || v.Len() == 0 && hasJSONOption(tag, "omitempty")
+	case reflect.Array:
+		return v.Len() == 0 && hasJSONOption(tag, "omitempty")
+	}
+
+	return false
+}
+
+// isNillable checks if values of the given type can be nil, which encodes them as null.
+func isNillable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		return true
+	}
+
+	return false
+}
+
+// withJSONName returns the given tag with the given JSON name, so a field of a built struct keeps the name of the field it
+// is copied from.
+func withJSONName(tag reflect.StructTag, name string) reflect.StructTag {
+	jsonTag, ok := tag.Lookup("json")
+	if current, _, _ := strings.Cut(jsonTag, ","); ok && current != "" {
+		return tag
+	}
+
+	// the first json key of a tag wins, so the name is prepended
+	_, options, _ := strings.Cut(jsonTag, ",")
+	if options != "" {
+		options = "," + options
+	}
+
+	return reflect.StructTag(`json:"` + name + options + `" ` + string(tag))
+}